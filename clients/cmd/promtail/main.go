@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/loki/clients/pkg/logentry/stages"
 	"github.com/grafana/loki/clients/pkg/promtail"
 	"github.com/grafana/loki/clients/pkg/promtail/config"
+	"github.com/grafana/loki/clients/pkg/promtail/pipelinetest"
 
 	logutil "github.com/grafana/loki/pkg/util"
 	_ "github.com/grafana/loki/pkg/util/build"
@@ -62,7 +63,34 @@ func (c *Config) Clone() flagext.Registerer {
 	}(*c)
 }
 
+// runPipelineTest runs the pipeline-test command and returns its exit code.
+func runPipelineTest(args []string) int {
+	var config pipelinetest.Config
+	fs := flag.NewFlagSet("pipeline-test", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promtail pipeline-test -config.file=<file> [-job=<name>] [-input=<file>] [-test]")
+		fmt.Fprintln(fs.Output(), "Runs the pipeline stages of a scrape config against sample lines and prints the entry after each stage.")
+		fs.PrintDefaults()
+	}
+	config.RegisterFlags(fs)
+	_ = fs.Parse(args)
+
+	err := pipelinetest.Run(config, os.Stdin, os.Stdout)
+	if err == pipelinetest.ErrTestFailed {
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Pipeline test error:", err)
+		return 1
+	}
+	return 0
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "pipeline-test" {
+		os.Exit(runPipelineTest(os.Args[2:]))
+	}
+
 	// Load config, merging config file and CLI flags
 	var config Config
 	if err := cfg.Parse(&config); err != nil {
@@ -111,6 +111,12 @@ func (e *expiringCounter) Add(val float64) {
 	e.lastModSec = time.Now().Unix()
 }
 
+// AddWithExemplar adds the given value to the counter and attaches the exemplar to it.
+func (e *expiringCounter) AddWithExemplar(val float64, exemplar prometheus.Labels) {
+	e.Counter.(prometheus.ExemplarAdder).AddWithExemplar(val, exemplar)
+	e.lastModSec = time.Now().Unix()
+}
+
 // HasExpired implements Expirable
 func (e *expiringCounter) HasExpired(currentTimeSec int64, maxAgeSec int64) bool {
 	return currentTimeSec-e.lastModSec >= maxAgeSec
@@ -73,6 +73,12 @@ func (h *expiringHistogram) Observe(val float64) {
 	h.lastModSec = time.Now().Unix()
 }
 
+// ObserveWithExemplar adds a single observation to the histogram and attaches the exemplar to it.
+func (h *expiringHistogram) ObserveWithExemplar(val float64, exemplar prometheus.Labels) {
+	h.Histogram.(prometheus.ExemplarObserver).ObserveWithExemplar(val, exemplar)
+	h.lastModSec = time.Now().Unix()
+}
+
 // HasExpired implements Expirable
 func (h *expiringHistogram) HasExpired(currentTimeSec int64, maxAgeSec int64) bool {
 	return currentTimeSec-h.lastModSec >= maxAgeSec
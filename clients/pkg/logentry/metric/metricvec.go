@@ -20,6 +20,9 @@ type metricVec struct {
 	mtx       sync.Mutex
 	metrics   map[model.Fingerprint]prometheus.Metric
 	maxAgeSec int64
+
+	maxSeries     int
+	droppedSeries prometheus.Counter
 }
 
 func newMetricVec(factory func(labels map[string]string) prometheus.Metric, maxAgeSec int64) *metricVec {
@@ -44,6 +47,15 @@ func (c *metricVec) Collect(ch chan<- prometheus.Metric) {
 	c.prune()
 }
 
+// SetMaxSeries limits the number of series of the vector, updates of new series above the limit
+// are discarded and counted in dropped. A limit of 0 means no limit.
+func (c *metricVec) SetMaxSeries(maxSeries int, dropped prometheus.Counter) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.maxSeries = maxSeries
+	c.droppedSeries = dropped
+}
+
 // With returns the metric associated with the labelset.
 func (c *metricVec) With(labels model.LabelSet) prometheus.Metric {
 	c.mtx.Lock()
@@ -53,6 +65,17 @@ func (c *metricVec) With(labels model.LabelSet) prometheus.Metric {
 	var metric prometheus.Metric
 	if metric, ok = c.metrics[fp]; !ok {
 		metric = c.factory(util.ModelLabelSetToMap(labels))
+		if c.maxSeries > 0 && len(c.metrics) >= c.maxSeries {
+			// Expired series may not have been pruned yet if nothing scraped the metrics.
+			c.prune()
+		}
+		if c.maxSeries > 0 && len(c.metrics) >= c.maxSeries {
+			// The metric is returned to the caller but never collected.
+			if c.droppedSeries != nil {
+				c.droppedSeries.Inc()
+			}
+			return metric
+		}
 		c.metrics[fp] = metric
 	}
 	return metric
@@ -0,0 +1,112 @@
+package metric
+
+import (
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	ErrSummaryInvalidObjective = "summary objective %v is not valid, quantiles must be between 0 and 1 and errors greater than 0"
+	ErrSummaryInvalidMaxAge    = "summary max_age could not be parsed as a time.Duration: '%s'"
+)
+
+// DefaultSummaryObjectives are the quantiles of a summary when none are configured.
+var DefaultSummaryObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+type SummaryConfig struct {
+	Value      *string             `mapstructure:"value"`
+	Objectives map[float64]float64 `mapstructure:"objectives"`
+	MaxAge     *string             `mapstructure:"max_age"`
+	AgeBuckets uint32              `mapstructure:"age_buckets"`
+	maxAge     time.Duration
+}
+
+func validateSummaryConfig(config *SummaryConfig) error {
+	if len(config.Objectives) == 0 {
+		config.Objectives = DefaultSummaryObjectives
+	}
+	for q, e := range config.Objectives {
+		if q < 0 || q > 1 || e <= 0 {
+			return errors.Errorf(ErrSummaryInvalidObjective, q)
+		}
+	}
+	config.maxAge = prometheus.DefMaxAge
+	if config.MaxAge != nil {
+		d, err := time.ParseDuration(*config.MaxAge)
+		if err != nil || d <= 0 {
+			return errors.Errorf(ErrSummaryInvalidMaxAge, *config.MaxAge)
+		}
+		config.maxAge = d
+	}
+	if config.AgeBuckets == 0 {
+		config.AgeBuckets = prometheus.DefAgeBuckets
+	}
+	return nil
+}
+
+func parseSummaryConfig(config interface{}) (*SummaryConfig, error) {
+	cfg := &SummaryConfig{}
+	err := mapstructure.Decode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Summaries is a vector of summaries for a each log stream.
+type Summaries struct {
+	*metricVec
+	Cfg *SummaryConfig
+}
+
+// NewSummaries creates a new summary vec.
+func NewSummaries(name, help string, config interface{}, maxIdleSec int64) (*Summaries, error) {
+	cfg, err := parseSummaryConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	err = validateSummaryConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Summaries{
+		metricVec: newMetricVec(func(labels map[string]string) prometheus.Metric {
+			return &expiringSummary{prometheus.NewSummary(prometheus.SummaryOpts{
+				Help:        help,
+				Name:        name,
+				ConstLabels: labels,
+				Objectives:  cfg.Objectives,
+				MaxAge:      cfg.maxAge,
+				AgeBuckets:  cfg.AgeBuckets,
+			}),
+				0,
+			}
+		}, maxIdleSec),
+		Cfg: cfg,
+	}, nil
+}
+
+// With returns the summary associated with a stream labelset.
+func (s *Summaries) With(labels model.LabelSet) prometheus.Summary {
+	return s.metricVec.With(labels).(prometheus.Summary)
+}
+
+type expiringSummary struct {
+	prometheus.Summary
+	lastModSec int64
+}
+
+// Observe adds a single observation to the summary.
+func (s *expiringSummary) Observe(val float64) {
+	s.Summary.Observe(val)
+	s.lastModSec = time.Now().Unix()
+}
+
+// HasExpired implements Expirable
+func (s *expiringSummary) HasExpired(currentTimeSec int64, maxAgeSec int64) bool {
+	return currentTimeSec-s.lastModSec >= maxAgeSec
+}
@@ -0,0 +1,107 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateSummaryConfig(t *testing.T) {
+	t.Parallel()
+	invalidMaxAge := "1f"
+	tests := []struct {
+		name   string
+		config SummaryConfig
+		err    error
+	}{
+		{"invalid objective",
+			SummaryConfig{
+				Objectives: map[float64]float64{1.5: 0.01},
+			},
+			errors.Errorf(ErrSummaryInvalidObjective, 1.5),
+		},
+		{"invalid max age",
+			SummaryConfig{
+				MaxAge: &invalidMaxAge,
+			},
+			errors.Errorf(ErrSummaryInvalidMaxAge, invalidMaxAge),
+		},
+		{"valid",
+			SummaryConfig{},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateSummaryConfig(&tt.config)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, DefaultSummaryObjectives, tt.config.Objectives)
+			assert.Equal(t, prometheus.DefMaxAge, tt.config.maxAge)
+			assert.Equal(t, uint32(prometheus.DefAgeBuckets), tt.config.AgeBuckets)
+		})
+	}
+}
+
+func TestSummaryExpiration(t *testing.T) {
+	t.Parallel()
+	cfg := SummaryConfig{}
+
+	summary, err := NewSummaries("test1", "HELP ME!!!!!", cfg, 1)
+	assert.Nil(t, err)
+
+	// Create a label and observe a value
+	lbl1 := model.LabelSet{}
+	lbl1["test"] = "app"
+	summary.With(lbl1).Observe(23)
+
+	// Collect the metrics, should still find the metric in the map
+	collect(summary)
+	assert.Contains(t, summary.metrics, lbl1.Fingerprint())
+
+	time.Sleep(1100 * time.Millisecond) // Wait just past our max idle of 1 sec
+
+	//Add another summary with new label val
+	lbl2 := model.LabelSet{}
+	lbl2["test"] = "app2"
+	summary.With(lbl2).Observe(2)
+
+	// Collect the metrics, first summary should have expired and removed, second should still be present
+	collect(summary)
+	assert.NotContains(t, summary.metrics, lbl1.Fingerprint())
+	assert.Contains(t, summary.metrics, lbl2.Fingerprint())
+}
+
+func TestMaxSeries(t *testing.T) {
+	t.Parallel()
+	cnt, err := NewCounters("test1", "HELP ME!!!!!", CounterConfig{Action: CounterInc}, 1)
+	assert.Nil(t, err)
+	dropped := prometheus.NewCounter(prometheus.CounterOpts{Name: "dropped"})
+	cnt.SetMaxSeries(1, dropped)
+
+	lbl1 := model.LabelSet{"test": "app"}
+	lbl2 := model.LabelSet{"test": "app2"}
+	cnt.With(lbl1).Inc()
+	cnt.With(lbl2).Inc()
+	assert.Contains(t, cnt.metrics, lbl1.Fingerprint())
+	assert.NotContains(t, cnt.metrics, lbl2.Fingerprint())
+	assert.Equal(t, float64(1), testutil.ToFloat64(dropped))
+
+	time.Sleep(1100 * time.Millisecond) // Wait just past our max idle of 1 sec
+
+	// The expired series is pruned to make room for the new one, even without a collect.
+	cnt.With(lbl2).Inc()
+	assert.NotContains(t, cnt.metrics, lbl1.Fingerprint())
+	assert.Contains(t, cnt.metrics, lbl2.Fingerprint())
+	assert.Equal(t, float64(1), testutil.ToFloat64(dropped))
+}
@@ -0,0 +1,303 @@
+package stages
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	ErrGeoIPStageEmptyDB       = "geoip stage db is required"
+	ErrGeoIPStageEmptySource   = "geoip stage source is required"
+	ErrGeoIPStageInvalidField  = "geoip stage invalid field %q, supported fields are: %s"
+	ErrGeoIPStageInvalidReload = "geoip stage invalid reload_interval, %v cannot be converted to a duration: %v"
+	ErrGeoIPStageCannotOpenDB  = "geoip stage failed to open database: %v"
+
+	defaultGeoIPLanguage        = "en"
+	defaultGeoIPFieldNamePrefix = "geoip_"
+	defaultGeoIPReloadInterval  = time.Minute
+)
+
+// GeoIP fields which can be written in the extracted map.
+const (
+	GeoIPFieldCityName        = "city_name"
+	GeoIPFieldCountryName     = "country_name"
+	GeoIPFieldCountryCode     = "country_iso_code"
+	GeoIPFieldContinentName   = "continent_name"
+	GeoIPFieldContinentCode   = "continent_code"
+	GeoIPFieldSubdivisionName = "subdivision_name"
+	GeoIPFieldSubdivisionCode = "subdivision_iso_code"
+	GeoIPFieldPostalCode      = "postal_code"
+	GeoIPFieldLatitude        = "location_latitude"
+	GeoIPFieldLongitude       = "location_longitude"
+	GeoIPFieldTimezone        = "location_timezone"
+	GeoIPFieldASN             = "autonomous_system_number"
+	GeoIPFieldASOrganization  = "autonomous_system_organization"
+)
+
+// geoIPFields returns the value of each field of a record, empty values are not returned.
+var geoIPFields = map[string]func(r *geoIPRecord, lang string) interface{}{
+	GeoIPFieldCityName:      func(r *geoIPRecord, lang string) interface{} { return r.City.Names[lang] },
+	GeoIPFieldCountryName:   func(r *geoIPRecord, lang string) interface{} { return r.Country.Names[lang] },
+	GeoIPFieldCountryCode:   func(r *geoIPRecord, _ string) interface{} { return r.Country.IsoCode },
+	GeoIPFieldContinentName: func(r *geoIPRecord, lang string) interface{} { return r.Continent.Names[lang] },
+	GeoIPFieldContinentCode: func(r *geoIPRecord, _ string) interface{} { return r.Continent.Code },
+	GeoIPFieldSubdivisionName: func(r *geoIPRecord, lang string) interface{} {
+		if len(r.Subdivisions) == 0 {
+			return ""
+		}
+		return r.Subdivisions[0].Names[lang]
+	},
+	GeoIPFieldSubdivisionCode: func(r *geoIPRecord, _ string) interface{} {
+		if len(r.Subdivisions) == 0 {
+			return ""
+		}
+		return r.Subdivisions[0].IsoCode
+	},
+	GeoIPFieldPostalCode:     func(r *geoIPRecord, _ string) interface{} { return r.Postal.Code },
+	GeoIPFieldLatitude:       func(r *geoIPRecord, _ string) interface{} { return r.Location.Latitude },
+	GeoIPFieldLongitude:      func(r *geoIPRecord, _ string) interface{} { return r.Location.Longitude },
+	GeoIPFieldTimezone:       func(r *geoIPRecord, _ string) interface{} { return r.Location.TimeZone },
+	GeoIPFieldASN:            func(r *geoIPRecord, _ string) interface{} { return r.AutonomousSystemNumber },
+	GeoIPFieldASOrganization: func(r *geoIPRecord, _ string) interface{} { return r.AutonomousSystemOrganization },
+}
+
+// geoIPRecord holds the data of City, Country and ASN databases, fields which are not in
+// the database are left empty.
+type geoIPRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Continent struct {
+		Code  string            `maxminddb:"code"`
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"continent"`
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+		TimeZone  string   `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// GeoIPConfig contains the configuration for a geoIPStage
+type GeoIPConfig struct {
+	DB             string            `mapstructure:"db"`
+	Source         string            `mapstructure:"source"`
+	Fields         map[string]string `mapstructure:"fields"`
+	Language       string            `mapstructure:"language"`
+	ReloadInterval *string           `mapstructure:"reload_interval"`
+	reloadInterval time.Duration
+}
+
+// validateGeoIPConfig validates the GeoIPConfig for the geoIPStage
+func validateGeoIPConfig(cfg *GeoIPConfig) error {
+	if cfg.DB == "" {
+		return errors.New(ErrGeoIPStageEmptyDB)
+	}
+	if cfg.Source == "" {
+		return errors.New(ErrGeoIPStageEmptySource)
+	}
+	if cfg.Language == "" {
+		cfg.Language = defaultGeoIPLanguage
+	}
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = make(map[string]string, len(geoIPFields))
+		for field := range geoIPFields {
+			cfg.Fields[defaultGeoIPFieldNamePrefix+field] = field
+		}
+	}
+	for _, field := range cfg.Fields {
+		if _, ok := geoIPFields[field]; !ok {
+			supported := make([]string, 0, len(geoIPFields))
+			for f := range geoIPFields {
+				supported = append(supported, f)
+			}
+			sort.Strings(supported)
+			return errors.Errorf(ErrGeoIPStageInvalidField, field, strings.Join(supported, ", "))
+		}
+	}
+	cfg.reloadInterval = defaultGeoIPReloadInterval
+	if cfg.ReloadInterval != nil {
+		dur, err := time.ParseDuration(*cfg.ReloadInterval)
+		if err != nil {
+			return errors.Errorf(ErrGeoIPStageInvalidReload, *cfg.ReloadInterval, err)
+		}
+		cfg.reloadInterval = dur
+	}
+	return nil
+}
+
+// newGeoIPStage creates a geoIPStage from config
+func newGeoIPStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg := &GeoIPConfig{}
+	err := mapstructure.WeakDecode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = validateGeoIPConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &geoIPStage{
+		logger: log.With(logger, "component", "stage", "type", "geoip"),
+		cfg:    cfg,
+	}
+	if err := s.load(); err != nil {
+		return nil, errors.Errorf(ErrGeoIPStageCannotOpenDB, err)
+	}
+	return toStage(s), nil
+}
+
+// geoIPStage looks up an IP address from the extracted map in a MaxMind database and writes
+// the location and autonomous system of the address in the extracted map.
+type geoIPStage struct {
+	logger log.Logger
+	cfg    *GeoIPConfig
+
+	mtx        sync.RWMutex
+	db         *maxminddb.Reader
+	modTime    time.Time
+	lastReload time.Time
+}
+
+// load reads the database file in memory, it can then be safely replaced on disk.
+func (g *geoIPStage) load() error {
+	fi, err := os.Stat(g.cfg.DB)
+	if err != nil {
+		return err
+	}
+	buf, err := ioutil.ReadFile(g.cfg.DB)
+	if err != nil {
+		return err
+	}
+	db, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		return err
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.db = db
+	g.modTime = fi.ModTime()
+	g.lastReload = time.Now()
+	return nil
+}
+
+// reloadIfModified reloads the database when the file has been modified since it was loaded,
+// at most once every reload interval.
+func (g *geoIPStage) reloadIfModified() {
+	g.mtx.RLock()
+	due := g.cfg.reloadInterval > 0 && time.Since(g.lastReload) >= g.cfg.reloadInterval
+	modTime := g.modTime
+	g.mtx.RUnlock()
+	if !due {
+		return
+	}
+
+	fi, err := os.Stat(g.cfg.DB)
+	if err == nil && fi.ModTime().Equal(modTime) {
+		g.mtx.Lock()
+		g.lastReload = time.Now()
+		g.mtx.Unlock()
+		return
+	}
+	if err == nil {
+		err = g.load()
+		if err == nil {
+			level.Info(g.logger).Log("msg", "reloaded geoip database", "db", g.cfg.DB)
+			return
+		}
+	}
+	// Keep the database loaded, the file may be in the middle of an update.
+	level.Warn(g.logger).Log("msg", "failed to reload geoip database", "db", g.cfg.DB, "err", err)
+	g.mtx.Lock()
+	g.lastReload = time.Now()
+	g.mtx.Unlock()
+}
+
+// Process implements Stage
+func (g *geoIPStage) Process(_ model.LabelSet, extracted map[string]interface{}, _ *time.Time, _ *string) {
+	g.reloadIfModified()
+
+	v, ok := extracted[g.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "source does not exist in the set of extracted values", "source", g.cfg.Source)
+		}
+		return
+	}
+	s, err := getString(v)
+	if err != nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "failed to convert source value to string", "source", g.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+		}
+		return
+	}
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "source value is not an IP address", "source", g.cfg.Source, "value", s)
+		}
+		return
+	}
+
+	var record geoIPRecord
+	g.mtx.RLock()
+	err = g.db.Lookup(ip, &record)
+	g.mtx.RUnlock()
+	if err != nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "failed to look up IP address", "ip", ip, "err", err)
+		}
+		return
+	}
+
+	for name, field := range g.cfg.Fields {
+		switch value := geoIPFields[field](&record, g.cfg.Language).(type) {
+		case string:
+			if value != "" {
+				extracted[name] = value
+			}
+		case *float64:
+			if value != nil {
+				extracted[name] = *value
+			}
+		case uint:
+			if value != 0 {
+				extracted[name] = fmt.Sprint(value)
+			}
+		}
+	}
+}
+
+// Name implements Stage
+func (g *geoIPStage) Name() string {
+	return StageTypeGeoIP
+}
@@ -0,0 +1,173 @@
+package stages
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+var testGeoIPYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      ip: client.ip
+- geoip:
+    db: testdata/geoip/GeoLite2-City-Test.mmdb
+    source: ip
+- geoip:
+    db: testdata/geoip/GeoLite2-ASN-Test.mmdb
+    source: ip
+    fields:
+      asn: autonomous_system_number
+      as_org: autonomous_system_organization
+`
+
+func TestGeoIPPipeline(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testGeoIPYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, nil, `{"client":{"ip":"81.2.69.160"}}`, time.Now()),
+		newEntry(nil, nil, `{"client":{"ip":"1.128.0.1"}}`, time.Now()),
+		newEntry(nil, nil, `{"client":{"ip":"127.0.0.1"}}`, time.Now()),
+	)
+	require.Len(t, out, 3)
+
+	require.Equal(t, map[string]interface{}{
+		"ip":                         "81.2.69.160",
+		"geoip_city_name":            "London",
+		"geoip_country_name":         "United Kingdom",
+		"geoip_country_iso_code":     "GB",
+		"geoip_continent_name":       "Europe",
+		"geoip_continent_code":       "EU",
+		"geoip_subdivision_name":     "England",
+		"geoip_subdivision_iso_code": "ENG",
+		"geoip_postal_code":          "EC2V",
+		"geoip_location_latitude":    51.5142,
+		"geoip_location_longitude":   -0.0931,
+		"geoip_location_timezone":    "Europe/London",
+		"asn":                        "20712",
+		"as_org":                     "Andrews & Arnold Ltd",
+	}, out[0].Extracted)
+	require.Equal(t, map[string]interface{}{
+		"ip":     "1.128.0.1",
+		"asn":    "1221",
+		"as_org": "Telstra Pty Ltd",
+	}, out[1].Extracted)
+	require.Equal(t, map[string]interface{}{
+		"ip": "127.0.0.1",
+	}, out[2].Extracted)
+}
+
+func TestGeoIPStage_InvalidSource(t *testing.T) {
+	s, err := newGeoIPStage(util_log.Logger, map[string]interface{}{
+		"db":     "testdata/geoip/GeoLite2-City-Test.mmdb",
+		"source": "ip",
+	})
+	require.NoError(t, err)
+
+	for _, extracted := range []map[string]interface{}{
+		{},
+		{"ip": "not an ip"},
+		{"ip": []string{"81.2.69.160"}},
+	} {
+		out := processEntries(s, newEntry(extracted, nil, "line", time.Now()))
+		require.Len(t, out, 1)
+		require.Len(t, out[0].Extracted, len(extracted))
+	}
+}
+
+func TestGeoIPStage_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geoip")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	db := filepath.Join(dir, "geoip.mmdb")
+	copyFile(t, "testdata/geoip/GeoLite2-City-Test.mmdb", db)
+
+	s, err := newGeoIPStage(util_log.Logger, map[string]interface{}{
+		"db":              db,
+		"source":          "ip",
+		"reload_interval": "10ms",
+		"fields": map[string]interface{}{
+			"city": GeoIPFieldCityName,
+			"asn":  GeoIPFieldASN,
+		},
+	})
+	require.NoError(t, err)
+
+	out := processEntries(s, newEntry(map[string]interface{}{"ip": "81.2.69.160"}, nil, "line", time.Now()))
+	require.Equal(t, map[string]interface{}{"ip": "81.2.69.160", "city": "London"}, out[0].Extracted)
+
+	copyFile(t, "testdata/geoip/GeoLite2-ASN-Test.mmdb", db)
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(db, modTime, modTime))
+	time.Sleep(20 * time.Millisecond)
+
+	out = processEntries(s, newEntry(map[string]interface{}{"ip": "81.2.69.160"}, nil, "line", time.Now()))
+	require.Equal(t, map[string]interface{}{"ip": "81.2.69.160", "asn": "20712"}, out[0].Extracted)
+
+	// A broken database is ignored and the previous one is kept.
+	require.NoError(t, ioutil.WriteFile(db, []byte("corrupted"), 0644))
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(db, modTime, modTime))
+	time.Sleep(20 * time.Millisecond)
+
+	out = processEntries(s, newEntry(map[string]interface{}{"ip": "81.2.69.160"}, nil, "line", time.Now()))
+	require.Equal(t, map[string]interface{}{"ip": "81.2.69.160", "asn": "20712"}, out[0].Extracted)
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	buf, err := ioutil.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(dst, buf, 0644))
+}
+
+func Test_validateGeoIPConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  *GeoIPConfig
+		wantErr string
+	}{
+		{"valid", &GeoIPConfig{DB: "db.mmdb", Source: "ip"}, ""},
+		{"missing db", &GeoIPConfig{Source: "ip"}, ErrGeoIPStageEmptyDB},
+		{"missing source", &GeoIPConfig{DB: "db.mmdb"}, ErrGeoIPStageEmptySource},
+		{
+			"invalid field",
+			&GeoIPConfig{DB: "db.mmdb", Source: "ip", Fields: map[string]string{"foo": "bar"}},
+			`geoip stage invalid field "bar", supported fields are: autonomous_system_number, autonomous_system_organization, city_name, continent_code, continent_name, country_iso_code, country_name, location_latitude, location_longitude, location_timezone, postal_code, subdivision_iso_code, subdivision_name`,
+		},
+		{
+			"invalid reload interval",
+			&GeoIPConfig{DB: "db.mmdb", Source: "ip", ReloadInterval: ptrFromString("foo")},
+			`geoip stage invalid reload_interval, foo cannot be converted to a duration: time: invalid duration "foo"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateGeoIPConfig(tc.config)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, defaultGeoIPLanguage, tc.config.Language)
+			require.Equal(t, defaultGeoIPReloadInterval, tc.config.reloadInterval)
+			require.Len(t, tc.config.Fields, len(geoIPFields))
+			require.Equal(t, GeoIPFieldCityName, tc.config.Fields["geoip_city_name"])
+		})
+	}
+}
+
+func TestGeoIPStage_MissingDB(t *testing.T) {
+	_, err := newGeoIPStage(util_log.Logger, map[string]interface{}{
+		"db":     "testdata/geoip/missing.mmdb",
+		"source": "ip",
+	})
+	require.Error(t, err)
+}
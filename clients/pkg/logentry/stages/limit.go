@@ -0,0 +1,157 @@
+package stages
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/time/rate"
+)
+
+const (
+	ErrLimitStageInvalidRate             = "limit stage rate must be greater than zero"
+	ErrLimitStageInvalidBurst            = "limit stage burst must be greater than zero"
+	ErrLimitStageInvalidMaxDistinctLabel = "limit stage max_distinct_labels must be greater than zero"
+
+	defaultLimitMaxDistinctLabels = 10000
+)
+
+var (
+	defaultLimitDropReason = "limit_stage"
+)
+
+// LimitConfig contains the configuration for a limitStage
+type LimitConfig struct {
+	Rate              float64  `mapstructure:"rate"`
+	Burst             int      `mapstructure:"burst"`
+	Drop              bool     `mapstructure:"drop"`
+	ByLabels          []string `mapstructure:"by_labels"`
+	MaxDistinctLabels int      `mapstructure:"max_distinct_labels"`
+	DropReason        *string  `mapstructure:"drop_counter_reason"`
+}
+
+// validateLimitConfig validates the LimitConfig for the limitStage
+func validateLimitConfig(cfg *LimitConfig) error {
+	if cfg.Rate <= 0 {
+		return errors.New(ErrLimitStageInvalidRate)
+	}
+	if cfg.Burst <= 0 {
+		return errors.New(ErrLimitStageInvalidBurst)
+	}
+	if cfg.MaxDistinctLabels < 0 {
+		return errors.New(ErrLimitStageInvalidMaxDistinctLabel)
+	}
+	if cfg.MaxDistinctLabels == 0 {
+		cfg.MaxDistinctLabels = defaultLimitMaxDistinctLabels
+	}
+	if cfg.DropReason == nil || *cfg.DropReason == "" {
+		cfg.DropReason = &defaultLimitDropReason
+	}
+	sort.Strings(cfg.ByLabels)
+	return nil
+}
+
+// newLimitStage creates a limitStage from config
+func newLimitStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
+	cfg := &LimitConfig{}
+	err := mapstructure.WeakDecode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = validateLimitConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limitStage{
+		logger:    log.With(logger, "component", "stage", "type", "limit"),
+		cfg:       cfg,
+		dropCount: getDropCountMetric(registerer),
+		limiter:   rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst),
+		limiters:  map[string]*rate.Limiter{},
+	}, nil
+}
+
+// limitStage limits the rate of log lines going through the pipeline with a token bucket,
+// either for the whole pipeline or for each set of values of the configured labels.
+// Lines above the rate are either dropped or wait for the rate to allow them.
+type limitStage struct {
+	logger    log.Logger
+	cfg       *LimitConfig
+	dropCount *prometheus.CounterVec
+
+	limiter *rate.Limiter
+
+	mtx      sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (m *limitStage) Run(in chan Entry) chan Entry {
+	return m.runContext(context.Background(), in)
+}
+
+// runContext implements contextStage, once ctx is done lines are no longer waiting for the rate to allow them.
+func (m *limitStage) runContext(ctx context.Context, in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			limiter := m.limiterFor(e.Labels)
+			if !m.cfg.Drop {
+				// Wait fails when ctx is done, the burst being validated. The pipeline is stopping then,
+				// let the line through instead of losing it.
+				_ = limiter.Wait(ctx)
+				out <- e
+				continue
+			}
+			if limiter.Allow() {
+				out <- e
+				continue
+			}
+			if Debug {
+				level.Debug(m.logger).Log("msg", "line dropped, rate limit exceeded", "labels", e.Labels)
+			}
+			m.dropCount.WithLabelValues(*m.cfg.DropReason).Inc()
+		}
+	}()
+	return out
+}
+
+// limiterFor returns the limiter to use for a label set.
+func (m *limitStage) limiterFor(lbs model.LabelSet) *rate.Limiter {
+	if len(m.cfg.ByLabels) == 0 {
+		return m.limiter
+	}
+
+	values := make([]string, 0, len(m.cfg.ByLabels))
+	for _, name := range m.cfg.ByLabels {
+		values = append(values, string(lbs[model.LabelName(name)]))
+	}
+	key := strings.Join(values, "\xff")
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	limiter, ok := m.limiters[key]
+	if !ok {
+		if len(m.limiters) >= m.cfg.MaxDistinctLabels {
+			// Forget every limiter rather than growing without bounds, they are re-created with a full bucket.
+			level.Warn(m.logger).Log("msg", "too many distinct label values, resetting rate limiters", "max_distinct_labels", m.cfg.MaxDistinctLabels)
+			m.limiters = map[string]*rate.Limiter{}
+		}
+		limiter = rate.NewLimiter(rate.Limit(m.cfg.Rate), m.cfg.Burst)
+		m.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// Name implements Stage
+func (m *limitStage) Name() string {
+	return StageTypeLimit
+}
@@ -0,0 +1,210 @@
+package stages
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+var testLimitDropYaml = `
+pipeline_stages:
+- limit:
+    rate: 1
+    burst: 2
+    drop: true
+`
+
+var testLimitByLabelsYaml = `
+pipeline_stages:
+- limit:
+    rate: 1
+    burst: 1
+    drop: true
+    by_labels: [app]
+    drop_counter_reason: noisy_app
+`
+
+var testLimitBlockYaml = `
+pipeline_stages:
+- limit:
+    rate: 50
+    burst: 1
+`
+
+func TestLimitPipeline(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		config          string
+		entries         []Entry
+		expectedLines   []string
+		expectedReason  string
+		expectedDropped float64
+	}{
+		{
+			"drop",
+			testLimitDropYaml,
+			[]Entry{
+				newEntry(nil, nil, "1", time.Now()),
+				newEntry(nil, nil, "2", time.Now()),
+				newEntry(nil, nil, "3", time.Now()),
+				newEntry(nil, nil, "4", time.Now()),
+			},
+			[]string{"1", "2"},
+			"limit_stage",
+			2,
+		},
+		{
+			"drop by labels",
+			testLimitByLabelsYaml,
+			[]Entry{
+				newEntry(nil, model.LabelSet{"app": "foo"}, "1", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "bar"}, "2", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "foo"}, "3", time.Now()),
+				newEntry(nil, model.LabelSet{"app": "bar"}, "4", time.Now()),
+				newEntry(nil, model.LabelSet{"job": "baz"}, "5", time.Now()),
+			},
+			[]string{"1", "2", "5"},
+			"noisy_app",
+			2,
+		},
+		{
+			"block",
+			testLimitBlockYaml,
+			[]Entry{
+				newEntry(nil, nil, "1", time.Now()),
+				newEntry(nil, nil, "2", time.Now()),
+				newEntry(nil, nil, "3", time.Now()),
+			},
+			[]string{"1", "2", "3"},
+			"limit_stage",
+			0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			pl, err := NewPipeline(util_log.Logger, loadConfig(tc.config), nil, registry)
+			require.NoError(t, err)
+
+			var lines []string
+			for _, e := range processEntries(pl, tc.entries...) {
+				lines = append(lines, e.Line)
+			}
+			require.Equal(t, tc.expectedLines, lines)
+			require.Equal(t, tc.expectedDropped, testutil.ToFloat64(getDropCountMetric(registry).WithLabelValues(tc.expectedReason)))
+		})
+	}
+}
+
+func TestLimitStage_Block(t *testing.T) {
+	s, err := newLimitStage(util_log.Logger, map[string]interface{}{
+		"rate":  20,
+		"burst": 1,
+	}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	start := time.Now()
+	out := processEntries(s,
+		newEntry(nil, nil, "1", time.Now()),
+		newEntry(nil, nil, "2", time.Now()),
+		newEntry(nil, nil, "3", time.Now()),
+	)
+	require.Len(t, out, 3)
+	// The first line uses the burst, the two others wait 50ms each.
+	require.True(t, time.Since(start) >= 90*time.Millisecond)
+}
+
+var testLimitBlockInMatchYaml = `
+pipeline_stages:
+- match:
+    selector: '{app="foo"}'
+    stages:
+    - limit:
+        rate: 0.001
+        burst: 1
+`
+
+func TestLimitStage_BlockStopsWithPipeline(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testLimitBlockInMatchYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	c := fake.New(func() {})
+	handler := pl.Wrap(c)
+	for _, line := range []string{"1", "2", "3"} {
+		handler.Chan() <- api.Entry{
+			Labels: model.LabelSet{"app": "foo"},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+		}
+	}
+
+	// Without a cancelled context, the second line would wait for about 17 minutes.
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		handler.Stop()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stopping the pipeline is blocked by the limit stage")
+	}
+	c.Stop()
+
+	var lines []string
+	for _, e := range c.Received() {
+		lines = append(lines, e.Line)
+	}
+	require.Equal(t, []string{"1", "2", "3"}, lines)
+}
+
+func TestLimitStage_MaxDistinctLabels(t *testing.T) {
+	s, err := newLimitStage(util_log.Logger, map[string]interface{}{
+		"rate":                1,
+		"burst":               1,
+		"drop":                true,
+		"by_labels":           []string{"app"},
+		"max_distinct_labels": 2,
+	}, prometheus.NewRegistry())
+	require.NoError(t, err)
+	stage := s.(*limitStage)
+
+	stage.limiterFor(model.LabelSet{"app": "foo"})
+	stage.limiterFor(model.LabelSet{"app": "bar"})
+	require.Len(t, stage.limiters, 2)
+	stage.limiterFor(model.LabelSet{"app": "baz"})
+	require.Len(t, stage.limiters, 1)
+}
+
+func Test_validateLimitConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  *LimitConfig
+		wantErr error
+	}{
+		{"valid", &LimitConfig{Rate: 10, Burst: 10}, nil},
+		{"missing rate", &LimitConfig{Burst: 10}, errors.New(ErrLimitStageInvalidRate)},
+		{"missing burst", &LimitConfig{Rate: 10}, errors.New(ErrLimitStageInvalidBurst)},
+		{"negative max distinct labels", &LimitConfig{Rate: 10, Burst: 10, MaxDistinctLabels: -1}, errors.New(ErrLimitStageInvalidMaxDistinctLabel)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateLimitConfig(tc.config)
+			if tc.wantErr != nil {
+				require.EqualError(t, err, tc.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, defaultLimitMaxDistinctLabels, tc.config.MaxDistinctLabels)
+			require.Equal(t, defaultLimitDropReason, *tc.config.DropReason)
+		})
+	}
+}
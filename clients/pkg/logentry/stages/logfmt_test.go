@@ -0,0 +1,267 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogfmtYamlSingleStageWithoutSource = `
+pipeline_stages:
+- logfmt:
+    mapping:
+      out:  message
+      app:
+      duration:
+      unknown:
+`
+
+var testLogfmtYamlMultiStageWithSource = `
+pipeline_stages:
+- logfmt:
+    mapping:
+      extra:
+- logfmt:
+    mapping:
+      user:
+    source: extra
+`
+
+var testLogfmtLogLine = `time=2012-11-01T22:08:41+00:00 app=loki level=WARN duration=125 message="this is a log line" extra="user=foo"`
+
+// The example of docs/sources/clients/promtail/stages/logfmt.md using the log line,
+// the example using extracted data is testLogfmtYamlMultiStageWithSource.
+var testLogfmtYamlDocExampleLogLine = `
+pipeline_stages:
+- logfmt:
+    mapping:
+      timestamp: time
+      app:
+      duration:
+      unknown:
+`
+
+func TestPipeline_Logfmt(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully run a pipeline with 1 logfmt stage without source": {
+			testLogfmtYamlSingleStageWithoutSource,
+			testLogfmtLogLine,
+			map[string]interface{}{
+				"out":      "this is a log line",
+				"app":      "loki",
+				"duration": "125",
+			},
+		},
+		"successfully run a pipeline with 2 logfmt stages with source": {
+			testLogfmtYamlMultiStageWithSource,
+			testLogfmtLogLine,
+			map[string]interface{}{
+				"extra": "user=foo",
+				"user":  "foo",
+			},
+		},
+		"documentation example using log line": {
+			testLogfmtYamlDocExampleLogLine,
+			testLogfmtLogLine,
+			map[string]interface{}{
+				"timestamp": "2012-11-01T22:08:41+00:00",
+				"app":       "loki",
+				"duration":  "125",
+			},
+		},
+		"invalid line keeps the values decoded before the error": {
+			testLogfmtYamlSingleStageWithoutSource,
+			`app=loki duration=1"25`,
+			map[string]interface{}{
+				"app": "loki",
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(util_log.Logger, loadConfig(testData.config), nil, prometheus.DefaultRegisterer)
+			require.NoError(t, err)
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestLogfmtConfig_validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config           interface{}
+		wantMappingCount int
+		err              error
+	}{
+		"empty config": {
+			nil,
+			0,
+			errors.New(ErrMappingRequired),
+		},
+		"no mapping": {
+			map[string]interface{}{},
+			0,
+			errors.New(ErrMappingRequired),
+		},
+		"empty source": {
+			map[string]interface{}{
+				"mapping": map[string]interface{}{
+					"extr1": "expr",
+				},
+				"source": "",
+			},
+			0,
+			errors.New(ErrEmptyLogfmtStageSource),
+		},
+		"valid without source": {
+			map[string]interface{}{
+				"mapping": map[string]string{
+					"foo1": "foo",
+					"foo2": "",
+				},
+			},
+			2,
+			nil,
+		},
+		"valid with source": {
+			map[string]interface{}{
+				"mapping": map[string]string{
+					"foo1": "foo",
+					"foo2": "",
+				},
+				"source": "log",
+			},
+			2,
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			c, err := parseLogfmtConfig(tt.config)
+			require.NoError(t, err)
+			got, err := validateLogfmtConfig(c)
+			if tt.err != nil {
+				require.EqualError(t, err, tt.err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, got, tt.wantMappingCount)
+		})
+	}
+}
+
+func TestLogfmtParser_Parse(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config          interface{}
+		extracted       map[string]interface{}
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully decode logfmt on entry": {
+			map[string]interface{}{
+				"mapping": map[string]string{
+					"time":    "",
+					"app":     "",
+					"level":   "",
+					"nested":  "",
+					"message": "",
+				},
+			},
+			map[string]interface{}{},
+			`time=2012-11-01T22:08:41+00:00 app=loki level=WARN nested="{\"child\":\"value\"}" message="this is a log line"`,
+			map[string]interface{}{
+				"time":    "2012-11-01T22:08:41+00:00",
+				"app":     "loki",
+				"level":   "WARN",
+				"nested":  "{\"child\":\"value\"}",
+				"message": "this is a log line",
+			},
+		},
+		"successfully decode logfmt on extracted[source]": {
+			map[string]interface{}{
+				"mapping": map[string]string{
+					"user": "",
+				},
+				"source": "log",
+			},
+			map[string]interface{}{
+				"log": "user=foo",
+			},
+			"",
+			map[string]interface{}{
+				"log":  "user=foo",
+				"user": "foo",
+			},
+		},
+		"missing extracted[source]": {
+			map[string]interface{}{
+				"mapping": map[string]string{
+					"user": "",
+				},
+				"source": "log",
+			},
+			map[string]interface{}{},
+			"user=foo",
+			map[string]interface{}{},
+		},
+		"non string extracted[source]": {
+			map[string]interface{}{
+				"mapping": map[string]string{
+					"user": "",
+				},
+				"source": "log",
+			},
+			map[string]interface{}{
+				"log": []string{"user=foo"},
+			},
+			"",
+			map[string]interface{}{
+				"log": []string{"user=foo"},
+			},
+		},
+		"keys without value": {
+			map[string]interface{}{
+				"mapping": map[string]string{
+					"debug": "",
+				},
+			},
+			map[string]interface{}{},
+			"debug msg=hello",
+			map[string]interface{}{
+				"debug": "",
+			},
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			p, err := New(util_log.Logger, nil, StageTypeLogfmt, tt.config, nil)
+			require.NoError(t, err)
+			out := processEntries(p, newEntry(tt.extracted, model.LabelSet{}, tt.entry, time.Now()))[0]
+			assert.Equal(t, tt.expectedExtract, out.Extracted)
+		})
+	}
+}
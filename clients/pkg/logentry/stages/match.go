@@ -1,6 +1,8 @@
 package stages
 
 import (
+	"context"
+
 	"github.com/prometheus/prometheus/pkg/labels"
 
 	"github.com/go-kit/kit/log"
@@ -142,19 +144,24 @@ type matcherStage struct {
 }
 
 func (m *matcherStage) Run(in chan Entry) chan Entry {
+	return m.runContext(context.Background(), in)
+}
+
+// runContext implements contextStage
+func (m *matcherStage) runContext(ctx context.Context, in chan Entry) chan Entry {
 	switch m.action {
 	case MatchActionDrop:
 		return m.runDrop(in)
 	case MatchActionKeep:
-		return m.runKeep(in)
+		return m.runKeep(ctx, in)
 	}
 	panic("unexpected action")
 }
 
-func (m *matcherStage) runKeep(in chan Entry) chan Entry {
+func (m *matcherStage) runKeep(ctx context.Context, in chan Entry) chan Entry {
 	next := make(chan Entry)
 	out := make(chan Entry)
-	outNext := m.stage.Run(next)
+	outNext := runStage(ctx, m.stage, next)
 	go func() {
 		defer close(out)
 		for e := range outNext {
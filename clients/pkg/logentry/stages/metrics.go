@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -22,22 +23,31 @@ const (
 	MetricTypeCounter   = "counter"
 	MetricTypeGauge     = "gauge"
 	MetricTypeHistogram = "histogram"
+	MetricTypeSummary   = "summary"
 
 	ErrEmptyMetricsStageConfig = "empty metric stage configuration"
-	ErrMetricsStageInvalidType = "invalid metric type '%s', metric type must be one of 'counter', 'gauge', or 'histogram'"
+	ErrMetricsStageInvalidType = "invalid metric type '%s', metric type must be one of 'counter', 'gauge', 'histogram' or 'summary'"
 	ErrInvalidIdleDur          = "max_idle_duration could not be parsed as a time.Duration: '%s'"
 	ErrSubSecIdleDur           = "max_idle_duration less than 1s not allowed"
+	ErrInvalidMaxSeries        = "max_series must be greater than or equal to 0"
+	ErrExemplarNotSupported    = "exemplar_source is only supported by counters and histograms"
+	ErrEmptyExemplarSource     = "exemplar_source cannot be empty"
+
+	// exemplarLabel is the label of the exemplars holding the value of exemplar_source.
+	exemplarLabel = "trace_id"
 )
 
 // MetricConfig is a single metrics configuration.
 type MetricConfig struct {
-	MetricType   string  `mapstructure:"type"`
-	Description  string  `mapstructure:"description"`
-	Source       *string `mapstructure:"source"`
-	Prefix       string  `mapstructure:"prefix"`
-	IdleDuration *string `mapstructure:"max_idle_duration"`
-	maxIdleSec   int64
-	Config       interface{} `mapstructure:"config"`
+	MetricType     string  `mapstructure:"type"`
+	Description    string  `mapstructure:"description"`
+	Source         *string `mapstructure:"source"`
+	Prefix         string  `mapstructure:"prefix"`
+	IdleDuration   *string `mapstructure:"max_idle_duration"`
+	maxIdleSec     int64
+	MaxSeries      int         `mapstructure:"max_series"`
+	ExemplarSource *string     `mapstructure:"exemplar_source"`
+	Config         interface{} `mapstructure:"config"`
 }
 
 // MetricsConfig is a set of configured metrics.
@@ -59,10 +69,24 @@ func validateMetricsConfig(cfg MetricsConfig) error {
 		config.MetricType = strings.ToLower(config.MetricType)
 		if config.MetricType != MetricTypeCounter &&
 			config.MetricType != MetricTypeGauge &&
-			config.MetricType != MetricTypeHistogram {
+			config.MetricType != MetricTypeHistogram &&
+			config.MetricType != MetricTypeSummary {
 			return errors.Errorf(ErrMetricsStageInvalidType, config.MetricType)
 		}
 
+		if config.MaxSeries < 0 {
+			return errors.New(ErrInvalidMaxSeries)
+		}
+
+		if config.ExemplarSource != nil {
+			if *config.ExemplarSource == "" {
+				return errors.New(ErrEmptyExemplarSource)
+			}
+			if config.MetricType != MetricTypeCounter && config.MetricType != MetricTypeHistogram {
+				return errors.New(ErrExemplarNotSupported)
+			}
+		}
+
 		// Set the idle duration for metrics
 		if config.IdleDuration != nil {
 			d, err := time.ParseDuration(*config.IdleDuration)
@@ -95,6 +119,7 @@ func newMetricStage(logger log.Logger, config interface{}, registry prometheus.R
 	if err != nil {
 		return nil, err
 	}
+	droppedSeries := getDroppedSeriesMetric(registry)
 	metrics := map[string]prometheus.Collector{}
 	for name, cfg := range *cfgs {
 		var collector prometheus.Collector
@@ -122,8 +147,16 @@ func newMetricStage(logger log.Logger, config interface{}, registry prometheus.R
 			if err != nil {
 				return nil, err
 			}
+		case MetricTypeSummary:
+			collector, err = metric.NewSummaries(customPrefix+name, cfg.Description, cfg.Config, cfg.maxIdleSec)
+			if err != nil {
+				return nil, err
+			}
 		}
 		if collector != nil {
+			if cfg.MaxSeries > 0 {
+				collector.(seriesLimiter).SetMaxSeries(cfg.MaxSeries, droppedSeries.WithLabelValues(customPrefix+name))
+			}
 			registry.MustRegister(collector)
 			metrics[name] = collector
 		}
@@ -135,6 +168,30 @@ func newMetricStage(logger log.Logger, config interface{}, registry prometheus.R
 	}), nil
 }
 
+// seriesLimiter is implemented by the metric vectors of the metric package.
+type seriesLimiter interface {
+	SetMaxSeries(maxSeries int, dropped prometheus.Counter)
+}
+
+// getDroppedSeriesMetric returns the counter of updates dropped because a metric reached its max_series.
+func getDroppedSeriesMetric(registerer prometheus.Registerer) *prometheus.CounterVec {
+	droppedSeries := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logentry",
+		Name:      "metrics_dropped_series_total",
+		Help:      "A count of metric updates dropped because they would have created a series above the max_series of the metric",
+	}, []string{"metric"})
+	err := registerer.Register(droppedSeries)
+	if err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			droppedSeries = existing.ExistingCollector.(*prometheus.CounterVec)
+		} else {
+			// Same behavior as MustRegister if the error is not for AlreadyRegistered
+			panic(err)
+		}
+	}
+	return droppedSeries
+}
+
 // metricStage creates and updates prometheus metrics based on extracted pipeline data
 type metricStage struct {
 	logger  log.Logger
@@ -150,10 +207,10 @@ func (m *metricStage) Process(labels model.LabelSet, extracted map[string]interf
 			if c != nil && c.Cfg.MatchAll != nil && *c.Cfg.MatchAll {
 				if c.Cfg.CountBytes != nil && *c.Cfg.CountBytes {
 					if entry != nil {
-						m.recordCounter(name, c, labels, len(*entry))
+						m.recordCounter(name, c, labels, extracted, len(*entry))
 					}
 				} else {
-					m.recordCounter(name, c, labels, nil)
+					m.recordCounter(name, c, labels, extracted, nil)
 				}
 				continue
 			}
@@ -161,11 +218,13 @@ func (m *metricStage) Process(labels model.LabelSet, extracted map[string]interf
 		if v, ok := extracted[*m.cfg[name].Source]; ok {
 			switch vec := collector.(type) {
 			case *metric.Counters:
-				m.recordCounter(name, vec, labels, v)
+				m.recordCounter(name, vec, labels, extracted, v)
 			case *metric.Gauges:
 				m.recordGauge(name, vec, labels, v)
 			case *metric.Histograms:
-				m.recordHistogram(name, vec, labels, v)
+				m.recordHistogram(name, vec, labels, extracted, v)
+			case *metric.Summaries:
+				m.recordSummary(name, vec, labels, v)
 			}
 		} else {
 			level.Debug(m.logger).Log("msg", "source does not exist", "err", fmt.Sprintf("source: %s, does not exist", *m.cfg[name].Source))
@@ -178,8 +237,36 @@ func (m *metricStage) Name() string {
 	return StageTypeMetric
 }
 
+// exemplar returns the exemplar to attach to the update of a metric, or nil if the metric
+// has no exemplar_source or if the source is not in the extracted map.
+func (m *metricStage) exemplar(name string, extracted map[string]interface{}) prometheus.Labels {
+	source := m.cfg[name].ExemplarSource
+	if source == nil {
+		return nil
+	}
+	v, ok := extracted[*source]
+	if !ok {
+		return nil
+	}
+	s, err := getString(v)
+	if err != nil || s == "" {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "failed to convert exemplar source to string", "metric", name, "err", err)
+		}
+		return nil
+	}
+	// The client library panics on exemplars which are not valid.
+	if !utf8.ValidString(s) || utf8.RuneCountInString(exemplarLabel)+utf8.RuneCountInString(s) > prometheus.ExemplarMaxRunes {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "exemplar source is not valid UTF-8 or is too long", "metric", name, "value", s)
+		}
+		return nil
+	}
+	return prometheus.Labels{exemplarLabel: s}
+}
+
 // recordCounter will update a counter metric
-func (m *metricStage) recordCounter(name string, counter *metric.Counters, labels model.LabelSet, v interface{}) {
+func (m *metricStage) recordCounter(name string, counter *metric.Counters, labels model.LabelSet, extracted map[string]interface{}, v interface{}) {
 	// If value matching is defined, make sure value matches.
 	if counter.Cfg.Value != nil {
 		stringVal, err := getString(v)
@@ -196,19 +283,25 @@ func (m *metricStage) recordCounter(name string, counter *metric.Counters, label
 		}
 	}
 
+	var f float64
 	switch counter.Cfg.Action {
 	case metric.CounterInc:
-		counter.With(labels).Inc()
+		f = 1
 	case metric.CounterAdd:
-		f, err := getFloat(v)
+		var err error
+		f, err = getFloat(v)
 		if err != nil {
 			if Debug {
 				level.Debug(m.logger).Log("msg", "failed to convert extracted value to positive float", "metric", name, "err", err)
 			}
 			return
 		}
-		counter.With(labels).Add(f)
 	}
+	if exemplar := m.exemplar(name, extracted); exemplar != nil {
+		counter.With(labels).(prometheus.ExemplarAdder).AddWithExemplar(f, exemplar)
+		return
+	}
+	counter.With(labels).Add(f)
 }
 
 // recordGauge will update a gauge metric
@@ -265,7 +358,7 @@ func (m *metricStage) recordGauge(name string, gauge *metric.Gauges, labels mode
 }
 
 // recordHistogram will update a Histogram metric
-func (m *metricStage) recordHistogram(name string, histogram *metric.Histograms, labels model.LabelSet, v interface{}) {
+func (m *metricStage) recordHistogram(name string, histogram *metric.Histograms, labels model.LabelSet, extracted map[string]interface{}, v interface{}) {
 	// If value matching is defined, make sure value matches.
 	if histogram.Cfg.Value != nil {
 		stringVal, err := getString(v)
@@ -288,9 +381,40 @@ func (m *metricStage) recordHistogram(name string, histogram *metric.Histograms,
 		}
 		return
 	}
+	if exemplar := m.exemplar(name, extracted); exemplar != nil {
+		histogram.With(labels).(prometheus.ExemplarObserver).ObserveWithExemplar(f, exemplar)
+		return
+	}
 	histogram.With(labels).Observe(f)
 }
 
+// recordSummary will update a Summary metric
+func (m *metricStage) recordSummary(name string, summary *metric.Summaries, labels model.LabelSet, v interface{}) {
+	// If value matching is defined, make sure value matches.
+	if summary.Cfg.Value != nil {
+		stringVal, err := getString(v)
+		if err != nil {
+			if Debug {
+				level.Debug(m.logger).Log("msg", "failed to convert extracted value to string, "+
+					"can't perform value comparison", "metric", name, "err",
+					fmt.Sprintf("can't convert %v to string", reflect.TypeOf(v)))
+			}
+			return
+		}
+		if *summary.Cfg.Value != stringVal {
+			return
+		}
+	}
+	f, err := getFloat(v)
+	if err != nil {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "failed to convert extracted value to float", "metric", name, "err", err)
+		}
+		return
+	}
+	summary.With(labels).Observe(f)
+}
+
 // getFloat will take the provided value and return a float64 if possible
 func getFloat(unk interface{}) (float64, error) {
 	switch i := unk.(type) {
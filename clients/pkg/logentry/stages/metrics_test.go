@@ -2,6 +2,7 @@ package stages
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/clients/pkg/logentry/metric"
 )
@@ -230,7 +232,10 @@ func TestMetricsWithDropInPipeline(t *testing.T) {
 	}
 }
 
-var metricTestInvalidIdle = "10f"
+var (
+	metricTestInvalidIdle    = "10f"
+	metricTestExemplarSource = "trace_id"
+)
 
 func TestValidateMetricsConfig(t *testing.T) {
 	tests := map[string]struct {
@@ -258,6 +263,24 @@ func TestValidateMetricsConfig(t *testing.T) {
 			},
 			errors.Errorf(ErrInvalidIdleDur, `time: unknown unit "f" in duration "10f"`),
 		},
+		"invalid max series": {
+			MetricsConfig{
+				"metric1": MetricConfig{
+					MetricType: "Counter",
+					MaxSeries:  -1,
+				},
+			},
+			errors.New(ErrInvalidMaxSeries),
+		},
+		"exemplar on gauge": {
+			MetricsConfig{
+				"metric1": MetricConfig{
+					MetricType:     "Gauge",
+					ExemplarSource: &metricTestExemplarSource,
+				},
+			},
+			errors.New(ErrExemplarNotSupported),
+		},
 		"valid": {
 			MetricsConfig{
 				"metric1": MetricConfig{
@@ -270,6 +293,16 @@ func TestValidateMetricsConfig(t *testing.T) {
 			},
 			nil,
 		},
+		"valid summary": {
+			MetricsConfig{
+				"metric1": MetricConfig{
+					MetricType:  "Summary",
+					Description: "some description",
+					MaxSeries:   10,
+				},
+			},
+			nil,
+		},
 	}
 
 	for name, test := range tests {
@@ -491,3 +524,113 @@ promtail_custom_response_time_ms_count{baz="fu",fu="baz"} 1.0
 promtail_custom_total_keys{bar="foo",foo="bar"} 8.0
 promtail_custom_total_keys{baz="fu",fu="baz"} 8.0
 `
+
+var testMetricSummaryYaml = `
+pipeline_stages:
+- logfmt:
+    mapping:
+      duration:
+      trace_id:
+- metrics:
+    request_duration_seconds:
+      type: Summary
+      description: request duration
+      source: duration
+      config:
+        objectives:
+          0.5: 0.05
+          1: 0.001
+        max_age: 1m
+    requests_total:
+      type: Counter
+      description: requests
+      source: duration
+      exemplar_source: trace_id
+      config:
+        action: inc
+    request_duration_histogram_seconds:
+      type: Histogram
+      description: request duration
+      source: duration
+      exemplar_source: trace_id
+      config:
+        buckets: [1, 5]
+`
+
+func TestMetricsPipeline_SummaryAndExemplars(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testMetricSummaryYaml), nil, registry)
+	require.NoError(t, err)
+
+	processEntries(pl,
+		newEntry(nil, model.LabelSet{"test": "app"}, "duration=1 trace_id=abc", time.Now()),
+		newEntry(nil, model.LabelSet{"test": "app"}, "duration=3 trace_id=def", time.Now()),
+		newEntry(nil, model.LabelSet{"test": "app"}, "duration=2", time.Now()),
+	)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP promtail_custom_request_duration_seconds request duration
+# TYPE promtail_custom_request_duration_seconds summary
+promtail_custom_request_duration_seconds{test="app",quantile="0.5"} 2
+promtail_custom_request_duration_seconds{test="app",quantile="1"} 3
+promtail_custom_request_duration_seconds_sum{test="app"} 6
+promtail_custom_request_duration_seconds_count{test="app"} 3
+`), "promtail_custom_request_duration_seconds"))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	exemplars := map[string]string{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			if e := m.GetCounter().GetExemplar(); e != nil {
+				exemplars[mf.GetName()] = e.GetLabel()[0].GetName() + "=" + e.GetLabel()[0].GetValue()
+			}
+			for _, b := range m.GetHistogram().GetBucket() {
+				if e := b.GetExemplar(); e != nil {
+					exemplars[fmt.Sprintf("%s_bucket{le=%v}", mf.GetName(), b.GetUpperBound())] = e.GetLabel()[0].GetName() + "=" + e.GetLabel()[0].GetValue()
+				}
+			}
+		}
+	}
+	require.Equal(t, map[string]string{
+		"promtail_custom_requests_total":                                  "trace_id=def",
+		"promtail_custom_request_duration_histogram_seconds_bucket{le=1}": "trace_id=abc",
+		"promtail_custom_request_duration_histogram_seconds_bucket{le=5}": "trace_id=def",
+	}, exemplars)
+}
+
+var testMetricMaxSeriesYaml = `
+pipeline_stages:
+- metrics:
+    lines_total:
+      type: Counter
+      description: lines
+      max_series: 2
+      config:
+        match_all: true
+        action: inc
+`
+
+func TestMetricsPipeline_MaxSeries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testMetricMaxSeriesYaml), nil, registry)
+	require.NoError(t, err)
+
+	processEntries(pl,
+		newEntry(nil, model.LabelSet{"app": "foo"}, "1", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "bar"}, "2", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "baz"}, "3", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "foo"}, "4", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "baz"}, "5", time.Now()),
+	)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP logentry_metrics_dropped_series_total A count of metric updates dropped because they would have created a series above the max_series of the metric
+# TYPE logentry_metrics_dropped_series_total counter
+logentry_metrics_dropped_series_total{metric="promtail_custom_lines_total"} 2
+# HELP promtail_custom_lines_total lines
+# TYPE promtail_custom_lines_total counter
+promtail_custom_lines_total{app="foo"} 2
+promtail_custom_lines_total{app="bar"} 1
+`)))
+}
@@ -1,6 +1,7 @@
 package stages
 
 import (
+	"context"
 	"sync"
 
 	"github.com/go-kit/kit/log"
@@ -68,6 +69,23 @@ func RunWith(input chan Entry, process func(e Entry) Entry) chan Entry {
 
 // Run implements Stage
 func (p *Pipeline) Run(in chan Entry) chan Entry {
+	return p.run(context.Background(), in, nil)
+}
+
+// runContext implements contextStage
+func (p *Pipeline) runContext(ctx context.Context, in chan Entry) chan Entry {
+	return p.run(ctx, in, nil)
+}
+
+// RunWithObserver runs the entries through the pipeline like Run, observe is called with each entry
+// leaving a stage, before it is passed to the next one. The observed entry must not be modified nor
+// retained, observe is called concurrently for the different stages.
+func (p *Pipeline) RunWithObserver(in chan Entry, observe func(index int, stage string, e Entry)) chan Entry {
+	return p.run(context.Background(), in, observe)
+}
+
+// run chains all the stages together, the stages which can block stop blocking once ctx is done.
+func (p *Pipeline) run(ctx context.Context, in chan Entry, observe func(index int, stage string, e Entry)) chan Entry {
 	in = RunWith(in, func(e Entry) Entry {
 		// Initialize the extracted map with the initial labels (ie. "filename"),
 		// so that stages can operate on initial labels too
@@ -77,8 +95,15 @@ func (p *Pipeline) Run(in chan Entry) chan Entry {
 		return e
 	})
 	// chain all stages together.
-	for _, m := range p.stages {
-		in = m.Run(in)
+	for i, m := range p.stages {
+		in = runStage(ctx, m, in)
+		if observe != nil {
+			i, name := i, m.Name()
+			in = RunWith(in, func(e Entry) Entry {
+				observe(i, name, e)
+				return e
+			})
+		}
 	}
 	return in
 }
@@ -93,8 +118,10 @@ func (p *Pipeline) Wrap(next api.EntryHandler) api.EntryHandler {
 	handlerIn := make(chan api.Entry)
 	nextChan := next.Chan()
 	wg, once := sync.WaitGroup{}, sync.Once{}
+	// ctx is cancelled when the handler stops, so that stages blocked on an entry let it through.
+	ctx, cancel := context.WithCancel(context.Background())
 	pipelineIn := make(chan Entry)
-	pipelineOut := p.Run(pipelineIn)
+	pipelineOut := p.runContext(ctx, pipelineIn)
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
@@ -113,7 +140,10 @@ func (p *Pipeline) Wrap(next api.EntryHandler) api.EntryHandler {
 		}
 	}()
 	return api.NewEntryHandler(handlerIn, func() {
-		once.Do(func() { close(handlerIn) })
+		once.Do(func() {
+			cancel()
+			close(handlerIn)
+		})
 		wg.Wait()
 	})
 }
@@ -329,6 +329,35 @@ func TestPipeline_Wrap(t *testing.T) {
 	}
 }
 
+func TestPipeline_RunWithObserver(t *testing.T) {
+	p, err := NewPipeline(util_log.Logger, loadConfig(testLabelsFromJSONYaml), nil, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	var (
+		mtx      sync.Mutex
+		observed []string
+	)
+	out := p.RunWithObserver(withInboundEntries(
+		newEntry(nil, nil, `{"app":"loki","message":"hello"}`, ct),
+	), func(index int, stage string, e Entry) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		observed = append(observed, fmt.Sprintf("%d %s %s %s", index, stage, e.Labels, e.Line))
+	})
+	var res []Entry
+	for e := range out {
+		res = append(res, e)
+	}
+
+	require.Len(t, res, 1)
+	require.Equal(t, "hello", res[0].Line)
+	require.Equal(t, []string{
+		`0 json {} {"app":"loki","message":"hello"}`,
+		`1 labels {app="loki"} {"app":"loki","message":"hello"}`,
+		`2 output {app="loki"} hello`,
+	}, observed)
+}
+
 func newPipelineFromConfig(cfg, name string) (*Pipeline, error) {
 	var config map[string]interface{}
 
@@ -0,0 +1,117 @@
+package stages
+
+import (
+	"hash/fnv"
+	"reflect"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ErrSamplingStageInvalidPercentage = "sampling stage percentage must be between 0 and 100"
+
+	// samplingBuckets is the precision of the sampling percentage, two decimals.
+	samplingBuckets = 10000
+)
+
+var (
+	defaultSamplingDropReason = "sampling_stage"
+)
+
+// SamplingConfig contains the configuration for a samplingStage
+type SamplingConfig struct {
+	Percentage float64 `mapstructure:"percentage"`
+	Source     *string `mapstructure:"source"`
+	DropReason *string `mapstructure:"drop_counter_reason"`
+}
+
+// validateSamplingConfig validates the SamplingConfig for the samplingStage
+func validateSamplingConfig(cfg *SamplingConfig) error {
+	if cfg.Percentage < 0 || cfg.Percentage > 100 {
+		return errors.New(ErrSamplingStageInvalidPercentage)
+	}
+	if cfg.DropReason == nil || *cfg.DropReason == "" {
+		cfg.DropReason = &defaultSamplingDropReason
+	}
+	return nil
+}
+
+// newSamplingStage creates a samplingStage from config
+func newSamplingStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
+	cfg := &SamplingConfig{}
+	err := mapstructure.WeakDecode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = validateSamplingConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &samplingStage{
+		logger:    log.With(logger, "component", "stage", "type", "sampling"),
+		cfg:       cfg,
+		dropCount: getDropCountMetric(registerer),
+		threshold: uint64(cfg.Percentage * samplingBuckets / 100),
+	}, nil
+}
+
+// samplingStage keeps a percentage of the log lines. Lines are selected deterministically by hashing
+// a value from the extracted map, or the log line itself, so that all promtail instances keep the same
+// lines, for instance all the lines of a trace.
+type samplingStage struct {
+	logger    log.Logger
+	cfg       *SamplingConfig
+	dropCount *prometheus.CounterVec
+	threshold uint64
+}
+
+func (m *samplingStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			if m.shouldKeep(e) {
+				out <- e
+				continue
+			}
+			m.dropCount.WithLabelValues(*m.cfg.DropReason).Inc()
+		}
+	}()
+	return out
+}
+
+func (m *samplingStage) shouldKeep(e Entry) bool {
+	value := e.Line
+	if m.cfg.Source != nil {
+		v, ok := e.Extracted[*m.cfg.Source]
+		if !ok {
+			// Lines can't be sampled consistently without the source, keep them.
+			if Debug {
+				level.Debug(m.logger).Log("msg", "line will not be sampled, the provided source was not found in the extracted map", "source", *m.cfg.Source)
+			}
+			return true
+		}
+		s, err := getString(v)
+		if err != nil {
+			if Debug {
+				level.Debug(m.logger).Log("msg", "line will not be sampled, failed to convert extracted map value to string", "err", err, "type", reflect.TypeOf(v))
+			}
+			return true
+		}
+		value = s
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+	return h.Sum64()%samplingBuckets < m.threshold
+}
+
+// Name implements Stage
+func (m *samplingStage) Name() string {
+	return StageTypeSampling
+}
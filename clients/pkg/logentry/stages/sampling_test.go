@@ -0,0 +1,96 @@
+package stages
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var testSamplingYaml = `
+pipeline_stages:
+- json:
+    expressions:
+      trace_id:
+- sampling:
+    percentage: 25
+    source: trace_id
+`
+
+func TestSamplingPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testSamplingYaml), nil, registry)
+	require.NoError(t, err)
+
+	// Two lines for each trace.
+	var entries []Entry
+	for i := 0; i < 2000; i++ {
+		entries = append(entries, newEntry(nil, nil, fmt.Sprintf(`{"trace_id":"%d","msg":"line %d"}`, i/2, i), time.Now()))
+	}
+	out := processEntries(pl, entries...)
+
+	// The lines of a trace are either all kept or all dropped.
+	kept := map[string]int{}
+	for _, e := range out {
+		kept[e.Extracted["trace_id"].(string)]++
+	}
+	for trace, count := range kept {
+		require.Equal(t, 2, count, "trace %s", trace)
+	}
+	require.InDelta(t, 500, len(out), 100)
+	require.Equal(t, float64(2000-len(out)), testutil.ToFloat64(getDropCountMetric(registry).WithLabelValues(defaultSamplingDropReason)))
+
+	// Sampling is deterministic.
+	out2 := processEntries(pl, entries...)
+	require.Equal(t, len(out), len(out2))
+}
+
+func TestSamplingStage(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		config     map[string]interface{}
+		entry      Entry
+		shouldKeep bool
+	}{
+		{
+			"keep everything",
+			map[string]interface{}{"percentage": 100},
+			newEntry(nil, nil, "line", time.Now()),
+			true,
+		},
+		{
+			"keep nothing",
+			map[string]interface{}{"percentage": 0},
+			newEntry(nil, nil, "line", time.Now()),
+			false,
+		},
+		{
+			"missing source is kept",
+			map[string]interface{}{"percentage": 0, "source": "trace_id"},
+			newEntry(nil, nil, "line", time.Now()),
+			true,
+		},
+		{
+			"source",
+			map[string]interface{}{"percentage": 0, "source": "trace_id"},
+			newEntry(map[string]interface{}{"trace_id": "1234"}, nil, "line", time.Now()),
+			false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := newSamplingStage(util_log.Logger, tc.config, prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.Equal(t, tc.shouldKeep, s.(*samplingStage).shouldKeep(tc.entry))
+		})
+	}
+}
+
+func Test_validateSamplingConfig(t *testing.T) {
+	require.NoError(t, validateSamplingConfig(&SamplingConfig{Percentage: 10}))
+	require.EqualError(t, validateSamplingConfig(&SamplingConfig{Percentage: -1}), ErrSamplingStageInvalidPercentage)
+	require.EqualError(t, validateSamplingConfig(&SamplingConfig{Percentage: 101}), ErrSamplingStageInvalidPercentage)
+}
@@ -1,6 +1,7 @@
 package stages
 
 import (
+	"context"
 	"os"
 	"runtime"
 	"time"
@@ -15,24 +16,29 @@ import (
 )
 
 const (
-	StageTypeJSON       = "json"
-	StageTypeRegex      = "regex"
-	StageTypeReplace    = "replace"
-	StageTypeMetric     = "metrics"
-	StageTypeLabel      = "labels"
-	StageTypeLabelDrop  = "labeldrop"
-	StageTypeTimestamp  = "timestamp"
-	StageTypeOutput     = "output"
-	StageTypeDocker     = "docker"
-	StageTypeCRI        = "cri"
-	StageTypeMatch      = "match"
-	StageTypeTemplate   = "template"
-	StageTypePipeline   = "pipeline"
-	StageTypeTenant     = "tenant"
-	StageTypeDrop       = "drop"
-	StageTypeMultiline  = "multiline"
-	StageTypePack       = "pack"
-	StageTypeLabelAllow = "labelallow"
+	StageTypeJSON         = "json"
+	StageTypeLogfmt       = "logfmt"
+	StageTypeRegex        = "regex"
+	StageTypeReplace      = "replace"
+	StageTypeMetric       = "metrics"
+	StageTypeLabel        = "labels"
+	StageTypeLabelDrop    = "labeldrop"
+	StageTypeStaticLabels = "static_labels"
+	StageTypeTimestamp    = "timestamp"
+	StageTypeOutput       = "output"
+	StageTypeDocker       = "docker"
+	StageTypeCRI          = "cri"
+	StageTypeMatch        = "match"
+	StageTypeTemplate     = "template"
+	StageTypePipeline     = "pipeline"
+	StageTypeTenant       = "tenant"
+	StageTypeDrop         = "drop"
+	StageTypeMultiline    = "multiline"
+	StageTypePack         = "pack"
+	StageTypeLabelAllow   = "labelallow"
+	StageTypeLimit        = "limit"
+	StageTypeSampling     = "sampling"
+	StageTypeGeoIP        = "geoip"
 )
 
 // Processor takes an existing set of labels, timestamp and log entry and returns either a possibly mutated
@@ -53,6 +59,21 @@ type Stage interface {
 	Run(chan Entry) chan Entry
 }
 
+// contextStage is implemented by stages which can block while processing entries, they stop
+// blocking once the context of the pipeline running them is done.
+type contextStage interface {
+	Stage
+	runContext(ctx context.Context, in chan Entry) chan Entry
+}
+
+// runStage runs a stage, passing ctx to it if it can block.
+func runStage(ctx context.Context, s Stage, in chan Entry) chan Entry {
+	if cs, ok := s.(contextStage); ok {
+		return cs.runContext(ctx, in)
+	}
+	return s.Run(in)
+}
+
 func (entry *Entry) copy() *Entry {
 	out, err := yaml.Marshal(entry)
 	if err != nil {
@@ -121,6 +142,11 @@ func New(logger log.Logger, jobName *string, stageType string,
 		if err != nil {
 			return nil, err
 		}
+	case StageTypeLogfmt:
+		s, err = newLogfmtStage(logger, cfg)
+		if err != nil {
+			return nil, err
+		}
 	case StageTypeRegex:
 		s, err = newRegexStage(logger, cfg)
 		if err != nil {
@@ -136,6 +162,11 @@ func New(logger log.Logger, jobName *string, stageType string,
 		if err != nil {
 			return nil, err
 		}
+	case StageTypeStaticLabels:
+		s, err = newStaticLabelsStage(logger, cfg)
+		if err != nil {
+			return nil, err
+		}
 	case StageTypeLabelDrop:
 		s, err = newLabelDropStage(cfg)
 		if err != nil {
@@ -191,6 +222,21 @@ func New(logger log.Logger, jobName *string, stageType string,
 		if err != nil {
 			return nil, err
 		}
+	case StageTypeLimit:
+		s, err = newLimitStage(logger, cfg, registerer)
+		if err != nil {
+			return nil, err
+		}
+	case StageTypeSampling:
+		s, err = newSamplingStage(logger, cfg, registerer)
+		if err != nil {
+			return nil, err
+		}
+	case StageTypeGeoIP:
+		s, err = newGeoIPStage(logger, cfg)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.Errorf("Unknown stage type: %s", stageType)
 	}
@@ -0,0 +1,77 @@
+package stages
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	ErrEmptyStaticLabelStageConfig = "static_labels stage config cannot be empty"
+	ErrInvalidStaticLabelValue     = "invalid value for static label %s: %s"
+)
+
+// StaticLabelConfig is a set of labels to be added to every log line
+type StaticLabelConfig map[string]*string
+
+// validateStaticLabelConfig validates the static_labels stage configuration
+func validateStaticLabelConfig(c StaticLabelConfig) error {
+	if c == nil {
+		return errors.New(ErrEmptyStaticLabelStageConfig)
+	}
+	for labelName, labelValue := range c {
+		if !model.LabelName(labelName).IsValid() {
+			return fmt.Errorf(ErrInvalidLabelName, labelName)
+		}
+		if labelValue == nil || *labelValue == "" {
+			continue
+		}
+		if !model.LabelValue(*labelValue).IsValid() {
+			return fmt.Errorf(ErrInvalidStaticLabelValue, labelName, *labelValue)
+		}
+	}
+	return nil
+}
+
+// newStaticLabelsStage creates a new stage to add constant labels
+func newStaticLabelsStage(logger log.Logger, configs interface{}) (Stage, error) {
+	cfgs := &StaticLabelConfig{}
+	err := mapstructure.Decode(configs, cfgs)
+	if err != nil {
+		return nil, err
+	}
+	err = validateStaticLabelConfig(*cfgs)
+	if err != nil {
+		return nil, err
+	}
+	return toStage(&staticLabelStage{
+		cfgs:   *cfgs,
+		logger: logger,
+	}), nil
+}
+
+// staticLabelStage adds constant labels to every log line
+type staticLabelStage struct {
+	cfgs   StaticLabelConfig
+	logger log.Logger
+}
+
+// Process implements Stage
+func (l *staticLabelStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	for lName, lValue := range l.cfgs {
+		// Labels with an empty value are ignored, like they would be when sent to Loki.
+		if lValue == nil || *lValue == "" {
+			continue
+		}
+		labels[model.LabelName(lName)] = model.LabelValue(*lValue)
+	}
+}
+
+// Name implements Stage
+func (l *staticLabelStage) Name() string {
+	return StageTypeStaticLabels
+}
@@ -0,0 +1,124 @@
+package stages
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The example of docs/sources/clients/promtail/stages/static_labels.md.
+var testStaticLabelsYaml = `
+pipeline_stages:
+- logfmt:
+    mapping:
+      level:
+- match:
+    selector: '{app="payments"}'
+    stages:
+    - static_labels:
+        team: billing
+        environment: production
+- labels:
+    level:
+`
+
+func TestStaticLabelsPipeline(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testStaticLabelsYaml), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		newEntry(nil, model.LabelSet{"app": "payments"}, "level=info msg=paid", time.Now()),
+		newEntry(nil, model.LabelSet{"app": "checkout"}, "level=warn msg=retry", time.Now()),
+	)
+	require.Len(t, out, 2)
+	assert.Equal(t, model.LabelSet{
+		"app":         "payments",
+		"team":        "billing",
+		"environment": "production",
+		"level":       "info",
+	}, out[0].Labels)
+	assert.Equal(t, model.LabelSet{
+		"app":   "checkout",
+		"level": "warn",
+	}, out[1].Labels)
+}
+
+func TestStaticLabelStage_Process(t *testing.T) {
+	staticVal := "val"
+	emptyVal := ""
+
+	tests := map[string]struct {
+		config         StaticLabelConfig
+		inputLabels    model.LabelSet
+		expectedLabels model.LabelSet
+	}{
+		"add static label": {
+			StaticLabelConfig{"staticLabel": &staticVal},
+			model.LabelSet{"testLabel": "testValue"},
+			model.LabelSet{"testLabel": "testValue", "staticLabel": "val"},
+		},
+		"overwrite existing label": {
+			StaticLabelConfig{"testLabel": &staticVal},
+			model.LabelSet{"testLabel": "testValue"},
+			model.LabelSet{"testLabel": "val"},
+		},
+		"empty value is ignored": {
+			StaticLabelConfig{"staticLabel": &emptyVal, "other": nil},
+			model.LabelSet{"testLabel": "testValue"},
+			model.LabelSet{"testLabel": "testValue"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			st, err := newStaticLabelsStage(util_log.Logger, test.config)
+			require.NoError(t, err)
+			out := processEntries(st, newEntry(nil, test.inputLabels, "", time.Now()))[0]
+			assert.Equal(t, test.expectedLabels, out.Labels)
+		})
+	}
+}
+
+func Test_validateStaticLabelConfig(t *testing.T) {
+	invalidValue := "\xff"
+	value := "value"
+
+	tests := map[string]struct {
+		config StaticLabelConfig
+		err    error
+	}{
+		"missing config": {
+			nil,
+			fmt.Errorf(ErrEmptyStaticLabelStageConfig),
+		},
+		"invalid label name": {
+			StaticLabelConfig{"#*FDDS*": &value},
+			fmt.Errorf(ErrInvalidLabelName, "#*FDDS*"),
+		},
+		"invalid label value": {
+			StaticLabelConfig{"label": &invalidValue},
+			fmt.Errorf(ErrInvalidStaticLabelValue, "label", invalidValue),
+		},
+		"valid": {
+			StaticLabelConfig{"label": &value, "empty": nil},
+			nil,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			err := validateStaticLabelConfig(test.config)
+			if test.err != nil {
+				require.EqualError(t, err, test.err.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// +build ignore
+
+// This program generates the MaxMind format test databases used by the geoip stage tests.
+// Run it from this directory with `go run generate.go`.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"sort"
+	"time"
+)
+
+type network struct {
+	cidr string
+	data map[string]interface{}
+}
+
+func names(en string) map[string]interface{} {
+	return map[string]interface{}{"en": en}
+}
+
+func main() {
+	write("GeoLite2-City-Test.mmdb", "GeoLite2-City", []network{
+		{"81.2.69.0/24", map[string]interface{}{
+			"city":      map[string]interface{}{"names": names("London")},
+			"continent": map[string]interface{}{"code": "EU", "names": names("Europe")},
+			"country":   map[string]interface{}{"iso_code": "GB", "names": names("United Kingdom")},
+			"location": map[string]interface{}{
+				"latitude":  51.5142,
+				"longitude": -0.0931,
+				"time_zone": "Europe/London",
+			},
+			"postal":       map[string]interface{}{"code": "EC2V"},
+			"subdivisions": []interface{}{map[string]interface{}{"iso_code": "ENG", "names": names("England")}},
+		}},
+		{"175.16.199.0/24", map[string]interface{}{
+			"city":      map[string]interface{}{"names": names("Changchun")},
+			"continent": map[string]interface{}{"code": "AS", "names": names("Asia")},
+			"country":   map[string]interface{}{"iso_code": "CN", "names": names("China")},
+			"location": map[string]interface{}{
+				"latitude":  43.88,
+				"longitude": 125.3228,
+				"time_zone": "Asia/Harbin",
+			},
+			"subdivisions": []interface{}{map[string]interface{}{"iso_code": "22", "names": names("Jilin Sheng")}},
+		}},
+	})
+	write("GeoLite2-ASN-Test.mmdb", "GeoLite2-ASN", []network{
+		{"1.128.0.0/11", map[string]interface{}{
+			"autonomous_system_number":       uint32(1221),
+			"autonomous_system_organization": "Telstra Pty Ltd",
+		}},
+		{"81.2.69.0/24", map[string]interface{}{
+			"autonomous_system_number":       uint32(20712),
+			"autonomous_system_organization": "Andrews & Arnold Ltd",
+		}},
+	})
+}
+
+const recordSize = 24
+
+type node struct {
+	children [2]int // > 0: index of the child node, < 0: -(data offset + 1), 0: empty.
+}
+
+// write writes an IPv4 database with 24 bits records.
+func write(file, databaseType string, networks []network) {
+	var (
+		nodes = []node{{}}
+		data  bytes.Buffer
+	)
+	for _, n := range networks {
+		_, ipnet, err := net.ParseCIDR(n.cidr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ip := ipnet.IP.To4()
+		ones, _ := ipnet.Mask.Size()
+
+		offset := data.Len()
+		encode(&data, n.data)
+
+		current := 0
+		for i := 0; i < ones; i++ {
+			bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+			if i == ones-1 {
+				nodes[current].children[bit] = -(offset + 1)
+				break
+			}
+			if nodes[current].children[bit] <= 0 {
+				nodes = append(nodes, node{})
+				nodes[current].children[bit] = len(nodes) - 1
+			}
+			current = nodes[current].children[bit]
+		}
+	}
+
+	var out bytes.Buffer
+	nodeCount := len(nodes)
+	for _, n := range nodes {
+		for _, c := range n.children {
+			var record int
+			switch {
+			case c > 0:
+				record = c
+			case c < 0:
+				record = nodeCount + 16 + (-c - 1)
+			default:
+				record = nodeCount
+			}
+			out.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	encode(&out, map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		"database_type":               databaseType,
+		"description":                 map[string]interface{}{"en": "Loki test database"},
+		"ip_version":                  uint16(4),
+		"languages":                   []interface{}{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	})
+
+	if err := ioutil.WriteFile(file, out.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+const (
+	typeString = 2
+	typeDouble = 3
+	typeUint16 = 5
+	typeUint32 = 6
+	typeMap    = 7
+	typeUint64 = 9
+	typeArray  = 11
+)
+
+func control(buf *bytes.Buffer, typ int, size int) {
+	var ctrl byte
+	if typ <= 7 {
+		ctrl = byte(typ << 5)
+	}
+	var ext []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		ext = []byte{byte(size - 29)}
+	default:
+		ctrl |= 30
+		ext = []byte{byte((size - 285) >> 8), byte(size - 285)}
+	}
+	buf.WriteByte(ctrl)
+	if typ > 7 {
+		buf.WriteByte(byte(typ - 7))
+	}
+	buf.Write(ext)
+}
+
+func uintBytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return bytes.TrimLeft(b, "\x00")
+}
+
+func encode(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		control(buf, typeString, len(v))
+		buf.WriteString(v)
+	case float64:
+		control(buf, typeDouble, 8)
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, math.Float64bits(v))
+		buf.Write(b)
+	case uint16:
+		b := uintBytes(uint64(v))
+		control(buf, typeUint16, len(b))
+		buf.Write(b)
+	case uint32:
+		b := uintBytes(uint64(v))
+		control(buf, typeUint32, len(b))
+		buf.Write(b)
+	case uint64:
+		b := uintBytes(v)
+		control(buf, typeUint64, len(b))
+		buf.Write(b)
+	case []interface{}:
+		control(buf, typeArray, len(v))
+		for _, e := range v {
+			encode(buf, e)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		control(buf, typeMap, len(v))
+		for _, k := range keys {
+			encode(buf, k)
+			encode(buf, v[k])
+		}
+	default:
+		log.Fatalf("unsupported type %T", v)
+	}
+}
@@ -37,6 +37,9 @@ type Config struct {
 	// The tenant ID to use when pushing logs to Loki (empty string means
 	// single tenant mode)
 	TenantID string `yaml:"tenant_id"`
+
+	// The name of the client, used to refer to it in routes.
+	Name string `yaml:"name,omitempty"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -18,15 +18,44 @@ type MultiClient struct {
 	entries chan api.Entry
 	wg      sync.WaitGroup
 
+	// routes send entries to a subset of the clients, entries are sent to all the clients
+	// when there are no routes.
+	routes             []*route
+	defaultSentEntries prometheus.Counter
+
 	once sync.Once
 }
 
 // NewMulti creates a new client
 func NewMulti(reg prometheus.Registerer, logger log.Logger, externalLabels flagext.LabelSet, cfgs ...Config) (Client, error) {
+	return NewMultiWithRoutes(reg, logger, externalLabels, nil, cfgs...)
+}
+
+// NewMultiWithRoutes creates a new client sending entries to the clients of the first matching route,
+// entries which do not match any route are sent to all the clients.
+func NewMultiWithRoutes(reg prometheus.Registerer, logger log.Logger, externalLabels flagext.LabelSet, routeCfgs []RouteConfig, cfgs ...Config) (Client, error) {
 	if len(cfgs) == 0 {
 		return nil, errors.New("at least one client config should be provided")
 	}
 
+	clientNames := make([]string, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		clientNames = append(clientNames, cfg.Name)
+	}
+	var (
+		routes             []*route
+		defaultSentEntries prometheus.Counter
+	)
+	if len(routeCfgs) > 0 {
+		metrics := newRouteMetrics(reg)
+		var err error
+		routes, err = newRoutes(metrics, routeCfgs, clientNames)
+		if err != nil {
+			return nil, err
+		}
+		defaultSentEntries = metrics.sentEntries.WithLabelValues(defaultRouteName)
+	}
+
 	clients := make([]Client, 0, len(cfgs))
 	for _, cfg := range cfgs {
 		// Merge the provided external labels from the single client config/command line with each client config from
@@ -43,8 +72,10 @@ func NewMulti(reg prometheus.Registerer, logger log.Logger, externalLabels flage
 		clients = append(clients, client)
 	}
 	multi := &MultiClient{
-		clients: clients,
-		entries: make(chan api.Entry),
+		clients:            clients,
+		entries:            make(chan api.Entry),
+		routes:             routes,
+		defaultSentEntries: defaultSentEntries,
 	}
 	multi.start()
 	return multi, nil
@@ -55,6 +86,10 @@ func (m *MultiClient) start() {
 	go func() {
 		defer m.wg.Done()
 		for e := range m.entries {
+			if len(m.routes) > 0 {
+				m.route(e)
+				continue
+			}
 			for _, c := range m.clients {
 				c.Chan() <- e
 			}
@@ -62,6 +97,43 @@ func (m *MultiClient) start() {
 	}()
 }
 
+// route sends the entry to the clients of the matching routes, an entry is sent at most once to
+// each client.
+func (m *MultiClient) route(e api.Entry) {
+	var (
+		sent    = make([]bool, len(m.clients))
+		matched bool
+	)
+	for _, r := range m.routes {
+		if !r.matches(e.Labels) {
+			continue
+		}
+		matched = true
+		if r.cfg.Action == RouteActionDrop {
+			r.droppedEntries.Inc()
+			return
+		}
+		routed := r.apply(e)
+		for _, i := range r.clients {
+			if !sent[i] {
+				sent[i] = true
+				m.clients[i].Chan() <- routed
+			}
+		}
+		r.sentEntries.Inc()
+		if !r.cfg.Continue {
+			return
+		}
+	}
+	if matched {
+		return
+	}
+	for _, c := range m.clients {
+		c.Chan() <- e
+	}
+	m.defaultSentEntries.Inc()
+}
+
 func (m *MultiClient) Chan() chan<- api.Entry {
 	return m.entries
 }
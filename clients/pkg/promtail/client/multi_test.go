@@ -11,6 +11,7 @@ import (
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
@@ -138,3 +139,69 @@ func TestMultiClient_Handle_Race(t *testing.T) {
 
 	m.Stop()
 }
+
+func TestMultiClient_Route(t *testing.T) {
+	security, main, archive := fake.New(func() {}), fake.New(func() {}), fake.New(func() {})
+	metrics := newRouteMetrics(prometheus.NewRegistry())
+	routes, err := newRoutes(metrics, []RouteConfig{
+		{
+			Name:           "security",
+			Selector:       `{job="auth"}`,
+			Clients:        []string{"security"},
+			ExternalLabels: lokiflag.LabelSet{LabelSet: model.LabelSet{"route": "security"}},
+			TenantID:       "secops",
+			Continue:       true,
+		},
+		{Name: "debug", Selector: `{level="debug"}`, Action: RouteActionDrop},
+		{Name: "archive", Selector: `{job=~"auth|app"}`, Clients: []string{"archive", "security"}},
+	}, []string{"security", "main", "archive"})
+	require.NoError(t, err)
+
+	m := &MultiClient{
+		clients:            []Client{security, main, archive},
+		entries:            make(chan api.Entry),
+		routes:             routes,
+		defaultSentEntries: metrics.sentEntries.WithLabelValues(defaultRouteName),
+	}
+	m.start()
+
+	for _, lbs := range []model.LabelSet{
+		{"job": "auth", "level": "info"},
+		{"job": "app", "level": "debug"},
+		{"job": "app", "level": "info"},
+		{"job": "other"},
+		{"job": "auth", ReservedLabelTenantID: "tenant"},
+	} {
+		m.Chan() <- api.Entry{Labels: lbs, Entry: logproto.Entry{Line: "foo"}}
+	}
+	m.Stop()
+
+	labelsOf := func(c *fake.Client) []model.LabelSet {
+		var res []model.LabelSet
+		for _, e := range c.Received() {
+			res = append(res, e.Labels)
+		}
+		return res
+	}
+	// Entries are sent once to each client, with the labels of the first matching route.
+	require.Equal(t, []model.LabelSet{
+		{"job": "auth", "level": "info", "route": "security", ReservedLabelTenantID: "secops"},
+		{"job": "app", "level": "info"},
+		{"job": "other"},
+		{"job": "auth", "route": "security", ReservedLabelTenantID: "tenant"},
+	}, labelsOf(security))
+	require.Equal(t, []model.LabelSet{
+		{"job": "other"},
+	}, labelsOf(main))
+	require.Equal(t, []model.LabelSet{
+		{"job": "auth", "level": "info"},
+		{"job": "app", "level": "info"},
+		{"job": "other"},
+		{"job": "auth", ReservedLabelTenantID: "tenant"},
+	}, labelsOf(archive))
+
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.sentEntries.WithLabelValues("security")))
+	require.Equal(t, 3.0, testutil.ToFloat64(metrics.sentEntries.WithLabelValues("archive")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.sentEntries.WithLabelValues(defaultRouteName)))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.droppedEntries.WithLabelValues("debug")))
+}
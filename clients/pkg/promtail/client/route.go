@@ -0,0 +1,160 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/grafana/loki/clients/pkg/logentry/logql"
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+
+	lokiflag "github.com/grafana/loki/pkg/util/flagext"
+)
+
+const (
+	RouteActionRoute = "route"
+	RouteActionDrop  = "drop"
+
+	// defaultRouteName is the route of the entries which do not match any route, they are
+	// sent to all the clients.
+	defaultRouteName = "default"
+)
+
+// RouteConfig describes a routing rule, the entries matching the selector are sent to the
+// given clients or dropped.
+type RouteConfig struct {
+	// Name of the route, used in the metrics. Defaults to route_<index>.
+	Name string `yaml:"name,omitempty"`
+	// Stream selector of the entries to route, an empty selector matches all the entries.
+	Selector string `yaml:"selector,omitempty"`
+	// Action is either route or drop, defaults to route.
+	Action string `yaml:"action,omitempty"`
+	// Names of the clients to send the matching entries to.
+	Clients []string `yaml:"clients,omitempty"`
+	// Labels added to the entries sent by this route, the labels of the entries take precedence.
+	ExternalLabels lokiflag.LabelSet `yaml:"external_labels,omitempty"`
+	// Tenant ID of the entries sent by this route, unless overridden by a tenant stage.
+	TenantID string `yaml:"tenant_id,omitempty"`
+	// Whether to keep evaluating the next routes after a match.
+	Continue bool `yaml:"continue,omitempty"`
+}
+
+type routeMetrics struct {
+	sentEntries    *prometheus.CounterVec
+	droppedEntries *prometheus.CounterVec
+}
+
+func newRouteMetrics(reg prometheus.Registerer) *routeMetrics {
+	var m routeMetrics
+
+	m.sentEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "route_sent_entries_total",
+		Help:      "Number of log entries sent to the clients of a route.",
+	}, []string{"route"})
+	m.droppedEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "route_dropped_entries_total",
+		Help:      "Number of log entries dropped by a route.",
+	}, []string{"route"})
+
+	if reg != nil {
+		m.sentEntries = mustRegisterOrGet(reg, m.sentEntries).(*prometheus.CounterVec)
+		m.droppedEntries = mustRegisterOrGet(reg, m.droppedEntries).(*prometheus.CounterVec)
+	}
+
+	return &m
+}
+
+type route struct {
+	cfg      RouteConfig
+	matchers []*labels.Matcher
+	// clients holds the indexes of the clients of the route in the MultiClient.
+	clients []int
+
+	sentEntries    prometheus.Counter
+	droppedEntries prometheus.Counter
+}
+
+// newRoutes validates the route configs against the names of the clients.
+func newRoutes(metrics *routeMetrics, cfgs []RouteConfig, clientNames []string) ([]*route, error) {
+	indexes := make(map[string]int, len(clientNames))
+	for i, name := range clientNames {
+		if name == "" {
+			continue
+		}
+		if _, ok := indexes[name]; ok {
+			return nil, fmt.Errorf("duplicate client name %q", name)
+		}
+		indexes[name] = i
+	}
+
+	routes := make([]*route, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("route_%d", i)
+		}
+		if cfg.Action == "" {
+			cfg.Action = RouteActionRoute
+		}
+		r := &route{
+			cfg:            cfg,
+			sentEntries:    metrics.sentEntries.WithLabelValues(cfg.Name),
+			droppedEntries: metrics.droppedEntries.WithLabelValues(cfg.Name),
+		}
+		if cfg.Selector != "" {
+			matchers, err := logql.ParseMatchers(cfg.Selector)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid selector of route %s", cfg.Name)
+			}
+			r.matchers = matchers
+		}
+
+		switch cfg.Action {
+		case RouteActionRoute:
+			if len(cfg.Clients) == 0 {
+				return nil, fmt.Errorf("route %s has no clients, use the drop action to drop entries", cfg.Name)
+			}
+			for _, name := range cfg.Clients {
+				idx, ok := indexes[name]
+				if !ok {
+					return nil, fmt.Errorf("route %s has unknown client %q", cfg.Name, name)
+				}
+				r.clients = append(r.clients, idx)
+			}
+		case RouteActionDrop:
+			if len(cfg.Clients) > 0 {
+				return nil, fmt.Errorf("route %s drops entries and cannot have clients", cfg.Name)
+			}
+		default:
+			return nil, fmt.Errorf("route %s has invalid action %q, must be %s or %s", cfg.Name, cfg.Action, RouteActionRoute, RouteActionDrop)
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func (r *route) matches(lbs model.LabelSet) bool {
+	for _, m := range r.matchers {
+		if !m.Matches(string(lbs[model.LabelName(m.Name)])) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns the entry with the external labels and tenant of the route, the labels of
+// the given entry are not modified.
+func (r *route) apply(e api.Entry) api.Entry {
+	if len(r.cfg.ExternalLabels.LabelSet) > 0 {
+		e.Labels = r.cfg.ExternalLabels.Merge(e.Labels)
+	}
+	if _, ok := e.Labels[ReservedLabelTenantID]; r.cfg.TenantID != "" && !ok {
+		e.Labels = e.Labels.Clone()
+		e.Labels[ReservedLabelTenantID] = model.LabelValue(r.cfg.TenantID)
+	}
+	return e
+}
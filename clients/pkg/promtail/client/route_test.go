@@ -0,0 +1,38 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRoutes(t *testing.T) {
+	clientNames := []string{"a", "b", ""}
+	for _, tc := range []struct {
+		name    string
+		routes  []RouteConfig
+		clients []string
+		wantErr string
+	}{
+		{"valid", []RouteConfig{{Selector: `{job="a"}`, Clients: []string{"a"}}, {Action: RouteActionDrop}}, clientNames, ""},
+		{"duplicate client", []RouteConfig{{Clients: []string{"a"}}}, []string{"a", "a"}, `duplicate client name "a"`},
+		{"invalid selector", []RouteConfig{{Selector: `{job=}`, Clients: []string{"a"}}}, clientNames, "invalid selector of route route_0: parse error at line 1, col 6: syntax error: unexpected }, expecting STRING"},
+		{"no clients", []RouteConfig{{Name: "r"}}, clientNames, "route r has no clients, use the drop action to drop entries"},
+		{"unknown client", []RouteConfig{{Clients: []string{"c"}}}, clientNames, `route route_0 has unknown client "c"`},
+		{"drop with clients", []RouteConfig{{Action: RouteActionDrop, Clients: []string{"a"}}}, clientNames, "route route_0 drops entries and cannot have clients"},
+		{"invalid action", []RouteConfig{{Action: "keep"}}, clientNames, `route route_0 has invalid action "keep", must be route or drop`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			routes, err := newRoutes(newRouteMetrics(prometheus.NewRegistry()), tc.routes, tc.clients)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, routes, len(tc.routes))
+			require.Equal(t, []int{0}, routes[0].clients)
+			require.Equal(t, "route_1", routes[1].cfg.Name)
+		})
+	}
+}
@@ -18,6 +18,7 @@ type Config struct {
 	// deprecated use ClientConfigs instead
 	ClientConfig    client.Config         `yaml:"client,omitempty"`
 	ClientConfigs   []client.Config       `yaml:"clients,omitempty"`
+	Routes          []client.RouteConfig  `yaml:"routes,omitempty"`
 	PositionsConfig positions.Config      `yaml:"positions,omitempty"`
 	ScrapeConfig    []scrapeconfig.Config `yaml:"scrape_configs,omitempty"`
 	TargetConfig    file.Config           `yaml:"target_config,omitempty"`
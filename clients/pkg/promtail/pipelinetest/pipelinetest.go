@@ -0,0 +1,412 @@
+// Package pipelinetest runs the pipeline stages of a scrape config against sample lines, showing the
+// entry after each stage and optionally comparing the output of the pipeline against expectations.
+package pipelinetest
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drone/envsubst"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	// entryIDKey is the extracted key used to follow an entry through the stages, it is
+	// copied by the stages merging entries such as multiline.
+	entryIDKey = "__pipeline_test_entry_id"
+)
+
+// ErrTestFailed is returned when the output of the pipeline does not match the expectations.
+var ErrTestFailed = errors.New("pipeline test failed")
+
+// Config is the configuration of the pipeline-test command.
+type Config struct {
+	ConfigFile      string
+	ConfigExpandEnv bool
+	JobName         string
+	InputFile       string
+	Labels          string
+	Format          string
+	Test            bool
+}
+
+// RegisterFlags registers the flags of the pipeline-test command.
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ConfigFile, "config.file", "", "Promtail yaml configuration file holding the scrape config to test.")
+	f.BoolVar(&c.ConfigExpandEnv, "config.expand-env", false, "Expands ${var} in config according to the values of the environment variables.")
+	f.StringVar(&c.JobName, "job", "", "Name of the scrape config to test, can be omitted when there is a single scrape config.")
+	f.StringVar(&c.InputFile, "input", "", "File of sample lines, one per line, or JSON fixture file with a .json extension. Reads lines from stdin when empty.")
+	f.StringVar(&c.Labels, "labels", "{}", "Initial labels of the sample lines, e.g. '{job=\"varlogs\"}'. Fixtures can set their own labels.")
+	f.StringVar(&c.Format, "format", FormatText, "Output format, either text or json.")
+	f.BoolVar(&c.Test, "test", false, "Compare the output of the pipeline with the expectations of the JSON fixtures, and fail when they do not match.")
+}
+
+// Fixture is a sample entry and its expected output.
+type Fixture struct {
+	Labels    map[string]string `json:"labels,omitempty"`
+	Timestamp *time.Time        `json:"timestamp,omitempty"`
+	Line      string            `json:"line"`
+	Expected  *Expected         `json:"expected,omitempty"`
+}
+
+// Expected is the expected output of the pipeline for a fixture, only the fields which are set
+// are compared. Extracted values are compared by their string representation, other extracted
+// values are ignored.
+type Expected struct {
+	Dropped   bool                   `json:"dropped,omitempty"`
+	Labels    map[string]string      `json:"labels,omitempty"`
+	Extracted map[string]interface{} `json:"extracted,omitempty"`
+	Timestamp *time.Time             `json:"timestamp,omitempty"`
+	Line      *string                `json:"line,omitempty"`
+}
+
+// Snapshot is the state of an entry after a stage.
+type Snapshot struct {
+	Stage     string                 `json:"stage"`
+	Labels    map[string]string      `json:"labels"`
+	Extracted map[string]interface{} `json:"extracted"`
+	Timestamp time.Time              `json:"timestamp"`
+	Line      string                 `json:"line"`
+}
+
+// Result is the trace of a fixture through the pipeline.
+type Result struct {
+	Input    Fixture    `json:"input"`
+	Stages   []Snapshot `json:"stages"`
+	Output   *Snapshot  `json:"output"`
+	Failures []string   `json:"failures,omitempty"`
+}
+
+// Run runs the pipeline of the configured scrape config against the input and writes the results.
+// ErrTestFailed is returned when testing and an output does not match its expectations.
+func Run(cfg Config, stdin io.Reader, w io.Writer) error {
+	if cfg.Format != FormatText && cfg.Format != FormatJSON {
+		return errors.Errorf("unsupported format %q, must be %s or %s", cfg.Format, FormatText, FormatJSON)
+	}
+	sc, err := loadScrapeConfig(cfg.ConfigFile, cfg.ConfigExpandEnv, cfg.JobName)
+	if err != nil {
+		return err
+	}
+	lbls, err := promql_parser.ParseMetric(cfg.Labels)
+	if err != nil {
+		return errors.Wrap(err, "invalid labels")
+	}
+	defaultLabels := lbls.Map()
+
+	r := stdin
+	if cfg.InputFile != "" {
+		f, err := os.Open(cfg.InputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var fixtures []Fixture
+	if filepath.Ext(cfg.InputFile) == ".json" {
+		fixtures, err = readFixtures(r)
+	} else {
+		fixtures, err = readLines(r)
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range fixtures {
+		if fixtures[i].Labels == nil {
+			fixtures[i].Labels = defaultLabels
+		}
+		if fixtures[i].Timestamp == nil {
+			fixtures[i].Timestamp = &now
+		}
+		if cfg.Test && fixtures[i].Expected == nil {
+			return errors.Errorf("fixture %d has no expected output", i+1)
+		}
+	}
+
+	// Stages have side effects such as metrics, they are kept out of the default registry.
+	pipeline, err := stages.NewPipeline(log.NewNopLogger(), sc.PipelineStages, &sc.JobName, prometheus.NewRegistry())
+	if err != nil {
+		return err
+	}
+	results := runPipeline(pipeline, fixtures)
+
+	failed := false
+	if cfg.Test {
+		for i := range results {
+			results[i].Failures = compare(results[i].Input.Expected, results[i].Output)
+			failed = failed || len(results[i].Failures) > 0
+		}
+	}
+
+	switch cfg.Format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	default:
+		err = writeText(w, results, cfg.Test)
+	}
+	if err != nil {
+		return err
+	}
+	if failed {
+		return ErrTestFailed
+	}
+	return nil
+}
+
+func loadScrapeConfig(filename string, expandEnv bool, jobName string) (*scrapeconfig.Config, error) {
+	if filename == "" {
+		return nil, errors.New("-config.file is required")
+	}
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if expandEnv {
+		s, err := envsubst.EvalEnv(string(buf))
+		if err != nil {
+			return nil, err
+		}
+		buf = []byte(s)
+	}
+	// Only the scrape configs are read, the rest of the Promtail configuration is ignored.
+	var config struct {
+		ScrapeConfigs []scrapeconfig.Config `yaml:"scrape_configs"`
+	}
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return nil, errors.Wrap(err, filename)
+	}
+
+	if jobName == "" {
+		if len(config.ScrapeConfigs) != 1 {
+			return nil, errors.Errorf("%s has %d scrape configs, -job is required", filename, len(config.ScrapeConfigs))
+		}
+		return &config.ScrapeConfigs[0], nil
+	}
+	for i := range config.ScrapeConfigs {
+		if config.ScrapeConfigs[i].JobName == jobName {
+			return &config.ScrapeConfigs[i], nil
+		}
+	}
+	return nil, errors.Errorf("scrape config %q not found in %s", jobName, filename)
+}
+
+func readFixtures(r io.Reader) ([]Fixture, error) {
+	var fixtures []Fixture
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, errors.Wrap(err, "invalid fixtures")
+	}
+	return fixtures, nil
+}
+
+func readLines(r io.Reader) ([]Fixture, error) {
+	var fixtures []Fixture
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fixtures = append(fixtures, Fixture{Line: scanner.Text()})
+	}
+	return fixtures, scanner.Err()
+}
+
+// runPipeline sends all the fixtures through the pipeline and records the state of each of them after
+// every stage. Fixtures which do not reach the end of the pipeline have no output.
+func runPipeline(pipeline *stages.Pipeline, fixtures []Fixture) []Result {
+	results := make([]Result, len(fixtures))
+	for i := range fixtures {
+		results[i].Input = fixtures[i]
+	}
+
+	var mtx sync.Mutex
+	in := make(chan stages.Entry)
+	out := pipeline.RunWithObserver(in, func(_ int, stage string, e stages.Entry) {
+		id, ok := e.Extracted[entryIDKey].(int)
+		if !ok {
+			return
+		}
+		s := snapshot(stage, e)
+		mtx.Lock()
+		defer mtx.Unlock()
+		results[id].Stages = append(results[id].Stages, s)
+	})
+
+	go func() {
+		defer close(in)
+		for i, f := range fixtures {
+			in <- stages.Entry{
+				Extracted: map[string]interface{}{entryIDKey: i},
+				Entry: api.Entry{
+					Labels: toLabelSet(f.Labels),
+					Entry: logproto.Entry{
+						Timestamp: *f.Timestamp,
+						Line:      f.Line,
+					},
+				},
+			}
+		}
+	}()
+	for e := range out {
+		if id, ok := e.Extracted[entryIDKey].(int); ok {
+			s := snapshot("", e)
+			results[id].Output = &s
+		}
+	}
+	return results
+}
+
+func snapshot(stage string, e stages.Entry) Snapshot {
+	s := Snapshot{
+		Stage:     stage,
+		Labels:    make(map[string]string, len(e.Labels)),
+		Extracted: make(map[string]interface{}, len(e.Extracted)),
+		Timestamp: e.Timestamp,
+		Line:      e.Line,
+	}
+	for k, v := range e.Labels {
+		s.Labels[string(k)] = string(v)
+	}
+	for k, v := range e.Extracted {
+		if k != entryIDKey {
+			s.Extracted[k] = v
+		}
+	}
+	return s
+}
+
+func toLabelSet(lbls map[string]string) model.LabelSet {
+	ls := make(model.LabelSet, len(lbls))
+	for k, v := range lbls {
+		ls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return ls
+}
+
+// compare returns the differences between the expected and the actual output.
+func compare(expected *Expected, output *Snapshot) []string {
+	if output == nil {
+		if expected.Dropped {
+			return nil
+		}
+		return []string{"entry was dropped"}
+	}
+	if expected.Dropped {
+		return []string{"entry was not dropped"}
+	}
+
+	var failures []string
+	if expected.Labels != nil && !equalLabels(expected.Labels, output.Labels) {
+		failures = append(failures, fmt.Sprintf("labels: expected %s, got %s", toLabelSet(expected.Labels), toLabelSet(output.Labels)))
+	}
+	keys := make([]string, 0, len(expected.Extracted))
+	for k := range expected.Extracted {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		want := fmt.Sprint(expected.Extracted[k])
+		got, ok := output.Extracted[k]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("extracted %s: expected %q, got nothing", k, want))
+			continue
+		}
+		if fmt.Sprint(got) != want {
+			failures = append(failures, fmt.Sprintf("extracted %s: expected %q, got %q", k, want, fmt.Sprint(got)))
+		}
+	}
+	if expected.Timestamp != nil && !expected.Timestamp.Equal(output.Timestamp) {
+		failures = append(failures, fmt.Sprintf("timestamp: expected %s, got %s", expected.Timestamp.Format(time.RFC3339Nano), output.Timestamp.Format(time.RFC3339Nano)))
+	}
+	if expected.Line != nil && *expected.Line != output.Line {
+		failures = append(failures, fmt.Sprintf("line: expected %q, got %q", *expected.Line, output.Line))
+	}
+	return failures
+}
+
+func equalLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+func writeText(w io.Writer, results []Result, test bool) error {
+	bw := bufio.NewWriter(w)
+	failures := 0
+	for i, r := range results {
+		fmt.Fprintf(bw, "entry %d: %s %s\n", i+1, toLabelSet(r.Input.Labels), r.Input.Line)
+		for _, s := range r.Stages {
+			fmt.Fprintf(bw, "  [%s]\n", s.Stage)
+			writeSnapshot(bw, s)
+		}
+		if r.Output == nil {
+			fmt.Fprintln(bw, "  output: dropped")
+		} else {
+			fmt.Fprintln(bw, "  output:")
+			writeSnapshot(bw, *r.Output)
+		}
+		if test {
+			if len(r.Failures) == 0 {
+				fmt.Fprintln(bw, "  PASS")
+			} else {
+				failures++
+				fmt.Fprintln(bw, "  FAIL")
+				for _, f := range r.Failures {
+					fmt.Fprintf(bw, "    %s\n", f)
+				}
+			}
+		}
+		fmt.Fprintln(bw)
+	}
+	if test {
+		fmt.Fprintf(bw, "%d/%d entries passed\n", len(results)-failures, len(results))
+	}
+	return bw.Flush()
+}
+
+func writeSnapshot(w io.Writer, s Snapshot) {
+	keys := make([]string, 0, len(s.Extracted))
+	for k := range s.Extracted {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	extracted := make([]string, 0, len(keys))
+	for _, k := range keys {
+		extracted = append(extracted, fmt.Sprintf("%s=%q", k, fmt.Sprint(s.Extracted[k])))
+	}
+
+	fmt.Fprintf(w, "    labels:    %s\n", toLabelSet(s.Labels))
+	fmt.Fprintf(w, "    extracted: {%s}\n", strings.Join(extracted, ", "))
+	fmt.Fprintf(w, "    timestamp: %s\n", s.Timestamp.Format(time.RFC3339Nano))
+	fmt.Fprintf(w, "    line:      %s\n", s.Line)
+}
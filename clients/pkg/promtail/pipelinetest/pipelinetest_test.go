@@ -0,0 +1,88 @@
+package pipelinetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Lines(t *testing.T) {
+	var out bytes.Buffer
+	err := Run(Config{
+		ConfigFile: "testdata/config.yaml",
+		JobName:    "app",
+		Labels:     `{job="app"}`,
+		Format:     FormatJSON,
+	}, strings.NewReader("2021-07-01T10:00:00Z info hello world\n2021-07-01T10:00:01Z debug noisy\n"), &out)
+	require.NoError(t, err)
+
+	var results []Result
+	require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	require.Len(t, results, 2)
+
+	require.Len(t, results[0].Stages, 5)
+	stageNames := make([]string, 0, len(results[0].Stages))
+	for _, s := range results[0].Stages {
+		stageNames = append(stageNames, s.Stage)
+	}
+	require.Equal(t, []string{"regex", "labels", "timestamp", "drop", "output"}, stageNames)
+	require.Equal(t, map[string]string{"job": "app"}, results[0].Stages[0].Labels)
+	require.Equal(t, map[string]interface{}{
+		"job":   "app",
+		"ts":    "2021-07-01T10:00:00Z",
+		"level": "info",
+		"msg":   "hello world",
+	}, results[0].Stages[0].Extracted)
+	require.Equal(t, "2021-07-01T10:00:00Z info hello world", results[0].Stages[1].Line)
+	require.Equal(t, map[string]string{"job": "app", "level": "info"}, results[0].Output.Labels)
+	require.Equal(t, "hello world", results[0].Output.Line)
+	require.True(t, time.Date(2021, 7, 1, 10, 0, 0, 0, time.UTC).Equal(results[0].Output.Timestamp))
+
+	// The second line is dropped by the drop stage.
+	require.Len(t, results[1].Stages, 3)
+	require.Nil(t, results[1].Output)
+}
+
+func TestRun_Test(t *testing.T) {
+	var out bytes.Buffer
+	err := Run(Config{
+		ConfigFile: "testdata/config.yaml",
+		JobName:    "app",
+		InputFile:  "testdata/fixtures.json",
+		Labels:     "{}",
+		Format:     FormatText,
+		Test:       true,
+	}, nil, &out)
+	require.Equal(t, ErrTestFailed, err)
+
+	require.Contains(t, out.String(), "entry 1: {job=\"app\"} 2021-07-01T10:00:00Z info hello\n")
+	require.Contains(t, out.String(), "  output: dropped\n  PASS\n")
+	require.Contains(t, out.String(), "  FAIL\n"+
+		"    extracted level: expected \"error\", got \"warn\"\n"+
+		"    line: expected \"nope\", got \"oops\"\n")
+	require.True(t, strings.HasSuffix(out.String(), "2/3 entries passed\n"), out.String())
+}
+
+func TestRun_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cfg     Config
+		input   string
+		wantErr string
+	}{
+		{"missing config", Config{Labels: "{}", Format: FormatText}, "", "-config.file is required"},
+		{"ambiguous job", Config{ConfigFile: "testdata/config.yaml", Labels: "{}", Format: FormatText}, "", "testdata/config.yaml has 2 scrape configs, -job is required"},
+		{"unknown job", Config{ConfigFile: "testdata/config.yaml", JobName: "foo", Labels: "{}", Format: FormatText}, "", `scrape config "foo" not found in testdata/config.yaml`},
+		{"invalid format", Config{ConfigFile: "testdata/config.yaml", JobName: "app", Labels: "{}", Format: "xml"}, "", `unsupported format "xml", must be text or json`},
+		{"no expectations", Config{ConfigFile: "testdata/config.yaml", JobName: "app", Labels: "{}", Format: FormatText, Test: true}, "line\n", "fixture 1 has no expected output"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Run(tc.cfg, strings.NewReader(tc.input), &bytes.Buffer{})
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
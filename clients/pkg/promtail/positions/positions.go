@@ -16,7 +16,15 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-const positionFileMode = 0600
+const (
+	positionFileMode = 0600
+	cursorKeyPrefix  = "cursor-"
+)
+
+// CursorKey returns a key that can be saved as a cursor that is never deleted.
+func CursorKey(key string) string {
+	return cursorKeyPrefix + key
+}
 
 // Config describes where to get position information from.
 type Config struct {
@@ -186,6 +194,10 @@ func (p *positions) cleanup() {
 		if strings.HasPrefix(k, "journal-") {
 			continue
 		}
+		// Cursors of other targets are not files on disk either.
+		if strings.HasPrefix(k, cursorKeyPrefix) {
+			continue
+		}
 
 		if _, err := os.Stat(k); err != nil {
 			if os.IsNotExist(err) {
@@ -180,3 +180,25 @@ func Test_ReadOnly(t *testing.T) {
 	}, out)
 
 }
+
+func TestCleanupKeepsCursors(t *testing.T) {
+	temp := tempFilename(t)
+	defer func() {
+		_ = os.Remove(temp)
+	}()
+	p, err := New(util_log.Logger, Config{
+		SyncPeriod:    time.Hour,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+	defer p.Stop()
+
+	p.Put("/does/not/exist.log", 10)
+	p.Put(CursorKey("container"), 20)
+	p.(*positions).cleanup()
+
+	require.Equal(t, "", p.GetString("/does/not/exist.log"))
+	pos, err := p.Get(CursorKey("container"))
+	require.NoError(t, err)
+	require.Equal(t, int64(20), pos)
+}
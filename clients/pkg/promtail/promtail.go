@@ -77,7 +77,7 @@ func New(cfg config.Config, dryRun bool, opts ...Option) (*Promtail, error) {
 		}
 		cfg.PositionsConfig.ReadOnly = true
 	} else {
-		promtail.client, err = client.NewMulti(prometheus.DefaultRegisterer, promtail.logger, cfg.ClientConfig.ExternalLabels, cfg.ClientConfigs...)
+		promtail.client, err = client.NewMultiWithRoutes(prometheus.DefaultRegisterer, promtail.logger, cfg.ClientConfig.ExternalLabels, cfg.Routes, cfg.ClientConfigs...)
 		if err != nil {
 			return nil, err
 		}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"time"
 
+	promconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/prometheus/prometheus/discovery/aws"
@@ -35,8 +36,12 @@ type Config struct {
 	SyslogConfig           *SyslogTargetConfig        `yaml:"syslog,omitempty"`
 	GcplogConfig           *GcplogTargetConfig        `yaml:"gcplog,omitempty"`
 	PushConfig             *PushTargetConfig          `yaml:"loki_push_api,omitempty"`
+	HerokuDrainConfig      *HerokuDrainTargetConfig   `yaml:"heroku_drain,omitempty"`
+	GelfConfig             *GelfTargetConfig          `yaml:"gelf,omitempty"`
+	SplunkHECConfig        *SplunkHECTargetConfig     `yaml:"splunk_hec,omitempty"`
 	WindowsConfig          *WindowsEventsTargetConfig `yaml:"windows_events,omitempty"`
 	RelabelConfigs         []*relabel.Config          `yaml:"relabel_configs,omitempty"`
+	DockerSDConfigs        []*moby.DockerSDConfig     `yaml:"docker_sd_configs,omitempty"`
 	ServiceDiscoveryConfig ServiceDiscoveryConfig     `yaml:",inline"`
 }
 
@@ -173,6 +178,23 @@ type SyslogTargetConfig struct {
 
 	// MaxMessageLength sets the maximum limit to the length of syslog messages
 	MaxMessageLength int `yaml:"max_message_length"`
+
+	// ListenProtocol is the protocol to listen on, tcp or udp.
+	ListenProtocol string `yaml:"listen_protocol"`
+
+	// SyslogFormat is the format of the syslog messages, rfc5424 or rfc3164.
+	SyslogFormat string `yaml:"syslog_format"`
+
+	// RFC3164Year is the year of the rfc3164 timestamps, which have no year. If not set,
+	// the current year is used unless the timestamp would be more than a day in the future.
+	RFC3164Year int `yaml:"rfc3164_year"`
+
+	// RFC3164Timezone is the time zone of the rfc3164 timestamps, which have no time zone.
+	RFC3164Timezone string `yaml:"rfc3164_timezone"`
+
+	// TLSConfig configures TLS for the tcp listener, clients certificates are verified
+	// if a CA file is set.
+	TLSConfig promconfig.TLSConfig `yaml:"tls_config,omitempty"`
 }
 
 // WindowsEventsTargetConfig describes a scrape config that listen for windows event logs.
@@ -247,6 +269,54 @@ type PushTargetConfig struct {
 	KeepTimestamp bool `yaml:"use_incoming_timestamp"`
 }
 
+// HerokuDrainTargetConfig describes a scrape config that listens for log lines sent by a Heroku HTTPS drain.
+type HerokuDrainTargetConfig struct {
+	// Server is the weaveworks server config for listening connections
+	Server server.Config `yaml:"server"`
+
+	// Labels optionally holds labels to associate with each record received on the drain.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// UseIncomingTimestamp sets the timestamp to the incoming logplex messages
+	// timestamp if it's set.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+}
+
+// GelfTargetConfig describes a scrape config that listens for GELF messages.
+type GelfTargetConfig struct {
+	// ListenAddress is the address to listen on for GELF messages.
+	ListenAddress string `yaml:"listen_address"`
+
+	// ListenProtocol is the protocol of the listener, udp or tcp.
+	ListenProtocol string `yaml:"listen_protocol"`
+
+	// IdleTimeout is the idle timeout for tcp connections.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+
+	// Labels optionally holds labels to associate with each record received.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// UseIncomingTimestamp sets the timestamp to the incoming GELF messages
+	// timestamp if it's set.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+}
+
+// SplunkHECTargetConfig describes a scrape config that listens for events sent to the
+// Splunk HTTP Event Collector API.
+type SplunkHECTargetConfig struct {
+	// Server is the weaveworks server config for listening connections
+	Server server.Config `yaml:"server"`
+
+	// Labels optionally holds labels to associate with each event received.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// UseIncomingTimestamp sets the timestamp to the incoming events timestamp if it's set.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+
+	// Tokens are the accepted HEC tokens, all requests are accepted if empty.
+	Tokens []string `yaml:"tokens"`
+}
+
 // DefaultScrapeConfig is the default Config.
 var DefaultScrapeConfig = Config{
 	PipelineStages: stages.PipelineStages{},
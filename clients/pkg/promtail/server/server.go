@@ -22,6 +22,7 @@ import (
 
 	"github.com/grafana/loki/clients/pkg/promtail/server/ui"
 	"github.com/grafana/loki/clients/pkg/promtail/targets"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/file"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
 )
 
@@ -153,9 +154,9 @@ func (s *server) serviceDiscovery(rw http.ResponseWriter, req *http.Request) {
 		PageTitle:    "Service Discovery",
 		ExternalURL:  s.externalURL,
 		TemplateFuncs: template.FuncMap{
-			"fileTargetDetails": func(details interface{}) map[string]int64 {
+			"fileTargetDetails": func(details interface{}) map[string]file.ReaderDetails {
 				// you can't cast with a text template in go so this is a helper
-				return details.(map[string]int64)
+				return details.(map[string]file.ReaderDetails)
 			},
 			"dropReason": func(details interface{}) string {
 				if reason, ok := details.(string); ok {
@@ -198,9 +199,9 @@ func (s *server) targets(rw http.ResponseWriter, req *http.Request) {
 		PageTitle:    "Targets",
 		ExternalURL:  s.externalURL,
 		TemplateFuncs: template.FuncMap{
-			"fileTargetDetails": func(details interface{}) map[string]int64 {
+			"fileTargetDetails": func(details interface{}) map[string]file.ReaderDetails {
 				// you can't cast with a text template in go so this is a helper
-				return details.(map[string]int64)
+				return details.(map[string]file.ReaderDetails)
 			},
 			"journalTargetDetails": func(details interface{}) map[string]string {
 				// you can't cast with a text template in go so this is a helper
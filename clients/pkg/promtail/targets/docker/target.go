@@ -0,0 +1,355 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	docker_types "github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	dockerLabel                = model.MetaLabelPrefix + "docker_"
+	dockerLabelContainerPrefix = dockerLabel + "container_"
+	dockerLabelContainerID     = dockerLabelContainerPrefix + "id"
+	dockerLabelLogStream       = dockerLabelContainerPrefix + "log_stream"
+
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// Target streams the logs of a single container from the Docker Engine API.
+type Target struct {
+	logger           log.Logger
+	handler          api.EntryHandler
+	positions        positions.Positions
+	metrics          *Metrics
+	client           client.APIClient
+	containerID      string
+	discoveredLabels model.LabelSet
+	labels           model.LabelSet
+	// streamLabels are the labels of the lines of each stream, after relabeling.
+	// A stream dropped by the relabeling is not in the map.
+	streamLabels map[string]model.LabelSet
+
+	mtx   sync.Mutex
+	since int64
+	// sinceLines are the lines sent at the since timestamp, the logs are read again from since
+	// when the target restarts.
+	sinceLines map[string]struct{}
+	err        error
+
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running *atomic.Bool
+}
+
+// NewTarget starts a new target to read logs from a container, starting at its last saved position.
+func NewTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	position positions.Positions,
+	containerID string,
+	discoveredLabels model.LabelSet,
+	relabelConfig []*relabel.Config,
+	client client.APIClient,
+) (*Target, error) {
+	pos, err := position.Get(positionKey(containerID))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Target{
+		logger:           log.With(logger, "target", "docker", "container", containerID),
+		handler:          handler,
+		positions:        position,
+		metrics:          metrics,
+		client:           client,
+		containerID:      containerID,
+		discoveredLabels: discoveredLabels,
+		labels:           relabelLabels(discoveredLabels, relabelConfig),
+		streamLabels:     map[string]model.LabelSet{},
+		since:            pos,
+		sinceLines:       map[string]struct{}{},
+		running:          atomic.NewBool(false),
+	}
+	for _, stream := range []string{streamStdout, streamStderr} {
+		lbls := discoveredLabels.Clone()
+		lbls[dockerLabelLogStream] = model.LabelValue(stream)
+		if streamLabels := relabelLabels(lbls, relabelConfig); streamLabels != nil {
+			t.streamLabels[stream] = streamLabels
+		}
+	}
+
+	t.start()
+	return t, nil
+}
+
+func (t *Target) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.running.Store(true)
+	t.wg.Add(1)
+	go t.processLoop(ctx)
+}
+
+// startIfNotRunning starts reading the logs of the container again from the last position if the target stopped,
+// which happens when the logs stream fails or ends, e.g. when the container restarts.
+func (t *Target) startIfNotRunning() {
+	if t.running.Load() {
+		return
+	}
+	t.wg.Wait()
+	t.cancel()
+	level.Debug(t.logger).Log("msg", "restarting Docker target")
+	t.setError(nil)
+	t.start()
+}
+
+// positionKey returns the key of the position of a container in the positions file.
+func positionKey(containerID string) string {
+	return positions.CursorKey(containerID)
+}
+
+// relabelLabels applies the relabel configs and removes the internal labels, it returns
+// nil if the relabeling dropped the labels.
+func relabelLabels(lbls model.LabelSet, relabelConfig []*relabel.Config) model.LabelSet {
+	processed := relabel.Process(labels.FromMap(toMap(lbls)), relabelConfig...)
+	if processed == nil {
+		return nil
+	}
+	res := make(model.LabelSet, len(processed))
+	for _, l := range processed {
+		if strings.HasPrefix(l.Name, model.ReservedLabelPrefix) {
+			continue
+		}
+		res[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return res
+}
+
+func toMap(lbls model.LabelSet) map[string]string {
+	m := make(map[string]string, len(lbls))
+	for k, v := range lbls {
+		m[string(k)] = string(v)
+	}
+	return m
+}
+
+func (t *Target) processLoop(ctx context.Context) {
+	defer t.wg.Done()
+	defer t.running.Store(false)
+
+	info, err := t.client.ContainerInspect(ctx, t.containerID)
+	if err != nil {
+		t.setError(err)
+		level.Error(t.logger).Log("msg", "could not inspect container", "err", err)
+		return
+	}
+
+	t.mtx.Lock()
+	opts := docker_types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: true,
+	}
+	if t.since > 0 {
+		// Docker returns the lines at or after since, the position is the timestamp of the last line sent.
+		// Other lines may share that timestamp, the lines already sent are skipped.
+		opts.Since = fmt.Sprintf("%d.%09d", t.since/int64(time.Second), t.since%int64(time.Second))
+	}
+	t.mtx.Unlock()
+
+	logs, err := t.client.ContainerLogs(ctx, t.containerID, opts)
+	if err != nil {
+		t.setError(err)
+		level.Error(t.logger).Log("msg", "could not fetch logs for container", "err", err)
+		return
+	}
+	defer func() { _ = logs.Close() }()
+
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		var err error
+		// The output of containers with a TTY is not multiplexed, it is all written to stdout.
+		if info.Config != nil && info.Config.Tty {
+			_, err = io.Copy(stdoutWriter, logs)
+		} else {
+			_, err = stdcopy.StdCopy(stdoutWriter, stderrWriter, logs)
+		}
+		if err != nil && ctx.Err() == nil {
+			t.setError(err)
+			level.Warn(t.logger).Log("msg", "could not read logs of container", "err", err)
+		}
+		_ = stdoutWriter.Close()
+		_ = stderrWriter.Close()
+	}()
+	go t.process(ctx, stdout, streamStdout, &wg)
+	go t.process(ctx, stderr, streamStderr, &wg)
+
+	wg.Wait()
+	level.Debug(t.logger).Log("msg", "done reading logs of container")
+}
+
+// process reads the lines of a stream and sends them to the entry handler until ctx is done.
+func (t *Target) process(ctx context.Context, r io.Reader, stream string, wg *sync.WaitGroup) {
+	defer wg.Done()
+	lbls, keep := t.streamLabels[stream]
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err != io.EOF {
+				level.Warn(t.logger).Log("msg", "could not read line", "stream", stream, "err", err)
+			}
+			return
+		}
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+
+		ts, msg, err := parseLine(line)
+		if err != nil {
+			t.metrics.dockerErrors.Inc()
+			level.Debug(t.logger).Log("msg", "could not parse timestamp of line", "err", err)
+			ts, msg = time.Now(), line
+		}
+
+		key := stream + "\xff" + msg
+		if t.sent(ts.UnixNano(), key) {
+			continue
+		}
+
+		if keep {
+			select {
+			case t.handler.Chan() <- api.Entry{
+				Labels: lbls.Clone(),
+				Entry: logproto.Entry{
+					Timestamp: ts,
+					Line:      msg,
+				},
+			}:
+			case <-ctx.Done():
+				return
+			}
+			t.metrics.dockerEntries.Inc()
+		}
+		t.markSent(ts.UnixNano(), key)
+	}
+}
+
+// sent tells if a line was already sent, which happens for the lines at the position timestamp when the
+// logs are read again from the position.
+func (t *Target) sent(ts int64, key string) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if ts != t.since {
+		return false
+	}
+	_, ok := t.sinceLines[key]
+	return ok
+}
+
+// markSent saves the timestamp of a line sent as the position, if it is the most recent.
+func (t *Target) markSent(ts int64, key string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	switch {
+	case ts > t.since:
+		t.since = ts
+		t.sinceLines = map[string]struct{}{key: {}}
+		t.positions.Put(positionKey(t.containerID), t.since)
+	case ts == t.since:
+		t.sinceLines[key] = struct{}{}
+	}
+}
+
+// parseLine splits a line of the Docker API in its timestamp and message.
+func parseLine(line string) (time.Time, string, error) {
+	idx := strings.IndexByte(line, ' ')
+	if idx < 0 {
+		idx = len(line)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, line[:idx])
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if idx == len(line) {
+		return ts, "", nil
+	}
+	return ts, line[idx+1:], nil
+}
+
+func (t *Target) setError(err error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.err = err
+}
+
+// Stop stops reading the logs of the container.
+func (t *Target) Stop() {
+	t.cancel()
+	t.wg.Wait()
+	level.Debug(t.logger).Log("msg", "stopped Docker target")
+}
+
+// Type implements target.Target.
+func (t *Target) Type() target.TargetType {
+	return target.DockerTargetType
+}
+
+// Ready implements target.Target.
+func (t *Target) Ready() bool {
+	return t.running.Load()
+}
+
+// DiscoveredLabels implements target.Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return t.discoveredLabels
+}
+
+// Labels implements target.Target.
+func (t *Target) Labels() model.LabelSet {
+	return t.labels
+}
+
+// Details implements target.Target.
+func (t *Target) Details() interface{} {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	var errMsg string
+	if t.err != nil {
+		errMsg = t.err.Error()
+	}
+	return map[string]string{
+		"id":       t.containerID,
+		"error":    errMsg,
+		"position": t.positions.GetString(positionKey(t.containerID)),
+		"running":  fmt.Sprint(t.running.Load()),
+	}
+}
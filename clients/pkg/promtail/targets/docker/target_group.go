@@ -0,0 +1,219 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/pkg/relabel"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+)
+
+const (
+	userAgent = "promtail"
+
+	inspectTimeout = 10 * time.Second
+)
+
+// targetGroup manages all container targets of a Docker daemon discovered by a docker_sd_config.
+type targetGroup struct {
+	jobName       string
+	metrics       *Metrics
+	logger        log.Logger
+	positions     positions.Positions
+	entryHandler  api.EntryHandler
+	relabelConfig []*relabel.Config
+	host          string
+	httpConfig    config.HTTPClientConfig
+
+	mtx            sync.Mutex
+	client         client.APIClient
+	targets        map[string]*Target
+	droppedTargets []target.Target
+}
+
+// sync starts the targets of the containers which were discovered and stops the others.
+func (tg *targetGroup) sync(groups []*targetgroup.Group) {
+	stopped, c := tg.syncTargets(groups)
+
+	// The position of a stopped container is kept in case it is started again, only the ones of the removed
+	// containers are deleted. The Docker daemon is asked without holding the lock, it may be slow to answer.
+	for _, id := range stopped {
+		if containerRemoved(c, id) {
+			tg.positions.Remove(positionKey(id))
+		}
+	}
+}
+
+// syncTargets starts and stops the targets, it returns the containers of the stopped targets and the client
+// to ask the Docker daemon about them.
+func (tg *targetGroup) syncTargets(groups []*targetgroup.Group) ([]string, client.APIClient) {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	discovered := map[string]model.LabelSet{}
+	tg.droppedTargets = []target.Target{}
+	for _, group := range groups {
+		if group.Source == "" {
+			// Empty groups are sent when the discovery is stopped.
+			continue
+		}
+		for _, t := range group.Targets {
+			discoveredLabels := group.Labels.Merge(t)
+			id, ok := discoveredLabels[dockerLabelContainerID]
+			if !ok {
+				level.Debug(tg.logger).Log("msg", "target has no container id", "labels", discoveredLabels)
+				continue
+			}
+			// A container is discovered once per network or port, all with the same container labels.
+			if _, ok := discovered[string(id)]; ok {
+				continue
+			}
+			if relabelLabels(discoveredLabels, tg.relabelConfig) == nil {
+				tg.droppedTargets = append(tg.droppedTargets, target.NewDroppedTarget("dropping target, no labels", discoveredLabels))
+				continue
+			}
+			discovered[string(id)] = discoveredLabels
+		}
+	}
+
+	for id, discoveredLabels := range discovered {
+		if t, ok := tg.targets[id]; ok {
+			t.startIfNotRunning()
+			continue
+		}
+		if err := tg.addTarget(id, discoveredLabels); err != nil {
+			level.Error(tg.logger).Log("msg", "failed to add Docker target", "container", id, "err", err)
+		}
+	}
+
+	var stopped []string
+	for id, t := range tg.targets {
+		if _, ok := discovered[id]; ok {
+			continue
+		}
+		level.Info(tg.logger).Log("msg", "removing Docker target", "container", id)
+		t.Stop()
+		delete(tg.targets, id)
+		stopped = append(stopped, id)
+	}
+	return stopped, tg.client
+}
+
+// containerRemoved tells if the Docker daemon doesn't know the container anymore.
+func containerRemoved(c client.APIClient, id string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), inspectTimeout)
+	defer cancel()
+	_, err := c.ContainerInspect(ctx, id)
+	return client.IsErrNotFound(err)
+}
+
+func (tg *targetGroup) addTarget(id string, discoveredLabels model.LabelSet) error {
+	if tg.client == nil {
+		c, err := newClient(tg.host, tg.httpConfig)
+		if err != nil {
+			return err
+		}
+		tg.client = c
+	}
+
+	level.Info(tg.logger).Log("msg", "adding Docker target", "container", id)
+	t, err := NewTarget(tg.metrics, tg.logger, tg.entryHandler, tg.positions, id, discoveredLabels, tg.relabelConfig, tg.client)
+	if err != nil {
+		return err
+	}
+	tg.targets[id] = t
+	return nil
+}
+
+// newClient creates a Docker client for host, the HTTP client config is used only for http and https hosts.
+func newClient(host string, httpConfig config.HTTPClientConfig) (client.APIClient, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []client.Opt{
+		client.WithHost(host),
+		client.WithAPIVersionNegotiation(),
+	}
+
+	// There are other protocols than HTTP supported by the Docker daemon, like
+	// unix, which are not supported by the HTTP client.
+	if hostURL.Scheme == "http" || hostURL.Scheme == "https" {
+		rt, err := config.NewRoundTripperFromConfig(httpConfig, "docker_sd", config.WithHTTP2Disabled())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts,
+			// No timeout as the logs are streamed.
+			client.WithHTTPClient(&http.Client{Transport: rt}),
+			client.WithScheme(hostURL.Scheme),
+			client.WithHTTPHeaders(map[string]string{
+				"User-Agent": userAgent,
+			}),
+		)
+	}
+
+	c, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up docker client: %w", err)
+	}
+	return c, nil
+}
+
+// Ready returns true if at least one target is running.
+func (tg *targetGroup) Ready() bool {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	for _, t := range tg.targets {
+		if t.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveTargets returns the targets of the running containers.
+func (tg *targetGroup) ActiveTargets() []target.Target {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	res := make([]target.Target, 0, len(tg.targets))
+	for _, t := range tg.targets {
+		res = append(res, t)
+	}
+	return res
+}
+
+// AllTargets returns the targets of the running containers and the dropped targets.
+func (tg *targetGroup) AllTargets() []target.Target {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	res := make([]target.Target, 0, len(tg.targets)+len(tg.droppedTargets))
+	for _, t := range tg.targets {
+		res = append(res, t)
+	}
+	return append(res, tg.droppedTargets...)
+}
+
+// Stop stops all the targets of the group.
+func (tg *targetGroup) Stop() {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	for _, t := range tg.targets {
+		t.Stop()
+	}
+	tg.targets = map[string]*Target{}
+}
@@ -0,0 +1,310 @@
+package docker
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/testutils"
+)
+
+type frame struct {
+	stream stdcopy.StdType
+	line   string
+}
+
+// newDockerServer returns a fake Docker daemon serving the logs of containers which all have
+// the same frames, the since parameter of the last request is sent to since. The container
+// "removed" doesn't exist.
+func newDockerServer(t *testing.T, frames []frame, since chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			w.Header().Set("API-Version", "1.40")
+		case strings.Contains(r.URL.Path, "/containers/removed/"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container: removed"}`))
+		case strings.HasSuffix(r.URL.Path, "/json"):
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"Id":"1234","Config":{"Tty":false}}`))
+		case strings.HasSuffix(r.URL.Path, "/logs"):
+			if since != nil {
+				since <- r.URL.Query().Get("since")
+			}
+			for _, f := range frames {
+				header := make([]byte, 8)
+				header[0] = byte(f.stream)
+				binary.BigEndian.PutUint32(header[4:], uint32(len(f.line)))
+				_, _ = w.Write(header)
+				_, _ = w.Write([]byte(f.line))
+			}
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestPositions(t *testing.T, logger log.Logger) positions.Positions {
+	testutils.InitRandom()
+	dirName := "/tmp/" + testutils.RandName()
+	t.Cleanup(func() { _ = os.RemoveAll(dirName) })
+
+	ps, err := positions.New(logger, positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: dirName + "/positions.yml",
+	})
+	require.NoError(t, err)
+	return ps
+}
+
+func TestTarget(t *testing.T) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	since := make(chan string, 2)
+	server := newDockerServer(t, []frame{
+		{stdcopy.Stdout, "2021-07-01T10:00:00.000000001Z first line\n"},
+		{stdcopy.Stderr, "2021-07-01T10:00:01.000000002Z an error\n"},
+		{stdcopy.Stdout, "2021-07-01T10:00:02.000000003Z last line\n"},
+	}, since)
+	defer server.Close()
+
+	c, err := newClient(server.URL, config.HTTPClientConfig{})
+	require.NoError(t, err)
+	ps := newTestPositions(t, logger)
+	defer ps.Stop()
+	entryHandler := fake.New(func() {})
+	defer entryHandler.Stop()
+
+	var relabels []*relabel.Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- source_labels: ['__meta_docker_container_name']
+  target_label: 'container'
+- source_labels: ['__meta_docker_container_log_stream']
+  target_label: 'stream'`), &relabels))
+
+	discoveredLabels := model.LabelSet{
+		dockerLabelContainerID:         "1234",
+		"__meta_docker_container_name": "/flog",
+		"job":                          "docker",
+	}
+	tgt, err := NewTarget(NewMetrics(prometheus.NewRegistry()), logger, entryHandler, ps, "1234", discoveredLabels, relabels, c)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(entryHandler.Received()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	tgt.Stop()
+	require.Equal(t, "", <-since)
+
+	received := entryHandler.Received()
+	sort.Slice(received, func(i, j int) bool { return received[i].Timestamp.Before(received[j].Timestamp) })
+	expectedLines := []string{"first line", "an error", "last line"}
+	expectedStreams := []string{"stdout", "stderr", "stdout"}
+	for i, e := range received {
+		require.Equal(t, expectedLines[i], e.Line)
+		require.Equal(t, model.LabelSet{
+			"job":       "docker",
+			"container": "/flog",
+			"stream":    model.LabelValue(expectedStreams[i]),
+		}, e.Labels)
+	}
+	require.Equal(t, time.Date(2021, 7, 1, 10, 0, 2, 3, time.UTC).UnixNano(), received[2].Timestamp.UnixNano())
+	require.Equal(t, model.LabelSet{"job": "docker", "container": "/flog"}, tgt.Labels())
+
+	pos, err := ps.Get(positionKey("1234"))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 7, 1, 10, 0, 2, 3, time.UTC).UnixNano(), pos)
+
+	// A new target of the same container starts at the timestamp of the last line read.
+	tgt, err = NewTarget(NewMetrics(prometheus.NewRegistry()), logger, entryHandler, ps, "1234", discoveredLabels, relabels, c)
+	require.NoError(t, err)
+	require.Equal(t, "1625133602.000000003", <-since)
+	tgt.Stop()
+}
+
+func TestTarget_RestartSkipsLinesSent(t *testing.T) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	since := make(chan string, 2)
+	// The fake daemon sends the same lines when the target restarts, they share the timestamp of the position.
+	server := newDockerServer(t, []frame{
+		{stdcopy.Stdout, "2021-07-01T10:00:00.000000001Z first line\n"},
+		{stdcopy.Stdout, "2021-07-01T10:00:00.000000001Z second line\n"},
+		{stdcopy.Stderr, "2021-07-01T10:00:00.000000001Z first line\n"},
+	}, since)
+	defer server.Close()
+
+	c, err := newClient(server.URL, config.HTTPClientConfig{})
+	require.NoError(t, err)
+	ps := newTestPositions(t, logger)
+	defer ps.Stop()
+	entryHandler := fake.New(func() {})
+	defer entryHandler.Stop()
+
+	tgt, err := NewTarget(NewMetrics(prometheus.NewRegistry()), logger, entryHandler, ps, "1234", model.LabelSet{"job": "docker"}, nil, c)
+	require.NoError(t, err)
+	require.Equal(t, "", <-since)
+	require.Eventually(t, func() bool { return !tgt.Ready() }, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, entryHandler.Received(), 3)
+
+	tgt.startIfNotRunning()
+	require.Equal(t, "1625133600.000000001", <-since)
+	require.Eventually(t, func() bool { return !tgt.Ready() }, 5*time.Second, 10*time.Millisecond)
+	tgt.Stop()
+	require.Len(t, entryHandler.Received(), 3)
+}
+
+func TestTarget_StopWhileSending(t *testing.T) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	server := newDockerServer(t, []frame{
+		{stdcopy.Stdout, "2021-07-01T10:00:00Z out\n"},
+		{stdcopy.Stderr, "2021-07-01T10:00:01Z err\n"},
+	}, nil)
+	defer server.Close()
+
+	c, err := newClient(server.URL, config.HTTPClientConfig{})
+	require.NoError(t, err)
+	ps := newTestPositions(t, logger)
+	defer ps.Stop()
+	entries := make(chan api.Entry)
+	entryHandler := api.NewEntryHandler(entries, func() {})
+
+	tgt, err := NewTarget(NewMetrics(prometheus.NewRegistry()), logger, entryHandler, ps, "1234", model.LabelSet{"job": "docker"}, nil, c)
+	require.NoError(t, err)
+	// Only the first line is read from the handler, the target is blocked sending the other one.
+	<-entries
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		tgt.Stop()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stopping the target is blocked by the entry handler")
+	}
+}
+
+func TestTarget_DropStream(t *testing.T) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	server := newDockerServer(t, []frame{
+		{stdcopy.Stdout, "2021-07-01T10:00:00Z out\n"},
+		{stdcopy.Stderr, "2021-07-01T10:00:01Z err\n"},
+		{stdcopy.Stdout, "not a timestamp\n"},
+	}, nil)
+	defer server.Close()
+
+	c, err := newClient(server.URL, config.HTTPClientConfig{})
+	require.NoError(t, err)
+	ps := newTestPositions(t, logger)
+	defer ps.Stop()
+	entryHandler := fake.New(func() {})
+	defer entryHandler.Stop()
+
+	var relabels []*relabel.Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- source_labels: ['__meta_docker_container_log_stream']
+  regex: 'stdout'
+  action: 'drop'`), &relabels))
+
+	metrics := NewMetrics(prometheus.NewRegistry())
+	tgt, err := NewTarget(metrics, logger, entryHandler, ps, "1234", model.LabelSet{"job": "docker"}, relabels, c)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return !tgt.Ready() }, 5*time.Second, 10*time.Millisecond)
+	tgt.Stop()
+
+	received := entryHandler.Received()
+	require.Len(t, received, 1)
+	require.Equal(t, "err", received[0].Line)
+	require.Equal(t, model.LabelSet{"job": "docker"}, received[0].Labels)
+	require.Equal(t, map[string]string{
+		"id":       "1234",
+		"error":    "",
+		"position": ps.GetString(positionKey("1234")),
+		"running":  "false",
+	}, tgt.Details())
+}
+
+func TestTargetGroup_Sync(t *testing.T) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	logRequests := make(chan string, 10)
+	server := newDockerServer(t, nil, logRequests)
+	defer server.Close()
+
+	ps := newTestPositions(t, logger)
+	defer ps.Stop()
+	ps.Put(positionKey("1"), 1)
+	ps.Put(positionKey("removed"), 1)
+	entryHandler := fake.New(func() {})
+	defer entryHandler.Stop()
+
+	var relabels []*relabel.Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- source_labels: ['__meta_docker_container_name']
+  regex: '/dropped'
+  action: 'drop'`), &relabels))
+
+	tg := &targetGroup{
+		jobName:       "docker",
+		metrics:       NewMetrics(prometheus.NewRegistry()),
+		logger:        logger,
+		positions:     ps,
+		entryHandler:  entryHandler,
+		relabelConfig: relabels,
+		host:          server.URL,
+		targets:       map[string]*Target{},
+	}
+	defer tg.Stop()
+
+	groups := []*targetgroup.Group{{
+		Source: "docker",
+		Labels: model.LabelSet{"job": "docker"},
+		Targets: []model.LabelSet{
+			{dockerLabelContainerID: "1", "__meta_docker_container_name": "/one", "__meta_docker_network_name": "a"},
+			{dockerLabelContainerID: "1", "__meta_docker_container_name": "/one", "__meta_docker_network_name": "b"},
+			{dockerLabelContainerID: "2", "__meta_docker_container_name": "/dropped"},
+			{dockerLabelContainerID: "removed", "__meta_docker_container_name": "/removed"},
+			{"__meta_docker_container_name": "/no-id"},
+		},
+	}}
+	tg.sync(groups)
+	require.Len(t, tg.ActiveTargets(), 2)
+	require.Len(t, tg.AllTargets(), 3)
+	require.Contains(t, tg.targets, "1")
+
+	// The logs stream of the fake daemon ends right away, the target is started again on the next sync.
+	one := tg.targets["1"]
+	require.Eventually(t, func() bool { return len(logRequests) == 1 && !one.Ready() }, 5*time.Second, 10*time.Millisecond)
+	tg.sync(groups)
+	require.Same(t, one, tg.targets["1"])
+	require.Eventually(t, func() bool { return len(logRequests) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	tg.sync([]*targetgroup.Group{{
+		Source:  "docker",
+		Targets: []model.LabelSet{{dockerLabelContainerID: "3", "__meta_docker_container_name": "/three"}},
+	}})
+	require.Len(t, tg.ActiveTargets(), 1)
+	require.Len(t, tg.AllTargets(), 1)
+	require.Contains(t, tg.targets, "3")
+
+	// Only the position of the removed container is deleted, the other one may be started again.
+	require.Equal(t, "1", ps.GetString(positionKey("1")))
+	require.Equal(t, "", ps.GetString(positionKey("removed")))
+}
@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/discovery"
+
+	"github.com/grafana/loki/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/util"
+)
+
+// TargetManager manages the Docker targets of docker_sd_configs.
+type TargetManager struct {
+	logger  log.Logger
+	cancel  context.CancelFunc
+	manager *discovery.Manager
+	// groups are indexed by the name given to their configuration in the discovery manager.
+	groups   map[string]*targetGroup
+	handlers []api.EntryHandler
+}
+
+// NewTargetManager creates a new TargetManager discovering the containers of the Docker daemons
+// of the docker_sd_configs and reading their logs.
+func NewTargetManager(
+	metrics *Metrics,
+	logger log.Logger,
+	positions positions.Positions,
+	pushClient api.EntryHandler,
+	scrapeConfigs []scrapeconfig.Config,
+) (*TargetManager, error) {
+	reg := metrics.reg
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tm := &TargetManager{
+		logger:  logger,
+		cancel:  cancel,
+		manager: discovery.NewManager(ctx, log.With(logger, "component", "docker_discovery")),
+		groups:  map[string]*targetGroup{},
+	}
+
+	configs := map[string]discovery.Configs{}
+	for _, cfg := range scrapeConfigs {
+		if cfg.DockerSDConfigs == nil {
+			continue
+		}
+
+		pipeline, err := stages.NewPipeline(log.With(logger, "component", "docker_pipeline"), cfg.PipelineStages, &cfg.JobName, reg)
+		if err != nil {
+			return nil, err
+		}
+		handler := pipeline.Wrap(pushClient)
+		tm.handlers = append(tm.handlers, handler)
+
+		for i, sdConfig := range cfg.DockerSDConfigs {
+			// Each Docker daemon needs its own client, a target group is created for each of them.
+			name := fmt.Sprintf("%s/%d", cfg.JobName, i)
+			tm.groups[name] = &targetGroup{
+				jobName:       cfg.JobName,
+				metrics:       metrics,
+				logger:        log.With(logger, "job", cfg.JobName, "host", sdConfig.Host),
+				positions:     positions,
+				entryHandler:  handler,
+				relabelConfig: cfg.RelabelConfigs,
+				host:          sdConfig.Host,
+				httpConfig:    sdConfig.HTTPClientConfig,
+				targets:       map[string]*Target{},
+			}
+			configs[name] = discovery.Configs{sdConfig}
+		}
+	}
+
+	go tm.run()
+	go util.LogError("running docker target manager", tm.manager.Run)
+
+	return tm, tm.manager.ApplyConfig(configs)
+}
+
+func (tm *TargetManager) run() {
+	for targetGroups := range tm.manager.SyncCh() {
+		for name, groups := range targetGroups {
+			if group, ok := tm.groups[name]; ok {
+				group.sync(groups)
+			}
+		}
+	}
+}
+
+// Ready returns true if at least one Docker target is running.
+func (tm *TargetManager) Ready() bool {
+	for _, group := range tm.groups {
+		if group.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop the TargetManager and all of its targets.
+func (tm *TargetManager) Stop() {
+	tm.cancel()
+	for _, group := range tm.groups {
+		group.Stop()
+	}
+	for _, handler := range tm.handlers {
+		handler.Stop()
+	}
+}
+
+// ActiveTargets returns the active targets per job.
+func (tm *TargetManager) ActiveTargets() map[string][]target.Target {
+	res := make(map[string][]target.Target, len(tm.groups))
+	for _, group := range tm.groups {
+		res[group.jobName] = append(res[group.jobName], group.ActiveTargets()...)
+	}
+	return res
+}
+
+// AllTargets returns all targets per job, including the dropped ones.
+func (tm *TargetManager) AllTargets() map[string][]target.Target {
+	res := make(map[string][]target.Target, len(tm.groups))
+	for _, group := range tm.groups {
+		res[group.jobName] = append(res[group.jobName], group.AllTargets()...)
+	}
+	return res
+}
@@ -0,0 +1,292 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+type compressionFormat string
+
+const (
+	formatNone  compressionFormat = ""
+	formatGzip  compressionFormat = "gzip"
+	formatBzip2 compressionFormat = "bzip2"
+	formatZstd  compressionFormat = "zstd"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	errDecompressorStopped = errors.New("decompressor stopped")
+	errPositionPastEnd     = errors.New("position is past the end of the decompressed file")
+)
+
+// compression returns the compression format of a file, detected from its extension and
+// otherwise from its first bytes.
+func compression(path string) (compressionFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return formatGzip, nil
+	case ".bz2":
+		return formatBzip2, nil
+	case ".zst":
+		return formatZstd, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return formatNone, err
+	}
+	defer func() { _ = f.Close() }()
+
+	header := make([]byte, 4)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return formatNone, err
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return formatGzip, nil
+	case len(header) == 4 && bytes.HasPrefix(header, bzip2Magic) && header[3] >= '1' && header[3] <= '9':
+		return formatBzip2, nil
+	case bytes.Equal(header, zstdMagic):
+		return formatZstd, nil
+	}
+	return formatNone, nil
+}
+
+// decompressor reads a compressed file once to completion. Compressed files, such as
+// archives created by logrotate, are not expected to be appended to so they are not tailed.
+// The position saved is the offset in the decompressed stream.
+type decompressor struct {
+	metrics   *Metrics
+	logger    log.Logger
+	handler   api.EntryHandler
+	positions positions.Positions
+
+	path   string
+	format compressionFormat
+
+	position *atomic.Int64
+	stopOnce sync.Once
+
+	running  *atomic.Bool
+	finished *atomic.Bool
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+func newDecompressor(metrics *Metrics, logger log.Logger, handler api.EntryHandler, positions positions.Positions, path string, format compressionFormat) (*decompressor, error) {
+	pos, err := positions.Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	logger = log.With(logger, "component", "decompressor")
+	decompressor := &decompressor{
+		metrics:   metrics,
+		logger:    logger,
+		handler:   api.AddLabelsMiddleware(model.LabelSet{FilenameLabel: model.LabelValue(path)}).Wrap(handler),
+		positions: positions,
+		path:      path,
+		format:    format,
+		position:  atomic.NewInt64(pos),
+		running:   atomic.NewBool(true),
+		finished:  atomic.NewBool(false),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go decompressor.readLines()
+	metrics.filesActive.Add(1.)
+	return decompressor, nil
+}
+
+// readLines runs in a goroutine and reads the whole file. If reading fails the decompressor stops running,
+// it will be re-opened by the filetarget sync method if the file still exists and will start reading from
+// the last successful entry in the positions file.
+func (d *decompressor) readLines() {
+	level.Info(d.logger).Log("msg", "decompressor: started", "path", d.path, "format", d.format)
+
+	defer func() {
+		if err := d.markPositionAndSize(); err != nil {
+			level.Error(d.logger).Log("msg", "decompressor: error marking file position", "path", d.path, "error", err)
+		}
+		if !d.finished.Load() {
+			d.cleanupMetrics()
+			d.running.Store(false)
+		}
+		close(d.done)
+	}()
+
+	err := d.read()
+	if err == errPositionPastEnd {
+		// The file has been replaced, read it from the beginning.
+		level.Warn(d.logger).Log("msg", "decompressor: saved position is past the end of the file, reading from the beginning", "path", d.path, "position", d.position.Load())
+		d.position.Store(0)
+		err = d.read()
+	}
+	switch err {
+	case nil:
+		d.finished.Store(true)
+		level.Info(d.logger).Log("msg", "decompressor: finished reading file", "path", d.path, "position", d.position.Load())
+	case errDecompressorStopped:
+		level.Info(d.logger).Log("msg", "decompressor: stopped before the end of the file", "path", d.path)
+	default:
+		level.Error(d.logger).Log("msg", "decompressor: error reading file, stopping decompressor", "path", d.path, "error", err)
+	}
+}
+
+func (d *decompressor) read() error {
+	f, err := os.Open(d.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	r, err := d.newReader(f)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	// Skip what has already been read, there is no way to seek in a compressed stream.
+	if pos := d.position.Load(); pos > 0 {
+		if _, err := io.CopyN(ioutil.Discard, r, pos); err != nil {
+			if err == io.EOF {
+				return errPositionPastEnd
+			}
+			return err
+		}
+	}
+
+	var (
+		br            = bufio.NewReader(r)
+		entries       = d.handler.Chan()
+		syncPeriod    = d.positions.SyncPeriod()
+		lastPositions = time.Now()
+	)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			text := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			select {
+			case entries <- api.Entry{
+				Labels: model.LabelSet{},
+				Entry: logproto.Entry{
+					Timestamp: time.Now(),
+					Line:      text,
+				},
+			}:
+			case <-d.quit:
+				return errDecompressorStopped
+			}
+			d.position.Add(int64(len(line)))
+			d.metrics.readLines.WithLabelValues(d.path).Inc()
+			d.metrics.logLengthHistogram.WithLabelValues(d.path).Observe(float64(len(text)))
+
+			if time.Since(lastPositions) >= syncPeriod {
+				if err := d.markPositionAndSize(); err != nil {
+					return err
+				}
+				lastPositions = time.Now()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *decompressor) newReader(r io.Reader) (io.ReadCloser, error) {
+	switch d.format {
+	case formatGzip:
+		return gzip.NewReader(r)
+	case formatBzip2:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case formatZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, errors.Errorf("unsupported compression format %q", d.format)
+	}
+}
+
+func (d *decompressor) markPositionAndSize() error {
+	pos := d.position.Load()
+	d.metrics.readBytes.WithLabelValues(d.path).Set(float64(pos))
+	// The size of the decompressed stream is only known once it has been read entirely.
+	if d.finished.Load() {
+		d.metrics.totalBytes.WithLabelValues(d.path).Set(float64(pos))
+	}
+	d.positions.Put(d.path, pos)
+	return nil
+}
+
+func (d *decompressor) stop() {
+	// stop can be called by two separate threads in filetarget, to avoid a panic closing channels more than once
+	// we wrap the stop in a sync.Once.
+	d.stopOnce.Do(func() {
+		close(d.quit)
+		// Wait for readLines() to exit, it saves the current position.
+		<-d.done
+		if d.finished.Load() {
+			d.cleanupMetrics()
+			d.running.Store(false)
+		}
+		level.Info(d.logger).Log("msg", "stopped reading compressed file", "path", d.path)
+		d.handler.Stop()
+	})
+}
+
+func (d *decompressor) isRunning() bool {
+	return d.running.Load()
+}
+
+func (d *decompressor) details() ReaderDetails {
+	return ReaderDetails{
+		Position:   d.position.Load(),
+		Compressed: true,
+		Finished:   d.finished.Load(),
+	}
+}
+
+// cleanupMetrics removes all metrics exported by this decompressor
+func (d *decompressor) cleanupMetrics() {
+	d.metrics.filesActive.Add(-1.)
+	d.metrics.readLines.DeleteLabelValues(d.path)
+	d.metrics.readBytes.DeleteLabelValues(d.path)
+	d.metrics.totalBytes.DeleteLabelValues(d.path)
+	d.metrics.logLengthHistogram.DeleteLabelValues(d.path)
+}
@@ -0,0 +1,147 @@
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+)
+
+const testLines = "line 1\nline 2\nline 3\n"
+
+func gzipData(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstdData(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func bzip2Data(t *testing.T) []byte {
+	// testdata/test.log.bz2 contains testLines, the standard library has no bzip2 writer.
+	data, err := ioutil.ReadFile("testdata/test.log.bz2")
+	require.NoError(t, err)
+	return data
+}
+
+func TestCompression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compression")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	for _, tc := range []struct {
+		name     string
+		content  []byte
+		expected compressionFormat
+	}{
+		{"plain.log", []byte(testLines), formatNone},
+		{"empty.log", nil, formatNone},
+		{"short.log", []byte("a"), formatNone},
+		{"bzh.log", []byte("BZh is not a bzip2 header"), formatNone},
+		{"ext.gz", []byte(testLines), formatGzip},
+		{"ext.bz2", []byte(testLines), formatBzip2},
+		{"ext.zst", []byte(testLines), formatZstd},
+		{"ext.GZ", []byte(testLines), formatGzip},
+		{"magic-gzip.1", gzipData(t, testLines), formatGzip},
+		{"magic-bzip2.1", bzip2Data(t), formatBzip2},
+		{"magic-zstd.1", zstdData(t, testLines), formatZstd},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			require.NoError(t, ioutil.WriteFile(path, tc.content, 0600))
+
+			format, err := compression(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, format)
+		})
+	}
+}
+
+func TestCompressedFileTarget(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		file     string
+		content  []byte
+		position int64
+		expected []string
+	}{
+		{"gzip", "test.log.1.gz", gzipData(t, testLines), 0, []string{"line 1", "line 2", "line 3"}},
+		{"bzip2", "test.log.1.bz2", bzip2Data(t), 0, []string{"line 1", "line 2", "line 3"}},
+		{"zstd", "test.log.1.zst", zstdData(t, testLines), 0, []string{"line 1", "line 2", "line 3"}},
+		{"detected by magic bytes", "test.log.1", gzipData(t, testLines), 0, []string{"line 1", "line 2", "line 3"}},
+		{"no trailing new line", "test.log.1.gz", gzipData(t, "line 1\r\nline 2"), 0, []string{"line 1", "line 2"}},
+		{"resume from position", "test.log.1.gz", gzipData(t, testLines), 7, []string{"line 2", "line 3"}},
+		{"position past the end", "test.log.1.gz", gzipData(t, testLines), 100, []string{"line 1", "line 2", "line 3"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+
+			dir, err := ioutil.TempDir("", "decompressor")
+			require.NoError(t, err)
+			defer func() { _ = os.RemoveAll(dir) }()
+
+			path := filepath.Join(dir, tc.file)
+			require.NoError(t, ioutil.WriteFile(path, tc.content, 0600))
+
+			ps, err := positions.New(logger, positions.Config{
+				SyncPeriod:    10 * time.Second,
+				PositionsFile: filepath.Join(dir, "positions.yml"),
+			})
+			require.NoError(t, err)
+			ps.Put(path, tc.position)
+
+			client := fake.New(func() {})
+			defer client.Stop()
+
+			target, err := NewFileTarget(NewMetrics(nil), logger, client, ps, filepath.Join(dir, "*.log*"), nil, nil, &Config{
+				SyncPeriod: 10 * time.Second,
+			})
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool {
+				return target.Details().(map[string]ReaderDetails)[path].Finished
+			}, 5*time.Second, 10*time.Millisecond)
+			require.Eventually(t, func() bool {
+				return len(client.Received()) == len(tc.expected)
+			}, 5*time.Second, 10*time.Millisecond)
+
+			details := target.Details().(map[string]ReaderDetails)[path]
+			require.True(t, details.Compressed)
+
+			target.Stop()
+			ps.Stop()
+
+			var lines []string
+			for _, e := range client.Received() {
+				lines = append(lines, e.Line)
+				require.Equal(t, path, string(e.Labels[FilenameLabel]))
+			}
+			require.Equal(t, tc.expected, lines)
+
+			pos, err := ps.Get(path)
+			require.NoError(t, err)
+			require.Equal(t, details.Position, pos)
+		})
+	}
+}
@@ -60,7 +60,7 @@ type FileTarget struct {
 	quit    chan struct{}
 	done    chan struct{}
 
-	tails map[string]*tailer
+	tails map[string]reader
 
 	targetConfig *Config
 }
@@ -93,7 +93,7 @@ func NewFileTarget(
 		positions:        positions,
 		quit:             make(chan struct{}),
 		done:             make(chan struct{}),
-		tails:            map[string]*tailer{},
+		tails:            map[string]reader{},
 		targetConfig:     targetConfig,
 	}
 
@@ -106,7 +106,7 @@ func NewFileTarget(
 	return t, nil
 }
 
-// Ready if at least one file is being tailed
+// Ready if at least one file is being tailed or read
 func (t *FileTarget) Ready() bool {
 	return len(t.tails) > 0
 }
@@ -135,9 +135,9 @@ func (t *FileTarget) Labels() model.LabelSet {
 
 // Details implements a Target
 func (t *FileTarget) Details() interface{} {
-	files := map[string]int64{}
-	for fileName := range t.tails {
-		files[fileName], _ = t.positions.Get(fileName)
+	files := map[string]ReaderDetails{}
+	for fileName, reader := range t.tails {
+		files[fileName] = reader.details()
 	}
 	return files
 }
@@ -281,13 +281,28 @@ func (t *FileTarget) startTailing(ps []string) {
 			level.Error(t.logger).Log("msg", "failed to tail file", "error", "file is a directory", "filename", p)
 			continue
 		}
-		level.Debug(t.logger).Log("msg", "tailing new file", "filename", p)
-		tailer, err := newTailer(t.metrics, t.logger, t.handler, t.positions, p)
+		format, err := compression(p)
 		if err != nil {
-			level.Error(t.logger).Log("msg", "failed to start tailer", "error", err, "filename", p)
+			level.Error(t.logger).Log("msg", "failed to detect file compression", "error", err, "filename", p)
 			continue
 		}
-		t.tails[p] = tailer
+		var reader reader
+		if format != formatNone {
+			level.Debug(t.logger).Log("msg", "reading new compressed file", "filename", p, "format", format)
+			reader, err = newDecompressor(t.metrics, t.logger, t.handler, t.positions, p, format)
+			if err != nil {
+				level.Error(t.logger).Log("msg", "failed to start decompressor", "error", err, "filename", p)
+				continue
+			}
+		} else {
+			level.Debug(t.logger).Log("msg", "tailing new file", "filename", p)
+			reader, err = newTailer(t.metrics, t.logger, t.handler, t.positions, p)
+			if err != nil {
+				level.Error(t.logger).Log("msg", "failed to start tailer", "error", err, "filename", p)
+				continue
+			}
+		}
+		t.tails[p] = reader
 	}
 }
 
@@ -295,9 +310,9 @@ func (t *FileTarget) startTailing(ps []string) {
 // Call this when a file no longer exists and you want to remove all traces of it.
 func (t *FileTarget) stopTailingAndRemovePosition(ps []string) {
 	for _, p := range ps {
-		if tailer, ok := t.tails[p]; ok {
-			tailer.stop()
-			t.positions.Remove(tailer.path)
+		if reader, ok := t.tails[p]; ok {
+			reader.stop()
+			t.positions.Remove(p)
 			delete(t.tails, p)
 		}
 		if h, ok := t.handler.(api.InstrumentedEntryHandler); ok {
@@ -320,7 +335,7 @@ func (t *FileTarget) pruneStoppedTailers() {
 	}
 }
 
-func toStopTailing(nt []string, et map[string]*tailer) []string {
+func toStopTailing(nt []string, et map[string]reader) []string {
 	// Make a set of all existing tails
 	existingTails := make(map[string]struct{}, len(et))
 	for file := range et {
@@ -344,9 +359,9 @@ func toStopTailing(nt []string, et map[string]*tailer) []string {
 
 func (t *FileTarget) reportSize(ms []string) {
 	for _, m := range ms {
-		// Ask the tailer to update the size if a tailer exists, this keeps position and size metrics in sync
-		if tailer, ok := t.tails[m]; ok {
-			err := tailer.markPositionAndSize()
+		// Ask the reader to update the size if a reader exists, this keeps position and size metrics in sync
+		if reader, ok := t.tails[m]; ok {
+			err := reader.markPositionAndSize()
 			if err != nil {
 				level.Warn(t.logger).Log("msg", "failed to get file size from tailer, ", "file", m, "error", err)
 				return
@@ -632,7 +632,7 @@ func TestFileTargetSync(t *testing.T) {
 
 func TestToStopTailing(t *testing.T) {
 	nt := []string{"file1", "file2", "file3", "file4", "file5", "file6", "file7", "file11", "file12", "file15"}
-	et := make(map[string]*tailer, 15)
+	et := make(map[string]reader, 15)
 	for i := 1; i <= 15; i++ {
 		et[fmt.Sprintf("file%d", i)] = nil
 	}
@@ -652,7 +652,7 @@ func TestToStopTailing(t *testing.T) {
 
 func BenchmarkToStopTailing(b *testing.B) {
 	nt := []string{"file1", "file2", "file3", "file4", "file5", "file6", "file7", "file11", "file12", "file15"}
-	et := make(map[string]*tailer, 15)
+	et := make(map[string]reader, 15)
 	for i := 1; i <= 15; i++ {
 		et[fmt.Sprintf("file%d", i)] = nil
 	}
@@ -0,0 +1,21 @@
+package file
+
+// reader reads log lines from a single file and sends them to a handler, keeping track of
+// the position read in the positions file.
+type reader interface {
+	stop()
+	isRunning() bool
+	markPositionAndSize() error
+	details() ReaderDetails
+}
+
+// ReaderDetails describes the state of a file read by a FileTarget.
+type ReaderDetails struct {
+	// Position is the offset read in the file, for compressed files it is the offset in the
+	// decompressed stream.
+	Position int64
+	// Compressed is true when the file is compressed and read once instead of being tailed.
+	Compressed bool
+	// Finished is true once a compressed file has been entirely read.
+	Finished bool
+}
@@ -218,6 +218,11 @@ func (t *tailer) isRunning() bool {
 	return t.running.Load()
 }
 
+func (t *tailer) details() ReaderDetails {
+	pos, _ := t.positions.Get(t.path)
+	return ReaderDetails{Position: pos}
+}
+
 // cleanupMetrics removes all metrics exported by this tailer
 func (t *tailer) cleanupMetrics() {
 	// When we stop tailing the file, also un-export metrics related to the file
@@ -0,0 +1,230 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/util/strutil"
+)
+
+const (
+	// Chunked messages start with the magic bytes 0x1e 0x0f followed by an 8 bytes message id,
+	// the sequence number and the sequence count of the chunk.
+	chunkHeaderLength = 12
+	// maxChunks is the maximum number of chunks of a message allowed by the GELF specification.
+	maxChunks = 128
+	// chunkTimeout is the time after which the chunks of an incomplete message are discarded.
+	chunkTimeout = 5 * time.Second
+	// maxPendingMessages and maxPendingSize bound the incomplete messages kept in memory,
+	// the oldest ones are discarded above that.
+	maxPendingMessages = 1024
+	maxPendingSize     = 32 << 20
+	// maxMessageSize is the maximum size of a decompressed message.
+	maxMessageSize = 1 << 20
+)
+
+var chunkMagic = []byte{0x1e, 0x0f}
+
+// chunkedMessage holds the chunks received for a message.
+type chunkedMessage struct {
+	chunks   [][]byte
+	received int
+	size     int
+	first    time.Time
+}
+
+// chunkAssembler reassembles the chunked messages received over UDP, it is not safe for concurrent use.
+type chunkAssembler struct {
+	messages   map[string]*chunkedMessage
+	size       int
+	lastExpire time.Time
+}
+
+func newChunkAssembler() *chunkAssembler {
+	return &chunkAssembler{messages: map[string]*chunkedMessage{}}
+}
+
+// isChunk returns true if a datagram is a chunk of a message.
+func isChunk(b []byte) bool {
+	return len(b) >= chunkHeaderLength && bytes.HasPrefix(b, chunkMagic)
+}
+
+// add adds a chunk and returns the message once all its chunks have been received.
+func (a *chunkAssembler) add(b []byte, now time.Time) ([]byte, bool, error) {
+	a.expire(now)
+
+	id := string(b[2:10])
+	seq, count := int(b[10]), int(b[11])
+	if count == 0 || count > maxChunks {
+		return nil, false, fmt.Errorf("invalid chunk sequence count %d", count)
+	}
+	if seq >= count {
+		return nil, false, fmt.Errorf("invalid chunk sequence number %d of %d", seq, count)
+	}
+
+	msg, ok := a.messages[id]
+	if !ok {
+		if len(a.messages) >= maxPendingMessages {
+			a.evictOldest()
+		}
+		msg = &chunkedMessage{chunks: make([][]byte, count), first: now}
+		a.messages[id] = msg
+	}
+	if len(msg.chunks) != count {
+		a.remove(id)
+		return nil, false, fmt.Errorf("chunk sequence count %d does not match the previous chunks", count)
+	}
+	if msg.chunks[seq] == nil {
+		msg.chunks[seq] = append([]byte(nil), b[chunkHeaderLength:]...)
+		msg.received++
+		msg.size += len(msg.chunks[seq])
+		a.size += len(msg.chunks[seq])
+	}
+	if msg.received < count {
+		for a.size > maxPendingSize {
+			a.evictOldest()
+		}
+		return nil, false, nil
+	}
+
+	a.remove(id)
+	return bytes.Join(msg.chunks, nil), true, nil
+}
+
+// remove discards the chunks of a message.
+func (a *chunkAssembler) remove(id string) {
+	if msg, ok := a.messages[id]; ok {
+		a.size -= msg.size
+		delete(a.messages, id)
+	}
+}
+
+// evictOldest discards the incomplete message whose first chunk was received first.
+func (a *chunkAssembler) evictOldest() {
+	var (
+		oldestID string
+		oldest   *chunkedMessage
+	)
+	for id, msg := range a.messages {
+		if oldest == nil || msg.first.Before(oldest.first) {
+			oldestID, oldest = id, msg
+		}
+	}
+	a.remove(oldestID)
+}
+
+// expire discards the incomplete messages older than the chunk timeout, at most once per second.
+func (a *chunkAssembler) expire(now time.Time) {
+	if now.Sub(a.lastExpire) < time.Second {
+		return
+	}
+	a.lastExpire = now
+	for id, msg := range a.messages {
+		if now.Sub(msg.first) > chunkTimeout {
+			a.remove(id)
+		}
+	}
+}
+
+// decompress decompresses gzip and zlib messages, other messages are returned as is.
+// Messages larger than maxMessageSize once decompressed are rejected.
+func decompress(b []byte) ([]byte, error) {
+	var (
+		r   io.ReadCloser
+		err error
+	)
+	switch {
+	case len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(b))
+	case len(b) >= 2 && b[0]&0x0f == 0x08 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(b))
+	default:
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	d, err := ioutil.ReadAll(io.LimitReader(r, maxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(d) > maxMessageSize {
+		return nil, fmt.Errorf("decompressed message exceeds %d bytes", maxMessageSize)
+	}
+	return d, nil
+}
+
+// message is a GELF message, see https://docs.graylog.org/en/latest/pages/gelf.html#gelf-payload-specification.
+type message struct {
+	line      string
+	labels    map[string]string
+	timestamp time.Time
+}
+
+// parseMessage parses the payload of a GELF message. The line is the full_message field,
+// or the short_message field when there is none, the other fields are returned as labels.
+func parseMessage(b []byte) (message, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return message{}, fmt.Errorf("invalid GELF message: %w", err)
+	}
+	short, ok := fields["short_message"].(string)
+	if !ok {
+		return message{}, errors.New("invalid GELF message: short_message is required")
+	}
+
+	msg := message{line: short, labels: map[string]string{}}
+	if full, ok := fields["full_message"].(string); ok && full != "" {
+		msg.line = full
+		msg.labels["__gelf_message_short_message"] = short
+	}
+	for name, value := range fields {
+		switch name {
+		case "short_message", "full_message":
+		case "timestamp":
+			if ts, ok := value.(float64); ok {
+				sec, frac := splitFloat(ts)
+				msg.timestamp = time.Unix(sec, frac).UTC()
+			}
+		case "host", "version", "level", "facility":
+			msg.labels["__gelf_message_"+name] = toString(value)
+		default:
+			// Additional fields are prefixed by an underscore.
+			if strings.HasPrefix(name, "_") && len(name) > 1 {
+				msg.labels["__gelf_message_field_"+strutil.SanitizeLabelName(name[1:])] = toString(value)
+			}
+		}
+	}
+	return msg, nil
+}
+
+// splitFloat splits a timestamp in seconds in seconds and nanoseconds, rounded to the microsecond.
+func splitFloat(ts float64) (int64, int64) {
+	sec := int64(ts)
+	usec := int64((ts-float64(sec))*1e6 + 0.5)
+	return sec, usec * int64(time.Microsecond)
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -0,0 +1,348 @@
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	protocolUDP = "udp"
+	protocolTCP = "tcp"
+
+	defaultListenAddress = ":12201"
+	defaultIdleTimeout   = 120 * time.Second
+	// maxDatagramSize is the maximum size of an UDP datagram, a message is chunked above that.
+	maxDatagramSize = 65536
+	// maxTCPMessageSize is the maximum size of a message received over TCP.
+	maxTCPMessageSize = 1 << 20
+)
+
+// Target receives GELF messages over UDP or TCP.
+type Target struct {
+	metrics       *Metrics
+	logger        log.Logger
+	handler       api.EntryHandler
+	config        *scrapeconfig.GelfTargetConfig
+	relabelConfig []*relabel.Config
+
+	conn     net.PacketConn
+	listener net.Listener
+
+	ctx             context.Context
+	ctxCancel       context.CancelFunc
+	openConnections *sync.WaitGroup
+}
+
+// NewTarget configures a new Target and starts listening for messages.
+func NewTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	relabel []*relabel.Config,
+	config *scrapeconfig.GelfTargetConfig,
+) (*Target, error) {
+	if config.ListenAddress == "" {
+		config.ListenAddress = defaultListenAddress
+	}
+	if config.ListenProtocol == "" {
+		config.ListenProtocol = protocolUDP
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = defaultIdleTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Target{
+		metrics:       metrics,
+		logger:        logger,
+		handler:       handler,
+		config:        config,
+		relabelConfig: relabel,
+
+		ctx:             ctx,
+		ctxCancel:       cancel,
+		openConnections: new(sync.WaitGroup),
+	}
+
+	var err error
+	switch config.ListenProtocol {
+	case protocolUDP:
+		err = t.runUDP()
+	case protocolTCP:
+		err = t.runTCP()
+	default:
+		err = fmt.Errorf("unsupported GELF listen protocol %q, must be %s or %s", config.ListenProtocol, protocolUDP, protocolTCP)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Target) runUDP() error {
+	conn, err := net.ListenPacket(protocolUDP, t.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("error setting up GELF target %w", err)
+	}
+	t.conn = conn
+	level.Info(t.logger).Log("msg", "GELF listening on address", "address", conn.LocalAddr().String(), "protocol", protocolUDP)
+
+	t.openConnections.Add(1)
+	go t.readDatagrams()
+	return nil
+}
+
+func (t *Target) readDatagrams() {
+	defer t.openConnections.Done()
+
+	assembler := newChunkAssembler()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			if t.ctx.Err() != nil {
+				level.Info(t.logger).Log("msg", "GELF server shutting down")
+				return
+			}
+			level.Warn(t.logger).Log("msg", "failed to read GELF datagram", "err", err)
+			continue
+		}
+
+		payload := buf[:n]
+		if isChunk(payload) {
+			var complete bool
+			payload, complete, err = assembler.add(payload, time.Now())
+			if err != nil {
+				t.handleError(err)
+				continue
+			}
+			if !complete {
+				continue
+			}
+		}
+		t.handleMessage(payload, addr)
+	}
+}
+
+func (t *Target) runTCP() error {
+	l, err := net.Listen(protocolTCP, t.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("error setting up GELF target %w", err)
+	}
+	t.listener = l
+	level.Info(t.logger).Log("msg", "GELF listening on address", "address", l.Addr().String(), "protocol", protocolTCP)
+
+	t.openConnections.Add(1)
+	go t.acceptConnections()
+	return nil
+}
+
+func (t *Target) acceptConnections() {
+	defer t.openConnections.Done()
+
+	for {
+		c, err := t.listener.Accept()
+		if err != nil {
+			if t.ctx.Err() != nil {
+				level.Info(t.logger).Log("msg", "GELF server shutting down")
+				return
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				level.Warn(t.logger).Log("msg", "failed to accept GELF connection", "err", err)
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			level.Error(t.logger).Log("msg", "failed to accept GELF connection. quiting", "err", err)
+			return
+		}
+
+		t.openConnections.Add(1)
+		go t.handleConnection(c)
+	}
+}
+
+// handleConnection reads the messages of a TCP connection, they are delimited by a null byte.
+func (t *Target) handleConnection(c net.Conn) {
+	defer t.openConnections.Done()
+
+	handlerCtx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	go func() {
+		<-handlerCtx.Done()
+		_ = c.Close()
+	}()
+
+	scanner := bufio.NewScanner(&idleTimeoutConn{c, t.config.IdleTimeout})
+	scanner.Buffer(make([]byte, 0, 4096), maxTCPMessageSize)
+	scanner.Split(splitNull)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		t.handleMessage(scanner.Bytes(), c.RemoteAddr())
+	}
+	if err := scanner.Err(); err != nil && t.ctx.Err() == nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			level.Debug(t.logger).Log("msg", "connection timed out", "err", ne)
+			return
+		}
+		t.handleError(err)
+	}
+}
+
+// splitNull is a bufio.SplitFunc splitting on null bytes.
+func splitNull(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (t *Target) handleError(err error) {
+	level.Warn(t.logger).Log("msg", "error parsing GELF message", "err", err)
+	t.metrics.gelfErrors.Inc()
+}
+
+func (t *Target) handleMessage(payload []byte, addr net.Addr) {
+	payload, err := decompress(payload)
+	if err != nil {
+		t.handleError(err)
+		return
+	}
+	msg, err := parseMessage(payload)
+	if err != nil {
+		t.handleError(err)
+		return
+	}
+
+	lb := labels.NewBuilder(nil)
+	for k, v := range t.config.Labels {
+		lb.Set(string(k), string(v))
+	}
+	if ip := ipFromAddr(addr); ip != nil {
+		lb.Set("__gelf_connection_ip_address", ip.String())
+	}
+	for k, v := range msg.labels {
+		lb.Set(k, v)
+	}
+
+	processed := relabel.Process(lb.Labels(), t.relabelConfig...)
+	if len(processed) == 0 {
+		return
+	}
+	filtered := make(model.LabelSet)
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+
+	timestamp := time.Now()
+	if t.config.UseIncomingTimestamp && !msg.timestamp.IsZero() {
+		timestamp = msg.timestamp
+	}
+	t.handler.Chan() <- api.Entry{
+		Labels: filtered,
+		Entry: logproto.Entry{
+			Timestamp: timestamp,
+			Line:      msg.line,
+		},
+	}
+	t.metrics.gelfEntries.Inc()
+}
+
+func ipFromAddr(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr.IP
+	case *net.TCPAddr:
+		return addr.IP
+	}
+	return nil
+}
+
+// Type returns GelfTargetType.
+func (t *Target) Type() target.TargetType {
+	return target.GelfTargetType
+}
+
+// Ready indicates whether or not the GELF target is ready to be read from.
+func (t *Target) Ready() bool {
+	return true
+}
+
+// DiscoveredLabels returns the set of labels discovered by the GELF target, which
+// is always nil. Implements Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return nil
+}
+
+// Labels returns the set of labels that statically apply to all log entries
+// produced by the GELF target.
+func (t *Target) Labels() model.LabelSet {
+	return t.config.Labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return map[string]string{
+		"address":  t.ListenAddress().String(),
+		"protocol": t.config.ListenProtocol,
+	}
+}
+
+// Stop shuts down the GELF target.
+func (t *Target) Stop() error {
+	t.ctxCancel()
+	var err error
+	if t.conn != nil {
+		err = t.conn.Close()
+	}
+	if t.listener != nil {
+		err = t.listener.Close()
+	}
+	t.openConnections.Wait()
+	t.handler.Stop()
+	return err
+}
+
+// ListenAddress returns the address the GELF target is listening on.
+func (t *Target) ListenAddress() net.Addr {
+	if t.conn != nil {
+		return t.conn.LocalAddr()
+	}
+	return t.listener.Addr()
+}
+
+type idleTimeoutConn struct {
+	net.Conn
+	idleTimeout time.Duration
+}
+
+func (c *idleTimeoutConn) Read(b []byte) (int, error) {
+	_ = c.Conn.SetDeadline(time.Now().Add(c.idleTimeout))
+	return c.Conn.Read(b)
+}
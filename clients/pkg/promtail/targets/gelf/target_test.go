@@ -0,0 +1,248 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+const testMessage = `{"version":"1.1","host":"example.org","short_message":"A short message","timestamp":1625133600.123,"level":1,"_user_id":9001,"_some-info":"foo"}`
+
+func gzipped(t *testing.T, b []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zlibbed(t *testing.T, b []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, err := w.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// chunk splits a message in n chunks.
+func chunk(id string, b []byte, n int) [][]byte {
+	var chunks [][]byte
+	size := len(b)/n + 1
+	for i := 0; i < n; i++ {
+		end := (i + 1) * size
+		if end > len(b) {
+			end = len(b)
+		}
+		c := append([]byte{0x1e, 0x0f}, id...)
+		c = append(c, byte(i), byte(n))
+		chunks = append(chunks, append(c, b[i*size:end]...))
+	}
+	return chunks
+}
+
+func TestParseMessage(t *testing.T) {
+	msg, err := parseMessage([]byte(testMessage))
+	require.NoError(t, err)
+	require.Equal(t, "A short message", msg.line)
+	require.Equal(t, time.Date(2021, 7, 1, 10, 0, 0, 123000000, time.UTC), msg.timestamp)
+	require.Equal(t, map[string]string{
+		"__gelf_message_version":         "1.1",
+		"__gelf_message_host":            "example.org",
+		"__gelf_message_level":           "1",
+		"__gelf_message_field_user_id":   "9001",
+		"__gelf_message_field_some_info": "foo",
+	}, msg.labels)
+
+	msg, err = parseMessage([]byte(`{"version":"1.1","host":"example.org","short_message":"A short message","full_message":"A full message\nwith a backtrace"}`))
+	require.NoError(t, err)
+	require.Equal(t, "A full message\nwith a backtrace", msg.line)
+	require.Equal(t, "A short message", msg.labels["__gelf_message_short_message"])
+
+	_, err = parseMessage([]byte(`{"version":"1.1","host":"example.org"}`))
+	require.Error(t, err)
+	_, err = parseMessage([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestDecompress(t *testing.T) {
+	for name, payload := range map[string][]byte{
+		"plain": []byte(testMessage),
+		"gzip":  gzipped(t, []byte(testMessage)),
+		"zlib":  zlibbed(t, []byte(testMessage)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := decompress(payload)
+			require.NoError(t, err)
+			require.Equal(t, testMessage, string(b))
+		})
+	}
+
+	large := bytes.Repeat([]byte("a"), maxMessageSize+1)
+	for name, payload := range map[string][]byte{
+		"gzip": gzipped(t, large),
+		"zlib": zlibbed(t, large),
+	} {
+		t.Run(name+" too large", func(t *testing.T) {
+			_, err := decompress(payload)
+			require.EqualError(t, err, "decompressed message exceeds 1048576 bytes")
+		})
+	}
+}
+
+func TestChunkAssembler(t *testing.T) {
+	a := newChunkAssembler()
+	now := time.Now()
+
+	chunks := chunk("abcdefgh", []byte(testMessage), 3)
+	// Chunks can be received in any order and more than once.
+	for _, c := range [][]byte{chunks[2], chunks[0], chunks[2]} {
+		_, complete, err := a.add(c, now)
+		require.NoError(t, err)
+		require.False(t, complete)
+	}
+	msg, complete, err := a.add(chunks[1], now)
+	require.NoError(t, err)
+	require.True(t, complete)
+	require.Equal(t, testMessage, string(msg))
+	require.Empty(t, a.messages)
+
+	// Incomplete messages are discarded after the timeout.
+	_, _, err = a.add(chunk("12345678", []byte(testMessage), 2)[0], now)
+	require.NoError(t, err)
+	require.Len(t, a.messages, 1)
+	_, _, err = a.add(chunk("abcdefgh", []byte(testMessage), 2)[0], now.Add(chunkTimeout+time.Second))
+	require.NoError(t, err)
+	require.Len(t, a.messages, 1)
+	require.Contains(t, a.messages, "abcdefgh")
+
+	_, _, err = a.add(append([]byte("\x1e\x0fabcdefgh\x00\x81"), testMessage...), now)
+	require.EqualError(t, err, "invalid chunk sequence count 129")
+}
+
+func TestChunkAssembler_Limits(t *testing.T) {
+	a := newChunkAssembler()
+	now := time.Now()
+
+	// The oldest incomplete messages are discarded above maxPendingMessages.
+	for i := 0; i < maxPendingMessages+10; i++ {
+		id := fmt.Sprintf("%08d", i)
+		_, _, err := a.add(chunk(id, []byte(testMessage), 2)[0], now.Add(time.Duration(i)*time.Microsecond))
+		require.NoError(t, err)
+	}
+	require.Len(t, a.messages, maxPendingMessages)
+	require.NotContains(t, a.messages, "00000009")
+	require.Contains(t, a.messages, "00000010")
+
+	// And above maxPendingSize.
+	a = newChunkAssembler()
+	large := bytes.Repeat([]byte("a"), maxDatagramSize-chunkHeaderLength)
+	n := maxPendingSize / len(large)
+	for i := 0; i < n+1; i++ {
+		_, _, err := a.add(chunk(fmt.Sprintf("%08d", i), append(large, large...), 2)[0], now.Add(time.Duration(i)*time.Microsecond))
+		require.NoError(t, err)
+	}
+	require.LessOrEqual(t, a.size, maxPendingSize)
+	require.Len(t, a.messages, n)
+	require.NotContains(t, a.messages, "00000000")
+
+	a.expire(now.Add(chunkTimeout + time.Second))
+	require.Empty(t, a.messages)
+	require.Zero(t, a.size)
+}
+
+func newTestTarget(t *testing.T, protocol string) (*Target, *fake.Client) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	eh := fake.New(func() {})
+
+	tgt, err := NewTarget(NewMetrics(prometheus.NewRegistry()), logger, eh, []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__gelf_message_host"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+			TargetLabel:  "host",
+			Action:       relabel.Replace,
+		},
+		{
+			SourceLabels: model.LabelNames{"__gelf_message_field_user_id"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+			TargetLabel:  "user_id",
+			Action:       relabel.Replace,
+		},
+	}, &scrapeconfig.GelfTargetConfig{
+		ListenAddress:        "127.0.0.1:0",
+		ListenProtocol:       protocol,
+		Labels:               model.LabelSet{"job": "gelf"},
+		UseIncomingTimestamp: true,
+	})
+	require.NoError(t, err)
+	return tgt, eh
+}
+
+func requireReceived(t *testing.T, eh *fake.Client, n int) {
+	require.Eventually(t, func() bool { return len(eh.Received()) == n }, 5*time.Second, 10*time.Millisecond)
+	for _, e := range eh.Received() {
+		require.Equal(t, "A short message", e.Line)
+		require.Equal(t, model.LabelSet{"job": "gelf", "host": "example.org", "user_id": "9001"}, e.Labels)
+		require.Equal(t, time.Date(2021, 7, 1, 10, 0, 0, 123000000, time.UTC), e.Timestamp)
+	}
+}
+
+func TestTarget_UDP(t *testing.T) {
+	tgt, eh := newTestTarget(t, protocolUDP)
+	defer func() { _ = tgt.Stop() }()
+
+	conn, err := net.Dial("udp", tgt.ListenAddress().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	datagrams := [][]byte{
+		[]byte(testMessage),
+		gzipped(t, []byte(testMessage)),
+		[]byte("not a GELF message"),
+	}
+	datagrams = append(datagrams, chunk("abcdefgh", zlibbed(t, []byte(testMessage)), 3)...)
+	for _, d := range datagrams {
+		_, err := conn.Write(d)
+		require.NoError(t, err)
+	}
+
+	requireReceived(t, eh, 3)
+}
+
+func TestTarget_TCP(t *testing.T) {
+	tgt, eh := newTestTarget(t, protocolTCP)
+	defer func() { _ = tgt.Stop() }()
+
+	conn, err := net.Dial("tcp", tgt.ListenAddress().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(testMessage + "\x00" + testMessage + "\x00"))
+	require.NoError(t, err)
+
+	requireReceived(t, eh, 2)
+}
+
+func TestNewTarget_InvalidProtocol(t *testing.T) {
+	_, err := NewTarget(NewMetrics(nil), log.NewNopLogger(), fake.New(func() {}), nil, &scrapeconfig.GelfTargetConfig{
+		ListenProtocol: "http",
+	})
+	require.EqualError(t, err, `unsupported GELF listen protocol "http", must be udp or tcp`)
+}
@@ -0,0 +1,79 @@
+package heroku
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// message is a logplex message, a syslog message without structured data.
+// See https://devcenter.heroku.com/articles/log-drains#https-drains.
+type message struct {
+	Timestamp   time.Time
+	Hostname    string
+	Application string
+	Process     string
+	ID          string
+	Message     string
+}
+
+// parseFrames reads the octet counted messages of a logplex frame and calls fn with each of them.
+func parseFrames(r io.Reader, fn func(message)) error {
+	buf := bufio.NewReader(r)
+	for {
+		length, err := buf.ReadString(' ')
+		if err == io.EOF && strings.TrimSpace(length) == "" {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read message length: %w", err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid message length %q", strings.TrimSpace(length))
+		}
+		raw := make([]byte, n)
+		if _, err := io.ReadFull(buf, raw); err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+		msg, err := parseMessage(strings.TrimRight(string(raw), "\r\n"))
+		if err != nil {
+			return err
+		}
+		fn(msg)
+	}
+}
+
+// parseMessage parses a message formatted as `<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID MSG`.
+func parseMessage(raw string) (message, error) {
+	fields := strings.SplitN(raw, " ", 7)
+	if len(fields) < 6 || !strings.HasPrefix(fields[0], "<") {
+		return message{}, fmt.Errorf("invalid logplex message %q", raw)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, fields[1])
+	if err != nil {
+		return message{}, fmt.Errorf("invalid logplex message timestamp: %w", err)
+	}
+	msg := message{
+		Timestamp:   ts,
+		Hostname:    nilValue(fields[2]),
+		Application: nilValue(fields[3]),
+		Process:     nilValue(fields[4]),
+		ID:          nilValue(fields[5]),
+	}
+	if len(fields) == 7 {
+		msg.Message = fields[6]
+	}
+	return msg, nil
+}
+
+// nilValue returns the empty string for the syslog NILVALUE.
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
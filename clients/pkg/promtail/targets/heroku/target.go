@@ -0,0 +1,169 @@
+package heroku
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/util/strutil"
+	"github.com/weaveworks/common/server"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/serverutils"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	drainPath = "/heroku/api/v1/drain"
+
+	drainTokenHeader = "Logplex-Drain-Token"
+)
+
+// Target receives the logplex messages of a Heroku HTTPS drain.
+type Target struct {
+	metrics       *Metrics
+	logger        log.Logger
+	handler       api.EntryHandler
+	config        *scrapeconfig.HerokuDrainTargetConfig
+	relabelConfig []*relabel.Config
+	jobName       string
+	server        *server.Server
+}
+
+// NewTarget creates and starts a Heroku drain target.
+func NewTarget(metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	jobName string,
+	config *scrapeconfig.HerokuDrainTargetConfig,
+	relabel []*relabel.Config,
+) (*Target, error) {
+	mergedServerConfigs, err := serverutils.MergeWithDefaults(config.Server)
+	if err != nil {
+		return nil, err
+	}
+	// Set the config to the new combined config.
+	config.Server = mergedServerConfigs
+
+	t := &Target{
+		metrics:       metrics,
+		logger:        logger,
+		handler:       handler,
+		jobName:       jobName,
+		config:        config,
+		relabelConfig: relabel,
+	}
+
+	level.Info(logger).Log("msg", "starting Heroku drain server", "job", jobName)
+	t.server, err = serverutils.StartServer(logger, jobName, "Heroku drain", &t.config.Server, func(router *mux.Router) {
+		router.Path(drainPath).Methods(http.MethodPost).Handler(http.HandlerFunc(t.drain))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Target) drain(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	requestLabels := labels.NewBuilder(nil)
+	for k, v := range t.config.Labels {
+		requestLabels.Set(string(k), string(v))
+	}
+	if token := r.Header.Get(drainTokenHeader); token != "" {
+		requestLabels.Set("__heroku_drain_drain_token", token)
+	}
+	// The query parameters of the drain URL can be used to identify the drain.
+	for name, values := range r.URL.Query() {
+		if len(values) > 0 {
+			requestLabels.Set("__heroku_drain_param_"+strutil.SanitizeLabelName(name), values[0])
+		}
+	}
+	connLabels := requestLabels.Labels()
+
+	err := parseFrames(r.Body, func(msg message) {
+		lb := labels.NewBuilder(connLabels)
+		lb.Set("__heroku_drain_host", msg.Hostname)
+		lb.Set("__heroku_drain_app", msg.Application)
+		lb.Set("__heroku_drain_proc", msg.Process)
+		lb.Set("__heroku_drain_log_id", msg.ID)
+
+		processed := relabel.Process(lb.Labels(), t.relabelConfig...)
+		if len(processed) == 0 {
+			return
+		}
+		filtered := make(model.LabelSet)
+		for _, lbl := range processed {
+			if strings.HasPrefix(lbl.Name, "__") {
+				continue
+			}
+			filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+		}
+
+		timestamp := time.Now()
+		if t.config.UseIncomingTimestamp {
+			timestamp = msg.Timestamp
+		}
+		t.handler.Chan() <- api.Entry{
+			Labels: filtered,
+			Entry: logproto.Entry{
+				Timestamp: timestamp,
+				Line:      msg.Message,
+			},
+		}
+		t.metrics.herokuEntries.Inc()
+	})
+	if err != nil {
+		t.metrics.herokuErrors.Inc()
+		level.Warn(t.logger).Log("msg", "failed to parse Heroku drain request", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Type returns HerokuDrainTargetType.
+func (t *Target) Type() target.TargetType {
+	return target.HerokuDrainTargetType
+}
+
+// Ready indicates whether or not the Heroku drain target is ready to be read from.
+func (t *Target) Ready() bool {
+	return true
+}
+
+// DiscoveredLabels returns the set of labels discovered by the Heroku drain target, which
+// is always nil. Implements Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return nil
+}
+
+// Labels returns the set of labels that statically apply to all log entries
+// produced by the Heroku drain target.
+func (t *Target) Labels() model.LabelSet {
+	return t.config.Labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return map[string]string{}
+}
+
+// Stop shuts down the Heroku drain target.
+func (t *Target) Stop() error {
+	level.Info(t.logger).Log("msg", "stopping Heroku drain server", "job", t.jobName)
+	t.server.Shutdown()
+	t.handler.Stop()
+	return nil
+}
@@ -0,0 +1,149 @@
+package heroku
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+const testDrainBody = `90 <40>1 2021-07-01T10:00:00.000000+00:00 host app web.1 - State changed from starting to up
+123 <40>1 2021-07-01T10:00:01.000000+00:00 host app router - at=info method=GET path="/" host=example.herokuapp.com status=200
+`
+
+func TestParseFrames(t *testing.T) {
+	var messages []message
+	err := parseFrames(strings.NewReader(testDrainBody), func(msg message) {
+		msg.Timestamp = msg.Timestamp.UTC()
+		messages = append(messages, msg)
+	})
+	require.NoError(t, err)
+	require.Equal(t, []message{
+		{
+			Timestamp:   time.Date(2021, 7, 1, 10, 0, 0, 0, time.UTC),
+			Hostname:    "host",
+			Application: "app",
+			Process:     "web.1",
+			Message:     "State changed from starting to up",
+		},
+		{
+			Timestamp:   time.Date(2021, 7, 1, 10, 0, 1, 0, time.UTC),
+			Hostname:    "host",
+			Application: "app",
+			Process:     "router",
+			Message:     `at=info method=GET path="/" host=example.herokuapp.com status=200`,
+		},
+	}, messages)
+
+	for _, body := range []string{
+		"83",
+		"foo <40>1 2021-07-01T10:00:00.000000+00:00 host app web.1 - up\n",
+		"200 <40>1 2021-07-01T10:00:00.000000+00:00 host app web.1 - up\n",
+		"15 <40>1 yesterday\n",
+	} {
+		require.Error(t, parseFrames(strings.NewReader(body), func(message) {}), body)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	eh := fake.New(func() {})
+	defer eh.Stop()
+
+	port := freePort(t)
+	config := &scrapeconfig.HerokuDrainTargetConfig{
+		Server:               testServerConfig(port),
+		Labels:               model.LabelSet{"job": "heroku"},
+		UseIncomingTimestamp: true,
+	}
+	rlbl := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__heroku_drain_proc"},
+			Regex:        relabel.MustNewRegexp("router"),
+			Action:       relabel.Drop,
+		},
+		{
+			SourceLabels: model.LabelNames{"__heroku_drain_app"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+			TargetLabel:  "app",
+			Action:       relabel.Replace,
+		},
+		{
+			SourceLabels: model.LabelNames{"__heroku_drain_param_env"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+			TargetLabel:  "env",
+			Action:       relabel.Replace,
+		},
+		{
+			SourceLabels: model.LabelNames{"__heroku_drain_drain_token"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+			TargetLabel:  "drain",
+			Action:       relabel.Replace,
+		},
+	}
+
+	tgt, err := NewTarget(NewMetrics(prometheus.NewRegistry()), logger, eh, "heroku_test", config, rlbl)
+	require.NoError(t, err)
+	defer func() { _ = tgt.Stop() }()
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d%s?env=prod", port, drainPath), strings.NewReader(testDrainBody))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/logplex-1")
+	req.Header.Set(drainTokenHeader, "d.1234")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	require.Eventually(t, func() bool { return len(eh.Received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	received := eh.Received()[0]
+	require.Equal(t, "State changed from starting to up", received.Line)
+	require.Equal(t, time.Date(2021, 7, 1, 10, 0, 0, 0, time.UTC), received.Timestamp.UTC())
+	require.Equal(t, model.LabelSet{
+		"job":   "heroku",
+		"app":   "app",
+		"env":   "prod",
+		"drain": "d.1234",
+	}, received.Labels)
+
+	res, err = http.Post(fmt.Sprintf("http://127.0.0.1:%d%s", port, drainPath), "application/logplex-1", strings.NewReader("not a frame"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+// freePort returns a randomly available port by opening and closing a TCP socket.
+func freePort(t *testing.T) int {
+	addr, err := net.ResolveTCPAddr("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l, err := net.ListenTCP("tcp", addr)
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	return port
+}
+
+func testServerConfig(port int) server.Config {
+	cfg := server.Config{}
+	cfg.RegisterFlags(flag.NewFlagSet("empty", flag.ContinueOnError))
+	cfg.HTTPListenAddress = "127.0.0.1"
+	cfg.HTTPListenPort = port
+	cfg.GRPCListenAddress = "127.0.0.1"
+	cfg.GRPCListenPort = 0 // Not testing GRPC, a random port will be assigned
+	return cfg
+}
@@ -1,7 +1,7 @@
 package lokipush
 
 import (
-	"flag"
+	"fmt"
 	"net/http"
 	"sort"
 	"strings"
@@ -11,7 +11,7 @@ import (
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
-	"github.com/imdario/mergo"
+	"github.com/gorilla/mux"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/relabel"
@@ -20,6 +20,7 @@ import (
 
 	"github.com/grafana/loki/clients/pkg/promtail/api"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/serverutils"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
 
 	"github.com/grafana/loki/pkg/loghttp/push"
@@ -49,26 +50,14 @@ func NewPushTarget(logger log.Logger,
 		config:        config,
 	}
 
-	// Bit of a chicken and egg problem trying to register the defaults and apply overrides from the loaded config.
-	// First create an empty config and set defaults.
-	defaults := server.Config{}
-	defaults.RegisterFlags(flag.NewFlagSet("empty", flag.ContinueOnError))
-	// Then apply any config values loaded as overrides to the defaults.
-	if err := mergo.Merge(&defaults, config.Server, mergo.WithOverride); err != nil {
-		level.Error(logger).Log("msg", "failed to parse configs and override defaults when configuring push server", "err", err)
-	}
-	// The merge won't overwrite with a zero value but in the case of ports 0 value
-	// indicates the desire for a random port so reset these to zero if the incoming config val is 0
-	if config.Server.HTTPListenPort == 0 {
-		defaults.HTTPListenPort = 0
-	}
-	if config.Server.GRPCListenPort == 0 {
-		defaults.GRPCListenPort = 0
+	mergedServerConfigs, err := serverutils.MergeWithDefaults(config.Server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configs and override defaults when configuring push server: %w", err)
 	}
 	// Set the config to the new combined config.
-	config.Server = defaults
+	config.Server = mergedServerConfigs
 
-	err := pt.run()
+	err = pt.run()
 	if err != nil {
 		return nil, err
 	}
@@ -78,28 +67,13 @@ func NewPushTarget(logger log.Logger,
 
 func (t *PushTarget) run() error {
 	level.Info(t.logger).Log("msg", "starting push server", "job", t.jobName)
-	// To prevent metric collisions because all metrics are going to be registered in the global Prometheus registry.
-	t.config.Server.MetricsNamespace = "promtail_" + t.jobName
-
-	// We don't want the /debug and /metrics endpoints running
-	t.config.Server.RegisterInstrumentation = false
-
-	util_log.InitLogger(&t.config.Server)
-
-	srv, err := server.New(t.config.Server)
+	srv, err := serverutils.StartServer(t.logger, t.jobName, "Loki push", &t.config.Server, func(router *mux.Router) {
+		router.Handle("/loki/api/v1/push", http.HandlerFunc(t.handle))
+	})
 	if err != nil {
 		return err
 	}
-
 	t.server = srv
-	t.server.HTTP.Handle("/loki/api/v1/push", http.HandlerFunc(t.handle))
-
-	go func() {
-		err := srv.Run()
-		if err != nil {
-			level.Error(t.logger).Log("msg", "Loki push server shutdown with error", "err", err)
-		}
-	}()
 
 	return nil
 }
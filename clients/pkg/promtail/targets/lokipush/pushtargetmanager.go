@@ -1,10 +1,6 @@
 package lokipush
 
 import (
-	"errors"
-	"fmt"
-	"strings"
-
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,6 +8,7 @@ import (
 	"github.com/grafana/loki/clients/pkg/logentry/stages"
 	"github.com/grafana/loki/clients/pkg/promtail/api"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/serverutils"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
 )
 
@@ -56,22 +53,7 @@ func NewPushTargetManager(
 }
 
 func validateJobName(scrapeConfigs []scrapeconfig.Config) error {
-	jobNames := map[string]struct{}{}
-	for i, cfg := range scrapeConfigs {
-		if cfg.JobName == "" {
-			return errors.New("`job_name` must be defined for the `push` scrape_config with a " +
-				"unique name to properly register metrics, " +
-				"at least one `push` scrape_config has no `job_name` defined")
-		}
-		if _, ok := jobNames[cfg.JobName]; ok {
-			return fmt.Errorf("`job_name` must be unique for each `push` scrape_config, "+
-				"a duplicate `job_name` of %s was found", cfg.JobName)
-		}
-		jobNames[cfg.JobName] = struct{}{}
-
-		scrapeConfigs[i].JobName = strings.Replace(cfg.JobName, " ", "_", -1)
-	}
-	return nil
+	return serverutils.ValidateJobName(scrapeConfigs, "push")
 }
 
 // Ready returns true if at least one PushTarget is also ready.
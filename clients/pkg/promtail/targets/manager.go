@@ -9,10 +9,14 @@ import (
 	"github.com/grafana/loki/clients/pkg/promtail/api"
 	"github.com/grafana/loki/clients/pkg/promtail/positions"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/docker"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/file"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/gcplog"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/gelf"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/heroku"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/journal"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/lokipush"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/splunkhec"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/stdin"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/syslog"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
@@ -26,6 +30,10 @@ const (
 	GcplogScrapeConfigs  = "gcplogScrapeConfigs"
 	PushScrapeConfigs    = "pushScrapeConfigs"
 	WindowsEventsConfigs = "windowsEventsConfigs"
+	DockerConfigs        = "dockerConfigs"
+	HerokuDrainConfigs   = "herokuDrainConfigs"
+	GelfConfigs          = "gelfConfigs"
+	SplunkHECConfigs     = "splunkHECConfigs"
 )
 
 type targetManager interface {
@@ -78,6 +86,14 @@ func NewTargetManagers(
 			targetScrapeConfigs[PushScrapeConfigs] = append(targetScrapeConfigs[PushScrapeConfigs], cfg)
 		case cfg.WindowsConfig != nil:
 			targetScrapeConfigs[WindowsEventsConfigs] = append(targetScrapeConfigs[WindowsEventsConfigs], cfg)
+		case cfg.DockerSDConfigs != nil:
+			targetScrapeConfigs[DockerConfigs] = append(targetScrapeConfigs[DockerConfigs], cfg)
+		case cfg.HerokuDrainConfig != nil:
+			targetScrapeConfigs[HerokuDrainConfigs] = append(targetScrapeConfigs[HerokuDrainConfigs], cfg)
+		case cfg.GelfConfig != nil:
+			targetScrapeConfigs[GelfConfigs] = append(targetScrapeConfigs[GelfConfigs], cfg)
+		case cfg.SplunkHECConfig != nil:
+			targetScrapeConfigs[SplunkHECConfigs] = append(targetScrapeConfigs[SplunkHECConfigs], cfg)
 
 		default:
 			return nil, errors.New("unknown scrape config")
@@ -102,6 +118,10 @@ func NewTargetManagers(
 		fileMetrics   *file.Metrics
 		syslogMetrics *syslog.Metrics
 		gcplogMetrics *gcplog.Metrics
+		dockerMetrics *docker.Metrics
+		herokuMetrics *heroku.Metrics
+		gelfMetrics   *gelf.Metrics
+		hecMetrics    *splunkhec.Metrics
 	)
 	if len(targetScrapeConfigs[FileScrapeConfigs]) > 0 {
 		fileMetrics = file.NewMetrics(reg)
@@ -112,6 +132,18 @@ func NewTargetManagers(
 	if len(targetScrapeConfigs[GcplogScrapeConfigs]) > 0 {
 		gcplogMetrics = gcplog.NewMetrics(reg)
 	}
+	if len(targetScrapeConfigs[DockerConfigs]) > 0 {
+		dockerMetrics = docker.NewMetrics(reg)
+	}
+	if len(targetScrapeConfigs[HerokuDrainConfigs]) > 0 {
+		herokuMetrics = heroku.NewMetrics(reg)
+	}
+	if len(targetScrapeConfigs[GelfConfigs]) > 0 {
+		gelfMetrics = gelf.NewMetrics(reg)
+	}
+	if len(targetScrapeConfigs[SplunkHECConfigs]) > 0 {
+		hecMetrics = splunkhec.NewMetrics(reg)
+	}
 
 	for target, scrapeConfigs := range targetScrapeConfigs {
 		switch target {
@@ -187,6 +219,34 @@ func NewTargetManagers(
 				return nil, errors.Wrap(err, "failed to make windows target manager")
 			}
 			targetManagers = append(targetManagers, windowsTargetManager)
+		case DockerConfigs:
+			pos, err := getPositionFile()
+			if err != nil {
+				return nil, err
+			}
+			dockerTargetManager, err := docker.NewTargetManager(dockerMetrics, logger, pos, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make Docker target manager")
+			}
+			targetManagers = append(targetManagers, dockerTargetManager)
+		case HerokuDrainConfigs:
+			herokuTargetManager, err := heroku.NewTargetManager(herokuMetrics, logger, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make Heroku drain target manager")
+			}
+			targetManagers = append(targetManagers, herokuTargetManager)
+		case GelfConfigs:
+			gelfTargetManager, err := gelf.NewTargetManager(gelfMetrics, logger, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make GELF target manager")
+			}
+			targetManagers = append(targetManagers, gelfTargetManager)
+		case SplunkHECConfigs:
+			hecTargetManager, err := splunkhec.NewTargetManager(hecMetrics, logger, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make Splunk HEC target manager")
+			}
+			targetManagers = append(targetManagers, hecTargetManager)
 		default:
 			return nil, errors.New("unknown scrape config")
 		}
@@ -0,0 +1,88 @@
+package serverutils
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/imdario/mergo"
+	"github.com/weaveworks/common/server"
+
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+// MergeWithDefaults applies the server.Config defaults to the values which are not set in config.
+func MergeWithDefaults(config server.Config) (server.Config, error) {
+	// Bit of a chicken and egg problem trying to register the defaults and apply overrides from the loaded config.
+	// First create an empty config and set defaults.
+	defaults := server.Config{}
+	defaults.RegisterFlags(flag.NewFlagSet("empty", flag.ContinueOnError))
+	// Then apply any config values loaded as overrides to the defaults.
+	if err := mergo.Merge(&defaults, config, mergo.WithOverride); err != nil {
+		return server.Config{}, err
+	}
+	// The merge won't overwrite with a zero value but in the case of ports 0 value
+	// indicates the desire for a random port so reset these to zero if the incoming config val is 0
+	if config.HTTPListenPort == 0 {
+		defaults.HTTPListenPort = 0
+	}
+	if config.GRPCListenPort == 0 {
+		defaults.GRPCListenPort = 0
+	}
+	return defaults, nil
+}
+
+// ValidateJobName checks that the scrape configs of a kind of server target have unique job names,
+// which are used to register the metrics of their servers. Spaces are replaced in the job names.
+func ValidateJobName(scrapeConfigs []scrapeconfig.Config, kind string) error {
+	jobNames := map[string]struct{}{}
+	for i, cfg := range scrapeConfigs {
+		if cfg.JobName == "" {
+			return fmt.Errorf("`job_name` must be defined for the `%s` scrape_config with a "+
+				"unique name to properly register metrics, "+
+				"at least one `%s` scrape_config has no `job_name` defined", kind, kind)
+		}
+		if _, ok := jobNames[cfg.JobName]; ok {
+			return fmt.Errorf("`job_name` must be unique for each `%s` scrape_config, "+
+				"a duplicate `job_name` of %s was found", kind, cfg.JobName)
+		}
+		jobNames[cfg.JobName] = struct{}{}
+
+		scrapeConfigs[i].JobName = strings.Replace(cfg.JobName, " ", "_", -1)
+	}
+	return nil
+}
+
+// StartServer creates the server of a job, registers its HTTP handlers and runs it in the background.
+// The /debug and /metrics endpoints are not registered and the metrics are namespaced by the job name.
+func StartServer(logger log.Logger, jobName, name string, config *server.Config, register func(router *mux.Router)) (*server.Server, error) {
+	if config == nil {
+		return nil, errors.New("missing server config")
+	}
+	// To prevent metric collisions because all metrics are going to be registered in the global Prometheus registry.
+	config.MetricsNamespace = "promtail_" + jobName
+
+	// We don't want the /debug and /metrics endpoints running
+	config.RegisterInstrumentation = false
+
+	util_log.InitLogger(config)
+
+	srv, err := server.New(*config)
+	if err != nil {
+		return nil, err
+	}
+	register(srv.HTTP)
+
+	go func() {
+		err := srv.Run()
+		if err != nil {
+			level.Error(logger).Log("msg", name+" server shutdown with error", "err", err)
+		}
+	}()
+	return srv, nil
+}
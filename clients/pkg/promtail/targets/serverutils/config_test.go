@@ -0,0 +1,23 @@
+package serverutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+)
+
+func TestMergeWithDefaults(t *testing.T) {
+	cfg, err := MergeWithDefaults(server.Config{
+		HTTPListenAddress: "127.0.0.1",
+		HTTPListenPort:    3500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", cfg.HTTPListenAddress)
+	require.Equal(t, 3500, cfg.HTTPListenPort)
+	// A port of 0 is kept to listen on a random port.
+	require.Equal(t, 0, cfg.GRPCListenPort)
+	// The other values are set to their default.
+	require.Equal(t, 4*1024*1024, cfg.GPRCServerMaxRecvMsgSize)
+	require.Equal(t, "info", cfg.LogLevel.String())
+}
@@ -0,0 +1,38 @@
+package splunkhec
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of Splunk HEC metrics.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	hecEntries prometheus.Counter
+	hecErrors  prometheus.Counter
+}
+
+// NewMetrics creates a new set of Splunk HEC metrics. If reg is non-nil, the
+// metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.hecEntries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "splunk_hec_target_entries_total",
+		Help:      "Total number of successful entries sent to the Splunk HEC target",
+	})
+	m.hecErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "splunk_hec_target_parsing_errors_total",
+		Help:      "Total number of parsing errors while receiving Splunk HEC events",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.hecEntries,
+			m.hecErrors,
+		)
+	}
+
+	return &m
+}
@@ -0,0 +1,401 @@
+package splunkhec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/util/strutil"
+	"github.com/weaveworks/common/server"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/serverutils"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const authorizationPrefix = "Splunk "
+
+// response is the body of the responses of the HEC API, see
+// https://docs.splunk.com/Documentation/Splunk/latest/Data/TroubleshootHTTPEventCollector.
+type response struct {
+	Text       string `json:"text"`
+	Code       int    `json:"code"`
+	statusCode int
+}
+
+var (
+	responseSuccess              = response{"Success", 0, http.StatusOK}
+	responseTokenRequired        = response{"Token is required", 2, http.StatusUnauthorized}
+	responseInvalidAuthorization = response{"Invalid authorization", 3, http.StatusUnauthorized}
+	responseInvalidToken         = response{"Invalid token", 4, http.StatusForbidden}
+	responseNoData               = response{"No data", 5, http.StatusBadRequest}
+	responseInvalidDataFormat    = response{"Invalid data format", 6, http.StatusBadRequest}
+	responseEventRequired        = response{"Event field is required", 12, http.StatusBadRequest}
+	responseEventBlank           = response{"Event field cannot be blank", 13, http.StatusBadRequest}
+	responseHealthy              = response{"HEC is healthy", 17, http.StatusOK}
+)
+
+// event is an event sent to the event endpoint, its metadata fields are optional.
+type event struct {
+	Time       interface{}            `json:"time"`
+	Host       string                 `json:"host"`
+	Source     string                 `json:"source"`
+	SourceType string                 `json:"sourcetype"`
+	Index      string                 `json:"index"`
+	Event      json.RawMessage        `json:"event"`
+	Fields     map[string]interface{} `json:"fields"`
+}
+
+// Target receives events sent to the Splunk HTTP Event Collector API.
+type Target struct {
+	metrics       *Metrics
+	logger        log.Logger
+	handler       api.EntryHandler
+	config        *scrapeconfig.SplunkHECTargetConfig
+	relabelConfig []*relabel.Config
+	jobName       string
+	server        *server.Server
+}
+
+// NewTarget creates and starts a Splunk HEC target.
+func NewTarget(metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	jobName string,
+	config *scrapeconfig.SplunkHECTargetConfig,
+	relabel []*relabel.Config,
+) (*Target, error) {
+	mergedServerConfigs, err := serverutils.MergeWithDefaults(config.Server)
+	if err != nil {
+		return nil, err
+	}
+	// Set the config to the new combined config.
+	config.Server = mergedServerConfigs
+
+	t := &Target{
+		metrics:       metrics,
+		logger:        logger,
+		handler:       handler,
+		jobName:       jobName,
+		config:        config,
+		relabelConfig: relabel,
+	}
+
+	level.Info(logger).Log("msg", "starting Splunk HEC server", "job", jobName)
+	t.server, err = serverutils.StartServer(logger, jobName, "Splunk HEC", &t.config.Server, func(router *mux.Router) {
+		for _, path := range []string{"/services/collector", "/services/collector/event", "/services/collector/event/1.0"} {
+			router.Path(path).Methods(http.MethodPost).Handler(t.authorize(t.event))
+		}
+		for _, path := range []string{"/services/collector/raw", "/services/collector/raw/1.0"} {
+			router.Path(path).Methods(http.MethodPost).Handler(t.authorize(t.raw))
+		}
+		for _, path := range []string{"/services/collector/health", "/services/collector/health/1.0"} {
+			router.Path(path).Methods(http.MethodGet).HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				writeResponse(w, responseHealthy)
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func writeResponse(w http.ResponseWriter, res response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.statusCode)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// authorize checks the HEC token of the requests when tokens are configured.
+func (t *Target) authorize(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(t.config.Tokens) == 0 {
+			next(w, r)
+			return
+		}
+		authorization := r.Header.Get("Authorization")
+		if authorization == "" {
+			writeResponse(w, responseTokenRequired)
+			return
+		}
+		if !strings.HasPrefix(authorization, authorizationPrefix) {
+			writeResponse(w, responseInvalidAuthorization)
+			return
+		}
+		token := []byte(strings.TrimPrefix(authorization, authorizationPrefix))
+		for _, valid := range t.config.Tokens {
+			if subtle.ConstantTimeCompare(token, []byte(valid)) == 1 {
+				next(w, r)
+				return
+			}
+		}
+		writeResponse(w, responseInvalidToken)
+	})
+}
+
+// body returns the body of a request, decompressed if needed.
+func body(r *http.Request) (io.Reader, error) {
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		return gzip.NewReader(r.Body)
+	}
+	return r.Body, nil
+}
+
+// event handles the event endpoint, the body is a list of JSON events which are all rejected
+// if one of them is invalid.
+func (t *Target) event(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	reader, err := body(r)
+	if err != nil {
+		t.reject(w, responseInvalidDataFormat, err)
+		return
+	}
+	dec := json.NewDecoder(reader)
+	dec.UseNumber()
+
+	var (
+		entries []api.Entry
+		events  int
+	)
+	for {
+		var ev event
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			t.reject(w, responseInvalidDataFormat, err)
+			return
+		}
+		events++
+		if len(ev.Event) == 0 || string(ev.Event) == "null" {
+			t.reject(w, responseEventRequired, nil)
+			return
+		}
+		line, err := eventLine(ev.Event)
+		if err != nil {
+			t.reject(w, responseInvalidDataFormat, err)
+			return
+		}
+		if line == "" {
+			t.reject(w, responseEventBlank, nil)
+			return
+		}
+
+		timestamp := time.Now()
+		if t.config.UseIncomingTimestamp && ev.Time != nil {
+			ts, err := parseTime(ev.Time)
+			if err != nil {
+				t.reject(w, responseInvalidDataFormat, err)
+				return
+			}
+			timestamp = ts
+		}
+
+		lbls, ok := t.labels(ev.Host, ev.Source, ev.SourceType, ev.Index, ev.Fields)
+		if !ok {
+			continue
+		}
+		entries = append(entries, api.Entry{
+			Labels: lbls,
+			Entry: logproto.Entry{
+				Timestamp: timestamp,
+				Line:      line,
+			},
+		})
+	}
+	if events == 0 {
+		t.reject(w, responseNoData, nil)
+		return
+	}
+	t.send(w, entries)
+}
+
+// raw handles the raw endpoint, each line of the body is an entry. The metadata is
+// sent in the query parameters.
+func (t *Target) raw(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	reader, err := body(r)
+	if err != nil {
+		t.reject(w, responseInvalidDataFormat, err)
+		return
+	}
+	query := r.URL.Query()
+	lbls, keep := t.labels(query.Get("host"), query.Get("source"), query.Get("sourcetype"), query.Get("index"), nil)
+
+	var entries []api.Entry
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entries = append(entries, api.Entry{
+			Labels: lbls.Clone(),
+			Entry: logproto.Entry{
+				Timestamp: time.Now(),
+				Line:      line,
+			},
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		t.reject(w, responseInvalidDataFormat, err)
+		return
+	}
+	if len(entries) == 0 {
+		t.reject(w, responseNoData, nil)
+		return
+	}
+	if !keep {
+		entries = nil
+	}
+	t.send(w, entries)
+}
+
+func (t *Target) reject(w http.ResponseWriter, res response, err error) {
+	t.metrics.hecErrors.Inc()
+	if err != nil {
+		level.Warn(t.logger).Log("msg", "failed to parse Splunk HEC request", "err", err.Error())
+	}
+	writeResponse(w, res)
+}
+
+func (t *Target) send(w http.ResponseWriter, entries []api.Entry) {
+	for _, e := range entries {
+		t.handler.Chan() <- e
+		t.metrics.hecEntries.Inc()
+	}
+	writeResponse(w, responseSuccess)
+}
+
+// labels returns the labels of an event after relabeling, or false if the event is dropped.
+func (t *Target) labels(host, source, sourceType, index string, fields map[string]interface{}) (model.LabelSet, bool) {
+	lb := labels.NewBuilder(nil)
+	for k, v := range t.config.Labels {
+		lb.Set(string(k), string(v))
+	}
+	lb.Set("__splunk_hec_host", host)
+	lb.Set("__splunk_hec_source", source)
+	lb.Set("__splunk_hec_sourcetype", sourceType)
+	lb.Set("__splunk_hec_index", index)
+	for name, value := range fields {
+		lb.Set("__splunk_hec_field_"+strutil.SanitizeLabelName(name), toString(value))
+	}
+
+	processed := relabel.Process(lb.Labels(), t.relabelConfig...)
+	if len(processed) == 0 {
+		return nil, false
+	}
+	filtered := make(model.LabelSet)
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+	return filtered, true
+}
+
+// eventLine returns the line of an event, string events are unquoted and other events are
+// kept as compact JSON.
+func eventLine(raw json.RawMessage) (string, error) {
+	if bytes.HasPrefix(raw, []byte(`"`)) {
+		var line string
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return "", err
+		}
+		return line, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parseTime parses the time of an event, in seconds since the epoch as a number or a string.
+func parseTime(v interface{}) (time.Time, error) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	default:
+		return time.Time{}, errors.New("invalid event time")
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec := int64(f)
+	// Splunk timestamps have a millisecond precision at most.
+	msec := int64((f-float64(sec))*1e3 + 0.5)
+	return time.Unix(sec, msec*int64(time.Millisecond)), nil
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// Type returns SplunkHECTargetType.
+func (t *Target) Type() target.TargetType {
+	return target.SplunkHECTargetType
+}
+
+// Ready indicates whether or not the Splunk HEC target is ready to be read from.
+func (t *Target) Ready() bool {
+	return true
+}
+
+// DiscoveredLabels returns the set of labels discovered by the Splunk HEC target, which
+// is always nil. Implements Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return nil
+}
+
+// Labels returns the set of labels that statically apply to all log entries
+// produced by the Splunk HEC target.
+func (t *Target) Labels() model.LabelSet {
+	return t.config.Labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return map[string]string{}
+}
+
+// Stop shuts down the Splunk HEC target.
+func (t *Target) Stop() error {
+	level.Info(t.logger).Log("msg", "stopping Splunk HEC server", "job", t.jobName)
+	t.server.Shutdown()
+	t.handler.Stop()
+	return nil
+}
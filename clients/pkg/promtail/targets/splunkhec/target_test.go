@@ -0,0 +1,158 @@
+package splunkhec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+const testEvents = `{"time": 1625133600.123, "host": "web-1", "source": "app", "sourcetype": "json", "event": {"message": "hello", "severity": "info"}, "fields": {"region": "eu-west-1", "shard": 3}}
+{"time": "1625133601", "host": "web-2", "event": "Hello world!"}`
+
+func TestTarget(t *testing.T) {
+	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	eh := fake.New(func() {})
+	defer eh.Stop()
+
+	port := freePort(t)
+	config := &scrapeconfig.SplunkHECTargetConfig{
+		Server:               testServerConfig(port),
+		Labels:               model.LabelSet{"job": "splunk"},
+		UseIncomingTimestamp: true,
+		Tokens:               []string{"00000000-0000-0000-0000-000000000000"},
+	}
+	rlbl := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__splunk_hec_host"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			Replacement:  "$1",
+			TargetLabel:  "host",
+			Action:       relabel.Replace,
+		},
+		{
+			SourceLabels: model.LabelNames{"__splunk_hec_field_region"},
+			Regex:        relabel.MustNewRegexp("(.+)"),
+			Replacement:  "$1",
+			TargetLabel:  "region",
+			Action:       relabel.Replace,
+		},
+	}
+
+	tgt, err := NewTarget(NewMetrics(prometheus.NewRegistry()), logger, eh, "splunk_test", config, rlbl)
+	require.NoError(t, err)
+	defer func() { _ = tgt.Stop() }()
+
+	baseURL := fmt.Sprintf("http://127.0.0.1:%d", port)
+	post := func(path, token string, body []byte, gzipped bool) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, baseURL+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Splunk "+token)
+		}
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, strings.TrimSpace(string(b))
+	}
+
+	status, body := post("/services/collector/event", "", []byte(testEvents), false)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, `{"text":"Token is required","code":2}`, body)
+	status, body = post("/services/collector/event", "invalid", []byte(testEvents), false)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, `{"text":"Invalid token","code":4}`, body)
+
+	status, body = post("/services/collector/event", config.Tokens[0], gzipBytes(t, []byte(testEvents)), true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, `{"text":"Success","code":0}`, body)
+
+	require.Eventually(t, func() bool { return len(eh.Received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	received := eh.Received()
+	require.Equal(t, `{"message":"hello","severity":"info"}`, received[0].Line)
+	require.Equal(t, model.LabelSet{"job": "splunk", "host": "web-1", "region": "eu-west-1"}, received[0].Labels)
+	require.Equal(t, time.Unix(1625133600, 123000000), received[0].Timestamp)
+	require.Equal(t, "Hello world!", received[1].Line)
+	require.Equal(t, model.LabelSet{"job": "splunk", "host": "web-2"}, received[1].Labels)
+	require.Equal(t, time.Unix(1625133601, 0), received[1].Timestamp)
+
+	// A batch with an invalid event is rejected.
+	status, body = post("/services/collector", config.Tokens[0], []byte(`{"event": "ok"}{"host": "web-1"}`), false)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, `{"text":"Event field is required","code":12}`, body)
+	status, body = post("/services/collector", config.Tokens[0], []byte(`{"event": ""}`), false)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, `{"text":"Event field cannot be blank","code":13}`, body)
+	status, body = post("/services/collector", config.Tokens[0], []byte(`{"event": "ok"`), false)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, `{"text":"Invalid data format","code":6}`, body)
+	status, body = post("/services/collector", config.Tokens[0], nil, false)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, `{"text":"No data","code":5}`, body)
+	require.Len(t, eh.Received(), 2)
+
+	status, _ = post("/services/collector/raw?host=web-3", config.Tokens[0], []byte("first line\nsecond line\n"), false)
+	require.Equal(t, http.StatusOK, status)
+	require.Eventually(t, func() bool { return len(eh.Received()) == 4 }, 5*time.Second, 10*time.Millisecond)
+	received = eh.Received()
+	require.Equal(t, "first line", received[2].Line)
+	require.Equal(t, "second line", received[3].Line)
+	require.Equal(t, model.LabelSet{"job": "splunk", "host": "web-3"}, received[3].Labels)
+
+	res, err := http.Get(baseURL + "/services/collector/health")
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// freePort returns a randomly available port by opening and closing a TCP socket.
+func freePort(t *testing.T) int {
+	addr, err := net.ResolveTCPAddr("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l, err := net.ListenTCP("tcp", addr)
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	return port
+}
+
+func testServerConfig(port int) server.Config {
+	cfg := server.Config{}
+	cfg.RegisterFlags(flag.NewFlagSet("empty", flag.ContinueOnError))
+	cfg.HTTPListenAddress = "127.0.0.1"
+	cfg.HTTPListenPort = port
+	cfg.GRPCListenAddress = "127.0.0.1"
+	cfg.GRPCListenPort = 0 // Not testing GRPC, a random port will be assigned
+	return cfg
+}
@@ -2,12 +2,16 @@ package syslogparser
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strconv"
 
 	"github.com/influxdata/go-syslog/v3"
 	"github.com/influxdata/go-syslog/v3/nontransparent"
 	"github.com/influxdata/go-syslog/v3/octetcounting"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
 )
 
 // ParseStream parses a rfc5424 syslog stream from the given Reader, calling
@@ -33,3 +37,80 @@ func ParseStream(r io.Reader, callback func(res *syslog.Result), maxMessageLengt
 
 	return nil
 }
+
+// ParseRFC3164Stream parses a rfc3164 syslog stream from the given Reader, calling
+// the callback function with the parsed messages. The parser automatically
+// detects octet counting, messages are separated by newlines otherwise.
+// The function returns on EOF or unrecoverable errors.
+func ParseRFC3164Stream(r io.Reader, callback func(res *syslog.Result), maxMessageLength int, opts ...syslog.MachineOption) error {
+	buf := bufio.NewReader(r)
+
+	firstByte, err := buf.Peek(1)
+	if err != nil {
+		return err
+	}
+
+	b := firstByte[0]
+	if b != '<' && (b < '0' || b > '9') {
+		return fmt.Errorf("invalid or unsupported framing. first byte: '%s'", firstByte)
+	}
+
+	parser := rfc3164.NewParser(opts...)
+	parse := func(msg []byte) {
+		if len(msg) > maxMessageLength {
+			callback(&syslog.Result{Error: fmt.Errorf("message too long to parse. was size %d, max length %d", len(msg), maxMessageLength)})
+			return
+		}
+		m, err := parser.Parse(msg)
+		if m != nil {
+			// In best effort mode, the partially parsed messages are kept.
+			err = nil
+		}
+		callback(&syslog.Result{Message: m, Error: err})
+	}
+
+	octetCounting := b != '<'
+	for {
+		if octetCounting {
+			length, err := buf.ReadString(' ')
+			if err == io.EOF && len(length) == 0 {
+				return nil
+			}
+			if err != nil {
+				callback(&syslog.Result{Error: err})
+				return nil
+			}
+			n, err := strconv.Atoi(length[:len(length)-1])
+			if err != nil {
+				callback(&syslog.Result{Error: fmt.Errorf("invalid message length %q", length[:len(length)-1])})
+				return nil
+			}
+			if n > maxMessageLength {
+				callback(&syslog.Result{Error: fmt.Errorf("message too long to parse. was size %d, max length %d", n, maxMessageLength)})
+				if _, err := io.CopyN(ioutil.Discard, buf, int64(n)); err != nil {
+					return nil
+				}
+				continue
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(buf, msg); err != nil {
+				callback(&syslog.Result{Error: err})
+				return nil
+			}
+			parse(bytes.TrimRight(msg, "\r\n"))
+			continue
+		}
+
+		line, err := buf.ReadBytes('\n')
+		if line = bytes.TrimRight(line, "\r\n"); len(line) > 0 {
+			parse(line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			callback(&syslog.Result{Error: err})
+			return nil
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
 	"github.com/influxdata/go-syslog/v3/rfc5424"
 	"github.com/stretchr/testify/require"
 
@@ -80,3 +82,52 @@ func TestParseStream_EmptyStream(t *testing.T) {
 	err := syslogparser.ParseStream(r, func(res *syslog.Result) {}, defaultMaxMessageLength)
 	require.Equal(t, err, io.EOF)
 }
+
+func TestParseRFC3164Stream(t *testing.T) {
+	for name, stream := range map[string]string{
+		"newline separated": "<13>Jul  1 10:00:00 host app[123]: First\n<13>Jul  1 10:00:01 host app[123]: Second\n",
+		"octet counting":    "40 <13>Jul  1 10:00:00 host app[123]: First41 <13>Jul  1 10:00:01 host app[123]: Second",
+		"no trailer":        "<13>Jul  1 10:00:00 host app[123]: First\r\n<13>Jul  1 10:00:01 host app[123]: Second",
+	} {
+		t.Run(name, func(t *testing.T) {
+			results := make([]*syslog.Result, 0)
+			cb := func(res *syslog.Result) {
+				results = append(results, res)
+			}
+
+			err := syslogparser.ParseRFC3164Stream(strings.NewReader(stream), cb, defaultMaxMessageLength, rfc3164.WithLocaleTimezone(time.UTC))
+			require.NoError(t, err)
+
+			require.Equal(t, 2, len(results))
+			require.NoError(t, results[0].Error)
+			msg := results[0].Message.(*rfc3164.SyslogMessage)
+			require.Equal(t, "First", *msg.Message)
+			require.Equal(t, "host", *msg.Hostname)
+			require.Equal(t, "app", *msg.Appname)
+			require.Equal(t, "123", *msg.ProcID)
+			require.Equal(t, time.Date(0, 7, 1, 10, 0, 0, 0, time.UTC), *msg.Timestamp)
+			require.NoError(t, results[1].Error)
+			require.Equal(t, "Second", *results[1].Message.(*rfc3164.SyslogMessage).Message)
+		})
+	}
+}
+
+func TestParseRFC3164Stream_LongMessage(t *testing.T) {
+	results := make([]*syslog.Result, 0)
+	cb := func(res *syslog.Result) {
+		results = append(results, res)
+	}
+
+	err := syslogparser.ParseRFC3164Stream(strings.NewReader("41 <13>Jul  1 10:00:01 host app[123]: Second40 <13>Jul  1 10:00:00 host app[123]: First"), cb, 40)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(results))
+	require.EqualError(t, results[0].Error, "message too long to parse. was size 41, max length 40")
+	require.NoError(t, results[1].Error)
+	require.Equal(t, "First", *results[1].Message.(*rfc3164.SyslogMessage).Message)
+}
+
+func TestParseRFC3164Stream_InvalidStream(t *testing.T) {
+	err := syslogparser.ParseRFC3164Stream(strings.NewReader("invalid"), func(res *syslog.Result) {}, defaultMaxMessageLength)
+	require.EqualError(t, err, "invalid or unsupported framing. first byte: 'i'")
+}
@@ -1,9 +1,14 @@
 package syslog
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"strings"
 	"sync"
@@ -13,8 +18,10 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
 	"github.com/influxdata/go-syslog/v3/rfc5424"
 	"github.com/mwitkow/go-conntrack"
+	promconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/relabel"
@@ -32,6 +39,17 @@ var (
 	defaultMaxMessageLength = 8192
 )
 
+const (
+	protocolTCP = "tcp"
+	protocolUDP = "udp"
+
+	formatRFC5424 = "rfc5424"
+	formatRFC3164 = "rfc3164"
+
+	// maxDatagramSize is the maximum size of an UDP datagram.
+	maxDatagramSize = 65536
+)
+
 // SyslogTarget listens to syslog messages.
 // nolint:golint
 type SyslogTarget struct {
@@ -42,8 +60,12 @@ type SyslogTarget struct {
 	relabelConfig []*relabel.Config
 
 	listener net.Listener
+	udpConn  net.PacketConn
 	messages chan message
 
+	// rfc3164Location is the time zone of the rfc3164 timestamps.
+	rfc3164Location *time.Location
+
 	ctx             context.Context
 	ctxCancel       context.CancelFunc
 	openConnections *sync.WaitGroup
@@ -63,6 +85,13 @@ func NewSyslogTarget(
 	relabel []*relabel.Config,
 	config *scrapeconfig.SyslogTargetConfig,
 ) (*SyslogTarget, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(config.RFC3164Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rfc3164_timezone: %w", err)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -73,6 +102,8 @@ func NewSyslogTarget(
 		config:        config,
 		relabelConfig: relabel,
 
+		rfc3164Location: location,
+
 		ctx:             ctx,
 		ctxCancel:       cancel,
 		openConnections: new(sync.WaitGroup),
@@ -81,18 +112,52 @@ func NewSyslogTarget(
 	t.messages = make(chan message)
 	go t.messageSender(handler.Chan())
 
-	err := t.run()
+	err = t.run()
 	return t, err
 }
 
+func validateConfig(config *scrapeconfig.SyslogTargetConfig) error {
+	if config.ListenProtocol == "" {
+		config.ListenProtocol = protocolTCP
+	}
+	if config.ListenProtocol != protocolTCP && config.ListenProtocol != protocolUDP {
+		return fmt.Errorf("unsupported syslog listen_protocol %q, must be %s or %s", config.ListenProtocol, protocolTCP, protocolUDP)
+	}
+	if config.SyslogFormat == "" {
+		config.SyslogFormat = formatRFC5424
+	}
+	if config.SyslogFormat != formatRFC5424 && config.SyslogFormat != formatRFC3164 {
+		return fmt.Errorf("unsupported syslog_format %q, must be %s or %s", config.SyslogFormat, formatRFC5424, formatRFC3164)
+	}
+	if config.ListenProtocol == protocolUDP && tlsEnabled(config.TLSConfig) {
+		return errors.New("tls_config is only supported with the tcp listen_protocol")
+	}
+	if config.RFC3164Timezone == "" {
+		config.RFC3164Timezone = "Local"
+	}
+	return nil
+}
+
 func (t *SyslogTarget) run() error {
-	l, err := net.Listen("tcp", t.config.ListenAddress)
-	l = conntrack.NewListener(l, conntrack.TrackWithName("syslog_target/"+t.config.ListenAddress))
+	if t.config.ListenProtocol == protocolUDP {
+		return t.runUDP()
+	}
+
+	l, err := net.Listen(protocolTCP, t.config.ListenAddress)
 	if err != nil {
 		return fmt.Errorf("error setting up syslog target %w", err)
 	}
+	l = conntrack.NewListener(l, conntrack.TrackWithName("syslog_target/"+t.config.ListenAddress))
+	if tlsEnabled(t.config.TLSConfig) {
+		tlsConfig, err := newTLSConfig(t.config.TLSConfig)
+		if err != nil {
+			_ = l.Close()
+			return fmt.Errorf("error setting up syslog target TLS %w", err)
+		}
+		l = tls.NewListener(l, tlsConfig)
+	}
 	t.listener = l
-	level.Info(t.logger).Log("msg", "syslog listening on address", "address", t.ListenAddress().String())
+	level.Info(t.logger).Log("msg", "syslog listening on address", "address", t.ListenAddress().String(), "protocol", protocolTCP, "tls", tlsEnabled(t.config.TLSConfig))
 
 	t.openConnections.Add(1)
 	go t.acceptConnections()
@@ -100,6 +165,83 @@ func (t *SyslogTarget) run() error {
 	return nil
 }
 
+func tlsEnabled(cfg promconfig.TLSConfig) bool {
+	return cfg.CertFile != "" || cfg.KeyFile != ""
+}
+
+// newTLSConfig creates the TLS config of the listener, clients must present a certificate
+// signed by the CA when a CA file is set.
+func newTLSConfig(cfg promconfig.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("both cert_file and key_file must be set")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("unable to parse client CA certificate")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+func (t *SyslogTarget) runUDP() error {
+	conn, err := net.ListenPacket(protocolUDP, t.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("error setting up syslog target %w", err)
+	}
+	t.udpConn = conn
+	level.Info(t.logger).Log("msg", "syslog listening on address", "address", t.ListenAddress().String(), "protocol", protocolUDP)
+
+	t.openConnections.Add(1)
+	go t.readDatagrams()
+
+	return nil
+}
+
+// readDatagrams reads the UDP datagrams, each of them holds a single message as per RFC 5426.
+func (t *SyslogTarget) readDatagrams() {
+	defer t.openConnections.Done()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := t.udpConn.ReadFrom(buf)
+		if err != nil {
+			if t.ctx.Err() != nil {
+				level.Info(t.logger).Log("msg", "syslog server shutting down")
+				return
+			}
+			level.Warn(t.logger).Log("msg", "failed to read syslog datagram", "err", err)
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+
+		// The host name of the sender is not looked up for each datagram.
+		connLabels := t.addressLabels(addr, false)
+		msg, err := t.parseDatagram(bytes.TrimRight(buf[:n], "\r\n"))
+		if err != nil {
+			t.handleMessageError(err)
+			continue
+		}
+		t.handleMessage(connLabels, msg)
+	}
+}
+
 func (t *SyslogTarget) acceptConnections() {
 	defer t.openConnections.Done()
 
@@ -147,21 +289,53 @@ func (t *SyslogTarget) handleConnection(cn net.Conn) {
 		_ = c.Close()
 	}()
 
-	connLabels := t.connectionLabels(c)
+	connLabels := t.addressLabels(c.RemoteAddr(), true)
 
-	err := syslogparser.ParseStream(c, func(msg *syslog.Result) {
+	err := t.parseStream(c, func(msg *syslog.Result) {
 		if err := msg.Error; err != nil {
 			t.handleMessageError(err)
 			return
 		}
 		t.handleMessage(connLabels.Copy(), msg.Message)
-	}, t.maxMessageLength())
+	})
 
 	if err != nil {
 		level.Warn(t.logger).Log("msg", "error initializing syslog stream", "err", err)
 	}
 }
 
+// parseStream parses the messages of a stream in the configured syslog format.
+func (t *SyslogTarget) parseStream(r io.Reader, callback func(res *syslog.Result)) error {
+	if t.config.SyslogFormat == formatRFC3164 {
+		return syslogparser.ParseRFC3164Stream(r, callback, t.maxMessageLength(),
+			rfc3164.WithLocaleTimezone(t.rfc3164Location),
+			rfc3164.WithRFC3339(),
+			rfc3164.WithBestEffort(),
+		)
+	}
+	return syslogparser.ParseStream(r, callback, t.maxMessageLength())
+}
+
+// parseDatagram parses a single message without framing.
+func (t *SyslogTarget) parseDatagram(b []byte) (syslog.Message, error) {
+	if len(b) > t.maxMessageLength() {
+		return nil, fmt.Errorf("message too long to parse. was size %d, max length %d", len(b), t.maxMessageLength())
+	}
+	if t.config.SyslogFormat == formatRFC3164 {
+		msg, err := rfc3164.NewParser(
+			rfc3164.WithLocaleTimezone(t.rfc3164Location),
+			rfc3164.WithRFC3339(),
+			rfc3164.WithBestEffort(),
+		).Parse(b)
+		if msg != nil {
+			// In best effort mode, the partially parsed messages are kept.
+			return msg, nil
+		}
+		return nil, err
+	}
+	return rfc5424.NewParser().Parse(b)
+}
+
 func (t *SyslogTarget) handleMessageError(err error) {
 	var ne net.Error
 	if errors.As(err, &ne) && ne.Timeout() {
@@ -173,35 +347,47 @@ func (t *SyslogTarget) handleMessageError(err error) {
 }
 
 func (t *SyslogTarget) handleMessage(connLabels labels.Labels, msg syslog.Message) {
-	rfc5424Msg := msg.(*rfc5424.SyslogMessage)
+	// The fields common to all formats are in syslog.Base, so that they have the same labels.
+	var (
+		base           *syslog.Base
+		structuredData *map[string]map[string]string
+	)
+	switch m := msg.(type) {
+	case *rfc5424.SyslogMessage:
+		base, structuredData = &m.Base, m.StructuredData
+	case *rfc3164.SyslogMessage:
+		base = &m.Base
+	default:
+		return
+	}
 
-	if rfc5424Msg.Message == nil {
+	if base.Message == nil {
 		t.metrics.syslogEmptyMessages.Inc()
 		return
 	}
 
 	lb := labels.NewBuilder(connLabels)
-	if v := rfc5424Msg.SeverityLevel(); v != nil {
+	if v := base.SeverityLevel(); v != nil {
 		lb.Set("__syslog_message_severity", *v)
 	}
-	if v := rfc5424Msg.FacilityLevel(); v != nil {
+	if v := base.FacilityLevel(); v != nil {
 		lb.Set("__syslog_message_facility", *v)
 	}
-	if v := rfc5424Msg.Hostname; v != nil {
+	if v := base.Hostname; v != nil {
 		lb.Set("__syslog_message_hostname", *v)
 	}
-	if v := rfc5424Msg.Appname; v != nil {
+	if v := base.Appname; v != nil {
 		lb.Set("__syslog_message_app_name", *v)
 	}
-	if v := rfc5424Msg.ProcID; v != nil {
+	if v := base.ProcID; v != nil {
 		lb.Set("__syslog_message_proc_id", *v)
 	}
-	if v := rfc5424Msg.MsgID; v != nil {
+	if v := base.MsgID; v != nil {
 		lb.Set("__syslog_message_msg_id", *v)
 	}
 
-	if t.config.LabelStructuredData && rfc5424Msg.StructuredData != nil {
-		for id, params := range *rfc5424Msg.StructuredData {
+	if t.config.LabelStructuredData && structuredData != nil {
+		for id, params := range *structuredData {
 			id = strings.Replace(id, "@", "_", -1)
 			for name, value := range params {
 				key := "__syslog_message_sd_" + id + "_" + name
@@ -221,12 +407,32 @@ func (t *SyslogTarget) handleMessage(connLabels labels.Labels, msg syslog.Messag
 	}
 
 	var timestamp time.Time
-	if t.config.UseIncomingTimestamp && rfc5424Msg.Timestamp != nil {
-		timestamp = *rfc5424Msg.Timestamp
+	if t.config.UseIncomingTimestamp && base.Timestamp != nil {
+		timestamp = *base.Timestamp
+		if t.config.SyslogFormat == formatRFC3164 {
+			timestamp = t.rfc3164Timestamp(timestamp, time.Now())
+		}
 	} else {
 		timestamp = time.Now()
 	}
-	t.messages <- message{filtered, *rfc5424Msg.Message, timestamp}
+	t.messages <- message{filtered, *base.Message, timestamp}
+}
+
+// rfc3164Timestamp sets the year of a rfc3164 timestamp, which is parsed in the year 0.
+func (t *SyslogTarget) rfc3164Timestamp(ts time.Time, now time.Time) time.Time {
+	// RFC3339 timestamps are also accepted and have a year.
+	if ts.Year() != 0 {
+		return ts
+	}
+	if t.config.RFC3164Year != 0 {
+		return ts.AddDate(t.config.RFC3164Year, 0, 0)
+	}
+	res := ts.AddDate(now.In(ts.Location()).Year(), 0, 0)
+	// Messages sent at the end of a year can be received at the beginning of the next one.
+	if res.After(now.Add(24 * time.Hour)) {
+		res = res.AddDate(-1, 0, 0)
+	}
+	return res
 }
 
 func (t *SyslogTarget) messageSender(entries chan<- api.Entry) {
@@ -242,23 +448,29 @@ func (t *SyslogTarget) messageSender(entries chan<- api.Entry) {
 	}
 }
 
-func (t *SyslogTarget) connectionLabels(c net.Conn) labels.Labels {
+// addressLabels returns the labels of the remote address of a connection, the host name is
+// looked up only if lookupHostname is true.
+func (t *SyslogTarget) addressLabels(remoteAddr net.Addr, lookupHostname bool) labels.Labels {
 	lb := labels.NewBuilder(nil)
 	for k, v := range t.config.Labels {
 		lb.Set(string(k), string(v))
 	}
 
-	ip := ipFromConn(c).String()
+	ip := ipFromAddr(remoteAddr).String()
 	lb.Set("__syslog_connection_ip_address", ip)
-	lb.Set("__syslog_connection_hostname", lookupAddr(ip))
+	if lookupHostname {
+		lb.Set("__syslog_connection_hostname", lookupAddr(ip))
+	}
 
 	return lb.Labels()
 }
 
-func ipFromConn(c net.Conn) net.IP {
-	switch addr := c.RemoteAddr().(type) {
+func ipFromAddr(remoteAddr net.Addr) net.IP {
+	switch addr := remoteAddr.(type) {
 	case *net.TCPAddr:
 		return addr.IP
+	case *net.UDPAddr:
+		return addr.IP
 	}
 
 	return nil
@@ -299,7 +511,12 @@ func (t *SyslogTarget) Details() interface{} {
 // Stop shuts down the SyslogTarget.
 func (t *SyslogTarget) Stop() error {
 	t.ctxCancel()
-	err := t.listener.Close()
+	var err error
+	if t.udpConn != nil {
+		err = t.udpConn.Close()
+	} else {
+		err = t.listener.Close()
+	}
 	t.openConnections.Wait()
 	close(t.messages)
 	t.handler.Stop()
@@ -308,6 +525,9 @@ func (t *SyslogTarget) Stop() error {
 
 // ListenAddress returns the address SyslogTarget is listening on.
 func (t *SyslogTarget) ListenAddress() net.Addr {
+	if t.udpConn != nil {
+		return t.udpConn.LocalAddr()
+	}
 	return t.listener.Addr()
 }
 
@@ -1,15 +1,25 @@
 package syslog
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/big"
 	"net"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 	"unicode/utf8"
 
 	"github.com/go-kit/kit/log"
+	promconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/stretchr/testify/require"
@@ -225,3 +235,236 @@ func TestSyslogTarget_IdleTimeout(t *testing.T) {
 	_, err = c.Read(buf)
 	require.EqualError(t, err, "EOF")
 }
+
+func TestSyslogTarget_UDP(t *testing.T) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+	client := fake.New(func() {})
+	metrics := NewMetrics(nil)
+
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+		ListenAddress:  "127.0.0.1:0",
+		ListenProtocol: "udp",
+		Labels: model.LabelSet{
+			"test": "syslog_target",
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tgt.Stop())
+	}()
+
+	c, err := net.Dial("udp", tgt.ListenAddress().String())
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, datagram := range []string{
+		`<165>1 2018-10-11T22:14:15.003Z host5 e - id1 - An application event log entry...`,
+		"<165>1 2018-10-11T22:14:15.005Z host5 e - id2 - An application event log entry...\n",
+	} {
+		_, err = fmt.Fprint(c, datagram)
+		require.NoError(t, err)
+	}
+
+	require.Eventuallyf(t, func() bool {
+		return len(client.Received()) == 2
+	}, time.Second, time.Millisecond, "Expected to receive 2 messages, got %d.", len(client.Received()))
+
+	require.Equal(t, model.LabelSet{
+		"test": "syslog_target",
+
+		"severity": "notice",
+		"facility": "local4",
+		"hostname": "host5",
+		"app_name": "e",
+		"msg_id":   "id1",
+	}, client.Received()[0].Labels)
+	require.Equal(t, "An application event log entry...", client.Received()[0].Line)
+}
+
+func TestSyslogTarget_RFC3164(t *testing.T) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+	client := fake.New(func() {})
+	metrics := NewMetrics(nil)
+
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+		ListenAddress:        "127.0.0.1:0",
+		SyslogFormat:         "rfc3164",
+		RFC3164Year:          2020,
+		RFC3164Timezone:      "Europe/Paris",
+		UseIncomingTimestamp: true,
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tgt.Stop())
+	}()
+
+	c, err := net.Dial("tcp", tgt.ListenAddress().String())
+	require.NoError(t, err)
+
+	err = writeMessagesToStream(c, []string{
+		`<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8`,
+		`<13>2021-07-01T10:00:00Z router01 sshd[1234]: Accepted publickey for admin`,
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	require.Eventuallyf(t, func() bool {
+		return len(client.Received()) == 2
+	}, time.Second, time.Millisecond, "Expected to receive 2 messages, got %d.", len(client.Received()))
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	received := client.Received()
+	require.Equal(t, model.LabelSet{
+		"severity": "critical",
+		"facility": "auth",
+		"hostname": "mymachine",
+		"app_name": "su",
+	}, received[0].Labels)
+	require.Equal(t, "'su root' failed for lonvick on /dev/pts/8", received[0].Line)
+	require.True(t, time.Date(2020, 10, 11, 22, 14, 15, 0, paris).Equal(received[0].Timestamp), received[0].Timestamp)
+
+	require.Equal(t, model.LabelSet{
+		"severity": "notice",
+		"facility": "user",
+		"hostname": "router01",
+		"app_name": "sshd",
+		"proc_id":  "1234",
+	}, received[1].Labels)
+	require.True(t, time.Date(2021, 7, 1, 10, 0, 0, 0, time.UTC).Equal(received[1].Timestamp), received[1].Timestamp)
+}
+
+func TestSyslogTarget_RFC3164Timestamp(t *testing.T) {
+	tgt := &SyslogTarget{config: &scrapeconfig.SyslogTargetConfig{}}
+	now := time.Date(2021, 1, 1, 0, 5, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		ts       time.Time
+		year     int
+		expected time.Time
+	}{
+		{"current year", time.Date(0, 1, 1, 0, 4, 0, 0, time.UTC), 0, time.Date(2021, 1, 1, 0, 4, 0, 0, time.UTC)},
+		{"previous year", time.Date(0, 12, 31, 23, 59, 0, 0, time.UTC), 0, time.Date(2020, 12, 31, 23, 59, 0, 0, time.UTC)},
+		{"configured year", time.Date(0, 12, 31, 23, 59, 0, 0, time.UTC), 2019, time.Date(2019, 12, 31, 23, 59, 0, 0, time.UTC)},
+		{"with year", time.Date(2018, 12, 31, 23, 59, 0, 0, time.UTC), 0, time.Date(2018, 12, 31, 23, 59, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tgt.config.RFC3164Year = tc.year
+			require.Equal(t, tc.expected, tgt.rfc3164Timestamp(tc.ts, now))
+		})
+	}
+}
+
+func TestSyslogTarget_InvalidConfig(t *testing.T) {
+	for _, tc := range []struct {
+		config  scrapeconfig.SyslogTargetConfig
+		wantErr string
+	}{
+		{scrapeconfig.SyslogTargetConfig{ListenProtocol: "http"}, `unsupported syslog listen_protocol "http", must be tcp or udp`},
+		{scrapeconfig.SyslogTargetConfig{SyslogFormat: "rfc1234"}, `unsupported syslog_format "rfc1234", must be rfc5424 or rfc3164`},
+		{scrapeconfig.SyslogTargetConfig{ListenProtocol: "udp", TLSConfig: promconfig.TLSConfig{CertFile: "cert.pem"}}, "tls_config is only supported with the tcp listen_protocol"},
+		{scrapeconfig.SyslogTargetConfig{RFC3164Timezone: "Nowhere/Somewhere"}, "invalid rfc3164_timezone: unknown time zone Nowhere/Somewhere"},
+	} {
+		t.Run(tc.wantErr, func(t *testing.T) {
+			_, err := NewSyslogTarget(NewMetrics(nil), log.NewNopLogger(), fake.New(func() {}), nil, &tc.config)
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSyslogTarget_TLSClientAuth(t *testing.T) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+	client := fake.New(func() {})
+	metrics := NewMetrics(nil)
+
+	dir, err := ioutil.TempDir("", "syslog-tls")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	ca, caKey := newCertificate(t, nil, nil, "ca")
+	serverCert, serverKey := newCertificate(t, ca, caKey, "127.0.0.1")
+	clientCert, clientKey := newCertificate(t, ca, caKey, "client")
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.Raw)
+	writePEM(t, filepath.Join(dir, "server.pem"), "CERTIFICATE", serverCert.Raw)
+	writePEM(t, filepath.Join(dir, "server.key"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(serverKey))
+
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+		ListenAddress: "127.0.0.1:0",
+		TLSConfig: promconfig.TLSConfig{
+			CAFile:   filepath.Join(dir, "ca.pem"),
+			CertFile: filepath.Join(dir, "server.pem"),
+			KeyFile:  filepath.Join(dir, "server.key"),
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tgt.Stop())
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	// Clients without a certificate are rejected.
+	c, err := tls.Dial("tcp", tgt.ListenAddress().String(), &tls.Config{RootCAs: roots})
+	if err == nil {
+		_ = writeMessagesToStream(c, []string{`<165>1 - host5 e - id1 - rejected`}, false)
+		_ = c.SetDeadline(time.Now().Add(time.Second))
+		_, err = c.Read(make([]byte, 1))
+		_ = c.Close()
+	}
+	require.Error(t, err)
+
+	c, err = tls.Dial("tcp", tgt.ListenAddress().String(), &tls.Config{
+		RootCAs: roots,
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{clientCert.Raw},
+			PrivateKey:  clientKey,
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, writeMessagesToStream(c, []string{`<165>1 - host5 e - id1 - accepted`}, false))
+	require.NoError(t, c.Close())
+
+	require.Eventuallyf(t, func() bool {
+		return len(client.Received()) == 1
+	}, time.Second, time.Millisecond, "Expected to receive 1 message, got %d.", len(client.Received()))
+	require.Equal(t, "accepted", client.Received()[0].Line)
+}
+
+// newCertificate creates a certificate signed by parent, or a self-signed CA if parent is nil.
+func newCertificate(t *testing.T, parent *x509.Certificate, parentKey *rsa.PrivateKey, name string) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writePEM(t *testing.T, path, typ string, b []byte) {
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600))
+}
@@ -29,6 +29,18 @@ const (
 
 	// WindowsTargetType is a Windows event target
 	WindowsTargetType = TargetType("WindowsEvent")
+
+	// DockerTargetType is a Docker target
+	DockerTargetType = TargetType("Docker")
+
+	// HerokuDrainTargetType is a Heroku drain target
+	HerokuDrainTargetType = TargetType("HerokuDrain")
+
+	// GelfTargetType is a GELF target
+	GelfTargetType = TargetType("Gelf")
+
+	// SplunkHECTargetType is a Splunk HTTP Event Collector target
+	SplunkHECTargetType = TargetType("SplunkHEC")
 )
 
 // Target is a promtail scrape target
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// chunkReport holds everything printed about a chunk, it is also the `-json` output.
+type chunkReport struct {
+	Source      string        `json:"source"`
+	FileSize    int           `json:"fileSize"`
+	UserID      string        `json:"userID"`
+	Fingerprint string        `json:"fingerprint"`
+	From        time.Time     `json:"from"`
+	Through     time.Time     `json:"through"`
+	Labels      labels.Labels `json:"labels"`
+	// DecodeError is set when the chunk could not be fully decoded by Loki,
+	// the blocks are still inspected when possible.
+	DecodeError string `json:"decodeError,omitempty"`
+
+	Format         int           `json:"format"`
+	Encoding       string        `json:"encoding"`
+	MetaChecksum   string        `json:"metaChecksum"`
+	MetaChecksumOK bool          `json:"metaChecksumOK"`
+	Blocks         []blockReport `json:"blocks"`
+
+	CompressedSize   int     `json:"compressedSize"`
+	UncompressedSize int     `json:"uncompressedSize"`
+	Ratio            float64 `json:"ratio"`
+}
+
+type blockReport struct {
+	Index            int       `json:"index"`
+	Offset           int       `json:"offset"`
+	Entries          int       `json:"entries"`
+	MinTime          time.Time `json:"minTime"`
+	MaxTime          time.Time `json:"maxTime"`
+	CompressedSize   int       `json:"compressedSize"`
+	UncompressedSize int       `json:"uncompressedSize"`
+	Ratio            float64   `json:"ratio"`
+	Checksum         string    `json:"checksum"`
+	ChecksumOK       bool      `json:"checksumOK"`
+	// DecompressError is set when the block data could not be decompressed.
+	DecompressError string `json:"decompressError,omitempty"`
+
+	Lines []lineReport `json:"lines,omitempty"`
+
+	compressed   []byte
+	decompressed []byte
+}
+
+type lineReport struct {
+	Timestamp time.Time `json:"ts"`
+	Line      string    `json:"line"`
+}
+
+// inspectChunk decodes a chunk as stored in an object store. When key is not nil, the chunk
+// checksum and metadata are verified against the chunk reference parsed from the chunk ID.
+func inspectChunk(source string, buf []byte, key *chunk.Chunk, withLines bool) (*chunkReport, error) {
+	data, err := chunkData(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	c := chunk.Chunk{}
+	if key != nil {
+		c = *key
+	}
+	report := &chunkReport{
+		Source:   source,
+		FileSize: len(buf),
+	}
+	if err := c.Decode(chunk.NewDecodeContext(), buf); err != nil {
+		report.DecodeError = err.Error()
+	}
+	report.UserID = c.UserID
+	report.Fingerprint = c.Fingerprint.String()
+	report.From = c.From.Time().In(timezone)
+	report.Through = c.Through.Time().In(timezone)
+	report.Labels = c.Metric
+
+	info, err := chunkenc.Inspect(data)
+	if err != nil {
+		return nil, err
+	}
+	report.Format = int(info.Format)
+	report.Encoding = info.Encoding.String()
+	report.MetaChecksum = fmt.Sprintf("%08x", info.MetaChecksum)
+	report.MetaChecksumOK = info.ChecksumOK()
+
+	// Lines are read through Loki's own chunk decoding, which skips blocks with invalid checksums.
+	var lokiBlocks map[int]chunkenc.Block
+	if withLines && report.DecodeError == "" {
+		lokiChunk := c.Data.(*chunkenc.Facade).LokiChunk()
+		lokiBlocks = map[int]chunkenc.Block{}
+		for _, b := range lokiChunk.Blocks(time.Unix(0, 0), time.Unix(0, math.MaxInt64)) {
+			lokiBlocks[b.Offset()] = b
+		}
+	}
+
+	for i, b := range info.Blocks {
+		br := blockReport{
+			Index:            i,
+			Offset:           b.Offset,
+			Entries:          b.Entries,
+			MinTime:          time.Unix(0, b.MinTime).In(timezone),
+			MaxTime:          time.Unix(0, b.MaxTime).In(timezone),
+			CompressedSize:   len(b.Data),
+			UncompressedSize: b.UncompressedSize,
+			Checksum:         fmt.Sprintf("%08x", b.StoredChecksum),
+			ChecksumOK:       b.ChecksumOK(),
+			compressed:       b.Data,
+		}
+		if !br.ChecksumOK {
+			br.Checksum = fmt.Sprintf("%08x (computed: %08x)", b.StoredChecksum, b.ComputedChecksum)
+		}
+		br.decompressed, err = info.Decompress(i)
+		if err != nil {
+			br.DecompressError = err.Error()
+		} else {
+			// Older chunk formats don't store the uncompressed size.
+			br.UncompressedSize = len(br.decompressed)
+		}
+		br.Ratio = ratio(br.UncompressedSize, br.CompressedSize)
+
+		if lb, ok := lokiBlocks[b.Offset]; ok {
+			br.Lines, err = readLines(lb.Iterator(context.Background(), log.NewNoopPipeline().ForStream(c.Metric)))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		report.CompressedSize += br.CompressedSize
+		report.UncompressedSize += br.UncompressedSize
+		report.Blocks = append(report.Blocks, br)
+	}
+	report.Ratio = ratio(report.UncompressedSize, report.FileSize)
+	return report, nil
+}
+
+// headReport holds everything printed about a head block.
+type headReport struct {
+	Source           string       `json:"source"`
+	Format           string       `json:"format"`
+	Entries          int          `json:"entries"`
+	MinTime          time.Time    `json:"minTime"`
+	MaxTime          time.Time    `json:"maxTime"`
+	UncompressedSize int          `json:"uncompressedSize"`
+	Lines            []lineReport `json:"lines,omitempty"`
+}
+
+// inspectHeadBlock decodes a head block as serialised in ingester WAL checkpoints.
+// Both ordered and unordered head blocks are supported, the format is kept as is.
+func inspectHeadBlock(source string, buf []byte, withLines bool) (*headReport, error) {
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("empty head block")
+	}
+	hb, err := chunkenc.HeadFromCheckpoint(buf, chunkenc.HeadBlockFmt(buf[0]))
+	if err != nil {
+		return nil, err
+	}
+	mint, maxt := hb.Bounds()
+	report := &headReport{
+		Source:           source,
+		Format:           hb.Format().String(),
+		Entries:          hb.Entries(),
+		MinTime:          time.Unix(0, mint).In(timezone),
+		MaxTime:          time.Unix(0, maxt).In(timezone),
+		UncompressedSize: hb.UncompressedSize(),
+	}
+	if withLines {
+		report.Lines, err = readLines(hb.Iterator(context.Background(), logproto.FORWARD, mint, maxt+1, log.NewNoopPipeline().ForStream(nil)))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// chunkData returns the Loki chunk data following the chunk header.
+// Older versions of Cortex didn't include the initial length word in the metadata length.
+func chunkData(buf []byte) ([]byte, error) {
+	if len(buf) < 4 {
+		return nil, fmt.Errorf("chunk too short")
+	}
+	metadataLen := int(binary.BigEndian.Uint32(buf))
+	for _, offset := range []int{metadataLen, metadataLen + 4} {
+		if offset+4 > len(buf) {
+			continue
+		}
+		dataLen := int(binary.BigEndian.Uint32(buf[offset:]))
+		if offset+4+dataLen == len(buf) {
+			return buf[offset+4:], nil
+		}
+	}
+	return nil, chunk.ErrDataLength
+}
+
+func readLines(it iter.EntryIterator) ([]lineReport, error) {
+	defer it.Close()
+	var lines []lineReport
+	for it.Next() {
+		e := it.Entry()
+		lines = append(lines, lineReport{Timestamp: e.Timestamp.In(timezone), Line: e.Line})
+	}
+	return lines, it.Error()
+}
+
+func ratio(uncompressed, compressed int) float64 {
+	if compressed == 0 {
+		return 0
+	}
+	return float64(uncompressed) / float64(compressed)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/util"
+)
+
+func newTestChunk(t *testing.T, enc chunkenc.Encoding, headFmt chunkenc.HeadBlockFmt, entries int) chunk.Chunk {
+	t.Helper()
+	mc := chunkenc.NewMemChunk(enc, headFmt, 1024, 0)
+	for i := 0; i < entries; i++ {
+		require.NoError(t, mc.Append(&logproto.Entry{
+			Timestamp: time.Unix(1000+int64(i), 0),
+			Line:      fmt.Sprintf("line %d with some padding to fill blocks", i),
+		}))
+	}
+	require.NoError(t, mc.Close())
+	metric := labels.Labels{{Name: "__name__", Value: "logs"}, {Name: "app", Value: "foo"}}
+	from, through := util.RoundToMilliseconds(mc.Bounds())
+	c := chunk.NewChunk("fake", model.Fingerprint(metric.Hash()), metric, chunkenc.NewFacade(mc, 1024, 0), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func Test_inspectChunk(t *testing.T) {
+	for _, enc := range []chunkenc.Encoding{chunkenc.EncGZIP, chunkenc.EncSnappy, chunkenc.EncZstd} {
+		t.Run(enc.String(), func(t *testing.T) {
+			c := newTestChunk(t, enc, chunkenc.OrderedHeadBlockFmt, 100)
+			buf, err := c.Encoded()
+			require.NoError(t, err)
+
+			report, err := inspectChunk("test", buf, &c, true)
+			require.NoError(t, err)
+			require.Empty(t, report.DecodeError)
+			require.Equal(t, "fake", report.UserID)
+			require.Equal(t, `{__name__="logs", app="foo"}`, report.Labels.String())
+			require.Equal(t, enc.String(), report.Encoding)
+			require.True(t, report.MetaChecksumOK)
+			require.True(t, len(report.Blocks) > 1)
+
+			var lines int
+			for _, b := range report.Blocks {
+				require.True(t, b.ChecksumOK)
+				require.Empty(t, b.DecompressError)
+				require.Equal(t, b.Entries, len(b.Lines))
+				require.Greater(t, b.Ratio, 0.0)
+				lines += len(b.Lines)
+			}
+			require.Equal(t, 100, lines)
+
+			// Corrupting a block is reported and the other blocks are still readable.
+			buf[len(buf)-len(report.Blocks[0].compressed)-100] ^= 0xff
+			corrupted, err := inspectChunk("test", buf, nil, true)
+			require.NoError(t, err)
+			var bad int
+			for _, b := range corrupted.Blocks {
+				if !b.ChecksumOK {
+					bad++
+					require.Empty(t, b.Lines)
+				}
+			}
+			require.Equal(t, 1, bad)
+		})
+	}
+}
+
+func Test_inspectHeadBlock(t *testing.T) {
+	for _, f := range chunkenc.HeadBlockFmts {
+		t.Run(f.String(), func(t *testing.T) {
+			hb := f.NewBlock()
+			require.NoError(t, hb.Append(time.Unix(2, 0).UnixNano(), "b"))
+			if f == chunkenc.UnorderedHeadBlockFmt {
+				require.NoError(t, hb.Append(time.Unix(1, 0).UnixNano(), "a"))
+			}
+			b, err := hb.CheckpointBytes(nil)
+			require.NoError(t, err)
+
+			report, err := inspectHeadBlock("test", b, true)
+			require.NoError(t, err)
+			require.Equal(t, f.String(), report.Format)
+			require.Equal(t, hb.Entries(), report.Entries)
+			require.Len(t, report.Lines, hb.Entries())
+			require.Equal(t, "b", report.Lines[len(report.Lines)-1].Line)
+		})
+	}
+}
+
+func Test_chunkData(t *testing.T) {
+	_, err := chunkData([]byte{0, 0})
+	require.Error(t, err)
+	_, err = chunkData([]byte{0, 0, 0, 4, 0, 0, 0, 10})
+	require.Equal(t, chunk.ErrDataLength, err)
+	data, err := chunkData([]byte{0, 0, 0, 4, 0, 0, 0, 1, 42})
+	require.NoError(t, err)
+	require.Equal(t, []byte{42}, data)
+}
@@ -1,10 +0,0 @@
-module github.com/grafana/loki/cmd/chunks-inspect
-
-go 1.15
-
-require (
-	github.com/frankban/quicktest v1.7.2 // indirect
-	github.com/golang/snappy v0.0.1
-	github.com/klauspost/compress v1.11.7
-	github.com/pierrec/lz4 v2.3.0+incompatible
-)
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +11,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
 )
 
 const format = "2006-01-02 15:04:05.000000 MST"
@@ -19,106 +23,174 @@ func main() {
 	blocks := flag.Bool("b", false, "print block details")
 	lines := flag.Bool("l", false, "print log lines")
 	storeBlocks := flag.Bool("s", false, "store blocks, using input filename, and appending block index to it")
+	jsonOutput := flag.Bool("json", false, "print one JSON document per chunk instead of text, block details are always included")
+	head := flag.Bool("head", false, "arguments are head blocks as serialised in ingester WAL checkpoints instead of chunks")
+	configFile := flag.String("config.file", "", "Loki config file, when set arguments are chunk IDs read from the configured filesystem object store")
+	userID := flag.String("user", "fake", "tenant of legacy chunk IDs which don't include it, only used with -config.file")
 	flag.Parse()
 
-	for _, f := range flag.Args() {
-		printFile(f, *blocks, *lines, *storeBlocks)
+	var store *objectStore
+	if *configFile != "" {
+		var err error
+		store, err = newObjectStore(*configFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
 	}
+
+	p := printer{
+		blockDetails: *blocks,
+		printLines:   *lines,
+		storeBlocks:  *storeBlocks,
+		json:         json.NewEncoder(os.Stdout),
+		jsonOutput:   *jsonOutput,
+	}
+
+	for _, arg := range flag.Args() {
+		var (
+			buf []byte
+			key *chunk.Chunk
+			err error
+		)
+		if store != nil {
+			key, buf, err = store.get(context.Background(), *userID, arg)
+		} else {
+			buf, err = ioutil.ReadFile(arg)
+		}
+		if err != nil {
+			log.Printf("%s: %v", arg, err)
+			continue
+		}
+
+		if *head {
+			err = p.printHeadBlock(arg, buf)
+		} else {
+			err = p.printChunk(arg, buf, key)
+		}
+		if err != nil {
+			log.Printf("%s: %v", arg, err)
+		}
+	}
+}
+
+type printer struct {
+	blockDetails bool
+	printLines   bool
+	storeBlocks  bool
+	json         *json.Encoder
+	jsonOutput   bool
 }
 
-func printFile(filename string, blockDetails, printLines, storeBlocks bool) {
-	f, err := os.Open(filename)
+func (p printer) printChunk(source string, buf []byte, key *chunk.Chunk) error {
+	report, err := inspectChunk(source, buf, key, p.printLines)
 	if err != nil {
-		log.Printf("%s: %v", filename, err)
-		return
+		return err
 	}
-	defer f.Close()
 
-	si, err := f.Stat()
-	if err != nil {
-		log.Println("failed to stat file", err)
-		return
+	if p.storeBlocks {
+		for _, b := range report.Blocks {
+			writeBlockToFile(b.compressed, b.Index, fmt.Sprintf("%s.block.%d", source, b.Index))
+			if b.decompressed != nil {
+				writeBlockToFile(b.decompressed, b.Index, fmt.Sprintf("%s.original.%d", source, b.Index))
+			}
+		}
 	}
 
-	h, err := DecodeHeader(f)
-	if err != nil {
-		log.Printf("%s: %v", filename, err)
-		return
+	if p.jsonOutput {
+		return p.json.Encode(report)
 	}
 
 	fmt.Println()
-	fmt.Println("Chunks file:", filename)
-	fmt.Println("Metadata length:", h.MetadataLength)
-	fmt.Println("Data length:", h.DataLength)
-	fmt.Println("UserID:", h.UserID)
-	from, through := h.From.Time().In(timezone), h.Through.Time().In(timezone)
-	fmt.Println("From:", from.Format(format))
-	fmt.Println("Through:", through.Format(format), "("+through.Sub(from).String()+")")
+	fmt.Println("Chunks file:", source)
+	if report.DecodeError != "" {
+		fmt.Println("Decode error:", report.DecodeError)
+	}
+	fmt.Println("UserID:", report.UserID)
+	fmt.Println("Fingerprint:", report.Fingerprint)
+	fmt.Println("From:", report.From.Format(format))
+	fmt.Println("Through:", report.Through.Format(format), "("+report.Through.Sub(report.From).String()+")")
 	fmt.Println("Labels:")
-
-	for _, l := range h.Metric {
+	for _, l := range report.Labels {
 		fmt.Println("\t", l.Name, "=", l.Value)
 	}
 
-	lokiChunk, err := parseLokiChunk(h, f)
-	if err != nil {
-		log.Printf("%s: %v", filename, err)
-		return
-	}
-
-	fmt.Println("Encoding:", lokiChunk.encoding)
-	fmt.Print("Blocks Metadata Checksum: ", fmt.Sprintf("%08x", lokiChunk.metadataChecksum))
-	if lokiChunk.metadataChecksum == lokiChunk.computedMetadataChecksum {
+	fmt.Println("Format:", report.Format)
+	fmt.Println("Encoding:", report.Encoding)
+	fmt.Print("Blocks Metadata Checksum: ", report.MetaChecksum)
+	if report.MetaChecksumOK {
 		fmt.Println(" OK")
 	} else {
-		fmt.Println(" BAD, computed checksum:", fmt.Sprintf("%08x", lokiChunk.computedMetadataChecksum))
+		fmt.Println(" BAD")
 	}
-	if blockDetails {
-		fmt.Println("Found", len(lokiChunk.blocks), "block(s)")
+	if p.blockDetails {
+		fmt.Println("Found", len(report.Blocks), "block(s)")
 	} else {
-		fmt.Println("Found", len(lokiChunk.blocks), "block(s), use -b to show block details")
+		fmt.Println("Found", len(report.Blocks), "block(s), use -b to show block details")
 	}
-	if len(lokiChunk.blocks) > 0 {
-		fmt.Println("Minimum time (from first block):", time.Unix(0, lokiChunk.blocks[0].minT).In(timezone).Format(format))
-		fmt.Println("Maximum time (from last block):", time.Unix(0, lokiChunk.blocks[len(lokiChunk.blocks)-1].maxT).In(timezone).Format(format))
+	if len(report.Blocks) > 0 {
+		fmt.Println("Minimum time (from first block):", report.Blocks[0].MinTime.Format(format))
+		fmt.Println("Maximum time (from last block):", report.Blocks[len(report.Blocks)-1].MaxTime.Format(format))
 	}
 
-	if blockDetails {
+	if p.blockDetails {
 		fmt.Println()
 	}
 
-	totalSize := 0
-
-	for ix, b := range lokiChunk.blocks {
-		if blockDetails {
-			cksum := ""
-			if b.storedChecksum == b.computedChecksum {
-				cksum = fmt.Sprintf("%08x OK", b.storedChecksum)
+	for _, b := range report.Blocks {
+		if p.blockDetails {
+			cksum := b.Checksum + " OK"
+			if !b.ChecksumOK {
+				cksum = b.Checksum + " BAD"
+			}
+			fmt.Printf("Block %4d: position: %8d, entries: %6d, original length: %6d (stored: %6d, ratio: %.2f), minT: %v maxT: %v, checksum: %s\n",
+				b.Index, b.Offset, b.Entries, b.UncompressedSize, b.CompressedSize, b.Ratio,
+				b.MinTime.Format(format), b.MaxTime.Format(format), cksum)
+			if b.DecompressError != "" {
+				fmt.Printf("Block %4d: failed to decompress: %s\n", b.Index, b.DecompressError)
 			} else {
-				cksum = fmt.Sprintf("%08x BAD (computed: %08x)", b.storedChecksum, b.computedChecksum)
+				fmt.Printf("Block %4d: digest compressed: %02x, original: %02x\n", b.Index, sha256.Sum256(b.compressed), sha256.Sum256(b.decompressed))
 			}
-			fmt.Printf("Block %4d: position: %8d, original length: %6d (stored: %6d, ratio: %.2f), minT: %v maxT: %v, checksum: %s\n",
-				ix, b.dataOffset, len(b.originalData), len(b.rawData), float64(len(b.originalData))/float64(len(b.rawData)),
-				time.Unix(0, b.minT).In(timezone).Format(format), time.Unix(0, b.maxT).In(timezone).Format(format),
-				cksum)
-			fmt.Printf("Block %4d: digest compressed: %02x, original: %02x\n", ix, sha256.Sum256(b.rawData), sha256.Sum256(b.originalData))
 		}
 
-		totalSize += len(b.originalData)
-
-		if printLines {
-			for _, l := range b.entries {
-				fmt.Printf("%v\t%s\n", time.Unix(0, l.timestamp).In(timezone).Format(format), strings.TrimSpace(l.line))
+		if p.printLines {
+			if !b.ChecksumOK {
+				fmt.Printf("Block %4d: lines skipped, invalid checksum\n", b.Index)
 			}
+			printLines(b.Lines)
 		}
+	}
 
-		if storeBlocks {
-			writeBlockToFile(b.rawData, ix, fmt.Sprintf("%s.block.%d", filename, ix))
-			writeBlockToFile(b.originalData, ix, fmt.Sprintf("%s.original.%d", filename, ix))
-		}
+	fmt.Println("Total size of original data:", report.UncompressedSize, "file size:", report.FileSize, "ratio:", fmt.Sprintf("%0.3g", report.Ratio))
+	return nil
+}
+
+func (p printer) printHeadBlock(source string, buf []byte) error {
+	report, err := inspectHeadBlock(source, buf, p.printLines)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Total size of original data:", totalSize, "file size:", si.Size(), "ratio:", fmt.Sprintf("%0.3g", float64(totalSize)/float64(si.Size())))
+	if p.jsonOutput {
+		return p.json.Encode(report)
+	}
+
+	fmt.Println()
+	fmt.Println("Head block file:", source)
+	fmt.Println("Format:", report.Format)
+	fmt.Println("Entries:", report.Entries)
+	fmt.Println("Minimum time:", report.MinTime.Format(format))
+	fmt.Println("Maximum time:", report.MaxTime.Format(format))
+	fmt.Println("Total size of original data:", report.UncompressedSize)
+	if p.printLines {
+		printLines(report.Lines)
+	}
+	return nil
+}
+
+func printLines(lines []lineReport) {
+	for _, l := range lines {
+		fmt.Printf("%v\t%s\n", l.Timestamp.Format(format), strings.TrimSpace(l.Line))
+	}
 }
 
 func writeBlockToFile(data []byte, blockIndex int, filename string) {
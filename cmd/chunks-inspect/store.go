@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/grafana/loki/pkg/loki"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/chunk/objectclient"
+	"github.com/grafana/loki/pkg/util/cfg"
+)
+
+// objectStore reads chunks from the filesystem object store configured in a Loki config file.
+type objectStore struct {
+	client chunk.ObjectClient
+}
+
+func newObjectStore(configFile string) (*objectStore, error) {
+	var config loki.Config
+	if err := cfg.Unmarshal(&config, cfg.Defaults(), cfg.YAML(configFile, true)); err != nil {
+		return nil, fmt.Errorf("failed parsing config file %s: %w", configFile, err)
+	}
+	if config.StorageConfig.FSConfig.Directory == "" {
+		return nil, fmt.Errorf("config file %s has no filesystem object store configured (storage_config.filesystem.directory)", configFile)
+	}
+	client, err := local.NewFSObjectClient(config.StorageConfig.FSConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &objectStore{client: client}, nil
+}
+
+// get returns the parsed chunk reference and the content of the chunk with the given ID,
+// userID is only used for legacy chunk IDs which don't contain the tenant.
+func (s *objectStore) get(ctx context.Context, userID, chunkID string) (*chunk.Chunk, []byte, error) {
+	c, err := chunk.ParseExternalKey(userID, chunkID)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := s.client.GetObject(ctx, objectclient.Base64Encoder(c.ExternalKey()))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &c, buf, nil
+}
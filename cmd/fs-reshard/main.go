@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/grafana/loki/pkg/loki"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/util/cfg"
+)
+
+func main() {
+	var fsConfig local.FSConfig
+	fsConfig.RegisterFlags(flag.CommandLine)
+	configFile := flag.String("config.file", "", "Optional Loki config file to read the filesystem storage config from, instead of the -local.* flags")
+	dryRun := flag.Bool("dry-run", false, "Only report how many objects would be moved")
+	flag.Parse()
+
+	logger := log.With(log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)), "ts", log.DefaultTimestampUTC)
+
+	if *configFile != "" {
+		// Defaults are not registered as they would conflict with the -local.* flags, only the filesystem config is used.
+		var config loki.Config
+		if err := cfg.Unmarshal(&config, cfg.YAML(*configFile, true)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed parsing config file %s: %v\n", *configFile, err)
+			os.Exit(1)
+		}
+		fsConfig = config.StorageConfig.FSConfig
+	}
+
+	if fsConfig.Directory == "" {
+		fmt.Fprintln(os.Stderr, "the filesystem directory must be set")
+		os.Exit(1)
+	}
+
+	client, err := local.NewFSObjectClient(fsConfig)
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to create filesystem object client", "err", err)
+		os.Exit(1)
+	}
+
+	moved, err := client.Reshard(context.Background(), logger, *dryRun)
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to reshard objects, it is safe to run the tool again", "moved", moved, "err", err)
+		os.Exit(1)
+	}
+	level.Info(logger).Log("msg", "done", "moved", moved, "directory", fsConfig.Directory, "shard_levels", fsConfig.ShardLevels, "dry_run", *dryRun)
+}
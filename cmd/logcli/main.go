@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/url"
 	"os"
@@ -15,18 +16,21 @@ import (
 	"github.com/grafana/loki/pkg/logcli/labelquery"
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/logcli/query"
+	"github.com/grafana/loki/pkg/logcli/rules"
 	"github.com/grafana/loki/pkg/logcli/seriesquery"
 	_ "github.com/grafana/loki/pkg/util/build"
 )
 
 var (
-	app        = kingpin.New("logcli", "A command-line for loki.").Version(version.Print("logcli"))
-	quiet      = app.Flag("quiet", "Suppress query metadata").Default("false").Short('q').Bool()
-	statistics = app.Flag("stats", "Show query statistics").Default("false").Bool()
-	outputMode = app.Flag("output", "Specify output mode [default, raw, jsonl]. raw suppresses log labels and timestamp.").Default("default").Short('o').Enum("default", "raw", "jsonl")
-	timezone   = app.Flag("timezone", "Specify the timezone to use when formatting output timestamps [Local, UTC]").Default("Local").Short('z').Enum("Local", "UTC")
-	cpuProfile = app.Flag("cpuprofile", "Specify the location for writing a CPU profile.").Default("").String()
-	memProfile = app.Flag("memprofile", "Specify the location for writing a memory profile.").Default("").String()
+	app            = kingpin.New("logcli", "A command-line for loki.").Version(version.Print("logcli"))
+	quiet          = app.Flag("quiet", "Suppress query metadata").Default("false").Short('q').Bool()
+	statistics     = app.Flag("stats", "Show query statistics").Default("false").Bool()
+	outputMode     = app.Flag("output", "Specify output mode [default, raw, jsonl, csv, tsv, logfmt, template]. raw suppresses log labels and timestamp, template formats each log line with --template.").Default("default").Short('o').Enum("default", "raw", "jsonl", "csv", "tsv", "logfmt", "template")
+	outputTemplate = app.Flag("template", "Go template of the template output mode, e.g. '{{.Timestamp.Unix}} {{.Labels.app}} {{.Line}}'. The template is given the Timestamp, Labels and Line of each log line.").Default("").String()
+	metricMode     = app.Flag("metric-output", "Specify output mode of metric query results [json, table, sparkline, columns]. table prints a row per sample, sparkline a row per series with a sparkline of its samples and columns a column per series.").Default("json").Enum("json", "table", "sparkline", "columns")
+	timezone       = app.Flag("timezone", "Specify the timezone to use when formatting output timestamps [Local, UTC]").Default("Local").Short('z').Enum("Local", "UTC")
+	cpuProfile     = app.Flag("cpuprofile", "Specify the location for writing a CPU profile.").Default("").String()
+	memProfile     = app.Flag("memprofile", "Specify the location for writing a memory profile.").Default("").String()
 
 	queryClient = newQueryClient(app)
 
@@ -38,10 +42,18 @@ returned in a few output modes:
 	raw: log line
 	default: log timestamp + log labels + log line
 	jsonl: JSON response from Loki API of log line
+	csv, tsv: log timestamp, log labels and log line as comma or tab separated values
+	logfmt: log timestamp, each log label and log line as logfmt
+	template: log line formatted with the Go template of the "--template" flag
 
 The output of the log can be specified with the "-o" flag, for
 example, "-o raw" for the raw output format.
 
+The results of metric queries are printed as JSON by default; use
+"--metric-output=table" to print them as a table with a column per label,
+"--metric-output=sparkline" to print a row per series with a sparkline of
+its samples, or "--metric-output=columns" to print a column per series.
+
 The "query" command will output extra information about the query
 and its results, such as the API URL, set of common labels, and set
 of excluded labels. This extra information can be suppressed with the
@@ -63,8 +75,38 @@ Example:
 	   --output=jsonl
 	   'my-query'
 
+	logcli query
+	   --output=template
+	   --template='{{.Timestamp.Unix}} {{.Labels.app}} {{.Line}}'
+	   'my-query'
+
 The output is limited to 30 entries by default; use --limit to increase.
 
+Large exports can be split in sub ranges queried in parallel with
+--parallel-duration and --parallel-max-workers. Each sub range is written
+to its own part file under --part-path-prefix, completed parts are skipped
+when the same query is run again, so that an interrupted export can be
+resumed. Use --from and --to so that the sub ranges are the same across
+runs. The limit applies to each part and --limit=0 removes it, use
+--merge-parts to print the parts in order once they are all completed.
+
+Example:
+
+	logcli query
+	   --from="2021-01-19T00:00:00Z"
+	   --to="2021-01-20T00:00:00Z"
+	   --limit=0
+	   --parallel-duration=15m
+	   --parallel-max-workers=8
+	   --part-path-prefix=out/
+	   --merge-parts
+	   'my-query' > audit.log
+
+Use --tail with --interactive to follow the logs: the matches of the line
+filters of the query are highlighted with --colored-output, pressing enter
+pauses and resumes the output, and the tail reconnects with backoff when the connection is lost,
+replaying the last --reconnect-replay of logs without duplicates.
+
 While "query" does support metrics queries, its output contains multiple
 data points between the start and end query time. This output is used to
 build graphs, similar to what is seen in the Grafana Explore graph view.
@@ -72,8 +114,11 @@ If you are querying metrics and just want the most recent data point
 (like what is seen in the Grafana Explore table view), then you should use
 the "instant-query" command instead.`)
 	rangeQuery = newQuery(false, queryCmd)
+	rangeStore = newStoreFlags(queryCmd)
 	tail       = queryCmd.Flag("tail", "Tail the logs").Short('t').Default("false").Bool()
 	delayFor   = queryCmd.Flag("delay-for", "Delay in tailing by number of seconds to accumulate logs for re-ordering").Default("0").Int()
+	interact   = queryCmd.Flag("interactive", "Tail the logs interactively: highlight the matches of the line filters with --colored-output, pause and resume the output with enter and reconnect when the connection is lost.").Short('i').Default("false").Bool()
+	replay     = queryCmd.Flag("reconnect-replay", "Duration of logs replayed when reconnecting in interactive mode, the logs already printed are not printed again.").Default("5m").Duration()
 
 	instantQueryCmd = app.Command("instant-query", `Run an instant LogQL query.
 
@@ -90,9 +135,11 @@ LogQL documentation:
 
 https://grafana.com/docs/loki/latest/logql/`)
 	instantQuery = newQuery(true, instantQueryCmd)
+	instantStore = newStoreFlags(instantQueryCmd)
 
 	labelsCmd   = app.Command("labels", "Find values for a given label.")
 	labelsQuery = newLabelQuery(labelsCmd)
+	labelsStore = newStoreFlags(labelsCmd)
 
 	seriesCmd = app.Command("series", `Run series query.
 
@@ -105,6 +152,70 @@ Use the --analyze-labels flag to get a summary of the labels found in all stream
 This is helpful to find high cardinality labels.
 `)
 	seriesQuery = newSeriesQuery(seriesCmd)
+	seriesStore = newStoreFlags(seriesCmd)
+
+	rulesCmd = app.Command("rules", `Manage the rule groups of the ruler.
+
+The rule files use the same format as the ruler. The rule groups of a file
+belong to the namespace named after the file, like with the local rules
+storage of the ruler; use --namespace to load all the files in a single
+namespace. Directories are expanded to the files they contain.
+
+The files are validated locally before being loaded, including the LogQL
+expressions of their rules. Only the rule groups which differ from the
+ones of the ruler are sent, so that the commands can be run again safely.
+
+Example:
+
+	logcli rules lint rules/
+	logcli rules diff rules/
+	logcli rules sync --prune rules/`)
+	rulesLintCmd = rulesCmd.Command("lint", "Validate rule files without contacting the ruler.")
+	rulesLint    = newRulesFiles(rulesLintCmd, false)
+	rulesTestCmd = rulesCmd.Command("test", `Unit test rule files without contacting Loki.
+
+The test files use the format of the unit tests of promtool, except that
+the input series are replaced by input log streams: their values are the
+number of lines logged at each interval, evenly spread across it. The
+annotations of an expected alert are only compared when exp_annotations
+are given.
+
+Example test file:
+
+	rule_files:
+	  - rules.yaml
+	evaluation_interval: 1m
+	tests:
+	  - interval: 1m
+	    input_streams:
+	      - labels: '{job="app"}'
+	        line: 'level=error msg="request failed"'
+	        values: '1x4 10x5'
+	    recording_rule_test:
+	      - eval_time: 6m
+	        record: job:errors:count1m
+	        exp_samples:
+	          - labels: '{job="app"}'
+	            value: 10
+	    alert_rule_test:
+	      - eval_time: 8m
+	        alertname: HighErrorRate
+	        exp_alerts:
+	          - exp_labels:
+	              job: app`)
+	rulesTest    = newRulesTest(rulesTestCmd)
+	rulesListCmd = rulesCmd.Command("list", "List the rule groups of the ruler.")
+	rulesList    = newRulesList(rulesListCmd)
+	rulesGetCmd  = rulesCmd.Command("get", "Print out a rule group of the ruler.")
+	rulesGet     = newRulesGroup(rulesGetCmd, true)
+	rulesLoadCmd = rulesCmd.Command("load", "Create or update the rule groups of rule files on the ruler, other rule groups are left untouched.")
+	rulesLoad    = newRulesFiles(rulesLoadCmd, false)
+	rulesDiffCmd = rulesCmd.Command("diff", "Print out the changes a sync of rule files would apply to the ruler.")
+	rulesDiff    = newRulesFiles(rulesDiffCmd, true)
+	rulesSyncCmd = rulesCmd.Command("sync", "Make the rule groups of the ruler match rule files, the rule groups missing from the files are deleted from their namespaces.")
+	rulesSync    = newRulesFiles(rulesSyncCmd, true)
+	rulesDelCmd  = rulesCmd.Command("delete", "Delete a rule group of the ruler, or a whole namespace when no rule group is given.")
+	rulesDel     = newRulesGroup(rulesDelCmd, false)
 )
 
 func main() {
@@ -148,6 +259,7 @@ func main() {
 			Timezone:      location,
 			NoLabels:      rangeQuery.NoLabels,
 			ColoredOutput: rangeQuery.ColoredOutput,
+			Template:      *outputTemplate,
 		}
 
 		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
@@ -155,10 +267,26 @@ func main() {
 			log.Fatalf("Unable to create log output: %s", err)
 		}
 
-		if *tail {
-			rangeQuery.TailQuery(time.Duration(*delayFor)*time.Second, queryClient, out)
+		rangeQuery.MetricOutput, err = output.NewMetricOutput(os.Stdout, *metricMode, outputOptions)
+		if err != nil {
+			log.Fatalf("Unable to create metric output: %s", err)
+		}
+
+		if *tail && rangeStore.ConfigFile != "" {
+			log.Fatalf("Tailing is not supported when querying the store")
+		}
+
+		c, stop := rangeStore.client()
+		defer stop()
+
+		if *tail && *interact {
+			rangeQuery.FollowQuery(time.Duration(*delayFor)*time.Second, *replay, c, out, os.Stdin)
+		} else if *tail {
+			rangeQuery.TailQuery(time.Duration(*delayFor)*time.Second, c, out)
+		} else if rangeQuery.ParallelDuration > 0 {
+			rangeQuery.DoQueryParallel(c, out, os.Stdout, *statistics)
 		} else {
-			rangeQuery.DoQuery(queryClient, out, *statistics)
+			rangeQuery.DoQuery(c, out, *statistics)
 		}
 	case instantQueryCmd.FullCommand():
 		location, err := time.LoadLocation(*timezone)
@@ -170,6 +298,7 @@ func main() {
 			Timezone:      location,
 			NoLabels:      instantQuery.NoLabels,
 			ColoredOutput: instantQuery.ColoredOutput,
+			Template:      *outputTemplate,
 		}
 
 		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
@@ -177,15 +306,45 @@ func main() {
 			log.Fatalf("Unable to create log output: %s", err)
 		}
 
-		instantQuery.DoQuery(queryClient, out, *statistics)
+		instantQuery.MetricOutput, err = output.NewMetricOutput(os.Stdout, *metricMode, outputOptions)
+		if err != nil {
+			log.Fatalf("Unable to create metric output: %s", err)
+		}
+
+		c, stop := instantStore.client()
+		defer stop()
+
+		instantQuery.DoQuery(c, out, *statistics)
 	case labelsCmd.FullCommand():
-		labelsQuery.DoLabels(queryClient)
+		c, stop := labelsStore.client()
+		defer stop()
+
+		labelsQuery.DoLabels(c)
 	case seriesCmd.FullCommand():
-		seriesQuery.DoSeries(queryClient)
+		c, stop := seriesStore.client()
+		defer stop()
+
+		seriesQuery.DoSeries(c)
+	case rulesLintCmd.FullCommand():
+		rulesLint.DoLint()
+	case rulesTestCmd.FullCommand():
+		rulesTest.DoTest()
+	case rulesListCmd.FullCommand():
+		rulesList.DoList(queryClient)
+	case rulesGetCmd.FullCommand():
+		rulesGet.DoGet(queryClient)
+	case rulesLoadCmd.FullCommand():
+		rulesLoad.DoLoad(queryClient)
+	case rulesDiffCmd.FullCommand():
+		rulesDiff.DoDiff(queryClient)
+	case rulesSyncCmd.FullCommand():
+		rulesSync.DoSync(queryClient)
+	case rulesDelCmd.FullCommand():
+		rulesDel.DoDelete(queryClient)
 	}
 }
 
-func newQueryClient(app *kingpin.Application) client.Client {
+func newQueryClient(app *kingpin.Application) *client.DefaultClient {
 
 	client := &client.DefaultClient{
 		TLSConfig: config.TLSConfig{},
@@ -216,6 +375,43 @@ func newQueryClient(app *kingpin.Application) client.Client {
 	return client
 }
 
+// storeFlags are the flags of the commands which can run against the storage of a Loki configuration file.
+type storeFlags struct {
+	ConfigFile string
+	OfflineDir string
+}
+
+func newStoreFlags(cmd *kingpin.CmdClause) *storeFlags {
+	s := &storeFlags{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+		if s.OfflineDir != "" && s.ConfigFile == "" {
+			return errors.New("--store-offline-dir requires --store-config")
+		}
+		return nil
+	})
+
+	cmd.Flag("store-config", "Execute the current command directly against the storage configured in a given Loki configuration file, without a running Loki.").Default("").StringVar(&s.ConfigFile)
+	cmd.Flag("store-offline-dir", "Read the chunks and boltdb-shipper index of the storage from this local copy of its object store bucket, or of its filesystem directory, instead. Requires --store-config.").Default("").StringVar(&s.OfflineDir)
+
+	return s
+}
+
+// client returns the client running the command and a function releasing it, queryClient unless a
+// store configuration is given.
+func (s *storeFlags) client() (client.Client, func()) {
+	if s.ConfigFile == "" {
+		return queryClient, func() {}
+	}
+
+	c, err := client.NewLocalClient(s.ConfigFile, s.OfflineDir, queryClient.OrgID)
+	if err != nil {
+		log.Fatalf("Unable to open the store: %+v", err)
+	}
+	return c, c.Stop
+}
+
 func newLabelQuery(cmd *kingpin.CmdClause) *labelquery.LabelQuery {
 	var labelName, from, to string
 	var since time.Duration
@@ -271,6 +467,59 @@ func newSeriesQuery(cmd *kingpin.CmdClause) *seriesquery.SeriesQuery {
 	return q
 }
 
+func newRules(cmd *kingpin.CmdClause) *rules.Rules {
+	r := &rules.Rules{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+		r.Quiet = *quiet
+		return nil
+	})
+
+	return r
+}
+
+func newRulesFiles(cmd *kingpin.CmdClause, prune bool) *rules.Rules {
+	r := newRules(cmd)
+
+	cmd.Arg("files", "Rule files or directories of rule files.").Required().ExistingFilesOrDirsVar(&r.Files)
+	cmd.Flag("namespace", "Namespace of all the rule groups, defaults to the name of their file.").StringVar(&r.Namespace)
+	if prune {
+		cmd.Flag("prune", "Also delete the namespaces of the ruler which are not in the files.").Default("false").BoolVar(&r.Prune)
+	}
+
+	return r
+}
+
+func newRulesTest(cmd *kingpin.CmdClause) *rules.Rules {
+	r := newRules(cmd)
+
+	cmd.Arg("files", "Test files, the rule files are relative to them.").Required().ExistingFilesVar(&r.Files)
+
+	return r
+}
+
+func newRulesList(cmd *kingpin.CmdClause) *rules.Rules {
+	r := newRules(cmd)
+
+	cmd.Arg("namespace", "Only list the rule groups of this namespace.").Default("").StringVar(&r.Namespace)
+
+	return r
+}
+
+func newRulesGroup(cmd *kingpin.CmdClause, groupRequired bool) *rules.Rules {
+	r := newRules(cmd)
+
+	cmd.Arg("namespace", "The namespace of the rule group.").Required().StringVar(&r.Namespace)
+	group := cmd.Arg("group", "The name of the rule group.")
+	if groupRequired {
+		group = group.Required()
+	}
+	group.StringVar(&r.GroupName)
+
+	return r
+}
+
 func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 	// calculate query range from cli params
 	var now, from, to string
@@ -306,6 +555,12 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 		cmd.Flag("step", "Query resolution step width, for metric queries. Evaluate the query at the specified step over the time range.").DurationVar(&q.Step)
 		cmd.Flag("interval", "Query interval, for log queries. Return entries at the specified interval, ignoring those between. **This parameter is experimental, please see Issue 1779**").DurationVar(&q.Interval)
 		cmd.Flag("batch", "Query batch size to use until 'limit' is reached").Default("1000").IntVar(&q.BatchSize)
+		cmd.Flag("parallel-duration", "Split the query range in sub ranges of this duration and run them in parallel, each of them is written to its own part file. The limit applies to each part, a limit of 0 means no limit.").Default("0").DurationVar(&q.ParallelDuration)
+		cmd.Flag("parallel-max-workers", "Maximum number of sub ranges queried at the same time.").Default("1").IntVar(&q.ParallelMaxWorkers)
+		cmd.Flag("part-path-prefix", "Path prefix of the part files, e.g. 'out/' or 'out/export-'. Defaults to a temporary directory when the parts are merged.").Default("").StringVar(&q.PartPathPrefix)
+		cmd.Flag("overwrite-completed-parts", "Query the parts again even if they are already completed.").Default("false").BoolVar(&q.OverwriteCompletedParts)
+		cmd.Flag("merge-parts", "Print the parts in order once they are all completed.").Default("false").BoolVar(&q.MergeParts)
+		cmd.Flag("keep-parts", "Keep the part files after merging them.").Default("false").BoolVar(&q.KeepParts)
 	}
 
 	cmd.Flag("forward", "Scan forwards through logs.").Default("false").BoolVar(&q.Forward)
@@ -313,7 +568,6 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 	cmd.Flag("exclude-label", "Exclude labels given the provided key during output.").StringsVar(&q.IgnoreLabelsKey)
 	cmd.Flag("include-label", "Include labels given the provided key during output.").StringsVar(&q.ShowLabelsKey)
 	cmd.Flag("labels-length", "Set a fixed padding to labels").Default("0").IntVar(&q.FixedLabelsLen)
-	cmd.Flag("store-config", "Execute the current query using a configured storage from a given Loki configuration file.").Default("").StringVar(&q.LocalConfig)
 	cmd.Flag("colored-output", "Show output with colored labels").Default("false").BoolVar(&q.ColoredOutput)
 
 	return q
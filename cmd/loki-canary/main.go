@@ -3,16 +3,19 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/pkg/labels"
 
 	"github.com/grafana/loki/pkg/canary/comparator"
 	"github.com/grafana/loki/pkg/canary/reader"
@@ -23,9 +26,11 @@ import (
 type canary struct {
 	lock sync.Mutex
 
-	writer     *writer.Writer
-	reader     *reader.Reader
-	comparator *comparator.Comparator
+	writers     []*writer.Writer
+	pushes      []*writer.Push
+	probes      []*writer.OutOfOrderProbe
+	readers     []*reader.Reader
+	comparators []*comparator.Comparator
 }
 
 func main() {
@@ -42,7 +47,21 @@ func main() {
 	queryTimeout := flag.Duration("query-timeout", 10*time.Second, "How long to wait for a query response from Loki")
 
 	interval := flag.Duration("interval", 1000*time.Millisecond, "Duration between log entries")
-	size := flag.Int("size", 100, "Size in bytes of each log line")
+	size := flag.Int("size", 100, "Size in bytes of each log line, the minimum size for the uniform distribution and the mean size for the exponential distribution")
+	maxSize := flag.Int("max-size", 0, "Maximum size in bytes of each log line for the uniform and exponential distributions")
+	sizeDistribution := flag.String("size-distribution", "fixed", "Distribution of the sizes of the log lines, one of fixed, uniform or exponential")
+	tenants := flag.String("tenants", "", "Comma separated list of tenants to write streams for, each tenant is queried with its own X-Scope-OrgID header. "+
+		"The tenant is written in each log line so that the agent can route it")
+	streams := flag.Int("streams", 1, "Number of streams to write for each tenant, the stream is written in each log line so that the agent can extract it as a label "+
+		"and spread the streams across ingesters")
+	push := flag.Bool("push", false, "Push the log entries directly to Loki with the protobuf push API instead of writing them to stdout for an agent to ship them")
+	batchWait := flag.Duration("batch-wait", 1*time.Second, "Maximum duration to wait before pushing a batch of log entries when -push is set")
+	batchSize := flag.Int("batch-size", 100*1024, "Maximum size in bytes of the log lines of a batch before it is pushed when -push is set")
+	outOfOrderInterval := flag.Duration("out-of-order-interval", 0, "Interval to push an out of order entry to a dedicated stream of each tenant when -push is set, "+
+		"to check that it is accepted or rejected according to -unordered-writes. 0 disables the out of order probes")
+	outOfOrderOffset := flag.Duration("out-of-order-offset", 1*time.Minute, "How far before the previous entry the out of order entries are pushed, "+
+		"must be less than half the max chunk age of the ingesters to be accepted by unordered writes")
+	unorderedWrites := flag.Bool("unordered-writes", false, "Whether unordered writes are enabled for the tenants, the out of order entries are expected to be accepted when they are and rejected otherwise")
 	wait := flag.Duration("wait", 60*time.Second, "Duration to wait for log entries on websocket before querying loki for them")
 	maxWait := flag.Duration("max-wait", 5*time.Minute, "Duration to keep querying Loki for missing websocket entries before reporting them missing")
 	pruneInterval := flag.Duration("pruneinterval", 60*time.Second, "Frequency to check sent vs received logs, "+
@@ -73,8 +92,34 @@ func main() {
 		os.Exit(1)
 	}
 
-	sentChan := make(chan time.Time)
-	receivedChan := make(chan time.Time)
+	if *streams < 1 {
+		_, _ = fmt.Fprintf(os.Stderr, "Must write at least one stream with -streams\n")
+		os.Exit(1)
+	}
+
+	if !*push && *outOfOrderInterval > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "The out of order probes require -push\n")
+		os.Exit(1)
+	}
+
+	sizes, err := writer.NewSizeDistribution(*sizeDistribution, *size, *maxSize)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid line sizes: %s\n", err)
+		os.Exit(1)
+	}
+
+	tenantIDs := []string{""}
+	if *tenants != "" {
+		tenantIDs = strings.Split(*tenants, ",")
+	}
+	// The streams are only told apart when there are several of them.
+	streamIDs := []string{""}
+	if *streams > 1 {
+		streamIDs = make([]string, 0, *streams)
+		for i := 0; i < *streams; i++ {
+			streamIDs = append(streamIDs, strconv.Itoa(i))
+		}
+	}
 
 	c := &canary{}
 	startCanary := func() {
@@ -83,9 +128,36 @@ func main() {
 		c.lock.Lock()
 		defer c.lock.Unlock()
 
-		c.writer = writer.NewWriter(os.Stdout, sentChan, *interval, *size)
-		c.reader = reader.NewReader(os.Stderr, receivedChan, *tls, *addr, *user, *pass, *queryTimeout, *lName, *lVal, *sName, *sValue, *interval)
-		c.comparator = comparator.NewComparator(os.Stderr, *wait, *maxWait, *pruneInterval, *spotCheckInterval, *spotCheckMax, *spotCheckQueryRate, *spotCheckWait, *metricTestInterval, *metricTestQueryRange, *interval, *buckets, sentChan, receivedChan, c.reader, true)
+		for _, tenant := range tenantIDs {
+			received := make(map[string]chan time.Time, len(streamIDs))
+			for _, stream := range streamIDs {
+				received[stream] = make(chan time.Time)
+			}
+			r := reader.NewReader(os.Stderr, received, *tls, *addr, *user, *pass, tenant, *queryTimeout, *lName, *lVal, *sName, *sValue, *interval)
+			c.readers = append(c.readers, r)
+
+			if *push && *outOfOrderInterval > 0 {
+				lbls := labels.FromStrings(*lName, *lVal, *sName, *sValue+"-out-of-order").String()
+				c.probes = append(c.probes, writer.NewOutOfOrderProbe(os.Stderr, *tls, *addr, *user, *pass, tenant, lbls, *outOfOrderInterval, *outOfOrderOffset, *unorderedWrites, *queryTimeout))
+			}
+
+			for _, stream := range streamIDs {
+				var out io.Writer = os.Stdout
+				if *push {
+					lbls := labels.FromStrings(*lName, *lVal, *sName, *sValue)
+					if stream != "" {
+						lbls = labels.NewBuilder(lbls).Set(writer.StreamLabel, stream).Labels()
+					}
+					p := writer.NewPush(os.Stderr, *tls, *addr, *user, *pass, tenant, lbls.String(), *batchWait, *batchSize, *queryTimeout)
+					c.pushes = append(c.pushes, p)
+					out = p
+				}
+
+				sentChan := make(chan time.Time)
+				c.writers = append(c.writers, writer.NewWriter(out, sentChan, *interval, sizes, tenant, stream))
+				c.comparators = append(c.comparators, comparator.NewComparator(os.Stderr, *wait, *maxWait, *pruneInterval, *spotCheckInterval, *spotCheckMax, *spotCheckQueryRate, *spotCheckWait, *metricTestInterval, *metricTestQueryRange, *interval, *buckets, sentChan, received[stream], r.Stream(stream), true, tenant, stream))
+			}
+		}
 	}
 
 	startCanary()
@@ -120,15 +192,26 @@ func (c *canary) stop() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	if c.writer == nil || c.reader == nil || c.comparator == nil {
-		return
+	for _, w := range c.writers {
+		w.Stop()
+	}
+	// The pending batches are pushed once the writers are stopped.
+	for _, p := range c.pushes {
+		p.Stop()
+	}
+	for _, p := range c.probes {
+		p.Stop()
+	}
+	for _, r := range c.readers {
+		r.Stop()
+	}
+	for _, cmp := range c.comparators {
+		cmp.Stop()
 	}
 
-	c.writer.Stop()
-	c.reader.Stop()
-	c.comparator.Stop()
-
-	c.writer = nil
-	c.reader = nil
-	c.comparator = nil
+	c.writers = nil
+	c.pushes = nil
+	c.probes = nil
+	c.readers = nil
+	c.comparators = nil
 }
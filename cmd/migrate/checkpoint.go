@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// checkpoint records the sync ranges which have been fully migrated in a file,
+// so that a migration can be restarted and skip the ranges already done.
+// The file contains one `<from> <to>` line per completed range.
+type checkpoint struct {
+	mtx  sync.Mutex
+	f    *os.File
+	done map[syncRange]struct{}
+}
+
+func openCheckpoint(filename string) (*checkpoint, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	cp := &checkpoint{
+		f:    f,
+		done: map[syncRange]struct{}{},
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sr syncRange
+		if _, err := fmt.Sscanf(scanner.Text(), "%d %d", &sr.from, &sr.to); err != nil {
+			// A partially written last line is left behind when the process is killed while writing.
+			continue
+		}
+		cp.done[sr] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return cp, nil
+}
+
+// filter returns the sync ranges which have not been completed yet.
+func (c *checkpoint) filter(ranges []*syncRange) []*syncRange {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	result := make([]*syncRange, 0, len(ranges))
+	for _, sr := range ranges {
+		if _, ok := c.done[*sr]; ok {
+			continue
+		}
+		result = append(result, sr)
+	}
+	return result
+}
+
+// markDone durably records the sync range as completed.
+func (c *checkpoint) markDone(sr *syncRange) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, err := fmt.Fprintf(c.f, "%d %d\n", sr.from, sr.to); err != nil {
+		return err
+	}
+	if err := c.f.Sync(); err != nil {
+		return err
+	}
+	c.done[*sr] = struct{}{}
+	return nil
+}
+
+func (c *checkpoint) Close() error {
+	return c.f.Close()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "checkpoint")
+
+	ranges := calcSyncRanges(0, 20, 6)
+
+	cp, err := openCheckpoint(filename)
+	require.NoError(t, err)
+	require.Equal(t, ranges, cp.filter(ranges))
+	require.NoError(t, cp.markDone(ranges[0]))
+	require.NoError(t, cp.markDone(ranges[2]))
+	require.Equal(t, []*syncRange{ranges[1], ranges[3]}, cp.filter(ranges))
+	require.NoError(t, cp.Close())
+
+	// Simulate a crash while writing the last line.
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("19 ")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	cp, err = openCheckpoint(filename)
+	require.NoError(t, err)
+	defer cp.Close()
+	require.Equal(t, []*syncRange{ranges[1], ranges[3]}, cp.filter(ranges))
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/weaveworks/common/user"
 
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/loki"
 	"github.com/grafana/loki/pkg/storage"
@@ -49,6 +50,12 @@ func main() {
 	batch := flag.Int("batchLen", 500, "Specify how many chunks to read/write in one batch")
 	shardBy := flag.Duration("shardBy", 6*time.Hour, "Break down the total interval into shards of this size, making this too small can lead to syncing a lot of duplicate chunks")
 	parallel := flag.Int("parallel", 8, "How many parallel threads to process each shard")
+	encoding := flag.String("dest.encoding", "", fmt.Sprintf("Optional encoding to re-encode chunks with, supported values: %s. Default is to keep the source chunks encoding", chunkenc.SupportedEncoding()))
+	blockSize := flag.Int("dest.block-size", 256*1024, "Block size of rewritten chunks, only used when chunks are rewritten")
+	targetSize := flag.Int("dest.chunk-target-size", 1572864, "Target compressed size of rewritten chunks, small chunks of the same stream within a batch are merged up to this size")
+	pipeline := flag.String("pipeline", "", "Optional LogQL pipeline applied to every line, e.g. '|= \"error\" | logfmt | level=\"error\"', lines filtered out are not migrated")
+	relabelFile := flag.String("relabel.config.file", "", "Optional YAML file with a list of Prometheus relabel configs applied to every stream")
+	checkpointFile := flag.String("checkpoint.file", "", "Optional file recording completed sync ranges, a restarted migration with the same file skips them")
 	flag.Parse()
 
 	// Create a set of defaults
@@ -132,6 +139,33 @@ func main() {
 		matchers = append(matchers, m...)
 	}
 
+	rwCfg := rewriteConfig{
+		blockSize:  *blockSize,
+		targetSize: *targetSize,
+	}
+	if *encoding != "" {
+		rwCfg.reencode = true
+		rwCfg.encoding, err = chunkenc.ParseEncoding(*encoding)
+		if err != nil {
+			log.Println("Failed to parse encoding:", err)
+			os.Exit(1)
+		}
+	}
+	if *pipeline != "" {
+		rwCfg.pipeline, err = parsePipeline(*pipeline)
+		if err != nil {
+			log.Println("Failed to parse pipeline:", err)
+			os.Exit(1)
+		}
+	}
+	if *relabelFile != "" {
+		rwCfg.relabelConfigs, err = loadRelabelConfigs(*relabelFile)
+		if err != nil {
+			log.Println("Failed to load relabel configs:", err)
+			os.Exit(1)
+		}
+	}
+
 	ctx := context.Background()
 	// This is a little weird but it was the easiest way to guarantee the userID is in the right format
 	ctx = user.InjectOrgID(ctx, *source)
@@ -170,7 +204,25 @@ func main() {
 	syncRanges := calcSyncRanges(parsedFrom.UnixNano(), parsedTo.UnixNano(), shardByNs.Nanoseconds())
 	log.Printf("With a shard duration of %v, %v ranges have been calculated.\n", shardByNs, len(syncRanges))
 
-	cm := newChunkMover(ctx, s, d, *source, *dest, matchers, *batch)
+	var cp *checkpoint
+	if *checkpointFile != "" {
+		cp, err = openCheckpoint(*checkpointFile)
+		if err != nil {
+			log.Println("Failed to open checkpoint file:", err)
+			os.Exit(1)
+		}
+		defer cp.Close()
+		total := len(syncRanges)
+		syncRanges = cp.filter(syncRanges)
+		log.Printf("Checkpoint file %v: %v of %v ranges already completed, skipping them.\n", *checkpointFile, total-len(syncRanges), total)
+	}
+
+	var rw *chunkRewriter
+	if rwCfg.enabled() {
+		rw = newChunkRewriter(rwCfg, *dest)
+	}
+
+	cm := newChunkMover(ctx, s, d, *source, *dest, matchers, *batch, rw, cp)
 	syncChan := make(chan *syncRange)
 	errorChan := make(chan error)
 	statsChan := make(chan stats)
@@ -269,9 +321,13 @@ type chunkMover struct {
 	destUser   string
 	matchers   []*labels.Matcher
 	batch      int
+	// rewriter is nil when chunks are copied verbatim.
+	rewriter *chunkRewriter
+	// checkpoint is nil when progress is not recorded.
+	checkpoint *checkpoint
 }
 
-func newChunkMover(ctx context.Context, source, dest storage.Store, sourceUser, destUser string, matchers []*labels.Matcher, batch int) *chunkMover {
+func newChunkMover(ctx context.Context, source, dest storage.Store, sourceUser, destUser string, matchers []*labels.Matcher, batch int, rewriter *chunkRewriter, cp *checkpoint) *chunkMover {
 	cm := &chunkMover{
 		ctx:        ctx,
 		source:     source,
@@ -280,6 +336,8 @@ func newChunkMover(ctx context.Context, source, dest storage.Store, sourceUser,
 		destUser:   destUser,
 		matchers:   matchers,
 		batch:      batch,
+		rewriter:   rewriter,
+		checkpoint: cp,
 	}
 	return cm
 }
@@ -351,6 +409,9 @@ func (m *chunkMover) moveChunks(ctx context.Context, threadID int, syncRangeCh <
 							errCh <- err
 							return
 						}
+						if m.rewriter != nil {
+							continue
+						}
 						if m.sourceUser != m.destUser {
 							// Because the incoming chunks are already encoded, to change the username we have to make a new chunk
 							nc := chunk.NewChunk(m.destUser, chk.Fingerprint, chk.Metric, chk.Data, chk.From, chk.Through)
@@ -366,6 +427,15 @@ func (m *chunkMover) moveChunks(ctx context.Context, threadID int, syncRangeCh <
 						}
 
 					}
+					if m.rewriter != nil {
+						output, err = m.rewriter.rewrite(m.ctx, chks)
+						if err != nil {
+							log.Println(threadID, "Failed to rewrite chunks:", err)
+							errCh <- err
+							return
+						}
+						log.Printf("%v Rewrote %v chunks into %v chunks\n", threadID, len(chks), len(output))
+					}
 					for retry := 4; retry >= 0; retry-- {
 						err = m.dest.Put(m.ctx, output)
 						if err != nil {
@@ -382,6 +452,13 @@ func (m *chunkMover) moveChunks(ctx context.Context, threadID int, syncRangeCh <
 					log.Println(threadID, "Batch sent successfully")
 				}
 			}
+			if m.checkpoint != nil {
+				if err := m.checkpoint.markDone(sr); err != nil {
+					log.Println(threadID, "Failed to record sync range in checkpoint file:", err)
+					errCh <- err
+					return
+				}
+			}
 			log.Printf("%v Finished processing sync range, %v chunks, %v bytes in %v seconds\n", threadID, totalChunks, totalBytes, time.Since(start).Seconds())
 			statsCh <- stats{
 				totalChunks: totalChunks,
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/util"
+)
+
+const (
+	nameLabel = "__name__"
+	logsValue = "logs"
+)
+
+// rewriteConfig describes how chunks are transformed while being migrated.
+// When it is empty chunks are copied verbatim.
+type rewriteConfig struct {
+	// reencode tells to compress rewritten chunks with encoding instead of the encoding of the source chunks.
+	reencode bool
+	encoding chunkenc.Encoding
+	// blockSize and targetSize control how rewritten chunks are cut, they have the same
+	// meaning as the ingester `chunk_block_size` and `chunk_target_size` settings.
+	blockSize  int
+	targetSize int
+	// pipeline filters and transforms log lines, nil means lines are kept as is.
+	pipeline log.Pipeline
+	// relabelConfigs are applied to the labels of each stream after the pipeline.
+	relabelConfigs []*relabel.Config
+}
+
+// enabled tells if chunks have to be decoded and rebuilt.
+func (c rewriteConfig) enabled() bool {
+	return c.reencode || c.pipeline != nil || len(c.relabelConfigs) > 0
+}
+
+// parsePipeline parses a LogQL pipeline such as `|= "error" | logfmt | level="error"`.
+// The stream selector is only used to build a valid expression and is never evaluated.
+func parsePipeline(pipeline string) (log.Pipeline, error) {
+	expr, err := logql.ParseLogSelector(fmt.Sprintf(`{%s=%q} %s`, nameLabel, logsValue, pipeline), false)
+	if err != nil {
+		return nil, err
+	}
+	return expr.Pipeline()
+}
+
+// loadRelabelConfigs loads a YAML file containing a list of Prometheus relabel configs.
+func loadRelabelConfigs(filename string) ([]*relabel.Config, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cfgs []*relabel.Config
+	if err := yaml.UnmarshalStrict(b, &cfgs); err != nil {
+		return nil, fmt.Errorf("failed to parse relabel configs %s: %w", filename, err)
+	}
+	return cfgs, nil
+}
+
+type rewriteStream struct {
+	labels   labels.Labels
+	encoding chunkenc.Encoding
+	entries  []logproto.Entry
+}
+
+// chunkRewriter decodes chunks, runs their entries through the pipeline and relabel rules
+// and re-encodes them in new chunks, merging chunks of the same stream up to the target size.
+type chunkRewriter struct {
+	cfg    rewriteConfig
+	userID string
+}
+
+func newChunkRewriter(cfg rewriteConfig, userID string) *chunkRewriter {
+	if cfg.pipeline == nil {
+		cfg.pipeline = log.NewNoopPipeline()
+	}
+	return &chunkRewriter{
+		cfg:    cfg,
+		userID: userID,
+	}
+}
+
+// rewrite returns the new set of chunks for the given chunks, which must have been fetched.
+// Entries are only merged across the given chunks, so bigger batches produce fewer chunks.
+func (r *chunkRewriter) rewrite(ctx context.Context, chks []chunk.Chunk) ([]chunk.Chunk, error) {
+	streams := map[uint64]*rewriteStream{}
+	// relabeled caches the relabeling result for every label set produced by the pipeline.
+	relabeled := map[uint64]labels.Labels{}
+
+	for _, c := range chks {
+		lbs := labels.NewBuilder(c.Metric).Del(nameLabel).Labels()
+		sp := r.cfg.pipeline.ForStream(lbs)
+		lokiChunk := c.Data.(*chunkenc.Facade).LokiChunk()
+		from, through := c.From.Time(), c.Through.Time().Add(time.Millisecond)
+		it, err := lokiChunk.Iterator(ctx, from, through, logproto.FORWARD, log.NewNoopPipeline().ForStream(lbs))
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			entry := it.Entry()
+			line, res, ok := sp.ProcessString(entry.Line)
+			if !ok {
+				continue
+			}
+			final, ok := relabeled[res.Hash()]
+			if !ok {
+				final = res.Labels()
+				if len(r.cfg.relabelConfigs) > 0 {
+					final = relabel.Process(final, r.cfg.relabelConfigs...)
+				}
+				relabeled[res.Hash()] = final
+			}
+			// Streams without labels left after relabeling are dropped.
+			if len(final) == 0 {
+				continue
+			}
+			hash := final.Hash()
+			s, ok := streams[hash]
+			if !ok {
+				s = &rewriteStream{labels: final, encoding: lokiChunk.Encoding()}
+				if r.cfg.reencode {
+					s.encoding = r.cfg.encoding
+				}
+				streams[hash] = s
+			}
+			s.entries = append(s.entries, logproto.Entry{Timestamp: entry.Timestamp, Line: line})
+		}
+		if err := it.Error(); err != nil {
+			_ = it.Close()
+			return nil, err
+		}
+		if err := it.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]chunk.Chunk, 0, len(streams))
+	for _, s := range streams {
+		cs, err := r.buildChunks(s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, cs...)
+	}
+	return result, nil
+}
+
+// buildChunks sorts the entries of a stream, removes exact duplicates coming from
+// overlapping source chunks and cuts them into chunks of the configured target size.
+func (r *chunkRewriter) buildChunks(s *rewriteStream) ([]chunk.Chunk, error) {
+	sort.SliceStable(s.entries, func(i, j int) bool {
+		return s.entries[i].Timestamp.Before(s.entries[j].Timestamp)
+	})
+
+	var (
+		result []chunk.Chunk
+		mc     *chunkenc.MemChunk
+		// The lines of the current timestamp, duplicates are not necessarily adjacent.
+		ts    time.Time
+		lines = map[string]struct{}{}
+	)
+	for i := range s.entries {
+		e := &s.entries[i]
+		if !e.Timestamp.Equal(ts) {
+			ts = e.Timestamp
+			lines = map[string]struct{}{}
+		}
+		if _, ok := lines[e.Line]; ok {
+			continue
+		}
+		lines[e.Line] = struct{}{}
+		if mc != nil && !mc.SpaceFor(e) {
+			c, err := r.toChunk(s.labels, mc)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, c)
+			mc = nil
+		}
+		if mc == nil {
+			mc = chunkenc.NewMemChunk(s.encoding, chunkenc.OrderedHeadBlockFmt, r.cfg.blockSize, r.cfg.targetSize)
+		}
+		if err := mc.Append(e); err != nil {
+			return nil, err
+		}
+	}
+	if mc != nil {
+		c, err := r.toChunk(s.labels, mc)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func (r *chunkRewriter) toChunk(lbs labels.Labels, mc *chunkenc.MemChunk) (chunk.Chunk, error) {
+	if err := mc.Close(); err != nil {
+		return chunk.Chunk{}, err
+	}
+	fp := model.Fingerprint(lbs.Hash())
+	metric := labels.NewBuilder(lbs).Set(nameLabel, logsValue).Labels()
+	from, through := util.RoundToMilliseconds(mc.Bounds())
+	c := chunk.NewChunk(r.userID, fp, metric, chunkenc.NewFacade(mc, r.cfg.blockSize, r.cfg.targetSize), from, through)
+	if err := c.Encode(); err != nil {
+		return chunk.Chunk{}, err
+	}
+	return c, nil
+}
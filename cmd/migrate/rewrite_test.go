@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/util"
+)
+
+// testStart is the timestamp of the first entry of test chunks.
+const testStart = 1000
+
+func newTestChunk(t *testing.T, enc chunkenc.Encoding, lbs string, from, through int) chunk.Chunk {
+	t.Helper()
+	metric, err := parseTestLabels(lbs)
+	require.NoError(t, err)
+	mc := chunkenc.NewMemChunk(enc, chunkenc.OrderedHeadBlockFmt, 256*1024, 0)
+	for i := from; i < through; i++ {
+		level := "info"
+		if i%2 == 0 {
+			level = "error"
+		}
+		require.NoError(t, mc.Append(&logproto.Entry{
+			Timestamp: time.Unix(testStart+int64(i), 0),
+			Line:      fmt.Sprintf("level=%s line=%d", level, i),
+		}))
+	}
+	require.NoError(t, mc.Close())
+	f, th := util.RoundToMilliseconds(mc.Bounds())
+	c := chunk.NewChunk("fake", model.Fingerprint(metric.Hash()), metric, chunkenc.NewFacade(mc, 256*1024, 0), f, th)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func parseTestLabels(lbs string) (labels.Labels, error) {
+	ls, err := logql.ParseLabels(lbs)
+	if err != nil {
+		return nil, err
+	}
+	return labels.NewBuilder(ls).Set(nameLabel, logsValue).Labels(), nil
+}
+
+func readEntries(t *testing.T, c chunk.Chunk) []logproto.Entry {
+	t.Helper()
+	it, err := c.Data.(*chunkenc.Facade).LokiChunk().Iterator(context.Background(), time.Unix(testStart, 0), time.Unix(testStart+1000, 0), logproto.FORWARD, log.NewNoopPipeline().ForStream(nil))
+	require.NoError(t, err)
+	defer it.Close()
+	var res []logproto.Entry
+	for it.Next() {
+		res = append(res, it.Entry())
+	}
+	require.NoError(t, it.Error())
+	return res
+}
+
+func Test_chunkRewriter_reencodeAndMerge(t *testing.T) {
+	rw := newChunkRewriter(rewriteConfig{
+		reencode:   true,
+		encoding:   chunkenc.EncZstd,
+		blockSize:  256 * 1024,
+		targetSize: 1572864,
+	}, "dest")
+
+	// The two first chunks overlap, duplicated entries must be removed when merging.
+	chks := []chunk.Chunk{
+		newTestChunk(t, chunkenc.EncGZIP, `{app="foo"}`, 0, 10),
+		newTestChunk(t, chunkenc.EncGZIP, `{app="foo"}`, 5, 20),
+		newTestChunk(t, chunkenc.EncSnappy, `{app="bar"}`, 0, 10),
+	}
+	res, err := rw.rewrite(context.Background(), chks)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	for _, c := range res {
+		require.Equal(t, "dest", c.UserID)
+		require.Equal(t, logsValue, c.Metric.Get(nameLabel))
+		lokiChunk := c.Data.(*chunkenc.Facade).LokiChunk()
+		require.Equal(t, chunkenc.EncZstd, lokiChunk.Encoding())
+
+		entries := readEntries(t, c)
+		switch c.Metric.Get("app") {
+		case "foo":
+			require.Len(t, entries, 20)
+			require.Equal(t, model.TimeFromUnix(testStart), c.From)
+			require.Equal(t, model.TimeFromUnix(testStart+19), c.Through)
+		case "bar":
+			require.Len(t, entries, 10)
+		default:
+			t.Fatalf("unexpected stream %s", c.Metric)
+		}
+		for i := 1; i < len(entries); i++ {
+			require.True(t, entries[i-1].Timestamp.Before(entries[i].Timestamp))
+		}
+	}
+}
+
+func Test_chunkRewriter_sameTimestampDuplicates(t *testing.T) {
+	rw := newChunkRewriter(rewriteConfig{
+		reencode:   true,
+		encoding:   chunkenc.EncGZIP,
+		blockSize:  256 * 1024,
+		targetSize: 1572864,
+	}, "dest")
+
+	metric, err := parseTestLabels(`{app="foo"}`)
+	require.NoError(t, err)
+	ts := time.Unix(testStart, 0)
+	newChunk := func(lines ...string) chunk.Chunk {
+		mc := chunkenc.NewMemChunk(chunkenc.EncGZIP, chunkenc.OrderedHeadBlockFmt, 256*1024, 0)
+		for _, line := range lines {
+			require.NoError(t, mc.Append(&logproto.Entry{Timestamp: ts, Line: line}))
+		}
+		require.NoError(t, mc.Close())
+		f, th := util.RoundToMilliseconds(mc.Bounds())
+		c := chunk.NewChunk("fake", model.Fingerprint(metric.Hash()), metric, chunkenc.NewFacade(mc, 256*1024, 0), f, th)
+		require.NoError(t, c.Encode())
+		return c
+	}
+
+	// Once merged the entries are interleaved: a, b, c, a, b.
+	res, err := rw.rewrite(context.Background(), []chunk.Chunk{newChunk("a", "b", "c"), newChunk("a", "b")})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, []logproto.Entry{
+		{Timestamp: ts, Line: "a"},
+		{Timestamp: ts, Line: "b"},
+		{Timestamp: ts, Line: "c"},
+	}, readEntries(t, res[0]))
+}
+
+func Test_chunkRewriter_targetSize(t *testing.T) {
+	rw := newChunkRewriter(rewriteConfig{
+		reencode:   true,
+		encoding:   chunkenc.EncGZIP,
+		blockSize:  100,
+		targetSize: 500,
+	}, "fake")
+	res, err := rw.rewrite(context.Background(), []chunk.Chunk{newTestChunk(t, chunkenc.EncGZIP, `{app="foo"}`, 0, 100)})
+	require.NoError(t, err)
+	require.True(t, len(res) > 1, "expected the chunk to be split, got %d chunks", len(res))
+
+	var total int
+	for _, c := range res {
+		total += len(readEntries(t, c))
+	}
+	require.Equal(t, 100, total)
+}
+
+func Test_chunkRewriter_pipelineAndRelabel(t *testing.T) {
+	p, err := parsePipeline(`|= "error" | logfmt | line_format "{{.line}}"`)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	relabelFile := filepath.Join(dir, "relabel.yaml")
+	require.NoError(t, ioutil.WriteFile(relabelFile, []byte(`
+- source_labels: [app]
+  regex: bar
+  action: drop
+- action: labeldrop
+  regex: line
+- source_labels: [app]
+  target_label: service
+- action: labeldrop
+  regex: app
+`), 0644))
+	relabelConfigs, err := loadRelabelConfigs(relabelFile)
+	require.NoError(t, err)
+
+	rw := newChunkRewriter(rewriteConfig{
+		blockSize:      256 * 1024,
+		targetSize:     1572864,
+		pipeline:       p,
+		relabelConfigs: relabelConfigs,
+	}, "fake")
+	require.True(t, rw.cfg.enabled())
+
+	res, err := rw.rewrite(context.Background(), []chunk.Chunk{
+		newTestChunk(t, chunkenc.EncSnappy, `{app="foo"}`, 0, 10),
+		newTestChunk(t, chunkenc.EncSnappy, `{app="bar"}`, 0, 10),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	c := res[0]
+	require.Equal(t, `{__name__="logs", level="error", service="foo"}`, c.Metric.String())
+	// Without re-encoding the encoding of the source chunk is kept.
+	require.Equal(t, chunkenc.EncSnappy, c.Data.(*chunkenc.Facade).LokiChunk().Encoding())
+	entries := readEntries(t, c)
+	require.Len(t, entries, 5)
+	for i, e := range entries {
+		require.Equal(t, fmt.Sprintf("%d", i*2), e.Line)
+	}
+}
+
+func Test_rewriteConfig_enabled(t *testing.T) {
+	require.False(t, rewriteConfig{blockSize: 1, targetSize: 1}.enabled())
+	require.True(t, rewriteConfig{reencode: true}.enabled())
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
@@ -11,15 +13,15 @@ import (
 	"github.com/weaveworks/common/server"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
-	"github.com/cortexproject/cortex/tools/querytee"
 
-	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/tools/querytee"
 )
 
 type Config struct {
 	ServerMetricsPort int
 	LogLevel          logging.Level
 	ProxyConfig       querytee.ProxyConfig
+	ComparisonConfig  ComparisonConfig
 }
 
 func main() {
@@ -28,6 +30,7 @@ func main() {
 	flag.IntVar(&cfg.ServerMetricsPort, "server.metrics-port", 9900, "The port where metrics are exposed.")
 	cfg.LogLevel.RegisterFlags(flag.CommandLine)
 	cfg.ProxyConfig.RegisterFlags(flag.CommandLine)
+	cfg.ComparisonConfig.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
 	util_log.InitLogger(&server.Config{
@@ -45,7 +48,7 @@ func main() {
 	}
 
 	// Run the proxy.
-	proxy, err := querytee.NewProxy(cfg.ProxyConfig, util_log.Logger, lokiReadRoutes(cfg), registry)
+	proxy, err := querytee.NewProxy(cfg.ProxyConfig, util_log.Logger, lokiReadRoutes(cfg), lokiWriteRoutes(), registry)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "Unable to initialize the proxy", "err", err.Error())
 		os.Exit(1)
@@ -56,23 +59,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Stop the proxy on termination, the queued shadow write requests are forwarded before exiting.
+	go func() {
+		terminate := make(chan os.Signal, 1)
+		signal.Notify(terminate, syscall.SIGTERM, os.Interrupt)
+		<-terminate
+		level.Info(util_log.Logger).Log("msg", "Shutting down the proxy")
+		if err := proxy.Stop(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "Unable to stop the proxy", "err", err.Error())
+		}
+	}()
+
 	proxy.Await()
 }
 
 func lokiReadRoutes(cfg Config) []querytee.Route {
-	samplesComparator := querytee.NewSamplesComparator(cfg.ProxyConfig.ValueComparisonTolerance)
-	samplesComparator.RegisterSamplesType(loghttp.ResultTypeStream, compareStreams)
+	samplesComparator := newQueryComparator(cfg.ComparisonConfig, cfg.ProxyConfig.ValueComparisonTolerance)
+	labelsComparator := &labelsComparator{ignoreOrder: cfg.ComparisonConfig.IgnoreLabelOrder}
+	legacyLabelsComparator := &legacyLabelsComparator{ignoreOrder: cfg.ComparisonConfig.IgnoreLabelOrder}
+	seriesComparator := &seriesComparator{ignoreOrder: cfg.ComparisonConfig.IgnoreLabelOrder}
 
 	return []querytee.Route{
 		{Path: "/loki/api/v1/query_range", RouteName: "api_v1_query_range", Methods: []string{"GET"}, ResponseComparator: samplesComparator},
 		{Path: "/loki/api/v1/query", RouteName: "api_v1_query", Methods: []string{"GET"}, ResponseComparator: samplesComparator},
-		{Path: "/loki/api/v1/label", RouteName: "api_v1_label", Methods: []string{"GET"}, ResponseComparator: nil},
-		{Path: "/loki/api/v1/labels", RouteName: "api_v1_labels", Methods: []string{"GET"}, ResponseComparator: nil},
-		{Path: "/loki/api/v1/label/{name}/values", RouteName: "api_v1_label_name_values", Methods: []string{"GET"}, ResponseComparator: nil},
-		{Path: "/loki/api/v1/series", RouteName: "api_v1_series", Methods: []string{"GET"}, ResponseComparator: nil},
+		{Path: "/loki/api/v1/label", RouteName: "api_v1_label", Methods: []string{"GET"}, ResponseComparator: labelsComparator},
+		{Path: "/loki/api/v1/labels", RouteName: "api_v1_labels", Methods: []string{"GET"}, ResponseComparator: labelsComparator},
+		{Path: "/loki/api/v1/label/{name}/values", RouteName: "api_v1_label_name_values", Methods: []string{"GET"}, ResponseComparator: labelsComparator},
+		{Path: "/loki/api/v1/series", RouteName: "api_v1_series", Methods: []string{"GET"}, ResponseComparator: seriesComparator},
 		{Path: "/api/prom/query", RouteName: "api_prom_query", Methods: []string{"GET"}, ResponseComparator: samplesComparator},
-		{Path: "/api/prom/label", RouteName: "api_prom_label", Methods: []string{"GET"}, ResponseComparator: nil},
-		{Path: "/api/prom/label/{name}/values", RouteName: "api_prom_label_name_values", Methods: []string{"GET"}, ResponseComparator: nil},
-		{Path: "/api/prom/series", RouteName: "api_prom_series", Methods: []string{"GET"}, ResponseComparator: nil},
+		{Path: "/api/prom/label", RouteName: "api_prom_label", Methods: []string{"GET"}, ResponseComparator: legacyLabelsComparator},
+		{Path: "/api/prom/label/{name}/values", RouteName: "api_prom_label_name_values", Methods: []string{"GET"}, ResponseComparator: legacyLabelsComparator},
+		{Path: "/api/prom/series", RouteName: "api_prom_series", Methods: []string{"GET"}, ResponseComparator: seriesComparator},
+	}
+}
+
+func lokiWriteRoutes() []querytee.Route {
+	return []querytee.Route{
+		{Path: "/loki/api/v1/push", RouteName: "api_v1_push", Methods: []string{"POST"}, ResponseComparator: nil},
+		{Path: "/api/prom/push", RouteName: "api_prom_push", Methods: []string{"POST"}, ResponseComparator: nil},
 	}
 }
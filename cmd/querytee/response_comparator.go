@@ -2,16 +2,108 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"sort"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/kit/log/level"
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/tools/querytee"
 )
 
-func compareStreams(expectedRaw, actualRaw json.RawMessage, tolerance float64) error {
+// ComparisonConfig holds the rules applied when comparing the responses of the backends.
+type ComparisonConfig struct {
+	IgnoreEntryOrder bool
+	IgnoreLabelOrder bool
+	IgnoreStats      bool
+	MatrixTolerance  float64
+	VectorTolerance  float64
+	ScalarTolerance  float64
+}
+
+func (cfg *ComparisonConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.IgnoreEntryOrder, "comparison.ignore-entry-order", true, "Compare the entries of the streams regardless of their order, the entries with the same timestamp can be returned in any order.")
+	f.BoolVar(&cfg.IgnoreLabelOrder, "comparison.ignore-label-order", true, "Compare the label names, label values and series regardless of their order.")
+	f.BoolVar(&cfg.IgnoreStats, "comparison.ignore-stats", true, "Ignore the statistics of the query responses, otherwise the total lines and bytes processed must match.")
+	f.Float64Var(&cfg.MatrixTolerance, "comparison.matrix-tolerance", -1, "The tolerance to apply when comparing the values of matrix responses. -1 to use -proxy.value-comparison-tolerance.")
+	f.Float64Var(&cfg.VectorTolerance, "comparison.vector-tolerance", -1, "The tolerance to apply when comparing the values of vector responses. -1 to use -proxy.value-comparison-tolerance.")
+	f.Float64Var(&cfg.ScalarTolerance, "comparison.scalar-tolerance", -1, "The tolerance to apply when comparing the values of scalar responses. -1 to use -proxy.value-comparison-tolerance.")
+}
+
+// newQueryComparator returns the comparator of the query responses.
+func newQueryComparator(cfg ComparisonConfig, tolerance float64) querytee.ResponsesComparator {
+	samplesComparator := querytee.NewSamplesComparator(tolerance)
+	samplesComparator.RegisterSamplesType(loghttp.ResultTypeStream, newStreamsComparator(cfg.IgnoreEntryOrder))
+	for resultType, typeTolerance := range map[string]float64{
+		loghttp.ResultTypeMatrix: cfg.MatrixTolerance,
+		loghttp.ResultTypeVector: cfg.VectorTolerance,
+		loghttp.ResultTypeScalar: cfg.ScalarTolerance,
+	} {
+		if typeTolerance >= 0 {
+			samplesComparator.SetTolerance(resultType, typeTolerance)
+		}
+	}
+
+	return &queryComparator{samples: samplesComparator, compareStats: !cfg.IgnoreStats}
+}
+
+// queryComparator compares the results of the queries, and the totals of their statistics unless they are ignored.
+type queryComparator struct {
+	samples      *querytee.SamplesComparator
+	compareStats bool
+}
+
+func (c *queryComparator) Compare(expected, actual []byte) error {
+	if err := c.samples.Compare(expected, actual); err != nil {
+		return err
+	}
+	if !c.compareStats {
+		return nil
+	}
+	return compareStats(expected, actual)
+}
+
+func compareStats(expectedRaw, actualRaw []byte) error {
+	var expected, actual struct {
+		Data struct {
+			Statistics stats.Result `json:"stats"`
+		} `json:"data"`
+	}
+
+	err := jsoniter.Unmarshal(expectedRaw, &expected)
+	if err != nil {
+		return err
+	}
+	err = jsoniter.Unmarshal(actualRaw, &actual)
+	if err != nil {
+		return err
+	}
+
+	expectedSummary, actualSummary := expected.Data.Statistics.Summary, actual.Data.Statistics.Summary
+	if expectedSummary.TotalLinesProcessed != actualSummary.TotalLinesProcessed {
+		return fmt.Errorf("expected %d total lines processed but got %d", expectedSummary.TotalLinesProcessed, actualSummary.TotalLinesProcessed)
+	}
+	if expectedSummary.TotalBytesProcessed != actualSummary.TotalBytesProcessed {
+		return fmt.Errorf("expected %d total bytes processed but got %d", expectedSummary.TotalBytesProcessed, actualSummary.TotalBytesProcessed)
+	}
+
+	return nil
+}
+
+// newStreamsComparator returns the comparator of the streams results, which sorts the entries of the streams
+// before comparing them when ignoreEntryOrder is set.
+func newStreamsComparator(ignoreEntryOrder bool) querytee.SamplesComparatorFunc {
+	return func(expected, actual json.RawMessage, _ float64) error {
+		return compareStreamsWithOrder(expected, actual, ignoreEntryOrder)
+	}
+}
+
+func compareStreamsWithOrder(expectedRaw, actualRaw json.RawMessage, ignoreEntryOrder bool) error {
 	var expected, actual loghttp.Streams
 
 	err := jsoniter.Unmarshal(expectedRaw, &expected)
@@ -39,6 +131,10 @@ func compareStreams(expectedRaw, actualRaw json.RawMessage, tolerance float64) e
 		}
 
 		actualStream := actual[actualStreamIndex]
+		if ignoreEntryOrder {
+			sortEntries(expectedStream.Entries)
+			sortEntries(actualStream.Entries)
+		}
 		expectedValuesLen := len(expectedStream.Entries)
 		actualValuesLen := len(actualStream.Entries)
 
@@ -68,3 +164,108 @@ func compareStreams(expectedRaw, actualRaw json.RawMessage, tolerance float64) e
 
 	return nil
 }
+
+func sortEntries(entries []loghttp.Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Timestamp.Equal(entries[j].Timestamp) {
+			return entries[i].Line < entries[j].Line
+		}
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+}
+
+// labelsComparator compares the label names or label values responses.
+type labelsComparator struct {
+	ignoreOrder bool
+}
+
+func (c *labelsComparator) Compare(expectedRaw, actualRaw []byte) error {
+	var expected, actual loghttp.LabelResponse
+
+	err := jsoniter.Unmarshal(expectedRaw, &expected)
+	if err != nil {
+		return err
+	}
+	err = jsoniter.Unmarshal(actualRaw, &actual)
+	if err != nil {
+		return err
+	}
+
+	if expected.Status != actual.Status {
+		return fmt.Errorf("expected status %s but got %s", expected.Status, actual.Status)
+	}
+
+	return compareStrings("labels", expected.Data, actual.Data, c.ignoreOrder)
+}
+
+// legacyLabelsComparator compares the label names or label values responses of the legacy
+// /api/prom endpoints, which only have the values.
+type legacyLabelsComparator struct {
+	ignoreOrder bool
+}
+
+func (c *legacyLabelsComparator) Compare(expectedRaw, actualRaw []byte) error {
+	var expected, actual logproto.LabelResponse
+
+	err := jsoniter.Unmarshal(expectedRaw, &expected)
+	if err != nil {
+		return err
+	}
+	err = jsoniter.Unmarshal(actualRaw, &actual)
+	if err != nil {
+		return err
+	}
+
+	return compareStrings("labels", expected.Values, actual.Values, c.ignoreOrder)
+}
+
+// seriesComparator compares the series responses.
+type seriesComparator struct {
+	ignoreOrder bool
+}
+
+func (c *seriesComparator) Compare(expectedRaw, actualRaw []byte) error {
+	var expected, actual loghttp.SeriesResponse
+
+	err := jsoniter.Unmarshal(expectedRaw, &expected)
+	if err != nil {
+		return err
+	}
+	err = jsoniter.Unmarshal(actualRaw, &actual)
+	if err != nil {
+		return err
+	}
+
+	if expected.Status != actual.Status {
+		return fmt.Errorf("expected status %s but got %s", expected.Status, actual.Status)
+	}
+
+	return compareStrings("series", labelSetsToStrings(expected.Data), labelSetsToStrings(actual.Data), c.ignoreOrder)
+}
+
+func labelSetsToStrings(sets []loghttp.LabelSet) []string {
+	res := make([]string, 0, len(sets))
+	for _, set := range sets {
+		res = append(res, set.String())
+	}
+	return res
+}
+
+func compareStrings(kind string, expected, actual []string, ignoreOrder bool) error {
+	if len(expected) != len(actual) {
+		return fmt.Errorf("expected %d %s but got %d", len(expected), kind, len(actual))
+	}
+
+	if ignoreOrder {
+		sort.Strings(expected)
+		sort.Strings(actual)
+	}
+
+	for i := range expected {
+		if expected[i] != actual[i] {
+			return fmt.Errorf("expected %s %s at position %d but got %s", kind, expected[i], i, actual[i])
+		}
+	}
+
+	return nil
+}
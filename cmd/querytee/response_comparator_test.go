@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -90,7 +91,7 @@ func TestCompareStreams(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			err := compareStreams(tc.expected, tc.actual, 0)
+			err := compareStreamsWithOrder(tc.expected, tc.actual, false)
 			if tc.err == nil {
 				require.NoError(t, err)
 				return
@@ -100,3 +101,103 @@ func TestCompareStreams(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareStreams_IgnoreEntryOrder(t *testing.T) {
+	expected := json.RawMessage(`[
+					{"stream":{"foo":"bar"},"values":[["2","b"],["1","a"],["2","a"]]}
+				]`)
+	actual := json.RawMessage(`[
+					{"stream":{"foo":"bar"},"values":[["2","a"],["2","b"],["1","a"]]}
+				]`)
+
+	require.Error(t, newStreamsComparator(false)(expected, actual, 0))
+	require.NoError(t, newStreamsComparator(true)(expected, actual, 0))
+
+	actual = json.RawMessage(`[
+					{"stream":{"foo":"bar"},"values":[["2","a"],["2","c"],["1","a"]]}
+				]`)
+	err := newStreamsComparator(true)(expected, actual, 0)
+	require.Error(t, err)
+	require.Equal(t, "expected line b for timestamp 2 but got c for stream {foo=\"bar\"}", err.Error())
+}
+
+func TestQueryComparator(t *testing.T) {
+	response := func(value string, lines int) []byte {
+		return []byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"foo":"bar"},"value":[1,"` + value + `"]}],` +
+			`"stats":{"summary":{"totalLinesProcessed":` + strconv.Itoa(lines) + `,"totalBytesProcessed":100}}}}`)
+	}
+
+	cfg := ComparisonConfig{IgnoreStats: true, MatrixTolerance: -1, VectorTolerance: -1, ScalarTolerance: -1}
+	require.NoError(t, newQueryComparator(cfg, 0.1).Compare(response("1", 10), response("1.05", 20)))
+	require.Error(t, newQueryComparator(cfg, 0.01).Compare(response("1", 10), response("1.05", 10)))
+
+	// the tolerance of the result type overrides the default one.
+	cfg.VectorTolerance = 0.1
+	require.NoError(t, newQueryComparator(cfg, 0.01).Compare(response("1", 10), response("1.05", 10)))
+	// and only applies to its result type.
+	cfg.MatrixTolerance = 0
+	require.NoError(t, newQueryComparator(cfg, 0.01).Compare(response("1", 10), response("1.05", 10)))
+
+	cfg.IgnoreStats = false
+	require.NoError(t, newQueryComparator(cfg, 0.1).Compare(response("1", 10), response("1", 10)))
+	err := newQueryComparator(cfg, 0.1).Compare(response("1", 10), response("1", 20))
+	require.Error(t, err)
+	require.Equal(t, "expected 10 total lines processed but got 20", err.Error())
+}
+
+func TestLabelsComparator(t *testing.T) {
+	expected := []byte(`{"status":"success","data":["foo","bar"]}`)
+	actual := []byte(`{"status":"success","data":["bar","foo"]}`)
+
+	require.NoError(t, (&labelsComparator{ignoreOrder: true}).Compare(expected, actual))
+	err := (&labelsComparator{ignoreOrder: false}).Compare(expected, actual)
+	require.Error(t, err)
+	require.Equal(t, "expected labels foo at position 0 but got bar", err.Error())
+
+	err = (&labelsComparator{ignoreOrder: true}).Compare(expected, []byte(`{"status":"success","data":["foo"]}`))
+	require.Error(t, err)
+	require.Equal(t, "expected 2 labels but got 1", err.Error())
+}
+
+func TestLegacyLabelsComparator(t *testing.T) {
+	expected := []byte(`{"values":["foo","bar"]}`)
+	actual := []byte(`{"values":["bar","foo"]}`)
+
+	require.NoError(t, (&legacyLabelsComparator{ignoreOrder: true}).Compare(expected, actual))
+	err := (&legacyLabelsComparator{ignoreOrder: false}).Compare(expected, actual)
+	require.Error(t, err)
+	require.Equal(t, "expected labels foo at position 0 but got bar", err.Error())
+
+	err = (&legacyLabelsComparator{ignoreOrder: true}).Compare(expected, []byte(`{"values":["foo","baz"]}`))
+	require.Error(t, err)
+	require.Equal(t, "expected labels bar at position 0 but got baz", err.Error())
+
+	err = (&legacyLabelsComparator{ignoreOrder: true}).Compare(expected, []byte(`{}`))
+	require.Error(t, err)
+	require.Equal(t, "expected 2 labels but got 0", err.Error())
+}
+
+func TestLokiReadRoutes_LegacyLabels(t *testing.T) {
+	legacyPaths := 0
+	for _, route := range lokiReadRoutes(Config{}) {
+		switch route.Path {
+		case "/api/prom/label", "/api/prom/label/{name}/values":
+			legacyPaths++
+			require.IsType(t, &legacyLabelsComparator{}, route.ResponseComparator)
+			require.Error(t, route.ResponseComparator.Compare([]byte(`{"values":["foo"]}`), []byte(`{"values":["bar"]}`)))
+		}
+	}
+	require.Equal(t, 2, legacyPaths)
+}
+
+func TestSeriesComparator(t *testing.T) {
+	expected := []byte(`{"status":"success","data":[{"foo":"bar","app":"a"},{"foo":"baz"}]}`)
+	actual := []byte(`{"status":"success","data":[{"foo":"baz"},{"app":"a","foo":"bar"}]}`)
+
+	require.NoError(t, (&seriesComparator{ignoreOrder: true}).Compare(expected, actual))
+	require.Error(t, (&seriesComparator{ignoreOrder: false}).Compare(expected, actual))
+
+	err := (&seriesComparator{ignoreOrder: true}).Compare(expected, []byte(`{"status":"success","data":[{"foo":"baz"},{"app":"b","foo":"bar"}]}`))
+	require.Error(t, err)
+	require.Equal(t, "expected series {app=\"a\", foo=\"bar\"} at position 0 but got {app=\"b\", foo=\"bar\"}", err.Error())
+}
@@ -26,56 +26,60 @@ const (
 )
 
 var (
-	totalEntries = promauto.NewCounter(prometheus.CounterOpts{
+	// streamLabels are the tenant and the ID of the stream of the entries, they are empty when the
+	// canary writes a single stream without tenant.
+	streamLabels = []string{"tenant", "stream"}
+
+	totalEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "entries_total",
 		Help:      "counts log entries written to the file",
-	})
-	outOfOrderEntries = promauto.NewCounter(prometheus.CounterOpts{
+	}, streamLabels)
+	outOfOrderEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "out_of_order_entries_total",
 		Help:      "counts log entries received with a timestamp more recent than the others in the queue",
-	})
-	wsMissingEntries = promauto.NewCounter(prometheus.CounterOpts{
+	}, streamLabels)
+	wsMissingEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "websocket_missing_entries_total",
 		Help:      "counts log entries not received within the wait duration via the websocket connection",
-	})
-	missingEntries = promauto.NewCounter(prometheus.CounterOpts{
+	}, streamLabels)
+	missingEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "missing_entries_total",
 		Help:      "counts log entries not received within the maxWait duration via both websocket and direct query",
-	})
-	spotCheckMissing = promauto.NewCounter(prometheus.CounterOpts{
+	}, streamLabels)
+	spotCheckMissing = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "spot_check_missing_entries_total",
 		Help:      "counts log entries not received when directly queried as part of spot checking",
-	})
-	spotCheckEntries = promauto.NewCounter(prometheus.CounterOpts{
+	}, streamLabels)
+	spotCheckEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "spot_check_entries_total",
 		Help:      "total count of entries pot checked",
-	})
-	unexpectedEntries = promauto.NewCounter(prometheus.CounterOpts{
+	}, streamLabels)
+	unexpectedEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "unexpected_entries_total",
 		Help:      "counts a log entry received which was not expected (e.g. received after reported missing)",
-	})
-	duplicateEntries = promauto.NewCounter(prometheus.CounterOpts{
+	}, streamLabels)
+	duplicateEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "loki_canary",
 		Name:      "duplicate_entries_total",
 		Help:      "counts a log entry received more than one time",
-	})
-	metricTestExpected = promauto.NewGauge(prometheus.GaugeOpts{
+	}, streamLabels)
+	metricTestExpected = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "loki_canary",
 		Name:      "metric_test_expected",
 		Help:      "How many counts were expected by the metric test query",
-	})
-	metricTestActual = promauto.NewGauge(prometheus.GaugeOpts{
+	}, streamLabels)
+	metricTestActual = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "loki_canary",
 		Name:      "metric_test_actual",
 		Help:      "How many counts were actually received by the metric test query",
-	})
+	}, streamLabels)
 	responseLatency   prometheus.Histogram
 	metricTestLatency = promauto.NewHistogram(prometheus.HistogramOpts{
 		Namespace: "loki_canary",
@@ -91,6 +95,35 @@ var (
 	})
 )
 
+// streamMetrics are the metrics of the stream of a comparator.
+type streamMetrics struct {
+	totalEntries       prometheus.Counter
+	outOfOrderEntries  prometheus.Counter
+	wsMissingEntries   prometheus.Counter
+	missingEntries     prometheus.Counter
+	spotCheckMissing   prometheus.Counter
+	spotCheckEntries   prometheus.Counter
+	unexpectedEntries  prometheus.Counter
+	duplicateEntries   prometheus.Counter
+	metricTestExpected prometheus.Gauge
+	metricTestActual   prometheus.Gauge
+}
+
+func newStreamMetrics(tenant, stream string) streamMetrics {
+	return streamMetrics{
+		totalEntries:       totalEntries.WithLabelValues(tenant, stream),
+		outOfOrderEntries:  outOfOrderEntries.WithLabelValues(tenant, stream),
+		wsMissingEntries:   wsMissingEntries.WithLabelValues(tenant, stream),
+		missingEntries:     missingEntries.WithLabelValues(tenant, stream),
+		spotCheckMissing:   spotCheckMissing.WithLabelValues(tenant, stream),
+		spotCheckEntries:   spotCheckEntries.WithLabelValues(tenant, stream),
+		unexpectedEntries:  unexpectedEntries.WithLabelValues(tenant, stream),
+		duplicateEntries:   duplicateEntries.WithLabelValues(tenant, stream),
+		metricTestExpected: metricTestExpected.WithLabelValues(tenant, stream),
+		metricTestActual:   metricTestActual.WithLabelValues(tenant, stream),
+	}
+}
+
 type Comparator struct {
 	entMtx              sync.Mutex // Locks access to []entries and []ackdEntries
 	missingMtx          sync.Mutex // Locks access to []missingEntries
@@ -121,6 +154,7 @@ type Comparator struct {
 	sent                chan time.Time
 	recv                chan time.Time
 	rdr                 reader.LokiReader
+	metrics             streamMetrics
 	quit                chan struct{}
 	done                chan struct{}
 }
@@ -137,7 +171,8 @@ func NewComparator(writer io.Writer,
 	sentChan chan time.Time,
 	receivedChan chan time.Time,
 	reader reader.LokiReader,
-	confirmAsync bool) *Comparator {
+	confirmAsync bool,
+	tenant, stream string) *Comparator {
 	c := &Comparator{
 		w:                   writer,
 		entries:             []*time.Time{},
@@ -160,6 +195,7 @@ func NewComparator(writer io.Writer,
 		sent:                sentChan,
 		recv:                receivedChan,
 		rdr:                 reader,
+		metrics:             newStreamMetrics(tenant, stream),
 		quit:                make(chan struct{}),
 		done:                make(chan struct{}),
 	}
@@ -189,7 +225,7 @@ func (c *Comparator) Stop() {
 func (c *Comparator) entrySent(time time.Time) {
 	c.entMtx.Lock()
 	c.entries = append(c.entries, &time)
-	totalEntries.Inc()
+	c.metrics.totalEntries.Inc()
 	c.entMtx.Unlock()
 	//If this entry equals or exceeds the spot check interval from the last entry in the spot check array, add it.
 	c.spotEntMtx.Lock()
@@ -214,7 +250,7 @@ func (c *Comparator) entryReceived(ts time.Time) {
 			matched = true
 			// If this isn't the first item in the list we received it out of order
 			if i != 0 {
-				outOfOrderEntries.Inc()
+				c.metrics.outOfOrderEntries.Inc()
 				fmt.Fprintf(c.w, ErrOutOfOrderEntry, t, c.entries[:i])
 			}
 			responseLatency.Observe(time.Since(ts).Seconds())
@@ -227,14 +263,14 @@ func (c *Comparator) entryReceived(ts time.Time) {
 		for _, e := range c.ackdEntries {
 			if ts.Equal(*e) {
 				duplicate = true
-				duplicateEntries.Inc()
+				c.metrics.duplicateEntries.Inc()
 				fmt.Fprintf(c.w, ErrDuplicateEntry, ts.UnixNano())
 				break
 			}
 		}
 		if !duplicate {
 			fmt.Fprintf(c.w, ErrUnexpectedEntry, ts.UnixNano())
-			unexpectedEntries.Inc()
+			c.metrics.unexpectedEntries.Inc()
 		}
 	}
 }
@@ -315,8 +351,8 @@ func (c *Comparator) metricTest(currTime time.Time) {
 		return
 	}
 	expectedCount := float64(adjustedRange.Milliseconds()) / float64(c.writeInterval.Milliseconds())
-	metricTestExpected.Set(expectedCount)
-	metricTestActual.Set(actualCount)
+	c.metrics.metricTestExpected.Set(expectedCount)
+	c.metrics.metricTestActual.Set(actualCount)
 }
 
 func (c *Comparator) spotCheckEntries(currTime time.Time) {
@@ -347,7 +383,7 @@ func (c *Comparator) spotCheckEntries(currTime time.Time) {
 		if currTime.Sub(*sce) < c.spotCheckWait {
 			continue
 		}
-		spotCheckEntries.Inc()
+		c.metrics.spotCheckEntries.Inc()
 		// Because we are querying loki timestamps vs the timestamp in the log,
 		// make the range +/- 10 seconds to allow for clock inaccuracies
 		start := *sce
@@ -373,7 +409,7 @@ func (c *Comparator) spotCheckEntries(currTime time.Time) {
 			for _, r := range recvd {
 				fmt.Fprintf(c.w, DebugQueryResult, r.UnixNano())
 			}
-			spotCheckMissing.Inc()
+			c.metrics.spotCheckMissing.Inc()
 		}
 	}
 
@@ -397,7 +433,7 @@ func (c *Comparator) pruneEntries(currentTime time.Time) {
 		},
 		func(_ int, t *time.Time) {
 			missing = append(missing, t)
-			wsMissingEntries.Inc()
+			c.metrics.wsMissingEntries.Inc()
 			fmt.Fprintf(c.w, ErrEntryNotReceivedWs, t.UnixNano(), c.wait.Seconds())
 		})
 
@@ -487,7 +523,7 @@ func (c *Comparator) confirmMissing(currentTime time.Time) {
 
 	// Record the entries which were removed and never received
 	for _, e := range removed {
-		missingEntries.Inc()
+		c.metrics.missingEntries.Inc()
 		fmt.Fprintf(c.w, ErrEntryNotReceived, e.UnixNano(), c.maxWait.Seconds())
 	}
 }
@@ -8,18 +8,15 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	io_prometheus_client "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestComparatorEntryReceivedOutOfOrder(t *testing.T) {
-	outOfOrderEntries = &mockCounter{}
-	wsMissingEntries = &mockCounter{}
-	unexpectedEntries = &mockCounter{}
-	duplicateEntries = &mockCounter{}
-
 	actual := &bytes.Buffer{}
-	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 1*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false)
+	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 1*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false, "", "")
+	mockMetrics(c)
 
 	t1 := time.Now()
 	t2 := t1.Add(1 * time.Second)
@@ -42,10 +39,10 @@ func TestComparatorEntryReceivedOutOfOrder(t *testing.T) {
 	expected := fmt.Sprintf(ErrOutOfOrderEntry, t4, []time.Time{t2, t3})
 	assert.Equal(t, expected, actual.String())
 
-	assert.Equal(t, 1, outOfOrderEntries.(*mockCounter).count)
-	assert.Equal(t, 0, unexpectedEntries.(*mockCounter).count)
-	assert.Equal(t, 0, wsMissingEntries.(*mockCounter).count)
-	assert.Equal(t, 0, duplicateEntries.(*mockCounter).count)
+	assert.Equal(t, 1, c.metrics.outOfOrderEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.unexpectedEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.wsMissingEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.duplicateEntries.(*mockCounter).count)
 
 	// This avoids a panic on subsequent test execution,
 	// seems ugly but was easy, and multiple instantiations
@@ -54,13 +51,9 @@ func TestComparatorEntryReceivedOutOfOrder(t *testing.T) {
 }
 
 func TestComparatorEntryReceivedNotExpected(t *testing.T) {
-	outOfOrderEntries = &mockCounter{}
-	wsMissingEntries = &mockCounter{}
-	unexpectedEntries = &mockCounter{}
-	duplicateEntries = &mockCounter{}
-
 	actual := &bytes.Buffer{}
-	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 1*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false)
+	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 1*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false, "", "")
+	mockMetrics(c)
 
 	t1 := time.Now()
 	t2 := t1.Add(1 * time.Second)
@@ -83,10 +76,10 @@ func TestComparatorEntryReceivedNotExpected(t *testing.T) {
 	expected := fmt.Sprintf(ErrUnexpectedEntry, t1.UnixNano())
 	assert.Equal(t, expected, actual.String())
 
-	assert.Equal(t, 0, outOfOrderEntries.(*mockCounter).count)
-	assert.Equal(t, 1, unexpectedEntries.(*mockCounter).count)
-	assert.Equal(t, 0, wsMissingEntries.(*mockCounter).count)
-	assert.Equal(t, 0, duplicateEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.outOfOrderEntries.(*mockCounter).count)
+	assert.Equal(t, 1, c.metrics.unexpectedEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.wsMissingEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.duplicateEntries.(*mockCounter).count)
 
 	// This avoids a panic on subsequent test execution,
 	// seems ugly but was easy, and multiple instantiations
@@ -95,13 +88,9 @@ func TestComparatorEntryReceivedNotExpected(t *testing.T) {
 }
 
 func TestComparatorEntryReceivedDuplicate(t *testing.T) {
-	outOfOrderEntries = &mockCounter{}
-	wsMissingEntries = &mockCounter{}
-	unexpectedEntries = &mockCounter{}
-	duplicateEntries = &mockCounter{}
-
 	actual := &bytes.Buffer{}
-	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 1*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false)
+	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 1*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false, "", "")
+	mockMetrics(c)
 
 	t1 := time.Unix(0, 0)
 	t2 := t1.Add(1 * time.Second)
@@ -127,10 +116,10 @@ func TestComparatorEntryReceivedDuplicate(t *testing.T) {
 	expected := fmt.Sprintf(ErrDuplicateEntry, t2.UnixNano())
 	assert.Equal(t, expected, actual.String())
 
-	assert.Equal(t, 0, outOfOrderEntries.(*mockCounter).count)
-	assert.Equal(t, 0, unexpectedEntries.(*mockCounter).count)
-	assert.Equal(t, 0, wsMissingEntries.(*mockCounter).count)
-	assert.Equal(t, 1, duplicateEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.outOfOrderEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.unexpectedEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.wsMissingEntries.(*mockCounter).count)
+	assert.Equal(t, 1, c.metrics.duplicateEntries.(*mockCounter).count)
 
 	// This avoids a panic on subsequent test execution,
 	// seems ugly but was easy, and multiple instantiations
@@ -139,12 +128,6 @@ func TestComparatorEntryReceivedDuplicate(t *testing.T) {
 }
 
 func TestEntryNeverReceived(t *testing.T) {
-	outOfOrderEntries = &mockCounter{}
-	wsMissingEntries = &mockCounter{}
-	missingEntries = &mockCounter{}
-	unexpectedEntries = &mockCounter{}
-	duplicateEntries = &mockCounter{}
-
 	actual := &bytes.Buffer{}
 
 	t1 := time.Unix(10, 0)
@@ -159,7 +142,8 @@ func TestEntryNeverReceived(t *testing.T) {
 	wait := 60 * time.Second
 	maxWait := 300 * time.Second
 	//We set the prune interval timer to a huge value here so that it never runs, instead we call pruneEntries manually below
-	c := NewComparator(actual, wait, maxWait, 50*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), mr, false)
+	c := NewComparator(actual, wait, maxWait, 50*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), mr, false, "", "")
+	mockMetrics(c)
 
 	c.entrySent(t1)
 	c.entrySent(t2)
@@ -205,11 +189,11 @@ func TestEntryNeverReceived(t *testing.T) {
 	assert.Equal(t, expected, actual.String())
 	assert.Equal(t, 0, c.Size())
 
-	assert.Equal(t, 2, outOfOrderEntries.(*mockCounter).count)
-	assert.Equal(t, 0, unexpectedEntries.(*mockCounter).count)
-	assert.Equal(t, 2, wsMissingEntries.(*mockCounter).count)
-	assert.Equal(t, 1, missingEntries.(*mockCounter).count)
-	assert.Equal(t, 0, duplicateEntries.(*mockCounter).count)
+	assert.Equal(t, 2, c.metrics.outOfOrderEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.unexpectedEntries.(*mockCounter).count)
+	assert.Equal(t, 2, c.metrics.wsMissingEntries.(*mockCounter).count)
+	assert.Equal(t, 1, c.metrics.missingEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.duplicateEntries.(*mockCounter).count)
 
 	// This avoids a panic on subsequent test execution,
 	// seems ugly but was easy, and multiple instantiations
@@ -223,7 +207,8 @@ func TestPruneAckdEntires(t *testing.T) {
 	wait := 30 * time.Millisecond
 	maxWait := 30 * time.Millisecond
 	//We set the prune interval timer to a huge value here so that it never runs, instead we call pruneEntries manually below
-	c := NewComparator(actual, wait, maxWait, 50*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false)
+	c := NewComparator(actual, wait, maxWait, 50*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false, "", "")
+	mockMetrics(c)
 
 	t1 := time.Unix(0, 0)
 	t2 := t1.Add(1 * time.Millisecond)
@@ -258,9 +243,6 @@ func TestPruneAckdEntires(t *testing.T) {
 }
 
 func TestSpotCheck(t *testing.T) {
-	spotCheckMissing = &mockCounter{}
-	spotCheckEntries = &mockCounter{}
-
 	actual := &bytes.Buffer{}
 
 	t1 := time.Unix(0, 0)
@@ -280,7 +262,8 @@ func TestSpotCheck(t *testing.T) {
 	spotCheck := 10 * time.Millisecond
 	spotCheckMax := 20 * time.Millisecond
 	//We set the prune interval timer to a huge value here so that it never runs, instead we call spotCheckEntries manually below
-	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 50*time.Hour, spotCheck, spotCheckMax, 4*time.Hour, 3*time.Millisecond, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), mr, false)
+	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 50*time.Hour, spotCheck, spotCheckMax, 4*time.Hour, 3*time.Millisecond, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), mr, false, "", "")
+	mockMetrics(c)
 
 	// Send all the entries
 	for i := range entries {
@@ -296,7 +279,7 @@ func TestSpotCheck(t *testing.T) {
 	// Run with "current time" 1ms after start which is less than spotCheckWait so nothing should be checked
 	c.spotCheckEntries(time.Unix(0, 2*time.Millisecond.Nanoseconds()))
 	assert.Equal(t, 3, len(c.spotCheck))
-	assert.Equal(t, 0, spotCheckEntries.(*mockCounter).count)
+	assert.Equal(t, 0, c.metrics.spotCheckEntries.(*mockCounter).count)
 
 	// Run with "current time" at 25ms, the first entry should be pruned, the second entry should be found, and the last entry should come back as missing
 	c.spotCheckEntries(time.Unix(0, 25*time.Millisecond.Nanoseconds()))
@@ -314,16 +297,13 @@ func TestSpotCheck(t *testing.T) {
 
 	assert.Equal(t, expected, actual.String())
 
-	assert.Equal(t, 2, spotCheckEntries.(*mockCounter).count)
-	assert.Equal(t, 1, spotCheckMissing.(*mockCounter).count)
+	assert.Equal(t, 2, c.metrics.spotCheckEntries.(*mockCounter).count)
+	assert.Equal(t, 1, c.metrics.spotCheckMissing.(*mockCounter).count)
 
 	prometheus.Unregister(responseLatency)
 }
 
 func TestMetricTest(t *testing.T) {
-	metricTestActual = &mockGauge{}
-	metricTestExpected = &mockGauge{}
-
 	actual := &bytes.Buffer{}
 
 	writeInterval := 500 * time.Millisecond
@@ -331,7 +311,8 @@ func TestMetricTest(t *testing.T) {
 	mr := &mockReader{}
 	metricTestRange := 30 * time.Second
 	//We set the prune interval timer to a huge value here so that it never runs, instead we call spotCheckEntries manually below
-	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 50*time.Hour, 0, 0, 4*time.Hour, 0, 10*time.Minute, metricTestRange, writeInterval, 1, make(chan time.Time), make(chan time.Time), mr, false)
+	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 50*time.Hour, 0, 0, 4*time.Hour, 0, 10*time.Minute, metricTestRange, writeInterval, 1, make(chan time.Time), make(chan time.Time), mr, false, "", "")
+	mockMetrics(c)
 	// Force the start time to a known value
 	c.startTime = time.Unix(10, 0)
 
@@ -341,8 +322,8 @@ func TestMetricTest(t *testing.T) {
 	// We want to look back 30s but have only been running from time 10s to time 20s so the query range should be adjusted to 10s
 	assert.Equal(t, "10s", mr.queryRange)
 	// Should be no deviation we set countOverTime to the runtime/writeinterval which should be what metrictTest expected
-	assert.Equal(t, float64(20), metricTestExpected.(*mockGauge).val)
-	assert.Equal(t, float64(20), metricTestActual.(*mockGauge).val)
+	assert.Equal(t, float64(20), c.metrics.metricTestExpected.(*mockGauge).val)
+	assert.Equal(t, float64(20), c.metrics.metricTestActual.(*mockGauge).val)
 
 	// Run test at time 30s which is 20s after start
 	mr.countOverTime = float64((20 * time.Second).Milliseconds()) / float64(writeInterval.Milliseconds())
@@ -350,8 +331,8 @@ func TestMetricTest(t *testing.T) {
 	// We want to look back 30s but have only been running from time 10s to time 20s so the query range should be adjusted to 10s
 	assert.Equal(t, "20s", mr.queryRange)
 	// Gauge should be equal to the countOverTime value
-	assert.Equal(t, float64(40), metricTestExpected.(*mockGauge).val)
-	assert.Equal(t, float64(40), metricTestActual.(*mockGauge).val)
+	assert.Equal(t, float64(40), c.metrics.metricTestExpected.(*mockGauge).val)
+	assert.Equal(t, float64(40), c.metrics.metricTestActual.(*mockGauge).val)
 
 	// Run test 60s after start, we should now be capping the query range to 30s and expecting only 30s of counts
 	mr.countOverTime = float64((30 * time.Second).Milliseconds()) / float64(writeInterval.Milliseconds())
@@ -359,8 +340,8 @@ func TestMetricTest(t *testing.T) {
 	// We want to look back 30s but have only been running from time 10s to time 20s so the query range should be adjusted to 10s
 	assert.Equal(t, "30s", mr.queryRange)
 	// Gauge should be equal to the countOverTime value
-	assert.Equal(t, float64(60), metricTestExpected.(*mockGauge).val)
-	assert.Equal(t, float64(60), metricTestActual.(*mockGauge).val)
+	assert.Equal(t, float64(60), c.metrics.metricTestExpected.(*mockGauge).val)
+	assert.Equal(t, float64(60), c.metrics.metricTestActual.(*mockGauge).val)
 
 	prometheus.Unregister(responseLatency)
 }
@@ -385,6 +366,87 @@ func Test_pruneList(t *testing.T) {
 	assert.Equal(t, []*time.Time{&t1, &t3, &t4, &t5}, outList)
 }
 
+func TestComparatorStreamMetrics(t *testing.T) {
+	actual := &bytes.Buffer{}
+	c := NewComparator(actual, 1*time.Hour, 1*time.Hour, 1*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), nil, false, "team-a", "3")
+
+	t1 := time.Now()
+	c.entrySent(t1)
+	c.entryReceived(t1)
+	c.entryReceived(t1)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(totalEntries.WithLabelValues("team-a", "3")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(duplicateEntries.WithLabelValues("team-a", "3")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(duplicateEntries.WithLabelValues("team-b", "3")))
+
+	// This avoids a panic on subsequent test execution,
+	// seems ugly but was easy, and multiple instantiations
+	// of the comparator should be an error
+	prometheus.Unregister(responseLatency)
+}
+
+func TestComparatorStreamLoss(t *testing.T) {
+	t1 := time.Unix(10, 0)
+	t2 := time.Unix(20, 0)
+	t3 := time.Unix(30, 0)
+	maxWait := 300 * time.Second
+
+	// Each stream of a tenant has its own comparator, reader and metrics. Every stream misses t2 and t3 on
+	// the websocket, then t2 of stream 0 of team-a and t3 of stream 0 of team-b are never found.
+	for _, tc := range []struct {
+		tenant, stream string
+		found          []time.Time
+	}{
+		{"team-a", "0", []time.Time{t1, t3}},
+		{"team-a", "1", []time.Time{t1, t2, t3}},
+		{"team-b", "0", []time.Time{t1, t2}},
+	} {
+		c := NewComparator(&bytes.Buffer{}, 60*time.Second, maxWait, 50*time.Hour, 15*time.Minute, 4*time.Hour, 4*time.Hour, 0, 1*time.Minute, 0, 0, 1, make(chan time.Time), make(chan time.Time), &mockReader{resp: tc.found}, false, tc.tenant, tc.stream)
+		for _, ts := range []time.Time{t1, t2, t3} {
+			c.entrySent(ts)
+		}
+		c.entryReceived(t1)
+		c.pruneEntries(time.Unix(120, 0))
+		c.pruneEntries(t1.Add(2 * maxWait))
+		c.Stop()
+	}
+
+	for _, tc := range []struct {
+		tenant, stream            string
+		total, wsMissing, missing float64
+	}{
+		{"team-a", "0", 3, 2, 1},
+		{"team-a", "1", 3, 2, 0},
+		{"team-b", "0", 3, 2, 1},
+		{"team-b", "1", 0, 0, 0},
+	} {
+		assert.Equal(t, tc.total, testutil.ToFloat64(totalEntries.WithLabelValues(tc.tenant, tc.stream)), "%s/%s", tc.tenant, tc.stream)
+		assert.Equal(t, tc.wsMissing, testutil.ToFloat64(wsMissingEntries.WithLabelValues(tc.tenant, tc.stream)), "%s/%s", tc.tenant, tc.stream)
+		assert.Equal(t, tc.missing, testutil.ToFloat64(missingEntries.WithLabelValues(tc.tenant, tc.stream)), "%s/%s", tc.tenant, tc.stream)
+	}
+
+	// This avoids a panic on subsequent test execution,
+	// seems ugly but was easy, and multiple instantiations
+	// of the comparator should be an error
+	prometheus.Unregister(responseLatency)
+}
+
+// mockMetrics replaces the metrics of the comparator with mocks.
+func mockMetrics(c *Comparator) {
+	c.metrics = streamMetrics{
+		totalEntries:       &mockCounter{},
+		outOfOrderEntries:  &mockCounter{},
+		wsMissingEntries:   &mockCounter{},
+		missingEntries:     &mockCounter{},
+		spotCheckMissing:   &mockCounter{},
+		spotCheckEntries:   &mockCounter{},
+		unexpectedEntries:  &mockCounter{},
+		duplicateEntries:   &mockCounter{},
+		metricTestExpected: &mockGauge{},
+		metricTestActual:   &mockGauge{},
+	}
+}
+
 type mockCounter struct {
 	cLck  sync.Mutex
 	count int
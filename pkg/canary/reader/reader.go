@@ -22,12 +22,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
+	"github.com/grafana/loki/pkg/canary/writer"
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/util/build"
 	"github.com/grafana/loki/pkg/util/unmarshal"
 )
 
+const orgIDHeader = "X-Scope-OrgID"
+
 var (
 	reconnects = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: "loki_canary",
@@ -53,6 +56,7 @@ type Reader struct {
 	addr         string
 	user         string
 	pass         string
+	tenant       string
 	queryTimeout time.Duration
 	sName        string
 	sValue       string
@@ -64,18 +68,21 @@ type Reader struct {
 	interval     time.Duration
 	conn         *websocket.Conn
 	w            io.Writer
-	recv         chan time.Time
+	recv         map[string]chan time.Time
 	quit         chan struct{}
 	shuttingDown bool
 	done         chan struct{}
 }
 
+// NewReader tails the streams of a tenant written by the canary and sends the timestamps of their
+// entries to the received channel of their stream, received is keyed by the IDs of the streams.
 func NewReader(writer io.Writer,
-	receivedChan chan time.Time,
+	received map[string]chan time.Time,
 	tls bool,
 	address string,
 	user string,
 	pass string,
+	tenant string,
 	queryTimeout time.Duration,
 	labelName string,
 	labelVal string,
@@ -86,6 +93,9 @@ func NewReader(writer io.Writer,
 	if user != "" {
 		h = http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))}}
 	}
+	if tenant != "" {
+		h.Set(orgIDHeader, tenant)
+	}
 
 	next := time.Now()
 	bkcfg := util.BackoffConfig{
@@ -101,6 +111,7 @@ func NewReader(writer io.Writer,
 		addr:         address,
 		user:         user,
 		pass:         pass,
+		tenant:       tenant,
 		queryTimeout: queryTimeout,
 		sName:        streamName,
 		sValue:       streamValue,
@@ -110,7 +121,7 @@ func NewReader(writer io.Writer,
 		backoff:      bkoff,
 		interval:     interval,
 		w:            writer,
-		recv:         receivedChan,
+		recv:         received,
 		quit:         make(chan struct{}),
 		done:         make(chan struct{}),
 		shuttingDown: false,
@@ -141,6 +152,44 @@ func (r *Reader) Stop() {
 // QueryCountOverTime will ask Loki for a count of logs over the provided range e.g. 5m
 // QueryCountOverTime blocks if a previous query has failed until the appropriate backoff time has been reached.
 func (r *Reader) QueryCountOverTime(queryRange string) (float64, error) {
+	return r.queryCountOverTime(queryRange, "")
+}
+
+// Query will ask Loki for all canary timestamps in the requested timerange.
+// Query blocks if a previous query has failed until the appropriate backoff time has been reached.
+func (r *Reader) Query(start time.Time, end time.Time) ([]time.Time, error) {
+	return r.query(start, end, "")
+}
+
+// Stream returns a LokiReader querying the entries of a single stream of the tenant.
+func (r *Reader) Stream(id string) LokiReader {
+	return &streamReader{r: r, stream: id}
+}
+
+type streamReader struct {
+	r      *Reader
+	stream string
+}
+
+func (s *streamReader) QueryCountOverTime(queryRange string) (float64, error) {
+	return s.r.queryCountOverTime(queryRange, s.stream)
+}
+
+func (s *streamReader) Query(start time.Time, end time.Time) ([]time.Time, error) {
+	return s.r.query(start, end, s.stream)
+}
+
+// selector returns the log query of the entries of a stream of the tenant, or of all of them when stream
+// is empty. The streams and tenants are told apart by the tags of their lines.
+func (r *Reader) selector(stream string) string {
+	sel := fmt.Sprintf("{%v=\"%v\",%v=\"%v\"}", r.sName, r.sValue, r.lName, r.lVal)
+	if tags := writer.Tags(r.tenant, stream); tags != "" {
+		sel += fmt.Sprintf(" |= %q", tags+" ")
+	}
+	return sel
+}
+
+func (r *Reader) queryCountOverTime(queryRange string, stream string) (float64, error) {
 	r.backoffMtx.RLock()
 	next := r.nextQuery
 	r.backoffMtx.RUnlock()
@@ -160,7 +209,7 @@ func (r *Reader) QueryCountOverTime(queryRange string) (float64, error) {
 		Scheme: scheme,
 		Host:   r.addr,
 		Path:   "/loki/api/v1/query",
-		RawQuery: "query=" + url.QueryEscape(fmt.Sprintf("count_over_time({%v=\"%v\",%v=\"%v\"}[%s])", r.sName, r.sValue, r.lName, r.lVal, queryRange)) +
+		RawQuery: "query=" + url.QueryEscape(fmt.Sprintf("count_over_time(%s[%s])", r.selector(stream), queryRange)) +
 			fmt.Sprintf("&time=%d", time.Now().UnixNano()) +
 			"&limit=1000",
 	}
@@ -176,6 +225,9 @@ func (r *Reader) QueryCountOverTime(queryRange string) (float64, error) {
 
 	req.SetBasicAuth(r.user, r.pass)
 	req.Header.Set("User-Agent", userAgent)
+	if r.tenant != "" {
+		req.Header.Set(orgIDHeader, r.tenant)
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -224,9 +276,7 @@ func (r *Reader) QueryCountOverTime(queryRange string) (float64, error) {
 	return ret, nil
 }
 
-// Query will ask Loki for all canary timestamps in the requested timerange.
-// Query blocks if a previous query has failed until the appropriate backoff time has been reached.
-func (r *Reader) Query(start time.Time, end time.Time) ([]time.Time, error) {
+func (r *Reader) query(start time.Time, end time.Time, stream string) ([]time.Time, error) {
 	r.backoffMtx.RLock()
 	next := r.nextQuery
 	r.backoffMtx.RUnlock()
@@ -247,7 +297,7 @@ func (r *Reader) Query(start time.Time, end time.Time) ([]time.Time, error) {
 		Host:   r.addr,
 		Path:   "/loki/api/v1/query_range",
 		RawQuery: fmt.Sprintf("start=%d&end=%d", start.UnixNano(), end.UnixNano()) +
-			"&query=" + url.QueryEscape(fmt.Sprintf("{%v=\"%v\",%v=\"%v\"}", r.sName, r.sValue, r.lName, r.lVal)) +
+			"&query=" + url.QueryEscape(r.selector(stream)) +
 			"&limit=1000",
 	}
 	fmt.Fprintf(r.w, "Querying loki for logs with query: %v\n", u.String())
@@ -262,6 +312,9 @@ func (r *Reader) Query(start time.Time, end time.Time) ([]time.Time, error) {
 
 	req.SetBasicAuth(r.user, r.pass)
 	req.Header.Set("User-Agent", userAgent)
+	if r.tenant != "" {
+		req.Header.Set(orgIDHeader, r.tenant)
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -295,13 +348,16 @@ func (r *Reader) Query(start time.Time, end time.Time) ([]time.Time, error) {
 	value := decoded.Data.Result
 	switch value.Type() {
 	case logqlmodel.ValueTypeStreams:
-		for _, stream := range value.(loghttp.Streams) {
-			for _, entry := range stream.Entries {
-				ts, err := parseResponse(&entry)
+		for _, s := range value.(loghttp.Streams) {
+			for _, entry := range s.Entries {
+				ts, tenant, id, err := parseResponse(&entry)
 				if err != nil {
 					fmt.Fprint(r.w, err)
 					continue
 				}
+				if tenant != r.tenant || id != stream {
+					continue
+				}
 				tss = append(tss, *ts)
 			}
 		}
@@ -346,12 +402,21 @@ func (r *Reader) run() {
 		}
 		for _, stream := range tailResponse.Streams {
 			for _, entry := range stream.Entries {
-				ts, err := parseResponse(&entry)
+				ts, tenant, id, err := parseResponse(&entry)
 				if err != nil {
 					fmt.Fprint(r.w, err)
 					continue
 				}
-				r.recv <- *ts
+				// Loki does not tell the tenants apart when auth is disabled.
+				if tenant != r.tenant {
+					continue
+				}
+				recv, ok := r.recv[id]
+				if !ok {
+					fmt.Fprintf(r.w, "received an entry of unknown stream %q: %s\n", id, entry.Line)
+					continue
+				}
+				recv <- *ts
 			}
 		}
 		// Ping messages can reset the read deadline so also make sure we are receiving regular messages.
@@ -386,7 +451,7 @@ func (r *Reader) closeAndReconnect() {
 			Scheme:   scheme,
 			Host:     r.addr,
 			Path:     "/loki/api/v1/tail",
-			RawQuery: "query=" + url.QueryEscape(fmt.Sprintf("{%v=\"%v\",%v=\"%v\"}", r.sName, r.sValue, r.lName, r.lVal)),
+			RawQuery: "query=" + url.QueryEscape(r.selector("")),
 		}
 
 		fmt.Fprintf(r.w, "Connecting to loki at %v, querying for label '%v' with value '%v'\n", u.String(), r.lName, r.lVal)
@@ -412,17 +477,29 @@ func (r *Reader) closeAndReconnect() {
 	}
 }
 
-func parseResponse(entry *loghttp.Entry) (*time.Time, error) {
+// parseResponse returns the timestamp, the tenant and the stream of an entry.
+func parseResponse(entry *loghttp.Entry) (*time.Time, string, string, error) {
 	sp := strings.Split(entry.Line, " ")
-	if len(sp) != 2 {
-		return nil, errors.Errorf("received invalid entry: %s\n", entry.Line)
+	if len(sp) < 2 || len(sp) > 4 {
+		return nil, "", "", errors.Errorf("received invalid entry: %s\n", entry.Line)
 	}
 	ts, err := strconv.ParseInt(sp[0], 10, 64)
 	if err != nil {
-		return nil, errors.Errorf("failed to parse timestamp: %s\n", sp[0])
+		return nil, "", "", errors.Errorf("failed to parse timestamp: %s\n", sp[0])
+	}
+	var tenant, stream string
+	for _, tag := range sp[1 : len(sp)-1] {
+		switch {
+		case strings.HasPrefix(tag, writer.TenantKey):
+			tenant = strings.TrimPrefix(tag, writer.TenantKey)
+		case strings.HasPrefix(tag, writer.StreamKey):
+			stream = strings.TrimPrefix(tag, writer.StreamKey)
+		default:
+			return nil, "", "", errors.Errorf("received invalid entry: %s\n", entry.Line)
+		}
 	}
 	t := time.Unix(0, ts)
-	return &t, nil
+	return &t, tenant, stream, nil
 }
 
 func nextBackoff(w io.Writer, statusCode int, backoff *util.Backoff) time.Time {
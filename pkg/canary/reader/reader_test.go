@@ -0,0 +1,115 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestParseResponse(t *testing.T) {
+	ts := time.Unix(0, 1625133600123456789)
+	for _, tc := range []struct {
+		line           string
+		tenant, stream string
+		err            bool
+	}{
+		{line: "1625133600123456789 ppp"},
+		{line: "1625133600123456789 "},
+		{line: "1625133600123456789 tenant=team-a ppp", tenant: "team-a"},
+		{line: "1625133600123456789 stream=3 ppp", stream: "3"},
+		{line: "1625133600123456789 tenant=team-a stream=3 ppp", tenant: "team-a", stream: "3"},
+		{line: "1625133600123456789 tenant=team-a stream=3 ", tenant: "team-a", stream: "3"},
+		{line: "1625133600123456789", err: true},
+		{line: "1625133600123456789 foo=bar ppp", err: true},
+		{line: "1625133600123456789 tenant=team-a stream=3 foo=bar ppp", err: true},
+		{line: "now tenant=team-a ppp", err: true},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			actual, tenant, stream, err := parseResponse(&loghttp.Entry{Line: tc.line})
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, ts, *actual)
+			require.Equal(t, tc.tenant, tenant)
+			require.Equal(t, tc.stream, stream)
+		})
+	}
+}
+
+func TestReader_selector(t *testing.T) {
+	r := &Reader{sName: "name", sValue: "loki-canary", lName: "stream", lVal: "stdout"}
+	require.Equal(t, `{name="loki-canary",stream="stdout"}`, r.selector(""))
+	require.Equal(t, `{name="loki-canary",stream="stdout"} |= " stream=3 "`, r.selector("3"))
+
+	r.tenant = "team-a"
+	require.Equal(t, `{name="loki-canary",stream="stdout"} |= " tenant=team-a "`, r.selector(""))
+	require.Equal(t, `{name="loki-canary",stream="stdout"} |= " tenant=team-a stream=3 "`, r.selector("3"))
+}
+
+func TestReader_StreamQuery(t *testing.T) {
+	var (
+		queries []string
+		orgIDs  []string
+	)
+	lines := []string{
+		"1 tenant=team-a stream=0 p",
+		"2 tenant=team-a stream=1 p",
+		// Loki does not tell the tenants apart when auth is disabled.
+		"3 tenant=team-b stream=1 p",
+		"4 tenant=team-a stream=1 p",
+		"invalid",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		orgIDs = append(orgIDs, r.Header.Get(orgIDHeader))
+
+		var values []string
+		for _, line := range lines {
+			values = append(values, fmt.Sprintf(`["%d",%q]`, time.Now().UnixNano(), line))
+		}
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"name":"loki-canary"},"values":[%s]}]}}`, strings.Join(values, ","))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	r := &Reader{
+		addr:         u.Host,
+		tenant:       "team-a",
+		queryTimeout: 5 * time.Second,
+		sName:        "name",
+		sValue:       "loki-canary",
+		lName:        "stream",
+		lVal:         "stdout",
+		backoff:      util.NewBackoff(context.Background(), util.BackoffConfig{MinBackoff: time.Second, MaxBackoff: time.Second}),
+		w:            ioutil.Discard,
+	}
+
+	// Only the entries of the stream of the tenant are returned.
+	tss, err := r.Stream("1").Query(time.Unix(0, 0), time.Unix(10, 0))
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{time.Unix(0, 2), time.Unix(0, 4)}, tss)
+
+	tss, err = r.Stream("0").Query(time.Unix(0, 0), time.Unix(10, 0))
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{time.Unix(0, 1)}, tss)
+
+	require.Equal(t, []string{
+		`{name="loki-canary",stream="stdout"} |= " tenant=team-a stream=1 "`,
+		`{name="loki-canary",stream="stdout"} |= " tenant=team-a stream=0 "`,
+	}, queries)
+	require.Equal(t, []string{"team-a", "team-a"}, orgIDs)
+}
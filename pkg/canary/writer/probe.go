@@ -0,0 +1,154 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	ErrOutOfOrderMismatch = "out of order probe was %s but unordered writes are %s for the tenant %q: %s\n"
+
+	probeAccepted = "accepted"
+	probeRejected = "rejected"
+)
+
+var (
+	outOfOrderProbes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki_canary",
+		Name:      "out_of_order_probes_total",
+		Help:      "counts the out of order entries pushed to Loki by whether they were accepted or rejected",
+	}, []string{"tenant", "result"})
+	outOfOrderMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki_canary",
+		Name:      "out_of_order_probe_mismatches_total",
+		Help:      "counts the out of order entries accepted when unordered writes are disabled, or rejected when they are enabled",
+	}, []string{"tenant"})
+	outOfOrderProbeErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki_canary",
+		Name:      "out_of_order_probe_errors_total",
+		Help:      "counts the out of order probes which failed for another reason than the order of their entries",
+	}, []string{"tenant"})
+)
+
+// OutOfOrderProbe pushes an entry older than the previous one to a dedicated stream of a tenant every interval, and
+// checks that Loki accepts it when unordered writes are enabled for the tenant and rejects it otherwise.
+type OutOfOrderProbe struct {
+	w         io.Writer
+	client    pushClient
+	labels    string
+	interval  time.Duration
+	offset    time.Duration
+	unordered bool
+	timeout   time.Duration
+	quit      chan struct{}
+	done      chan struct{}
+}
+
+// NewOutOfOrderProbe probes the stream with the given labels, the out of order entries are offset before the entry
+// pushed right before them, which must stay within the validity window of unordered writes.
+func NewOutOfOrderProbe(writer io.Writer, tls bool, address, user, pass, tenant, labels string, interval, offset time.Duration, unordered bool, timeout time.Duration) *OutOfOrderProbe {
+	p := &OutOfOrderProbe{
+		w:         writer,
+		client:    newPushClient(tls, address, user, pass, tenant),
+		labels:    labels,
+		interval:  interval,
+		offset:    offset,
+		unordered: unordered,
+		timeout:   timeout,
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go p.run()
+
+	return p
+}
+
+func (p *OutOfOrderProbe) Stop() {
+	if p.quit != nil {
+		close(p.quit)
+		<-p.done
+		p.quit = nil
+	}
+}
+
+func (p *OutOfOrderProbe) run() {
+	t := time.NewTicker(p.interval)
+	defer func() {
+		t.Stop()
+		close(p.done)
+	}()
+	for {
+		select {
+		case <-t.C:
+			p.check(time.Now())
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// check runs a probe and records its result.
+func (p *OutOfOrderProbe) check(now time.Time) {
+	accepted, msg, err := p.probe(now)
+	if err != nil {
+		outOfOrderProbeErrors.WithLabelValues(p.client.tenant).Inc()
+		fmt.Fprintf(p.w, "error running out of order probe: %v\n", err)
+		return
+	}
+
+	result := probeRejected
+	if accepted {
+		result = probeAccepted
+	}
+	outOfOrderProbes.WithLabelValues(p.client.tenant, result).Inc()
+
+	if accepted != p.unordered {
+		outOfOrderMismatches.WithLabelValues(p.client.tenant).Inc()
+		enabled := "disabled"
+		if p.unordered {
+			enabled = "enabled"
+		}
+		fmt.Fprintf(p.w, ErrOutOfOrderMismatch, result, enabled, p.client.tenant, msg)
+	}
+}
+
+// probe pushes an entry at now followed by an entry offset before it in a single request, and returns whether the
+// second entry was accepted, along with the response of Loki.
+func (p *OutOfOrderProbe) probe(now time.Time) (bool, string, error) {
+	older := now.Add(-p.offset)
+	stream := logproto.Stream{
+		Labels: p.labels,
+		Entries: []logproto.Entry{
+			{Timestamp: now, Line: strconv.FormatInt(now.UnixNano(), 10)},
+			{Timestamp: older, Line: strconv.FormatInt(older.UnixNano(), 10)},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	status, body, err := p.client.push(ctx, stream)
+	if err != nil {
+		return false, "", err
+	}
+	body = strings.TrimSpace(body)
+
+	switch {
+	case status/100 == 2:
+		return true, fmt.Sprintf("HTTP status %d", status), nil
+	case status == http.StatusBadRequest && strings.Contains(body, "out of order"):
+		return false, body, nil
+	default:
+		return false, "", fmt.Errorf("server returned HTTP status %d: %s", status, body)
+	}
+}
@@ -0,0 +1,228 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util/build"
+)
+
+const (
+	pushPath    = "/loki/api/v1/push"
+	orgIDHeader = "X-Scope-OrgID"
+
+	// StreamLabel is the label of the ID of the stream of the entries pushed by the canary, so that the
+	// streams are spread across the ingesters.
+	StreamLabel = "canary_stream"
+)
+
+var (
+	pushRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki_canary",
+		Name:      "push_requests_total",
+		Help:      "counts the push requests sent to Loki by status code",
+	}, []string{"tenant", "status_code"})
+	pushDroppedEntries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki_canary",
+		Name:      "push_dropped_entries_total",
+		Help:      "counts the entries dropped after their push request failed too many times",
+	}, []string{"tenant"})
+
+	userAgent = fmt.Sprintf("loki-canary/%s", build.Version)
+)
+
+// pushClient sends push requests of a tenant to Loki.
+type pushClient struct {
+	url    string
+	user   string
+	pass   string
+	tenant string
+}
+
+func newPushClient(tls bool, address, user, pass, tenant string) pushClient {
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	u := url.URL{Scheme: scheme, Host: address, Path: pushPath}
+	return pushClient{url: u.String(), user: user, pass: pass, tenant: tenant}
+}
+
+// push sends the streams encoded with protobuf and snappy, and returns the status code and the body of the
+// response.
+func (c pushClient) push(ctx context.Context, streams ...logproto.Stream) (int, string, error) {
+	buf, err := proto.Marshal(&logproto.PushRequest{Streams: streams})
+	if err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(snappy.Encode(nil, buf)))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", userAgent)
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.pass)
+	}
+	if c.tenant != "" {
+		req.Header.Set(orgIDHeader, c.tenant)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	pushRequests.WithLabelValues(c.tenant, strconv.Itoa(resp.StatusCode)).Inc()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), nil
+}
+
+// Push is an io.Writer pushing the lines written by a Writer directly to Loki, instead of relying on an
+// agent to ship them. The lines are batched and pushed every batchWait, or as soon as their size reaches
+// batchSize.
+type Push struct {
+	w         io.Writer
+	client    pushClient
+	labels    string
+	batchWait time.Duration
+	batchSize int
+	backoff   util.BackoffConfig
+	timeout   time.Duration
+	entries   chan logproto.Entry
+	quit      chan struct{}
+	done      chan struct{}
+}
+
+// NewPush pushes the lines of a stream of a tenant with the given labels.
+func NewPush(writer io.Writer, tls bool, address, user, pass, tenant, labels string, batchWait time.Duration, batchSize int, timeout time.Duration) *Push {
+	p := &Push{
+		w:         writer,
+		client:    newPushClient(tls, address, user, pass, tenant),
+		labels:    labels,
+		batchWait: batchWait,
+		batchSize: batchSize,
+		backoff: util.BackoffConfig{
+			MinBackoff: 500 * time.Millisecond,
+			MaxBackoff: 30 * time.Second,
+			MaxRetries: 10,
+		},
+		timeout: timeout,
+		entries: make(chan logproto.Entry),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go p.run()
+
+	return p
+}
+
+// Write queues a line written by a Writer in the next batch, the timestamp of the entry is the one written at
+// the start of the line.
+func (p *Push) Write(b []byte) (int, error) {
+	line := strings.TrimSuffix(string(b), "\n")
+	sp := strings.SplitN(line, " ", 2)
+	ts, err := strconv.ParseInt(sp[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse timestamp of line: %s", line)
+	}
+
+	select {
+	case p.entries <- logproto.Entry{Timestamp: time.Unix(0, ts), Line: line}:
+		return len(b), nil
+	case <-p.quit:
+		return 0, fmt.Errorf("push stopped")
+	}
+}
+
+// Stop pushes the pending batch and stops, it must be called after the Writer is stopped.
+func (p *Push) Stop() {
+	if p.quit != nil {
+		close(p.quit)
+		<-p.done
+		p.quit = nil
+	}
+}
+
+func (p *Push) run() {
+	t := time.NewTicker(p.batchWait)
+	defer func() {
+		t.Stop()
+		close(p.done)
+	}()
+
+	var (
+		batch     []logproto.Entry
+		batchSize int
+	)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		p.send(batch)
+		batch, batchSize = nil, 0
+	}
+
+	for {
+		select {
+		case e := <-p.entries:
+			batch = append(batch, e)
+			batchSize += len(e.Line)
+			if batchSize >= p.batchSize {
+				flush()
+			}
+		case <-t.C:
+			flush()
+		case <-p.quit:
+			flush()
+			return
+		}
+	}
+}
+
+// send pushes a batch, retrying on server errors and rate limiting. The entries of a batch which can't be
+// pushed are dropped, and reported missing by the comparator.
+func (p *Push) send(entries []logproto.Entry) {
+	backoff := util.NewBackoff(context.Background(), p.backoff)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		status, body, err := p.client.push(ctx, logproto.Stream{Labels: p.labels, Entries: entries})
+		cancel()
+		if err == nil && status/100 == 2 {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("server returned HTTP status %d: %s", status, strings.TrimSpace(body))
+		}
+
+		// Client errors other than rate limiting won't succeed on retry.
+		retry := status == 0 || status == http.StatusTooManyRequests || status/100 == 5
+		if retry {
+			backoff.Wait()
+		}
+		if !retry || !backoff.Ongoing() {
+			fmt.Fprintf(p.w, "error pushing %d entries, dropping them: %v\n", len(entries), err)
+			pushDroppedEntries.WithLabelValues(p.client.tenant).Add(float64(len(entries)))
+			return
+		}
+		fmt.Fprintf(p.w, "error pushing %d entries, retrying: %v\n", len(entries), err)
+	}
+}
@@ -0,0 +1,173 @@
+package writer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// fakeLoki decodes the push requests and answers them with status, or with an out of order error when the entries
+// of a stream are not in order and unordered writes are disabled.
+type fakeLoki struct {
+	mtx       sync.Mutex
+	requests  []*logproto.PushRequest
+	tenants   []string
+	status    int
+	unordered bool
+}
+
+func (f *fakeLoki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	compressed, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req logproto.PushRequest
+	if err := proto.Unmarshal(buf, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.requests = append(f.requests, &req)
+	f.tenants = append(f.tenants, r.Header.Get(orgIDHeader))
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	for _, s := range req.Streams {
+		for i := 1; i < len(s.Entries); i++ {
+			if !f.unordered && s.Entries[i].Timestamp.Before(s.Entries[i-1].Timestamp) {
+				http.Error(w, "entry with timestamp ignored, reason: 'entry out of order'", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeLoki) entries() []logproto.Entry {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var entries []logproto.Entry
+	for _, req := range f.requests {
+		for _, s := range req.Streams {
+			entries = append(entries, s.Entries...)
+		}
+	}
+	return entries
+}
+
+func TestPush(t *testing.T) {
+	loki := &fakeLoki{}
+	server := httptest.NewServer(loki)
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	p := NewPush(out, false, strings.TrimPrefix(server.URL, "http://"), "", "", "tenant-a", `{name="loki-canary"}`, time.Hour, 80, time.Second)
+
+	ts := time.Unix(0, 1557935669096040040).UTC()
+	_, err := p.Write([]byte("1557935669096040040 tenant=tenant-a pppppp\n"))
+	require.NoError(t, err)
+	// the batch is full after this line.
+	_, err = p.Write([]byte("1557935669096040041 tenant=tenant-a pppppp\n"))
+	require.NoError(t, err)
+	_, err = p.Write([]byte("1557935669096040042 tenant=tenant-a p\n"))
+	require.NoError(t, err)
+	// the last batch is pushed on stop.
+	p.Stop()
+
+	require.Len(t, loki.requests, 2)
+	require.Equal(t, []string{"tenant-a", "tenant-a"}, loki.tenants)
+	require.Equal(t, `{name="loki-canary"}`, loki.requests[0].Streams[0].Labels)
+	require.Equal(t, []logproto.Entry{
+		{Timestamp: ts, Line: "1557935669096040040 tenant=tenant-a pppppp"},
+		{Timestamp: ts.Add(1), Line: "1557935669096040041 tenant=tenant-a pppppp"},
+		{Timestamp: ts.Add(2), Line: "1557935669096040042 tenant=tenant-a p"},
+	}, loki.entries())
+
+	_, err = p.Write([]byte("invalid\n"))
+	require.Error(t, err)
+}
+
+func TestPush_DropsRejectedBatches(t *testing.T) {
+	loki := &fakeLoki{status: http.StatusBadRequest}
+	server := httptest.NewServer(loki)
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	p := NewPush(out, false, strings.TrimPrefix(server.URL, "http://"), "", "", "", `{name="loki-canary"}`, time.Hour, 1, time.Second)
+	_, err := p.Write([]byte("1557935669096040040 pppppp\n"))
+	require.NoError(t, err)
+	p.Stop()
+
+	// client errors are not retried.
+	require.Len(t, loki.requests, 1)
+	require.Contains(t, out.String(), "dropping them")
+}
+
+func TestOutOfOrderProbe(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		lokiUnordered     bool
+		expectedUnordered bool
+		accepted          bool
+		mismatch          bool
+	}{
+		{name: "ordered writes rejected", accepted: false},
+		{name: "unordered writes accepted", lokiUnordered: true, expectedUnordered: true, accepted: true},
+		{name: "unexpected unordered writes", lokiUnordered: true, accepted: true, mismatch: true},
+		{name: "unexpected ordered writes", expectedUnordered: true, accepted: false, mismatch: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loki := &fakeLoki{unordered: tc.lokiUnordered}
+			server := httptest.NewServer(loki)
+			defer server.Close()
+
+			out := &bytes.Buffer{}
+			p := &OutOfOrderProbe{
+				w:         out,
+				client:    newPushClient(false, strings.TrimPrefix(server.URL, "http://"), "", "", "tenant-a"),
+				labels:    `{name="loki-canary", stream="out-of-order"}`,
+				offset:    time.Minute,
+				unordered: tc.expectedUnordered,
+				timeout:   time.Second,
+			}
+
+			now := time.Now()
+			accepted, _, err := p.probe(now)
+			require.NoError(t, err)
+			require.Equal(t, tc.accepted, accepted)
+			entries := loki.entries()
+			require.Len(t, entries, 2)
+			require.Equal(t, now.Add(-time.Minute).UnixNano(), entries[1].Timestamp.UnixNano())
+
+			p.check(now)
+			require.Equal(t, tc.mismatch, strings.Contains(out.String(), "out of order probe was"))
+		})
+	}
+
+	loki := &fakeLoki{status: http.StatusInternalServerError}
+	server := httptest.NewServer(loki)
+	defer server.Close()
+	p := &OutOfOrderProbe{client: newPushClient(false, strings.TrimPrefix(server.URL, "http://"), "", "", ""), timeout: time.Second}
+	_, _, err := p.probe(time.Now())
+	require.Error(t, err)
+}
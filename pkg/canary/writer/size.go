@@ -0,0 +1,71 @@
+package writer
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SizeDistribution draws the sizes in bytes of the log lines.
+type SizeDistribution interface {
+	Next() int
+	Max() int
+}
+
+// NewSizeDistribution returns the size distribution of the given name:
+//
+//	fixed: every line is size bytes.
+//	uniform: the sizes are uniformly distributed between size and maxSize.
+//	exponential: the sizes are exponentially distributed with a mean of size, up to maxSize.
+func NewSizeDistribution(name string, size, maxSize int) (SizeDistribution, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("the line size must be positive, got %d", size)
+	}
+
+	switch name {
+	case "fixed", "":
+		return fixedSize(size), nil
+	case "uniform", "exponential":
+		if maxSize < size {
+			return nil, fmt.Errorf("the maximum line size %d must not be less than the line size %d for the %s distribution", maxSize, size, name)
+		}
+		return &randomSize{
+			rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+			size:        size,
+			max:         maxSize,
+			exponential: name == "exponential",
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown line size distribution %q, must be one of fixed, uniform or exponential", name)
+	}
+}
+
+type fixedSize int
+
+func (s fixedSize) Next() int { return int(s) }
+func (s fixedSize) Max() int  { return int(s) }
+
+type randomSize struct {
+	mtx         sync.Mutex
+	rnd         *rand.Rand
+	size        int
+	max         int
+	exponential bool
+}
+
+func (s *randomSize) Next() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.exponential {
+		return s.size + s.rnd.Intn(s.max-s.size+1)
+	}
+	size := int(s.rnd.ExpFloat64() * float64(s.size))
+	if size > s.max {
+		return s.max
+	}
+	return size
+}
+
+func (s *randomSize) Max() int { return s.max }
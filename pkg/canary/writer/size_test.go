@@ -0,0 +1,91 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSizeDistribution(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		size    int
+		maxSize int
+		err     string
+	}{
+		{name: "", size: 100},
+		{name: "fixed", size: 100},
+		{name: "uniform", size: 100, maxSize: 200},
+		{name: "exponential", size: 100, maxSize: 100},
+		{name: "fixed", size: 0, err: "the line size must be positive, got 0"},
+		{name: "uniform", size: 100, maxSize: 50, err: "the maximum line size 50 must not be less than the line size 100 for the uniform distribution"},
+		{name: "exponential", size: 100, err: "the maximum line size 0 must not be less than the line size 100 for the exponential distribution"},
+		{name: "normal", size: 100, maxSize: 200, err: `unknown line size distribution "normal", must be one of fixed, uniform or exponential`},
+	} {
+		_, err := NewSizeDistribution(tc.name, tc.size, tc.maxSize)
+		if tc.err != "" {
+			require.EqualError(t, err, tc.err)
+			continue
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestSizeDistribution_Fixed(t *testing.T) {
+	sizes, err := NewSizeDistribution("fixed", 100, 0)
+	require.NoError(t, err)
+	require.Equal(t, 100, sizes.Max())
+	for i := 0; i < 100; i++ {
+		require.Equal(t, 100, sizes.Next())
+	}
+}
+
+func TestSizeDistribution_Uniform(t *testing.T) {
+	sizes, err := NewSizeDistribution("uniform", 100, 104)
+	require.NoError(t, err)
+	require.Equal(t, 104, sizes.Max())
+
+	seen := map[int]int{}
+	for i := 0; i < 10000; i++ {
+		size := sizes.Next()
+		require.GreaterOrEqual(t, size, 100)
+		require.LessOrEqual(t, size, 104)
+		seen[size]++
+	}
+	// Every size is drawn about 2000 times.
+	require.Len(t, seen, 5)
+	for size, n := range seen {
+		require.InDelta(t, 2000, n, 300, "size %d", size)
+	}
+}
+
+func TestSizeDistribution_Exponential(t *testing.T) {
+	sizes, err := NewSizeDistribution("exponential", 100, 1000)
+	require.NoError(t, err)
+	require.Equal(t, 1000, sizes.Max())
+
+	var sum, capped int
+	const n = 10000
+	for i := 0; i < n; i++ {
+		size := sizes.Next()
+		require.GreaterOrEqual(t, size, 0)
+		require.LessOrEqual(t, size, 1000)
+		if size == 1000 {
+			capped++
+		}
+		sum += size
+	}
+	// The mean is close to size, and P(X > 10*mean) = e^-10 so the sizes are almost never capped.
+	require.InDelta(t, 100, float64(sum)/n, 10)
+	require.Less(t, capped, 10)
+
+	// The sizes above the maximum are capped.
+	sizes, err = NewSizeDistribution("exponential", 100, 100)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		if sizes.Next() == 100 {
+			return
+		}
+	}
+	t.Fatal("no size was capped to the maximum")
+}
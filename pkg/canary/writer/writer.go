@@ -10,29 +10,38 @@ import (
 
 const (
 	LogEntry = "%s %s\n"
+
+	// TenantKey and StreamKey prefix the tenant and the ID of the stream of an entry in its line,
+	// when the canary writes several streams or tenants.
+	TenantKey = "tenant="
+	StreamKey = "stream="
 )
 
 type Writer struct {
-	w         io.Writer
-	sent      chan time.Time
-	interval  time.Duration
-	size      int
-	prevTsLen int
-	pad       string
-	quit      chan struct{}
-	done      chan struct{}
+	w        io.Writer
+	sent     chan time.Time
+	interval time.Duration
+	sizes    SizeDistribution
+	tags     string
+	pad      string
+	quit     chan struct{}
+	done     chan struct{}
 }
 
-func NewWriter(writer io.Writer, sentChan chan time.Time, entryInterval time.Duration, entrySize int) *Writer {
+// NewWriter writes an entry every entryInterval with a size taken from sizes. The tenant and the
+// stream of the entries are written in their line when set, so that they can be routed by the agent
+// and told apart by the reader.
+func NewWriter(writer io.Writer, sentChan chan time.Time, entryInterval time.Duration, sizes SizeDistribution, tenant, stream string) *Writer {
 
 	w := &Writer{
-		w:         writer,
-		sent:      sentChan,
-		interval:  entryInterval,
-		size:      entrySize,
-		prevTsLen: 0,
-		quit:      make(chan struct{}),
-		done:      make(chan struct{}),
+		w:        writer,
+		sent:     sentChan,
+		interval: entryInterval,
+		sizes:    sizes,
+		tags:     Tags(tenant, stream),
+		pad:      strings.Repeat("p", sizes.Max()),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 
 	go w.run()
@@ -40,6 +49,19 @@ func NewWriter(writer io.Writer, sentChan chan time.Time, entryInterval time.Dur
 	return w
 }
 
+// Tags returns the tenant and stream tags written after the timestamp of the lines of a stream, the
+// lines have no tags when the canary writes a single stream without tenant.
+func Tags(tenant, stream string) string {
+	var tags string
+	if tenant != "" {
+		tags += " " + TenantKey + tenant
+	}
+	if stream != "" {
+		tags += " " + StreamKey + stream
+	}
+	return tags
+}
+
 func (w *Writer) Stop() {
 	if w.quit != nil {
 		close(w.quit)
@@ -58,21 +80,15 @@ func (w *Writer) run() {
 		select {
 		case <-t.C:
 			t := time.Now()
-			ts := strconv.FormatInt(t.UnixNano(), 10)
-			tsLen := len(ts)
+			head := strconv.FormatInt(t.UnixNano(), 10) + w.tags
 
-			// I guess some day this could happen????
-			if w.prevTsLen != tsLen {
-				var str strings.Builder
-				// Total line length includes timestamp, white space separator, new line char.  Subtract those out
-				for str.Len() < w.size-tsLen-2 {
-					str.WriteString("p")
-				}
-				w.pad = str.String()
-				w.prevTsLen = tsLen
+			// Total line length includes timestamp, tags, white space separator, new line char.  Subtract those out
+			padLen := w.sizes.Next() - len(head) - 2
+			if padLen < 0 {
+				padLen = 0
 			}
 
-			fmt.Fprintf(w.w, LogEntry, ts, w.pad)
+			fmt.Fprintf(w.w, LogEntry, head, w.pad[:padLen])
 			w.sent <- t
 		case <-w.quit:
 			return
@@ -0,0 +1,79 @@
+package writer
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	require.Equal(t, "", Tags("", ""))
+	require.Equal(t, " tenant=team-a", Tags("team-a", ""))
+	require.Equal(t, " stream=3", Tags("", "3"))
+	require.Equal(t, " tenant=team-a stream=3", Tags("team-a", "3"))
+}
+
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestWriter(t *testing.T) {
+	for _, tc := range []struct {
+		tenant, stream string
+		tags           []string
+	}{
+		{tags: nil},
+		{tenant: "team-a", stream: "3", tags: []string{"tenant=team-a", "stream=3"}},
+	} {
+		t.Run(tc.tenant+tc.stream, func(t *testing.T) {
+			buf := &syncBuffer{}
+			sent := make(chan time.Time, 10)
+			sizes, err := NewSizeDistribution("fixed", 100, 0)
+			require.NoError(t, err)
+
+			w := NewWriter(buf, sent, 10*time.Millisecond, sizes, tc.tenant, tc.stream)
+			var ts []time.Time
+			for len(ts) < 3 {
+				ts = append(ts, <-sent)
+			}
+			w.Stop()
+
+			lines := strings.SplitAfter(buf.String(), "\n")
+			lines = lines[:len(lines)-1]
+			require.GreaterOrEqual(t, len(lines), 3)
+			for i, line := range lines[:3] {
+				require.Len(t, line, 100)
+				fields := strings.Fields(line)
+				require.Len(t, fields, len(tc.tags)+2)
+				require.Equal(t, strconv.FormatInt(ts[i].UnixNano(), 10), fields[0])
+				require.Equal(t, tc.tags, nilIfEmpty(fields[1:len(fields)-1]))
+				require.Equal(t, strings.Repeat("p", len(fields[len(fields)-1])), fields[len(fields)-1])
+			}
+		})
+	}
+}
+
+func nilIfEmpty(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package chunkenc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/pkg/errors"
+)
+
+// ChunkInfo describes the layout of a serialised chunk.
+// It is meant for tools inspecting chunks and is not used on the read or write path.
+type ChunkInfo struct {
+	// Format is the chunk format version.
+	Format   byte
+	Encoding Encoding
+
+	MetaChecksum         uint32
+	ComputedMetaChecksum uint32
+
+	Blocks []BlockInfo
+}
+
+// BlockInfo describes a single block of a serialised chunk.
+type BlockInfo struct {
+	Entries int
+	MinTime int64
+	MaxTime int64
+	// Offset is the position of the block in the chunk, it matches Block.Offset().
+	Offset int
+	// UncompressedSize is only stored in v3 chunks and is 0 for older formats.
+	UncompressedSize int
+
+	StoredChecksum   uint32
+	ComputedChecksum uint32
+
+	// Data is the compressed content of the block.
+	Data []byte
+}
+
+// ChecksumOK tells if the stored checksum of the block matches its content.
+func (b BlockInfo) ChecksumOK() bool {
+	return b.StoredChecksum == b.ComputedChecksum
+}
+
+// ChecksumOK tells if the stored checksum of the blocks metadata matches its content.
+func (c *ChunkInfo) ChecksumOK() bool {
+	return c.MetaChecksum == c.ComputedMetaChecksum
+}
+
+// Decompress returns the uncompressed content of the block at index i.
+// The reader pools panic on corrupt blocks, so the block is decompressed with a reader of its own.
+func (c *ChunkInfo) Decompress(i int) ([]byte, error) {
+	r, err := newInspectReader(c.Encoding, bytes.NewReader(c.Blocks[i].Data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func newInspectReader(enc Encoding, src io.Reader) (io.ReadCloser, error) {
+	switch enc {
+	case EncNone:
+		return ioutil.NopCloser(src), nil
+	case EncGZIP:
+		return gzip.NewReader(src)
+	case EncLZ4_64k, EncLZ4_256k, EncLZ4_1M, EncLZ4_4M:
+		return ioutil.NopCloser(lz4.NewReader(src)), nil
+	case EncSnappy:
+		return ioutil.NopCloser(snappy.NewReader(src)), nil
+	case EncFlate:
+		return flate.NewReader(src), nil
+	case EncZstd:
+		d, err := zstd.NewReader(src)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return nil, errors.Errorf("unknown encoding %d", enc)
+	}
+}
+
+// Inspect decodes the header and blocks metadata of a serialised chunk.
+// Unlike NewByteChunk, blocks with an invalid checksum are returned instead of being skipped,
+// and an invalid metadata checksum is reported in ChunkInfo instead of failing.
+func Inspect(b []byte) (*ChunkInfo, error) {
+	info := &ChunkInfo{}
+	db := decbuf{b: b}
+
+	m, version := db.be32(), db.byte()
+	if db.err() != nil {
+		return nil, errors.Wrap(db.err(), "verifying header")
+	}
+	if m != magicNumber {
+		return nil, errors.Errorf("invalid magic number %x", m)
+	}
+	info.Format = version
+	switch version {
+	case chunkFormatV1:
+		info.Encoding = EncGZIP
+	case chunkFormatV2, chunkFormatV3:
+		info.Encoding = Encoding(db.byte())
+		if db.err() != nil {
+			return nil, errors.Wrap(db.err(), "verifying encoding")
+		}
+	default:
+		return nil, errors.Errorf("invalid version %d", version)
+	}
+
+	if len(b) < 8+4 {
+		return nil, errors.New("chunk too short")
+	}
+	metasOffset := binary.BigEndian.Uint64(b[len(b)-8:])
+	if metasOffset > uint64(len(b)-(8+4)) {
+		return nil, errors.Errorf("invalid metas offset %d", metasOffset)
+	}
+	mb := b[metasOffset : len(b)-(8+4)]
+	info.MetaChecksum = binary.BigEndian.Uint32(b[len(b)-(8+4):])
+	info.ComputedMetaChecksum = crc32.Checksum(mb, castagnoliTable)
+
+	db = decbuf{b: mb}
+	num := db.uvarint()
+	for i := 0; i < num; i++ {
+		var blk BlockInfo
+		blk.Entries = db.uvarint()
+		blk.MinTime = db.varint64()
+		blk.MaxTime = db.varint64()
+		blk.Offset = db.uvarint()
+		if version == chunkFormatV3 {
+			blk.UncompressedSize = db.uvarint()
+		}
+		l := db.uvarint()
+		if db.err() != nil {
+			return nil, errors.Wrap(db.err(), "decoding block meta")
+		}
+		// The offset and length are untrusted, compare them without overflowing.
+		if blk.Offset < 0 || l < 0 || blk.Offset > len(b)-crc32.Size || l > len(b)-crc32.Size-blk.Offset {
+			return nil, errors.Errorf("block %d out of bounds", i)
+		}
+		blk.Data = b[blk.Offset : blk.Offset+l]
+		blk.StoredChecksum = binary.BigEndian.Uint32(b[blk.Offset+l:])
+		blk.ComputedChecksum = crc32.Checksum(blk.Data, castagnoliTable)
+		info.Blocks = append(info.Blocks, blk)
+	}
+	return info, nil
+}
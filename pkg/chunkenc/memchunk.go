@@ -1002,6 +1002,7 @@ func (hb *headBlock) Iterator(ctx context.Context, direction logproto.Direction,
 		if !ok {
 			return
 		}
+		chunkStats.PostFilterLines++
 		var stream *logproto.Stream
 		lhash := parsedLbs.Hash()
 		if stream, ok = streams[lhash]; !ok {
@@ -1049,6 +1050,7 @@ func (hb *headBlock) SampleIterator(ctx context.Context, mint, maxt int64, extra
 		if !ok {
 			continue
 		}
+		chunkStats.PostFilterLines++
 		var found bool
 		var s *logproto.Series
 		lhash := parsedLabels.Hash()
@@ -1249,6 +1251,7 @@ func (e *entryBufferedIterator) Next() bool {
 		if !ok {
 			continue
 		}
+		e.stats.PostFilterLines++
 		e.cur.Timestamp = time.Unix(0, e.currTs)
 		e.cur.Line = string(newLine)
 		e.currLabels = lbs
@@ -1280,6 +1283,7 @@ func (e *sampleBufferedIterator) Next() bool {
 		if !ok {
 			continue
 		}
+		e.stats.PostFilterLines++
 		e.currLabels = labels
 		e.cur.Value = val
 		e.cur.Hash = xxhash.Sum64(e.currLine)
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"math"
 	"math/rand"
 	"strconv"
@@ -1185,3 +1186,102 @@ func buildTestMemChunk(t *testing.T, from, through time.Time) *MemChunk {
 
 	return chk
 }
+
+func TestInspect(t *testing.T) {
+	for _, enc := range testEncoding {
+		for _, version := range []byte{chunkFormatV2, chunkFormatV3} {
+			t.Run(fmt.Sprintf("%v-%v", enc, version), func(t *testing.T) {
+				c := NewMemChunk(enc, DefaultHeadBlockFmt, testBlockSize, testTargetSize)
+				c.format = version
+				_ = fillChunk(c)
+
+				b, err := c.Bytes()
+				require.NoError(t, err)
+
+				info, err := Inspect(b)
+				require.NoError(t, err)
+				require.Equal(t, version, info.Format)
+				require.Equal(t, enc, info.Encoding)
+				require.True(t, info.ChecksumOK())
+				require.Len(t, info.Blocks, len(c.blocks))
+
+				for i, blk := range info.Blocks {
+					require.True(t, blk.ChecksumOK())
+					require.Equal(t, c.blocks[i].numEntries, blk.Entries)
+					require.Equal(t, c.blocks[i].mint, blk.MinTime)
+					require.Equal(t, c.blocks[i].maxt, blk.MaxTime)
+					require.Equal(t, c.blocks[i].offset, blk.Offset)
+					require.Equal(t, c.blocks[i].b, blk.Data)
+					if version == chunkFormatV3 {
+						require.Equal(t, c.blocks[i].uncompressedSize, blk.UncompressedSize)
+					} else {
+						require.Equal(t, 0, blk.UncompressedSize)
+					}
+
+					// The uncompressed size only accounts for lines, not timestamps and lengths.
+					data, err := info.Decompress(i)
+					require.NoError(t, err)
+					require.Greater(t, len(data), c.blocks[i].uncompressedSize)
+				}
+
+				// Corrupt the first block, it must be reported instead of skipped.
+				b[info.Blocks[0].Offset] ^= 0xff
+				info, err = Inspect(b)
+				require.NoError(t, err)
+				require.Len(t, info.Blocks, len(c.blocks))
+				require.False(t, info.Blocks[0].ChecksumOK())
+				require.NotPanics(t, func() { _, _ = info.Decompress(0) })
+
+				// A truncated header can't be decompressed.
+				info.Blocks[0].Data = info.Blocks[0].Data[:1]
+				require.NotPanics(t, func() { _, _ = info.Decompress(0) })
+				if enc == EncGZIP {
+					_, err = info.Decompress(0)
+					require.Error(t, err)
+				}
+
+				info.Encoding = Encoding(255)
+				_, err = info.Decompress(0)
+				require.Error(t, err)
+			})
+		}
+	}
+}
+
+func TestInspect_CorruptMetas(t *testing.T) {
+	// chunk returns a chunk with a single block of 4 bytes and the given block offset and length in its metas.
+	chunk := func(offset, length uint64) []byte {
+		b := make([]byte, 6, 64)
+		binary.BigEndian.PutUint32(b, magicNumber)
+		b[4], b[5] = chunkFormatV2, byte(EncGZIP)
+		b = append(b, 1, 2, 3, 4, 0, 0, 0, 0)
+
+		metasOffset := len(b)
+		buf := make([]byte, binary.MaxVarintLen64)
+		for _, v := range []uint64{1, 1, 0, 0, offset, length} {
+			b = append(b, buf[:binary.PutUvarint(buf, v)]...)
+		}
+		b = append(b, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], crc32.Checksum(b[metasOffset:], castagnoliTable))
+		b = append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(b[len(b)-8:], uint64(metasOffset))
+		return b
+	}
+
+	info, err := Inspect(chunk(6, 4))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3, 4}, info.Blocks[0].Data)
+
+	for _, tc := range []struct{ offset, length uint64 }{
+		{6, 100},
+		{100, 4},
+		{math.MaxUint64, 4},
+		{6, math.MaxUint64},
+		{math.MaxInt64, math.MaxInt64},
+	} {
+		require.NotPanics(t, func() {
+			_, err := Inspect(chunk(tc.offset, tc.length))
+			require.EqualError(t, err, "block 0 out of bounds", "offset %d, length %d", tc.offset, tc.length)
+		})
+	}
+}
@@ -221,6 +221,7 @@ func (hb *unorderedHeadBlock) Iterator(
 	// but the tradeoff is that queries to near-realtime data would be much lower than
 	// cutting of blocks.
 	streams := map[uint64]*logproto.Stream{}
+	chunkStats := stats.GetChunkData(ctx)
 
 	_ = hb.forEntries(
 		ctx,
@@ -232,6 +233,7 @@ func (hb *unorderedHeadBlock) Iterator(
 			if !ok {
 				return nil
 			}
+			chunkStats.PostFilterLines++
 
 			var stream *logproto.Stream
 			lhash := parsedLbs.Hash()
@@ -269,6 +271,7 @@ func (hb *unorderedHeadBlock) SampleIterator(
 ) iter.SampleIterator {
 
 	series := map[uint64]*logproto.Series{}
+	chunkStats := stats.GetChunkData(ctx)
 
 	_ = hb.forEntries(
 		ctx,
@@ -280,6 +283,7 @@ func (hb *unorderedHeadBlock) SampleIterator(
 			if !ok {
 				return nil
 			}
+			chunkStats.PostFilterLines++
 			var found bool
 			var s *logproto.Series
 			lhash := parsedLabels.Hash()
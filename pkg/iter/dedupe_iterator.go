@@ -0,0 +1,154 @@
+package iter
+
+import (
+	"context"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+// dedupeIterator merges iterators returning replicated entries, such as the ones
+// of each ingester and the store, and removes duplicates.
+type dedupeIterator struct {
+	*heapIterator
+	stats *stats.QuerierData
+
+	// streams holds for each stream the lines already returned at the stream's
+	// current timestamp.
+	streams    map[string]*streamLines
+	currEntry  logproto.Entry
+	currLabels string
+}
+
+type streamLines struct {
+	ts    int64
+	lines map[string]struct{}
+}
+
+// NewDedupeIterator returns an iterator merging entries from multiple sources which
+// may return the same entries, like replicated ingesters and the store.
+//
+// The heap iterator only removes identical entries when they are at the top of the heap
+// at the same time, which doesn't hold when sources return entries sharing a timestamp
+// in a different order, for instance because their chunks are cut at different
+// boundaries. The dedupe iterator keeps track of every line returned for the current
+// timestamp of each stream and removes the ones already seen.
+//
+// Removed entries are reported in the querier statistics.
+func NewDedupeIterator(ctx context.Context, is []EntryIterator, direction logproto.Direction) EntryIterator {
+	st := stats.GetQuerierData(ctx)
+	return &dedupeIterator{
+		heapIterator: newHeapIterator(is, direction, &st.TotalDuplicatesRemoved),
+		stats:        st,
+		streams:      map[string]*streamLines{},
+	}
+}
+
+func (i *dedupeIterator) Next() bool {
+	for i.heapIterator.Next() {
+		entry, labels := i.heapIterator.Entry(), i.heapIterator.Labels()
+		ts := entry.Timestamp.UnixNano()
+
+		s, ok := i.streams[labels]
+		if !ok {
+			s = &streamLines{ts: ts, lines: map[string]struct{}{}}
+			i.streams[labels] = s
+		}
+		if s.ts != ts {
+			s.ts = ts
+			for l := range s.lines {
+				delete(s.lines, l)
+			}
+		}
+		if _, ok := s.lines[entry.Line]; ok {
+			i.stats.TotalDuplicatesRemoved++
+			continue
+		}
+		s.lines[entry.Line] = struct{}{}
+
+		i.currEntry, i.currLabels = entry, labels
+		return true
+	}
+	return false
+}
+
+func (i *dedupeIterator) Entry() logproto.Entry {
+	return i.currEntry
+}
+
+func (i *dedupeIterator) Labels() string {
+	return i.currLabels
+}
+
+func (i *dedupeIterator) Close() error {
+	i.streams = nil
+	return i.heapIterator.Close()
+}
+
+// dedupeSampleIterator is the dedupeIterator of samples, the samples of a series are identified by the hash of
+// the line they were extracted from.
+type dedupeSampleIterator struct {
+	*heapSampleIterator
+	stats *stats.QuerierData
+
+	// series holds for each series the hashes of the samples already returned at the series' current timestamp.
+	series     map[string]*seriesHashes
+	currSample logproto.Sample
+	currLabels string
+}
+
+type seriesHashes struct {
+	ts     int64
+	hashes map[uint64]struct{}
+}
+
+// NewDedupeSampleIterator returns an iterator merging samples from multiple sources which may return the same
+// samples, like replicated ingesters and the store, see NewDedupeIterator.
+func NewDedupeSampleIterator(ctx context.Context, is []SampleIterator) SampleIterator {
+	st := stats.GetQuerierData(ctx)
+	return &dedupeSampleIterator{
+		heapSampleIterator: newHeapSampleIterator(is, &st.TotalDuplicatesRemoved),
+		stats:              st,
+		series:             map[string]*seriesHashes{},
+	}
+}
+
+func (i *dedupeSampleIterator) Next() bool {
+	for i.heapSampleIterator.Next() {
+		sample, labels := i.heapSampleIterator.Sample(), i.heapSampleIterator.Labels()
+
+		s, ok := i.series[labels]
+		if !ok {
+			s = &seriesHashes{ts: sample.Timestamp, hashes: map[uint64]struct{}{}}
+			i.series[labels] = s
+		}
+		if s.ts != sample.Timestamp {
+			s.ts = sample.Timestamp
+			for h := range s.hashes {
+				delete(s.hashes, h)
+			}
+		}
+		if _, ok := s.hashes[sample.Hash]; ok {
+			i.stats.TotalDuplicatesRemoved++
+			continue
+		}
+		s.hashes[sample.Hash] = struct{}{}
+
+		i.currSample, i.currLabels = sample, labels
+		return true
+	}
+	return false
+}
+
+func (i *dedupeSampleIterator) Sample() logproto.Sample {
+	return i.currSample
+}
+
+func (i *dedupeSampleIterator) Labels() string {
+	return i.currLabels
+}
+
+func (i *dedupeSampleIterator) Close() error {
+	i.series = nil
+	return i.heapSampleIterator.Close()
+}
@@ -0,0 +1,176 @@
+package iter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+func TestDedupeIterator(t *testing.T) {
+	stream := func(labels string, entries ...logproto.Entry) EntryIterator {
+		return NewStreamIterator(logproto.Stream{Labels: labels, Entries: entries})
+	}
+	entry := func(ts int64, line string) logproto.Entry {
+		return logproto.Entry{Timestamp: time.Unix(0, ts), Line: line}
+	}
+
+	for _, test := range []struct {
+		name               string
+		iters              func() []EntryIterator
+		direction          logproto.Direction
+		expected           []logproto.Entry
+		expectedDuplicates int64
+	}{
+		{
+			"replicas",
+			func() []EntryIterator {
+				return []EntryIterator{
+					stream(`{app="foo"}`, entry(1, "a"), entry(2, "b"), entry(3, "c")),
+					stream(`{app="foo"}`, entry(1, "a"), entry(2, "b"), entry(3, "c")),
+					stream(`{app="foo"}`, entry(1, "a"), entry(2, "b")),
+				}
+			},
+			logproto.FORWARD,
+			[]logproto.Entry{entry(1, "a"), entry(2, "b"), entry(3, "c")},
+			5,
+		},
+		{
+			"same timestamp in a different order",
+			func() []EntryIterator {
+				return []EntryIterator{
+					stream(`{app="foo"}`, entry(1, "a"), entry(1, "b"), entry(1, "c"), entry(2, "d")),
+					stream(`{app="foo"}`, entry(1, "c"), entry(1, "b"), entry(1, "a"), entry(2, "d")),
+				}
+			},
+			logproto.FORWARD,
+			[]logproto.Entry{entry(1, "a"), entry(1, "b"), entry(1, "c"), entry(2, "d")},
+			4,
+		},
+		{
+			"chunk boundaries differ",
+			func() []EntryIterator {
+				return []EntryIterator{
+					// an ingester cut its chunk in the middle of a timestamp.
+					NewNonOverlappingIterator([]EntryIterator{
+						stream(`{app="foo"}`, entry(1, "a"), entry(2, "b")),
+						stream(`{app="foo"}`, entry(2, "c"), entry(3, "d")),
+					}, ""),
+					// the store has the same lines in a single chunk.
+					stream(`{app="foo"}`, entry(1, "a"), entry(2, "c"), entry(2, "b"), entry(3, "d")),
+				}
+			},
+			logproto.FORWARD,
+			[]logproto.Entry{entry(1, "a"), entry(2, "b"), entry(2, "c"), entry(3, "d")},
+			4,
+		},
+		{
+			"same line in different streams",
+			func() []EntryIterator {
+				return []EntryIterator{
+					stream(`{app="foo"}`, entry(1, "a"), entry(2, "a")),
+					stream(`{app="bar"}`, entry(1, "a"), entry(2, "a")),
+				}
+			},
+			logproto.FORWARD,
+			[]logproto.Entry{entry(1, "a"), entry(1, "a"), entry(2, "a"), entry(2, "a")},
+			0,
+		},
+		{
+			"same line at different timestamps",
+			func() []EntryIterator {
+				return []EntryIterator{
+					stream(`{app="foo"}`, entry(1, "a"), entry(2, "a"), entry(3, "a")),
+				}
+			},
+			logproto.FORWARD,
+			[]logproto.Entry{entry(1, "a"), entry(2, "a"), entry(3, "a")},
+			0,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := stats.NewContext(context.Background())
+			it := NewDedupeIterator(ctx, test.iters(), test.direction)
+			defer it.Close()
+
+			var entries []logproto.Entry
+			for it.Next() {
+				entries = append(entries, it.Entry())
+			}
+			require.NoError(t, it.Error())
+			require.Equal(t, test.expected, entries)
+			require.Equal(t, test.expectedDuplicates, stats.GetQuerierData(ctx).TotalDuplicatesRemoved)
+			require.Equal(t, int64(0), stats.GetChunkData(ctx).TotalDuplicates)
+		})
+	}
+}
+
+func TestDedupeSampleIterator(t *testing.T) {
+	series := func(labels string, samples ...logproto.Sample) SampleIterator {
+		return NewSeriesIterator(logproto.Series{Labels: labels, Samples: samples})
+	}
+	sample := func(ts int64, hash uint64) logproto.Sample {
+		return logproto.Sample{Timestamp: ts, Hash: hash, Value: 1}
+	}
+
+	for _, test := range []struct {
+		name               string
+		iters              func() []SampleIterator
+		expected           []logproto.Sample
+		expectedDuplicates int64
+	}{
+		{
+			"replicas",
+			func() []SampleIterator {
+				return []SampleIterator{
+					series(`{app="foo"}`, sample(1, 1), sample(2, 2), sample(3, 3)),
+					series(`{app="foo"}`, sample(1, 1), sample(2, 2), sample(3, 3)),
+					series(`{app="foo"}`, sample(1, 1), sample(2, 2)),
+				}
+			},
+			[]logproto.Sample{sample(1, 1), sample(2, 2), sample(3, 3)},
+			5,
+		},
+		{
+			"same timestamp in a different order",
+			func() []SampleIterator {
+				return []SampleIterator{
+					series(`{app="foo"}`, sample(1, 1), sample(1, 2), sample(1, 3), sample(2, 4)),
+					series(`{app="foo"}`, sample(1, 3), sample(1, 2), sample(1, 1), sample(2, 4)),
+				}
+			},
+			[]logproto.Sample{sample(1, 1), sample(1, 2), sample(1, 3), sample(2, 4)},
+			4,
+		},
+		{
+			"same line in different series",
+			func() []SampleIterator {
+				return []SampleIterator{
+					series(`{app="foo"}`, sample(1, 1)),
+					series(`{app="bar"}`, sample(1, 1)),
+				}
+			},
+			[]logproto.Sample{sample(1, 1), sample(1, 1)},
+			0,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := stats.NewContext(context.Background())
+			it := NewDedupeSampleIterator(ctx, test.iters())
+			defer it.Close()
+
+			var samples []logproto.Sample
+			for it.Next() {
+				samples = append(samples, it.Sample())
+			}
+			require.NoError(t, it.Error())
+			require.Equal(t, test.expected, samples)
+			require.Equal(t, test.expectedDuplicates, stats.GetQuerierData(ctx).TotalDuplicatesRemoved)
+			require.Equal(t, int64(0), stats.GetChunkData(ctx).TotalDuplicates)
+		})
+	}
+}
@@ -143,7 +143,7 @@ type heapIterator struct {
 	}
 	is         []EntryIterator
 	prefetched bool
-	stats      *stats.ChunkData
+	duplicates *int64
 
 	tuples     []tuple
 	currEntry  logproto.Entry
@@ -154,7 +154,12 @@ type heapIterator struct {
 // NewHeapIterator returns a new iterator which uses a heap to merge together
 // entries for multiple interators.
 func NewHeapIterator(ctx context.Context, is []EntryIterator, direction logproto.Direction) HeapIterator {
-	result := &heapIterator{is: is, stats: stats.GetChunkData(ctx)}
+	return newHeapIterator(is, direction, &stats.GetChunkData(ctx).TotalDuplicates)
+}
+
+// newHeapIterator returns a heap iterator counting the duplicates it drops in duplicates.
+func newHeapIterator(is []EntryIterator, direction logproto.Direction, duplicates *int64) *heapIterator {
+	result := &heapIterator{is: is, duplicates: duplicates}
 	switch direction {
 	case logproto.BACKWARD:
 		result.heap = &iteratorMaxHeap{}
@@ -261,7 +266,7 @@ func (i *heapIterator) Next() bool {
 		}
 		// we count as duplicates only if the tuple is not the one (t) used to fill the current entry
 		if i.tuples[j] != t {
-			i.stats.TotalDuplicates++
+			*i.duplicates++
 		}
 		i.requeue(i.tuples[j].EntryIterator, false)
 	}
@@ -142,7 +142,7 @@ type heapSampleIterator struct {
 	heap       *sampleIteratorHeap
 	is         []SampleIterator
 	prefetched bool
-	stats      *stats.ChunkData
+	duplicates *int64
 
 	tuples     []sampletuple
 	curr       logproto.Sample
@@ -153,11 +153,16 @@ type heapSampleIterator struct {
 // NewHeapSampleIterator returns a new iterator which uses a heap to merge together
 // entries for multiple iterators.
 func NewHeapSampleIterator(ctx context.Context, is []SampleIterator) SampleIterator {
+	return newHeapSampleIterator(is, &stats.GetChunkData(ctx).TotalDuplicates)
+}
+
+// newHeapSampleIterator returns a heap iterator counting the duplicates it drops in duplicates.
+func newHeapSampleIterator(is []SampleIterator, duplicates *int64) *heapSampleIterator {
 	return &heapSampleIterator{
-		stats:  stats.GetChunkData(ctx),
-		is:     is,
-		heap:   &sampleIteratorHeap{},
-		tuples: make([]sampletuple, 0, len(is)),
+		duplicates: duplicates,
+		is:         is,
+		heap:       &sampleIteratorHeap{},
+		tuples:     make([]sampletuple, 0, len(is)),
 	}
 }
 
@@ -243,7 +248,7 @@ func (i *heapSampleIterator) Next() bool {
 		}
 		// we count as duplicates only if the tuple is not the one (t) used to fill the current entry
 		if i.tuples[j] != t {
-			i.stats.TotalDuplicates++
+			*i.duplicates++
 		}
 		i.requeue(i.tuples[j].SampleIterator, false)
 	}
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -32,6 +34,9 @@ const (
 
 var userAgent = fmt.Sprintf("loki-logcli/%s", build.Version)
 
+// ErrNotFound is returned when the server responds with a 404.
+var ErrNotFound = errors.New("not found")
+
 // Client contains all the methods to query a Loki instance, it's an interface to allow multiple implementations.
 type Client interface {
 	Query(queryStr string, limit int, time time.Time, direction logproto.Direction, quiet bool) (*loghttp.QueryResponse, error)
@@ -164,17 +169,33 @@ func (c *DefaultClient) doQuery(path string, query string, quiet bool) (*loghttp
 }
 
 func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{}) error {
-	us, err := buildURL(c.Address, path, query)
+	resp, err := c.do("GET", path, query, nil, quiet)
 	if err != nil {
 		return err
 	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Println("error closing body", err)
+		}
+	}()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do sends a request with the given body to the server, retrying it on error responses.
+// The returned response is always successful, it's up to the caller to close its body.
+func (c *DefaultClient) do(method, path, query string, body []byte, quiet bool) (*http.Response, error) {
+	us, err := buildURL(c.Address, path, query)
+	if err != nil {
+		return nil, err
+	}
 	if !quiet {
 		log.Print(us)
 	}
 
-	req, err := http.NewRequest("GET", us, nil)
+	req, err := http.NewRequest(method, us, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.SetBasicAuth(c.Username, c.Password)
@@ -185,11 +206,11 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 	}
 
 	if (c.Username != "" || c.Password != "") && (len(c.BearerToken) > 0 || len(c.BearerTokenFile) > 0) {
-		return fmt.Errorf("at most one of HTTP basic auth (username/password), bearer-token & bearer-token-file is allowed to be configured")
+		return nil, fmt.Errorf("at most one of HTTP basic auth (username/password), bearer-token & bearer-token-file is allowed to be configured")
 	}
 
 	if len(c.BearerToken) > 0 && len(c.BearerTokenFile) > 0 {
-		return fmt.Errorf("at most one of the options bearer-token & bearer-token-file is allowed to be configured")
+		return nil, fmt.Errorf("at most one of the options bearer-token & bearer-token-file is allowed to be configured")
 	}
 
 	if c.BearerToken != "" {
@@ -199,7 +220,7 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 	if c.BearerTokenFile != "" {
 		b, err := ioutil.ReadFile(c.BearerTokenFile)
 		if err != nil {
-			return fmt.Errorf("unable to read authorization credentials file %s: %s", c.BearerTokenFile, err)
+			return nil, fmt.Errorf("unable to read authorization credentials file %s: %s", c.BearerTokenFile, err)
 		}
 		bearerToken := strings.TrimSpace(string(b))
 		req.Header.Set("Authorization", "Bearer "+bearerToken)
@@ -212,7 +233,7 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 
 	client, err := config.NewClientFromConfig(clientConfig, "promtail", config.WithHTTP2Disabled())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if c.Tripperware != nil {
 		client.Transport = c.Tripperware(client.Transport)
@@ -225,11 +246,25 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 	for attempts > 0 {
 		attempts--
 
+		if body != nil {
+			// the body is consumed by each attempt.
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			req.ContentLength = int64(len(body))
+		}
+
 		resp, err = client.Do(req)
 		if err != nil {
 			log.Println("error sending request", err)
 			continue
 		}
+		if resp.StatusCode == http.StatusNotFound {
+			// retrying won't help, let the caller decide whether it's an error.
+			buf, _ := ioutil.ReadAll(resp.Body) // nolint
+			if err := resp.Body.Close(); err != nil {
+				log.Println("error closing body", err)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, strings.TrimSpace(string(buf)))
+		}
 		if resp.StatusCode/100 != 2 {
 			buf, _ := ioutil.ReadAll(resp.Body) // nolint
 			log.Printf("Error response from server: %s (%v) attempts remaining: %d", string(buf), err, attempts)
@@ -242,15 +277,10 @@ func (c *DefaultClient) doRequest(path, query string, quiet bool, out interface{
 		break
 	}
 	if !success {
-		return fmt.Errorf("Run out of attempts while querying the server")
+		return nil, fmt.Errorf("Run out of attempts while querying the server")
 	}
 
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Println("error closing body", err)
-		}
-	}()
-	return json.NewDecoder(resp.Body).Decode(out)
+	return resp, nil
 }
 
 func (c *DefaultClient) wsConnect(path, query string, quiet bool) (*websocket.Conn, error) {
@@ -316,13 +346,18 @@ func (c *DefaultClient) wsConnect(path, query string, quiet bool) (*websocket.Co
 	return conn, nil
 }
 
-// buildURL concats a url `http://foo/bar` with a path `/buzz`.
+// buildURL concats a url `http://foo/bar` with an escaped path `/buzz`.
 func buildURL(u, p, q string) (string, error) {
-	url, err := url.Parse(u)
+	endpoint, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	// The path is joined escaped, so that the escaped / of a path segment is kept.
+	endpoint.RawPath = path.Join(endpoint.EscapedPath(), p)
+	endpoint.Path, err = url.PathUnescape(endpoint.RawPath)
 	if err != nil {
 		return "", err
 	}
-	url.Path = path.Join(url.Path, p)
-	url.RawQuery = q
-	return url.String(), nil
+	endpoint.RawQuery = q
+	return endpoint.String(), nil
 }
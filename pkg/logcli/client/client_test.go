@@ -1,6 +1,18 @@
 package client
 
-import "testing"
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
 
 func Test_buildURL(t *testing.T) {
 	tests := []struct {
@@ -12,6 +24,7 @@ func Test_buildURL(t *testing.T) {
 		{"err", "8://2", "/bar", "", "", true},
 		{"strip /", "http://localhost//", "//bar", "a=b", "http://localhost/bar?a=b", false},
 		{"sub path", "https://localhost/loki/", "/bar/foo", "c=d&e=f", "https://localhost/loki/bar/foo?c=d&e=f", false},
+		{"escaped path", "https://localhost/loki/", "/bar/a%2Fb/%25", "", "https://localhost/loki/bar/a%2Fb/%25", false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -26,3 +39,77 @@ func Test_buildURL(t *testing.T) {
 		})
 	}
 }
+
+func Test_rulesURLPath(t *testing.T) {
+	for _, tc := range []struct {
+		elems []string
+		want  string
+	}{
+		{nil, "/loki/api/v1/rules"},
+		{[]string{"app.yaml", ""}, "/loki/api/v1/rules/app.yaml"},
+		{[]string{"app.yaml", "errors"}, "/loki/api/v1/rules/app.yaml/errors"},
+		{[]string{"team/app", "5% errors"}, "/loki/api/v1/rules/team%2Fapp/5%25%20errors"},
+		{[]string{"..", "."}, "/loki/api/v1/rules/%2E%2E/%2E"},
+	} {
+		require.Equal(t, tc.want, rulesURLPath(tc.elems...))
+	}
+}
+
+func TestDefaultClient_Rules(t *testing.T) {
+	var (
+		mtx    sync.Mutex
+		groups = map[string]string{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/loki/api/v1/rules/app.yaml":
+			b, _ := ioutil.ReadAll(r.Body)
+			groups["app.yaml"] = string(b)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "GET" && r.URL.Path == "/loki/api/v1/rules":
+			if len(groups) == 0 {
+				http.Error(w, "no rule groups found", http.StatusNotFound)
+				return
+			}
+			_, _ = fmt.Fprintf(w, "app.yaml:\n  - %s", strings.ReplaceAll(groups["app.yaml"], "\n", "\n    "))
+		case r.Method == "DELETE" && r.URL.Path == "/loki/api/v1/rules/app.yaml/errors":
+			delete(groups, "app.yaml")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "DELETE" && r.URL.EscapedPath() == "/loki/api/v1/rules/team%2Fapp/%2E%2E":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	c := &DefaultClient{Address: server.URL}
+
+	rules, err := c.ListRules("", true)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	var group rulefmt.RuleGroup
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: errors
+rules:
+  - record: app:errors:rate1m
+    expr: sum(rate({app="foo"} |= "error" [1m]))
+`), &group))
+	require.NoError(t, c.SetRuleGroup("app.yaml", group, true))
+
+	rules, err = c.ListRules("", true)
+	require.NoError(t, err)
+	require.Len(t, rules["app.yaml"], 1)
+	require.Equal(t, "errors", rules["app.yaml"][0].Name)
+	require.Equal(t, `sum(rate({app="foo"} |= "error" [1m]))`, rules["app.yaml"][0].Rules[0].Expr.Value)
+
+	require.NoError(t, c.DeleteRuleGroup("app.yaml", "errors", true))
+	_, err = c.GetRuleGroup("app.yaml", "errors", true)
+	require.Error(t, err)
+
+	// Each name is a single path segment.
+	require.NoError(t, c.DeleteRuleGroup("team/app", "..", true))
+}
@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/loki"
+	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	chunk_local "github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/chunk/objectclient"
+	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/util/cfg"
+	"github.com/grafana/loki/pkg/util/marshal"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+const (
+	// defaultLocalOrgID is the tenant of a Loki running with auth disabled.
+	defaultLocalOrgID = "fake"
+	// offlineObjectType is the object store type of the chunks of an offline copy of an object store
+	// bucket, their keys are not encoded like the ones of the filesystem object store.
+	offlineObjectType = "logcli-offline-copy"
+)
+
+// LocalClient runs queries directly against the storage configured in a Loki configuration file,
+// without a running Loki.
+type LocalClient struct {
+	OrgID string
+
+	store   storage.Store
+	engine  *logql.Engine
+	tempDir string
+}
+
+// NewLocalClient creates a client querying the storage of the Loki configuration file. When
+// offlineDir is set, the chunks and the boltdb-shipper index are read from this copy of the object
+// store on the local filesystem instead, and the caches of the configuration are disabled.
+// The client only reads from the storage, Stop cleans up the files it downloaded.
+func NewLocalClient(configFile, offlineDir, orgID string) (*LocalClient, error) {
+	var conf loki.Config
+	if err := loadConfig(configFile, &conf); err != nil {
+		return nil, err
+	}
+
+	c := &LocalClient{OrgID: orgID}
+	if offlineDir != "" {
+		tempDir, err := ioutil.TempDir("", "logcli-store")
+		if err != nil {
+			return nil, err
+		}
+		c.tempDir = tempDir
+		if err := setOffline(&conf, offlineDir, tempDir); err != nil {
+			c.Stop()
+			return nil, err
+		}
+	}
+	// Only read from the index, without uploading anything.
+	conf.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadOnly
+
+	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
+	if err != nil {
+		c.Stop()
+		return nil, err
+	}
+
+	storage.RegisterCustomIndexClients(&conf.StorageConfig, nil)
+	chunkStore, err := chunk_storage.NewStore(conf.StorageConfig.Config, conf.ChunkStoreConfig.StoreConfig, conf.SchemaConfig.SchemaConfig, limits, nil, nil, util_log.Logger)
+	if err != nil {
+		c.Stop()
+		return nil, err
+	}
+
+	c.store, err = storage.NewStore(conf.StorageConfig, conf.SchemaConfig, chunkStore, nil)
+	if err != nil {
+		chunkStore.Stop()
+		c.Stop()
+		return nil, err
+	}
+	c.engine = logql.NewEngine(conf.Querier.Engine, c.store, limits)
+
+	return c, nil
+}
+
+// loadConfig loads the Loki configuration file on top of the default configuration.
+func loadConfig(configFile string, conf *loki.Config) error {
+	if configFile == "" {
+		return errors.New("no supplied config file")
+	}
+
+	conf.RegisterFlags(flag.NewFlagSet("logcli", flag.ContinueOnError))
+	if err := cfg.YAML(configFile, false)(conf); err != nil {
+		return err
+	}
+	return conf.Validate()
+}
+
+// setOffline changes the storage of conf to read from the copy of the object store in dir, the
+// files of the index are downloaded to tempDir.
+func setOffline(conf *loki.Config, dir, tempDir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+
+	for i, period := range conf.SchemaConfig.Configs {
+		if period.IndexType != shipper.BoltDBShipperType {
+			return fmt.Errorf("the index type of the period starting at %s is %s, only %s can be read offline", period.From, period.IndexType, shipper.BoltDBShipperType)
+		}
+		// The chunks of the filesystem object store are copied as is, the other ones are named after
+		// their key.
+		if period.ObjectType != chunk_storage.StorageTypeFileSystem {
+			conf.SchemaConfig.Configs[i].ObjectType = offlineObjectType
+		}
+	}
+
+	conf.StorageConfig.FSConfig.Directory = dir
+	chunk_storage.RegisterChunkClient(offlineObjectType, func() (chunk.Client, error) {
+		store, err := chunk_local.NewFSObjectClient(chunk_local.FSConfig{Directory: dir})
+		if err != nil {
+			return nil, err
+		}
+		return objectclient.NewClient(store, nil), nil
+	})
+
+	shipperCfg := &conf.StorageConfig.BoltDBShipperConfig
+	shipperCfg.SharedStoreType = chunk_storage.StorageTypeFileSystem
+	shipperCfg.ActiveIndexDirectory = filepath.Join(tempDir, "index")
+	shipperCfg.CacheLocation = filepath.Join(tempDir, "cache")
+	shipperCfg.IndexGatewayClientConfig.Address = ""
+
+	conf.StorageConfig.IndexQueriesCacheConfig = cache.Config{}
+	conf.ChunkStoreConfig.ChunkCacheConfig = cache.Config{}
+	conf.ChunkStoreConfig.WriteDedupeCacheConfig = cache.Config{}
+	return nil
+}
+
+// Stop stops the store and removes the files downloaded by the client.
+func (c *LocalClient) Stop() {
+	if c.store != nil {
+		c.store.Stop()
+	}
+	if c.tempDir != "" {
+		_ = os.RemoveAll(c.tempDir)
+	}
+}
+
+// Query executes an instant query against the store.
+// nolint:interfacer
+func (c *LocalClient) Query(queryStr string, limit int, time time.Time, direction logproto.Direction, quiet bool) (*loghttp.QueryResponse, error) {
+	return c.exec(logql.NewLiteralParams(queryStr, time, time, 0, 0, direction, uint32(limit), nil))
+}
+
+// QueryRange executes a range query against the store, the step defaults to the one of the query
+// range API.
+// nolint:interfacer
+func (c *LocalClient) QueryRange(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool) (*loghttp.QueryResponse, error) {
+	if step == 0 {
+		step = time.Duration(loghttp.DefaultQueryRangeStep(start, end)) * time.Second
+	}
+	return c.exec(logql.NewLiteralParams(queryStr, start, end, step, interval, direction, uint32(limit), nil))
+}
+
+func (c *LocalClient) exec(params logql.LiteralParams) (*loghttp.QueryResponse, error) {
+	result, err := c.engine.Query(params).Exec(c.context())
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := marshal.NewResultValue(result.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loghttp.QueryResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: loghttp.QueryResponseData{
+			ResultType: value.Type(),
+			Result:     value,
+			Statistics: result.Statistics,
+		},
+	}, nil
+}
+
+// ListLabelNames returns the label names of the streams of the store.
+func (c *LocalClient) ListLabelNames(quiet bool, start, end time.Time) (*loghttp.LabelResponse, error) {
+	names, err := c.store.LabelNamesForMetricName(c.context(), c.GetOrgID(), model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(end.UnixNano()), "logs")
+	if err != nil {
+		return nil, err
+	}
+	return &loghttp.LabelResponse{Status: loghttp.QueryStatusSuccess, Data: names}, nil
+}
+
+// ListLabelValues returns the values of a label of the streams of the store.
+func (c *LocalClient) ListLabelValues(name string, quiet bool, start, end time.Time) (*loghttp.LabelResponse, error) {
+	values, err := c.store.LabelValuesForMetricName(c.context(), c.GetOrgID(), model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(end.UnixNano()), "logs", name)
+	if err != nil {
+		return nil, err
+	}
+	return &loghttp.LabelResponse{Status: loghttp.QueryStatusSuccess, Data: values}, nil
+}
+
+// Series returns the streams of the store matching any of the matchers, or all of them when there
+// are no matchers.
+func (c *LocalClient) Series(matchers []string, start, end time.Time, quiet bool) (*loghttp.SeriesResponse, error) {
+	if len(matchers) == 0 {
+		matchers = []string{""}
+	}
+
+	seen := map[string]struct{}{}
+	resp := &loghttp.SeriesResponse{Status: loghttp.QueryStatusSuccess}
+	for _, matcher := range matchers {
+		ids, err := c.store.GetSeries(c.context(), logql.SelectLogParams{
+			QueryRequest: &logproto.QueryRequest{
+				Selector:  matcher,
+				Limit:     1,
+				Start:     start,
+				End:       end,
+				Direction: logproto.FORWARD,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			ls := loghttp.LabelSet(id.Labels)
+			key := ls.String()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			resp.Data = append(resp.Data, ls)
+		}
+	}
+	sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].String() < resp.Data[j].String() })
+	return resp, nil
+}
+
+// LiveTailQueryConn is not supported, there is no ingester to tail.
+func (c *LocalClient) LiveTailQueryConn(queryStr string, delayFor time.Duration, limit int, start time.Time, quiet bool) (*websocket.Conn, error) {
+	return nil, errors.New("tailing is not supported when querying the store")
+}
+
+// GetOrgID returns the tenant of the queries, the one of a Loki running with auth disabled by default.
+func (c *LocalClient) GetOrgID() string {
+	if c.OrgID == "" {
+		return defaultLocalOrgID
+	}
+	return c.OrgID
+}
+
+func (c *LocalClient) context() context.Context {
+	return user.InjectOrgID(context.Background(), c.GetOrgID())
+}
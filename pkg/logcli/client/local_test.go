@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/loki"
+	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	chunk_local "github.com/grafana/loki/pkg/storage/chunk/local"
+	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	loki_util "github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+const localConfig = `
+auth_enabled: false
+schema_config:
+  configs:
+    - from: 2020-01-01
+      store: boltdb-shipper
+      object_store: filesystem
+      schema: v11
+      index:
+        prefix: index_
+        period: 24h
+storage_config:
+  boltdb_shipper:
+    active_index_directory: %[1]s/index
+    cache_location: %[1]s/cache
+    shared_store: filesystem
+  filesystem:
+    directory: %[1]s/chunks
+`
+
+// writeLocalStore writes a Loki configuration file and the chunks of two streams to its storage.
+func writeLocalStore(t *testing.T, now time.Time) (string, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "logcli-local")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	configFile := filepath.Join(dir, "loki.yaml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(fmt.Sprintf(localConfig, dir)), 0644))
+
+	var conf loki.Config
+	require.NoError(t, loadConfig(configFile, &conf))
+	conf.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadWrite
+
+	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
+	require.NoError(t, err)
+	storage.RegisterCustomIndexClients(&conf.StorageConfig, nil)
+	chunkStore, err := chunk_storage.NewStore(conf.StorageConfig.Config, conf.ChunkStoreConfig.StoreConfig, conf.SchemaConfig.SchemaConfig, limits, nil, nil, util_log.Logger)
+	require.NoError(t, err)
+	store, err := storage.NewStore(conf.StorageConfig, conf.SchemaConfig, chunkStore, nil)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), defaultLocalOrgID)
+	for _, lbs := range []string{`{app="foo", level="error"}`, `{app="bar", level="info"}`} {
+		chk := newChunk(t, lbs, now.Add(-30*time.Minute), 10)
+		require.NoError(t, store.PutOne(ctx, chk.From, chk.Through, chk))
+	}
+	// the index is uploaded when the store stops.
+	store.Stop()
+
+	return dir, configFile
+}
+
+func newChunk(t *testing.T, lbls string, from time.Time, n int) chunk.Chunk {
+	t.Helper()
+	lbs, err := logql.ParseLabels(lbls)
+	require.NoError(t, err)
+	ls := labels.NewBuilder(lbs).Set(labels.MetricName, "logs").Labels()
+
+	chk := chunkenc.NewMemChunk(chunkenc.EncGZIP, chunkenc.UnorderedHeadBlockFmt, 256*1024, 0)
+	for i := 0; i < n; i++ {
+		require.NoError(t, chk.Append(&logproto.Entry{Timestamp: from.Add(time.Duration(i) * time.Minute), Line: fmt.Sprintf("line %d", i)}))
+	}
+	chk.Close()
+	start, end := loki_util.RoundToMilliseconds(from, from.Add(time.Duration(n-1)*time.Minute))
+	c := chunk.NewChunk(defaultLocalOrgID, client.Fingerprint(ls), ls, chunkenc.NewFacade(chk, 0, 0), start, end)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func testLocalClient(t *testing.T, c *LocalClient, now time.Time) {
+	t.Helper()
+	start, end := now.Add(-time.Hour), now
+
+	names, err := c.ListLabelNames(true, start, end)
+	require.NoError(t, err)
+	require.Equal(t, []string{"__name__", "app", "level"}, names.Data)
+
+	values, err := c.ListLabelValues("app", true, start, end)
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar", "foo"}, values.Data)
+
+	series, err := c.Series([]string{`{app="foo"}`, `{level=~"error|info"}`}, start, end, true)
+	require.NoError(t, err)
+	require.Equal(t, []loghttp.LabelSet{
+		{"app": "bar", "level": "info"},
+		{"app": "foo", "level": "error"},
+	}, series.Data)
+
+	resp, err := c.QueryRange(`{app="foo"}`, 5, start, end, logproto.BACKWARD, 0, 0, true)
+	require.NoError(t, err)
+	streams := resp.Data.Result.(loghttp.Streams)
+	require.Len(t, streams, 1)
+	require.Len(t, streams[0].Entries, 5)
+	require.Equal(t, "line 9", streams[0].Entries[0].Line)
+
+	// the step defaults to the one of the API, a sample every 14s over the last hour.
+	resp, err = c.QueryRange(`sum by (app) (count_over_time({level=~".+"}[1h]))`, 100, start, end, logproto.FORWARD, 0, 0, true)
+	require.NoError(t, err)
+	matrix := resp.Data.Result.(loghttp.Matrix)
+	require.Len(t, matrix, 2)
+	require.Equal(t, 10.0, float64(matrix[0].Values[len(matrix[0].Values)-1].Value))
+
+	resp, err = c.Query(`sum(count_over_time({app="bar"}[1h]))`, 100, end, logproto.FORWARD, true)
+	require.NoError(t, err)
+	vector := resp.Data.Result.(loghttp.Vector)
+	require.Len(t, vector, 1)
+	require.Equal(t, 10.0, float64(vector[0].Value))
+
+	_, err = c.LiveTailQueryConn(`{app="foo"}`, 0, 10, start, true)
+	require.Error(t, err)
+}
+
+func TestLocalClient(t *testing.T) {
+	now := time.Now()
+	_, configFile := writeLocalStore(t, now)
+
+	c, err := NewLocalClient(configFile, "", "")
+	require.NoError(t, err)
+	defer c.Stop()
+
+	testLocalClient(t, c, now)
+}
+
+func TestLocalClient_Offline(t *testing.T) {
+	now := time.Now()
+	dir, configFile := writeLocalStore(t, now)
+
+	// the configuration refers to the original storage, which is moved away.
+	copyDir := dir + "-copy"
+	require.NoError(t, os.Rename(filepath.Join(dir, "chunks"), copyDir))
+	t.Cleanup(func() { _ = os.RemoveAll(copyDir) })
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "cache")))
+
+	c, err := NewLocalClient(configFile, copyDir, "")
+	require.NoError(t, err)
+	defer c.Stop()
+
+	testLocalClient(t, c, now)
+	_, err = os.Stat(filepath.Join(dir, "cache"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func Test_setOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logcli-offline")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := loki.Config{}
+	conf.SchemaConfig.Configs = []chunk.PeriodConfig{
+		{IndexType: shipper.BoltDBShipperType, ObjectType: "filesystem"},
+		{IndexType: shipper.BoltDBShipperType, ObjectType: "s3"},
+	}
+	conf.StorageConfig.BoltDBShipperConfig.SharedStoreType = "s3"
+	conf.StorageConfig.FSConfig = chunk_local.FSConfig{Directory: "/loki/chunks"}
+
+	require.NoError(t, setOffline(&conf, dir, "/tmp/logcli"))
+	require.Equal(t, "filesystem", conf.SchemaConfig.Configs[0].ObjectType)
+	require.Equal(t, offlineObjectType, conf.SchemaConfig.Configs[1].ObjectType)
+	require.Equal(t, dir, conf.StorageConfig.FSConfig.Directory)
+	require.Equal(t, "filesystem", conf.StorageConfig.BoltDBShipperConfig.SharedStoreType)
+	require.Equal(t, "/tmp/logcli/cache", conf.StorageConfig.BoltDBShipperConfig.CacheLocation)
+
+	conf.SchemaConfig.Configs = append(conf.SchemaConfig.Configs, chunk.PeriodConfig{IndexType: "cassandra"})
+	require.Error(t, setOffline(&conf, dir, "/tmp/logcli"))
+	require.Error(t, setOffline(&conf, filepath.Join(dir, "missing"), "/tmp/logcli"))
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const rulesPath = "/loki/api/v1/rules"
+
+// RulesClient contains all the methods to manage the rule groups of a Loki ruler.
+type RulesClient interface {
+	ListRules(namespace string, quiet bool) (map[string][]rulefmt.RuleGroup, error)
+	GetRuleGroup(namespace, groupName string, quiet bool) (*rulefmt.RuleGroup, error)
+	SetRuleGroup(namespace string, group rulefmt.RuleGroup, quiet bool) error
+	DeleteRuleGroup(namespace, groupName string, quiet bool) error
+	DeleteNamespace(namespace string, quiet bool) error
+}
+
+// ListRules uses the /loki/api/v1/rules endpoint to list the rule groups by namespace, all namespaces
+// are listed when namespace is empty.
+func (c *DefaultClient) ListRules(namespace string, quiet bool) (map[string][]rulefmt.RuleGroup, error) {
+	rules := map[string][]rulefmt.RuleGroup{}
+	if err := c.doRulesRequest("GET", rulesURLPath(namespace), nil, quiet, &rules); err != nil {
+		// the ruler responds with a 404 when there are no rule groups.
+		if errors.Is(err, ErrNotFound) {
+			return map[string][]rulefmt.RuleGroup{}, nil
+		}
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRuleGroup uses the /loki/api/v1/rules endpoint to get a rule group, ErrNotFound is returned when
+// the rule group doesn't exist.
+func (c *DefaultClient) GetRuleGroup(namespace, groupName string, quiet bool) (*rulefmt.RuleGroup, error) {
+	var group rulefmt.RuleGroup
+	if err := c.doRulesRequest("GET", rulesURLPath(namespace, groupName), nil, quiet, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// SetRuleGroup uses the /loki/api/v1/rules endpoint to create or replace a rule group of a namespace.
+func (c *DefaultClient) SetRuleGroup(namespace string, group rulefmt.RuleGroup, quiet bool) error {
+	b, err := yaml.Marshal(group)
+	if err != nil {
+		return err
+	}
+	return c.doRulesRequest("POST", rulesURLPath(namespace), b, quiet, nil)
+}
+
+// DeleteRuleGroup uses the /loki/api/v1/rules endpoint to delete a rule group of a namespace.
+func (c *DefaultClient) DeleteRuleGroup(namespace, groupName string, quiet bool) error {
+	return c.doRulesRequest("DELETE", rulesURLPath(namespace, groupName), nil, quiet, nil)
+}
+
+// DeleteNamespace uses the /loki/api/v1/rules endpoint to delete all the rule groups of a namespace.
+func (c *DefaultClient) DeleteNamespace(namespace string, quiet bool) error {
+	return c.doRulesRequest("DELETE", rulesURLPath(namespace), nil, quiet, nil)
+}
+
+// doRulesRequest sends a request to the rules API, the ruler uses YAML for both requests and responses.
+func (c *DefaultClient) doRulesRequest(method, path string, body []byte, quiet bool, out interface{}) error {
+	resp, err := c.do(method, path, "", body, quiet)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Println("error closing body", err)
+		}
+	}()
+	if out == nil {
+		return nil
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(b, out)
+}
+
+// rulesURLPath returns the escaped path of the rules API for the given elements, empty elements are ignored.
+// Each element is a single path segment, whatever it contains.
+func rulesURLPath(elems ...string) string {
+	p := rulesPath
+	for _, e := range elems {
+		if e == "" {
+			break
+		}
+		p += "/" + escapePathSegment(e)
+	}
+	return p
+}
+
+// escapePathSegment escapes a path segment, including the dot segments which would otherwise be
+// removed when the path is cleaned.
+func escapePathSegment(s string) string {
+	if s == "." || s == ".." {
+		return strings.ReplaceAll(s, ".", "%2E")
+	}
+	return url.PathEscape(s)
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// CSVOutput prints logs and metadata as comma or tab separated values, suitable for spreadsheets.
+// The columns are the timestamp, the labels unless they are disabled, and the log line.
+type CSVOutput struct {
+	w       io.Writer
+	options *LogOutputOptions
+	comma   rune
+}
+
+// Format a log entry as a CSV record
+func (o *CSVOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	record := []string{ts.In(o.options.Timezone).Format(time.RFC3339Nano)}
+	if !o.options.NoLabels {
+		record = append(record, lbls.String())
+	}
+	record = append(record, trimNewline(line))
+
+	w := csv.NewWriter(o.w)
+	w.Comma = o.comma
+	if err := w.Write(record); err != nil {
+		log.Fatalf("error writing entry: %s", err)
+	}
+	w.Flush()
+}
+
+// WithWriter returns a copy of the output writing to w.
+func (o *CSVOutput) WithWriter(w io.Writer) LogOutput {
+	return &CSVOutput{
+		w:       w,
+		options: o.options,
+		comma:   o.comma,
+	}
+}
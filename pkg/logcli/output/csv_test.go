@@ -0,0 +1,67 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestCSVOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type": "test",
+	})
+
+	tests := map[string]struct {
+		options   *LogOutputOptions
+		comma     rune
+		timestamp time.Time
+		lbls      loghttp.LabelSet
+		line      string
+		expected  string
+	}{
+		"csv with labels": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: false},
+			',',
+			timestamp,
+			someLabels,
+			"Hello",
+			`2006-01-02T08:04:05Z,"{type=""test""}",Hello` + "\n",
+		},
+		"csv line with separator": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: true},
+			',',
+			timestamp,
+			someLabels,
+			"Hello, world\n",
+			`2006-01-02T08:04:05Z,"Hello, world"` + "\n",
+		},
+		"tsv with labels": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: false},
+			'\t',
+			timestamp,
+			someLabels,
+			"Hello, world",
+			"2006-01-02T08:04:05Z\t\"{type=\"\"test\"\"}\"\tHello, world\n",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			writer := &bytes.Buffer{}
+			out := &CSVOutput{writer, testData.options, testData.comma}
+			out.FormatAndPrintln(testData.timestamp, testData.lbls, 0, testData.line)
+
+			assert.Equal(t, testData.expected, writer.String())
+		})
+	}
+}
@@ -43,3 +43,11 @@ func padLabel(ls loghttp.LabelSet, maxLabelsLen int) string {
 	}
 	return labels
 }
+
+// WithWriter returns a copy of the output writing to w.
+func (o *DefaultOutput) WithWriter(w io.Writer) LogOutput {
+	return &DefaultOutput{
+		w:       w,
+		options: o.options,
+	}
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/go-logfmt/logfmt"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// LogfmtOutput prints logs and metadata in logfmt, the labels are printed between the timestamp and the
+// log line, sorted by name.
+type LogfmtOutput struct {
+	w       io.Writer
+	options *LogOutputOptions
+}
+
+// Format a log entry as a logfmt line
+func (o *LogfmtOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	keyvals := []interface{}{"ts", ts.In(o.options.Timezone).Format(time.RFC3339Nano)}
+	if !o.options.NoLabels {
+		names := make([]string, 0, len(lbls))
+		for name := range lbls {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			keyvals = append(keyvals, name, lbls[name])
+		}
+	}
+	keyvals = append(keyvals, "line", trimNewline(line))
+
+	enc := logfmt.NewEncoder(o.w)
+	if err := enc.EncodeKeyvals(keyvals...); err != nil {
+		log.Fatalf("error encoding entry: %s", err)
+	}
+	if err := enc.EndRecord(); err != nil {
+		log.Fatalf("error encoding entry: %s", err)
+	}
+}
+
+// WithWriter returns a copy of the output writing to w.
+func (o *LogfmtOutput) WithWriter(w io.Writer) LogOutput {
+	return &LogfmtOutput{
+		w:       w,
+		options: o.options,
+	}
+}
@@ -0,0 +1,57 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestLogfmtOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type": "test",
+		"app":  "foo bar",
+	})
+
+	tests := map[string]struct {
+		options   *LogOutputOptions
+		timestamp time.Time
+		lbls      loghttp.LabelSet
+		line      string
+		expected  string
+	}{
+		"labels sorted by name": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: false},
+			timestamp,
+			someLabels,
+			"Hello",
+			`ts=2006-01-02T08:04:05Z app="foo bar" type=test line=Hello` + "\n",
+		},
+		"labels output disabled": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: true},
+			timestamp,
+			someLabels,
+			"level=info msg=\"Hello\"\n",
+			`ts=2006-01-02T08:04:05Z line="level=info msg=\"Hello\""` + "\n",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			writer := &bytes.Buffer{}
+			out := &LogfmtOutput{writer, testData.options}
+			out.FormatAndPrintln(testData.timestamp, testData.lbls, 0, testData.line)
+
+			assert.Equal(t, testData.expected, writer.String())
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// sparklineWidth is the maximum number of characters of a sparkline, samples are averaged above it.
+const sparklineWidth = 60
+
+var sparklineTicks = []rune("▁▂▃▄▅▆▇█")
+
+// MetricOutput is the interface any output mode of metric query results must implement
+type MetricOutput interface {
+	PrintScalar(scalar loghttp.Scalar)
+	PrintVector(vector loghttp.Vector)
+	PrintMatrix(matrix loghttp.Matrix)
+}
+
+// NewMetricOutput creates a metric output based on the input mode and options
+func NewMetricOutput(w io.Writer, mode string, options *LogOutputOptions) (MetricOutput, error) {
+	if options.Timezone == nil {
+		options.Timezone = time.Local
+	}
+
+	switch mode {
+	case "json":
+		return &JSONMetricOutput{w: w}, nil
+	case "table", "sparkline", "columns":
+		return &TableMetricOutput{
+			w:       w,
+			options: options,
+			mode:    mode,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown metric output mode '%s'", mode)
+	}
+}
+
+// JSONMetricOutput prints metric results as the indented JSON of the Loki API
+type JSONMetricOutput struct {
+	w io.Writer
+}
+
+func (o *JSONMetricOutput) PrintScalar(scalar loghttp.Scalar) {
+	o.print(scalar)
+}
+
+func (o *JSONMetricOutput) PrintVector(vector loghttp.Vector) {
+	o.print(vector)
+}
+
+func (o *JSONMetricOutput) PrintMatrix(matrix loghttp.Matrix) {
+	o.print(matrix)
+}
+
+func (o *JSONMetricOutput) print(v interface{}) {
+	bytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshalling %T: %v", v, err)
+	}
+
+	fmt.Fprint(o.w, string(bytes))
+}
+
+// TableMetricOutput prints metric results as human readable tables with a column per label.
+// Matrices are printed with a row per sample in table mode, with a row per series and a sparkline
+// of its samples in sparkline mode, and with a row per timestamp and a column per series in
+// columns mode.
+type TableMetricOutput struct {
+	w       io.Writer
+	options *LogOutputOptions
+	mode    string
+}
+
+func (o *TableMetricOutput) PrintScalar(scalar loghttp.Scalar) {
+	tw := o.newTabWriter()
+	fmt.Fprintln(tw, "TIMESTAMP\tVALUE")
+	fmt.Fprintf(tw, "%s\t%s\n", o.formatTime(scalar.Timestamp), scalar.Value)
+	_ = tw.Flush()
+}
+
+func (o *TableMetricOutput) PrintVector(vector loghttp.Vector) {
+	metrics := make([]model.Metric, 0, len(vector))
+	for _, s := range vector {
+		metrics = append(metrics, s.Metric)
+	}
+	names := o.labelNames(metrics)
+
+	sorted := append(loghttp.Vector(nil), vector...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Metric.Before(sorted[j].Metric) })
+
+	tw := o.newTabWriter()
+	fmt.Fprintln(tw, row(append(append([]string{"TIMESTAMP"}, names...), "VALUE")))
+	for _, s := range sorted {
+		fmt.Fprintln(tw, row(append(append([]string{o.formatTime(s.Timestamp)}, labelValues(names, s.Metric)...), s.Value.String())))
+	}
+	_ = tw.Flush()
+}
+
+func (o *TableMetricOutput) PrintMatrix(matrix loghttp.Matrix) {
+	sorted := append(loghttp.Matrix(nil), matrix...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Metric.Before(sorted[j].Metric) })
+
+	switch o.mode {
+	case "sparkline":
+		o.printSparklines(sorted)
+	case "columns":
+		o.printColumns(sorted)
+	default:
+		o.printSamples(sorted)
+	}
+}
+
+func (o *TableMetricOutput) printSamples(matrix loghttp.Matrix) {
+	names := o.labelNames(metrics(matrix))
+
+	tw := o.newTabWriter()
+	fmt.Fprintln(tw, row(append(append([]string{"TIMESTAMP"}, names...), "VALUE")))
+	for _, s := range matrix {
+		values := labelValues(names, s.Metric)
+		for _, p := range s.Values {
+			fmt.Fprintln(tw, row(append(append([]string{o.formatTime(p.Timestamp)}, values...), p.Value.String())))
+		}
+	}
+	_ = tw.Flush()
+}
+
+func (o *TableMetricOutput) printSparklines(matrix loghttp.Matrix) {
+	names := o.labelNames(metrics(matrix))
+
+	tw := o.newTabWriter()
+	fmt.Fprintln(tw, row(append(names, "MIN", "MAX", "LAST", "SAMPLES")))
+	for _, s := range matrix {
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, p := range s.Values {
+			min = math.Min(min, float64(p.Value))
+			max = math.Max(max, float64(p.Value))
+		}
+		last := ""
+		if len(s.Values) > 0 {
+			last = s.Values[len(s.Values)-1].Value.String()
+		} else {
+			min, max = math.NaN(), math.NaN()
+		}
+		fmt.Fprintln(tw, row(append(labelValues(names, s.Metric),
+			model.SampleValue(min).String(),
+			model.SampleValue(max).String(),
+			last,
+			Sparkline(s.Values, sparklineWidth),
+		)))
+	}
+	_ = tw.Flush()
+}
+
+func (o *TableMetricOutput) printColumns(matrix loghttp.Matrix) {
+	var (
+		timestamps []model.Time
+		seen       = map[model.Time]struct{}{}
+		values     = make([]map[model.Time]model.SampleValue, len(matrix))
+		columns    = []string{"TIMESTAMP"}
+	)
+	for i, s := range matrix {
+		columns = append(columns, s.Metric.String())
+		values[i] = make(map[model.Time]model.SampleValue, len(s.Values))
+		for _, p := range s.Values {
+			values[i][p.Timestamp] = p.Value
+			if _, ok := seen[p.Timestamp]; !ok {
+				seen[p.Timestamp] = struct{}{}
+				timestamps = append(timestamps, p.Timestamp)
+			}
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	tw := o.newTabWriter()
+	fmt.Fprintln(tw, row(columns))
+	for _, ts := range timestamps {
+		cells := []string{o.formatTime(ts)}
+		for i := range matrix {
+			v, ok := values[i][ts]
+			if !ok {
+				cells = append(cells, "")
+				continue
+			}
+			cells = append(cells, v.String())
+		}
+		fmt.Fprintln(tw, row(cells))
+	}
+	_ = tw.Flush()
+}
+
+// labelNames returns the sorted names of the labels of the metrics, or none when labels are disabled.
+func (o *TableMetricOutput) labelNames(metrics []model.Metric) []string {
+	if o.options.NoLabels {
+		return nil
+	}
+	set := map[string]struct{}{}
+	for _, m := range metrics {
+		for name := range m {
+			set[string(name)] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (o *TableMetricOutput) formatTime(ts model.Time) string {
+	return ts.Time().In(o.options.Timezone).Format(time.RFC3339)
+}
+
+func (o *TableMetricOutput) newTabWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(o.w, 0, 8, 2, ' ', 0)
+}
+
+// Sparkline renders the values of samples as a line of block characters scaled between their minimum and
+// maximum, the samples are averaged when there are more than width of them.
+func Sparkline(samples []model.SamplePair, width int) string {
+	if len(samples) == 0 {
+		return ""
+	}
+
+	buckets := len(samples)
+	if width > 0 && buckets > width {
+		buckets = width
+	}
+	values := make([]float64, buckets)
+	counts := make([]int, buckets)
+	for i, p := range samples {
+		b := i * buckets / len(samples)
+		values[b] += float64(p.Value)
+		counts[b]++
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for i := range values {
+		values[i] /= float64(counts[i])
+		if math.IsNaN(values[i]) || math.IsInf(values[i], 0) {
+			continue
+		}
+		min = math.Min(min, values[i])
+		max = math.Max(max, values[i])
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v) || math.IsInf(v, 0):
+			sb.WriteRune(' ')
+		case max == min:
+			sb.WriteRune(sparklineTicks[0])
+		default:
+			sb.WriteRune(sparklineTicks[int((v-min)/(max-min)*float64(len(sparklineTicks)-1))])
+		}
+	}
+	return sb.String()
+}
+
+func metrics(matrix loghttp.Matrix) []model.Metric {
+	res := make([]model.Metric, 0, len(matrix))
+	for _, s := range matrix {
+		res = append(res, s.Metric)
+	}
+	return res
+}
+
+func labelValues(names []string, m model.Metric) []string {
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		values = append(values, string(m[model.LabelName(name)]))
+	}
+	return values
+}
+
+func row(cells []string) string {
+	return strings.Join(cells, "\t")
+}
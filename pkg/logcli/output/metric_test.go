@@ -0,0 +1,131 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func testMatrix() loghttp.Matrix {
+	return loghttp.Matrix{
+		{
+			Metric: model.Metric{"app": "foo", "level": "error"},
+			Values: []model.SamplePair{{Timestamp: 0, Value: 1}, {Timestamp: 60000, Value: 3}},
+		},
+		{
+			Metric: model.Metric{"app": "bar"},
+			Values: []model.SamplePair{{Timestamp: 60000, Value: 2.5}},
+		},
+	}
+}
+
+func TestTableMetricOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		mode     string
+		print    func(o MetricOutput)
+		expected string
+	}{
+		"scalar": {
+			"table",
+			func(o MetricOutput) { o.PrintScalar(loghttp.Scalar{Timestamp: 60000, Value: 4}) },
+			"TIMESTAMP             VALUE\n" +
+				"1970-01-01T00:01:00Z  4\n",
+		},
+		"vector": {
+			"table",
+			func(o MetricOutput) {
+				o.PrintVector(loghttp.Vector{
+					{Metric: model.Metric{"app": "foo", "level": "error"}, Timestamp: 60000, Value: 3},
+					{Metric: model.Metric{"app": "bar"}, Timestamp: 60000, Value: 2.5},
+				})
+			},
+			"TIMESTAMP             app  level  VALUE\n" +
+				"1970-01-01T00:01:00Z  bar         2.5\n" +
+				"1970-01-01T00:01:00Z  foo  error  3\n",
+		},
+		"matrix": {
+			"table",
+			func(o MetricOutput) { o.PrintMatrix(testMatrix()) },
+			"TIMESTAMP             app  level  VALUE\n" +
+				"1970-01-01T00:01:00Z  bar         2.5\n" +
+				"1970-01-01T00:00:00Z  foo  error  1\n" +
+				"1970-01-01T00:01:00Z  foo  error  3\n",
+		},
+		"matrix sparkline": {
+			"sparkline",
+			func(o MetricOutput) { o.PrintMatrix(testMatrix()) },
+			"app  level  MIN  MAX  LAST  SAMPLES\n" +
+				"bar         2.5  2.5  2.5   ▁\n" +
+				"foo  error  1    3    3     ▁█\n",
+		},
+		"matrix columns": {
+			"columns",
+			func(o MetricOutput) { o.PrintMatrix(testMatrix()) },
+			"TIMESTAMP             {app=\"bar\"}  {app=\"foo\", level=\"error\"}\n" +
+				"1970-01-01T00:00:00Z               1\n" +
+				"1970-01-01T00:01:00Z  2.5          3\n",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			writer := &bytes.Buffer{}
+			out, err := NewMetricOutput(writer, testData.mode, &LogOutputOptions{Timezone: time.UTC})
+			require.NoError(t, err)
+			testData.print(out)
+
+			assert.Equal(t, testData.expected, writer.String())
+		})
+	}
+}
+
+func TestJSONMetricOutput(t *testing.T) {
+	writer := &bytes.Buffer{}
+	out, err := NewMetricOutput(writer, "json", &LogOutputOptions{Timezone: time.UTC})
+	require.NoError(t, err)
+	out.PrintVector(loghttp.Vector{{Metric: model.Metric{"app": "foo"}, Timestamp: 60000, Value: 3}})
+
+	assert.Equal(t, `[
+  {
+    "metric": {
+      "app": "foo"
+    },
+    "value": [
+      60,
+      "3"
+    ]
+  }
+]`, writer.String())
+
+	_, err = NewMetricOutput(writer, "unknown", &LogOutputOptions{})
+	assert.Error(t, err)
+}
+
+func TestSparkline(t *testing.T) {
+	samples := func(values ...float64) []model.SamplePair {
+		res := make([]model.SamplePair, 0, len(values))
+		for i, v := range values {
+			res = append(res, model.SamplePair{Timestamp: model.Time(i), Value: model.SampleValue(v)})
+		}
+		return res
+	}
+
+	assert.Equal(t, "", Sparkline(nil, 10))
+	assert.Equal(t, "▁▂▃▄▅▆▇█", Sparkline(samples(0, 1, 2, 3, 4, 5, 6, 7), 10))
+	assert.Equal(t, "▁▁▁", Sparkline(samples(5, 5, 5), 10))
+	assert.Equal(t, "▁ █", Sparkline(samples(1, math.NaN(), 2), 10))
+	// samples are averaged by pairs
+	assert.Equal(t, "▁█", Sparkline(samples(0, 2, 8, 10), 2))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -29,6 +30,8 @@ var colorList = []*color.Color{
 // LogOutput is the interface any output mode must implement
 type LogOutput interface {
 	FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string)
+	// WithWriter returns a copy of the output writing to w.
+	WithWriter(w io.Writer) LogOutput
 }
 
 // LogOutputOptions defines options supported by LogOutput
@@ -36,6 +39,8 @@ type LogOutputOptions struct {
 	Timezone      *time.Location
 	NoLabels      bool
 	ColoredOutput bool
+	// Template is the Go template of the template output mode.
+	Template string
 }
 
 // NewLogOutput creates a log output based on the input mode and options
@@ -60,6 +65,34 @@ func NewLogOutput(w io.Writer, mode string, options *LogOutputOptions) (LogOutpu
 			w:       w,
 			options: options,
 		}, nil
+	case "csv", "tsv":
+		comma := ','
+		if mode == "tsv" {
+			comma = '\t'
+		}
+		return &CSVOutput{
+			w:       w,
+			options: options,
+			comma:   comma,
+		}, nil
+	case "logfmt":
+		return &LogfmtOutput{
+			w:       w,
+			options: options,
+		}, nil
+	case "template":
+		if options.Template == "" {
+			return nil, fmt.Errorf("a template is required by the template output mode")
+		}
+		tmpl, err := NewTemplate(options.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return &TemplateOutput{
+			w:        w,
+			options:  options,
+			template: tmpl,
+		}, nil
 	default:
 		return nil, fmt.Errorf("unknown log output mode '%s'", mode)
 	}
@@ -72,3 +105,7 @@ func getColor(labels string) *color.Color {
 	color := colorList[id]
 	return color
 }
+
+func trimNewline(line string) string {
+	return strings.TrimSuffix(line, "\n")
+}
@@ -23,8 +23,13 @@ func NewRaw(writer io.Writer, options *LogOutputOptions) LogOutput {
 
 // Format a log entry as is
 func (o *RawOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
-	if len(line) > 0 && line[len(line)-1] == '\n' {
-		line = line[:len(line)-1]
+	fmt.Fprintln(o.w, trimNewline(line))
+}
+
+// WithWriter returns a copy of the output writing to w.
+func (o *RawOutput) WithWriter(w io.Writer) LogOutput {
+	return &RawOutput{
+		w:       w,
+		options: o.options,
 	}
-	fmt.Fprintln(o.w, line)
 }
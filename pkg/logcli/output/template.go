@@ -0,0 +1,62 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// TemplateEntry is the data of the template of a TemplateOutput.
+type TemplateEntry struct {
+	Timestamp time.Time
+	Labels    loghttp.LabelSet
+	Line      string
+}
+
+// TemplateOutput prints logs using a Go template, each entry is printed on its own line.
+type TemplateOutput struct {
+	w        io.Writer
+	options  *LogOutputOptions
+	template *template.Template
+}
+
+// NewTemplate parses the template of a TemplateOutput.
+func NewTemplate(text string) (*template.Template, error) {
+	return template.New("output").Option("missingkey=zero").Parse(text)
+}
+
+// Format a log entry using the template
+func (o *TemplateOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	entry := TemplateEntry{
+		Timestamp: ts.In(o.options.Timezone),
+		Labels:    lbls,
+		Line:      trimNewline(line),
+	}
+	if o.options.NoLabels {
+		entry.Labels = loghttp.LabelSet{}
+	}
+
+	var buf bytes.Buffer
+	if err := o.template.Execute(&buf, entry); err != nil {
+		log.Fatalf("error executing template: %s", err)
+	}
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	if _, err := o.w.Write(buf.Bytes()); err != nil {
+		log.Fatalf("error writing entry: %s", err)
+	}
+}
+
+// WithWriter returns a copy of the output writing to w.
+func (o *TemplateOutput) WithWriter(w io.Writer) LogOutput {
+	return &TemplateOutput{
+		w:        w,
+		options:  o.options,
+		template: o.template,
+	}
+}
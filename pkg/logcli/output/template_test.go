@@ -0,0 +1,63 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestTemplateOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type": "test",
+	})
+
+	tests := map[string]struct {
+		options  *LogOutputOptions
+		template string
+		line     string
+		expected string
+	}{
+		"timestamp labels and line": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: false},
+			`{{.Timestamp.Unix}} {{.Labels.type}} {{.Line}}`,
+			"Hello\n",
+			"1136189045 test Hello\n",
+		},
+		"timezone and trailing newline": {
+			&LogOutputOptions{Timezone: time.FixedZone("test", 2*60*60), NoLabels: false},
+			"{{.Timestamp.Format \"15:04\"}} {{.Labels}}\n",
+			"Hello",
+			"10:04 {type=\"test\"}\n",
+		},
+		"labels output disabled": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: true},
+			`[{{.Labels.type}}] {{.Line}}`,
+			"Hello",
+			"[] Hello\n",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			tmpl, err := NewTemplate(testData.template)
+			require.NoError(t, err)
+
+			writer := &bytes.Buffer{}
+			out := &TemplateOutput{writer, testData.options, tmpl}
+			out.FormatAndPrintln(timestamp, someLabels, 0, testData.line)
+
+			assert.Equal(t, testData.expected, writer.String())
+		})
+	}
+}
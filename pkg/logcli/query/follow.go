@@ -0,0 +1,347 @@
+package query
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/fatih/color"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/output"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/util/unmarshal"
+)
+
+// maxPausedEntries is the maximum number of entries kept while the output is paused, the
+// following ones are skipped.
+const maxPausedEntries = 10000
+
+var (
+	followBackoff = util.BackoffConfig{
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+	}
+	highlightColor = color.New(color.FgHiYellow, color.Bold)
+)
+
+// FollowQuery tails the logs interactively: the matches of the line filters of the query are
+// highlighted in the default output with colored output enabled, pressing enter on in pauses
+// and resumes the output and entering q quits. The tail is reconnected with backoff when the
+// connection is lost, replaying the last replay duration of logs without printing the entries
+// which were already printed.
+func (q *Query) FollowQuery(delayFor, replay time.Duration, c client.Client, out output.LogOutput, in io.Reader) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		stopChan := make(chan os.Signal, 1)
+		signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM)
+		<-stopChan
+		cancel()
+	}()
+
+	if len(q.IgnoreLabelsKey) > 0 {
+		log.Println("Ignoring labels key:", color.RedString(strings.Join(q.IgnoreLabelsKey, ",")))
+	}
+
+	if len(q.ShowLabelsKey) > 0 {
+		log.Println("Print only labels key:", color.RedString(strings.Join(q.ShowLabelsKey, ",")))
+	}
+
+	f := newFollower(q, out, replay)
+	if in != nil {
+		log.Println("Press enter to pause or resume the output, q and enter to quit")
+		go f.readCommands(in, cancel)
+	}
+	f.follow(ctx, delayFor, replay, c, followBackoff)
+}
+
+// follower prints the entries of tail responses, it keeps track of the entries printed for
+// each stream during the last replay duration so that the entries replayed on reconnection
+// are only printed once.
+type follower struct {
+	q         *Query
+	out       output.LogOutput
+	highlight *regexp.Regexp
+	replay    time.Duration
+
+	mtx     sync.Mutex
+	paused  bool
+	pending []streamEntryPair
+	skipped int
+	// printed are the entries printed for each stream which can still be replayed.
+	printed   map[string]map[printedEntry]struct{}
+	lastPrune time.Time
+	// reconnected is the time of the last reconnection, only the entries up to it can be
+	// replayed. Later entries are printed even if they are older than the ones printed.
+	reconnected time.Time
+}
+
+// printedEntry identifies an entry of a stream.
+type printedEntry struct {
+	ts   int64
+	line string
+}
+
+func newFollower(q *Query, out output.LogOutput, replay time.Duration) *follower {
+	f := &follower{
+		q:         q,
+		out:       out,
+		replay:    replay,
+		printed:   map[string]map[printedEntry]struct{}{},
+		lastPrune: time.Now(),
+	}
+	// Only highlight human readable output, escape sequences would corrupt the other formats.
+	if _, ok := out.(*output.DefaultOutput); ok && q.ColoredOutput {
+		f.highlight = lineFilterRegexp(q.QueryString)
+	}
+	return f
+}
+
+// follow tails the query until ctx is done, reconnecting when the connection is lost.
+func (f *follower) follow(ctx context.Context, delayFor, replay time.Duration, c client.Client, cfg util.BackoffConfig) {
+	backoff := util.NewBackoff(ctx, cfg)
+	start := f.q.Start
+	for ctx.Err() == nil {
+		conn, err := c.LiveTailQueryConn(f.q.QueryString, delayFor, f.q.Limit, start, f.q.Quiet)
+		if err != nil {
+			log.Println(color.YellowString("Tailing logs failed, retrying: %s", err))
+			backoff.Wait()
+			continue
+		}
+
+		if f.tail(ctx, conn) {
+			backoff.Reset()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		start = f.reconnect(time.Now())
+		log.Println(color.YellowString("Connection lost, reconnecting and replaying the logs since %s", start.Format(time.RFC3339)))
+		backoff.Wait()
+	}
+}
+
+// tail prints the responses of conn until ctx is done or an error occurs, it returns whether any
+// response was received.
+func (f *follower) tail(ctx context.Context, conn *websocket.Conn) bool {
+	responses := make(chan *loghttp.TailResponse)
+	go func() {
+		defer close(responses)
+		for {
+			resp := new(loghttp.TailResponse)
+			if err := unmarshal.ReadTailResponseJSON(resp, conn); err != nil {
+				if ctx.Err() == nil {
+					log.Println("Error reading stream:", err)
+				}
+				return
+			}
+			select {
+			case responses <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	received := false
+	for {
+		select {
+		case <-ctx.Done():
+			if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
+				log.Println("Error closing websocket:", err)
+			}
+			_ = conn.Close()
+			return received
+		case resp, ok := <-responses:
+			if !ok {
+				_ = conn.Close()
+				return received
+			}
+			received = true
+			f.handle(resp)
+		}
+	}
+}
+
+// reconnect records that the tail is reconnected at now, it returns the start of the logs replayed.
+func (f *follower) reconnect(now time.Time) time.Time {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.reconnected = now
+	return now.Add(-f.replay)
+}
+
+// handle prints the entries of a tail response which were not printed yet and notifies the entries
+// dropped by the server.
+func (f *follower) handle(resp *loghttp.TailResponse) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	// The entries older than the replay duration will not be replayed, forget them once in a while.
+	if now := time.Now(); now.Sub(f.lastPrune) >= f.replay {
+		f.prune(now.Add(-f.replay))
+		f.lastPrune = now
+	}
+
+	for _, stream := range resp.Streams {
+		key := stream.Labels.String()
+		lbls := f.q.tailLabels(stream.Labels)
+		for _, e := range stream.Entries {
+			if !f.advance(key, e) {
+				continue
+			}
+			f.print(streamEntryPair{entry: e, labels: lbls})
+		}
+	}
+
+	if len(resp.DroppedStreams) != 0 {
+		log.Println(color.YellowString("Server dropped the following entries due to slow client:"))
+		for _, d := range resp.DroppedStreams {
+			log.Println(d.Timestamp.Format(time.RFC3339Nano), d.Labels)
+		}
+	}
+}
+
+// advance records that the entry of a stream is printed, it returns false if the entry is replayed
+// and was already printed.
+func (f *follower) advance(stream string, e loghttp.Entry) bool {
+	if f.replay <= 0 {
+		// Nothing is replayed.
+		return true
+	}
+	printed, ok := f.printed[stream]
+	if !ok {
+		printed = map[printedEntry]struct{}{}
+		f.printed[stream] = printed
+	}
+	key := printedEntry{ts: e.Timestamp.UnixNano(), line: e.Line}
+	if !e.Timestamp.After(f.reconnected) {
+		if _, ok := printed[key]; ok {
+			return false
+		}
+	}
+	printed[key] = struct{}{}
+	return true
+}
+
+// prune forgets the entries printed before start.
+func (f *follower) prune(start time.Time) {
+	for stream, printed := range f.printed {
+		for e := range printed {
+			if e.ts < start.UnixNano() {
+				delete(printed, e)
+			}
+		}
+		if len(printed) == 0 {
+			delete(f.printed, stream)
+		}
+	}
+}
+
+func (f *follower) print(e streamEntryPair) {
+	if f.paused {
+		if len(f.pending) >= maxPausedEntries {
+			f.skipped++
+			return
+		}
+		f.pending = append(f.pending, e)
+		return
+	}
+
+	line := e.entry.Line
+	if f.highlight != nil {
+		line = f.highlight.ReplaceAllStringFunc(line, highlight)
+	}
+	f.out.FormatAndPrintln(e.entry.Timestamp, e.labels, 0, line)
+}
+
+// togglePause pauses or resumes the output, the entries received while paused are printed on resume.
+func (f *follower) togglePause() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if !f.paused {
+		f.paused = true
+		log.Println(color.YellowString("Paused, press enter to resume"))
+		return
+	}
+
+	f.paused = false
+	pending, skipped := f.pending, f.skipped
+	f.pending, f.skipped = nil, 0
+	for _, e := range pending {
+		f.print(e)
+	}
+	if skipped > 0 {
+		log.Println(color.YellowString("%d entries were skipped while paused", skipped))
+	}
+}
+
+func (f *follower) readCommands(in io.Reader, quit func()) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		switch strings.TrimSpace(scanner.Text()) {
+		case "q":
+			quit()
+			return
+		default:
+			f.togglePause()
+		}
+	}
+}
+
+func highlight(s string) string {
+	return highlightColor.Sprint(s)
+}
+
+// lineFilterRegexp returns a regexp matching what the line filters of the query match, or nil if the
+// query has none.
+func lineFilterRegexp(query string) *regexp.Regexp {
+	expr, err := logql.ParseExpr(query)
+	if err != nil {
+		return nil
+	}
+
+	var patterns []string
+	expr.Walk(func(e interface{}) {
+		filter, ok := e.(*logql.LineFilterExpr)
+		if !ok {
+			return
+		}
+		match, op := filter.Match()
+		if op != "" || match == "" {
+			return
+		}
+		switch filter.MatchType() {
+		case labels.MatchEqual:
+			patterns = append(patterns, regexp.QuoteMeta(match))
+		case labels.MatchRegexp:
+			if _, err := regexp.Compile(match); err == nil {
+				patterns = append(patterns, "(?:"+match+")")
+			}
+		}
+	})
+	if len(patterns) == 0 {
+		return nil
+	}
+	re, err := regexp.Compile(strings.Join(patterns, "|"))
+	if err != nil {
+		return nil
+	}
+	return re
+}
@@ -0,0 +1,206 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/output"
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func Test_lineFilterRegexp(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{`{app="foo"}`, ""},
+		{`{app="foo"} |= "err.or" != "debug"`, `err\.or`},
+		{`{app="foo"} |~ "time(out)?" |= "db" | json | line_format "{{.msg}}"`, `db|(?:time(out)?)`},
+		{`{app="foo"} |= ip("10.0.0.1")`, ""},
+		{`sum(rate({app="foo"} |= "error" [1m]))`, "error"},
+		{`invalid`, ""},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			re := lineFilterRegexp(tc.query)
+			if tc.expected == "" {
+				require.Nil(t, re)
+				return
+			}
+			require.NotNil(t, re)
+			require.Equal(t, tc.expected, re.String())
+		})
+	}
+}
+
+func tailResponse(entries ...loghttp.Entry) *loghttp.TailResponse {
+	return &loghttp.TailResponse{
+		Streams: []loghttp.Stream{{Labels: loghttp.LabelSet{"app": "foo"}, Entries: entries}},
+	}
+}
+
+func newTestFollower(t *testing.T, q *Query) (*follower, *syncBuffer) {
+	t.Helper()
+	buf := &syncBuffer{}
+	out, err := output.NewLogOutput(buf, "raw", &output.LogOutputOptions{Timezone: time.UTC})
+	require.NoError(t, err)
+	return newFollower(q, out, time.Minute), buf
+}
+
+func TestNewFollower_highlight(t *testing.T) {
+	query := `{app="foo"} |= "error"`
+	for _, tc := range []struct {
+		mode      string
+		colored   bool
+		highlight bool
+	}{
+		{"default", true, true},
+		{"default", false, false},
+		{"raw", true, false},
+		{"jsonl", true, false},
+	} {
+		t.Run(fmt.Sprintf("%s colored=%t", tc.mode, tc.colored), func(t *testing.T) {
+			out, err := output.NewLogOutput(&bytes.Buffer{}, tc.mode, &output.LogOutputOptions{Timezone: time.UTC, ColoredOutput: tc.colored})
+			require.NoError(t, err)
+			f := newFollower(&Query{QueryString: query, ColoredOutput: tc.colored}, out, time.Minute)
+			require.Equal(t, tc.highlight, f.highlight != nil)
+		})
+	}
+}
+
+func TestFollower_handle(t *testing.T) {
+	f, buf := newTestFollower(t, &Query{QueryString: `{app="foo"}`})
+	now := time.Now()
+	ts := func(s int) time.Time { return now.Add(time.Duration(s-10) * time.Second) }
+
+	f.handle(tailResponse(
+		loghttp.Entry{Timestamp: ts(1), Line: "a"},
+		loghttp.Entry{Timestamp: ts(2), Line: "b"},
+	))
+	// late entries are printed.
+	f.handle(tailResponse(loghttp.Entry{Timestamp: ts(0), Line: "late"}))
+	require.Equal(t, "a\nb\nlate\n", buf.String())
+
+	// replayed after a reconnection
+	require.Equal(t, ts(3).Add(-time.Minute), f.reconnect(ts(3)))
+	f.handle(tailResponse(
+		loghttp.Entry{Timestamp: ts(0), Line: "late"},
+		loghttp.Entry{Timestamp: ts(1), Line: "a"},
+		loghttp.Entry{Timestamp: ts(2), Line: "b"},
+		loghttp.Entry{Timestamp: ts(2), Line: "c"},
+		loghttp.Entry{Timestamp: ts(4), Line: "d"},
+	))
+	// entries after the reconnection are not deduplicated.
+	f.handle(tailResponse(loghttp.Entry{Timestamp: ts(4), Line: "d"}))
+	require.Equal(t, "a\nb\nlate\nc\nd\nd\n", buf.String())
+
+	f.togglePause()
+	f.handle(tailResponse(loghttp.Entry{Timestamp: ts(5), Line: "e"}))
+	require.Equal(t, "a\nb\nlate\nc\nd\nd\n", buf.String())
+	f.togglePause()
+	require.Equal(t, "a\nb\nlate\nc\nd\nd\ne\n", buf.String())
+}
+
+func TestFollower_prune(t *testing.T) {
+	f, _ := newTestFollower(t, &Query{QueryString: `{app="foo"}`})
+	now := time.Now()
+	f.handle(tailResponse(
+		loghttp.Entry{Timestamp: now.Add(-2 * time.Minute), Line: "old"},
+		loghttp.Entry{Timestamp: now, Line: "recent"},
+	))
+	require.Len(t, f.printed[`{app="foo"}`], 2)
+
+	// the entries older than the replay duration are forgotten once the replay duration elapsed.
+	f.lastPrune = now.Add(-time.Minute)
+	f.handle(tailResponse())
+	require.Equal(t, map[printedEntry]struct{}{{ts: now.UnixNano(), line: "recent"}: {}}, f.printed[`{app="foo"}`])
+}
+
+func TestFollower_follow(t *testing.T) {
+	var (
+		mtx         sync.Mutex
+		connections int
+		upgrader    websocket.Upgrader
+	)
+	start := time.Now().Add(-10 * time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		mtx.Lock()
+		connections++
+		n := connections
+		mtx.Unlock()
+
+		lines := []string{"a", "b"}
+		if n > 1 {
+			// the logs are replayed on reconnection.
+			lines = append(lines, "c")
+		}
+		for i, line := range lines {
+			msg := fmt.Sprintf(`{"streams":[{"stream":{"app":"foo"},"values":[["%d","%s"]]}]}`, start.Add(time.Duration(i)*time.Second).UnixNano(), line)
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				return
+			}
+		}
+		if n == 1 {
+			// the querier restarts.
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	f, buf := newTestFollower(t, &Query{QueryString: `{app="foo"}`, Limit: 30, Quiet: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.follow(ctx, 0, time.Minute, &client.DefaultClient{Address: server.URL}, util.BackoffConfig{
+			MinBackoff: time.Millisecond,
+			MaxBackoff: 10 * time.Millisecond,
+		})
+	}()
+
+	require.Eventually(t, func() bool { return buf.String() == "a\nb\nc\n" }, 5*time.Second, 10*time.Millisecond, buf.String())
+	cancel()
+	<-done
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	require.Equal(t, 2, connections)
+}
+
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,205 @@
+package query
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/output"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+const (
+	partTimeFormat = "20060102T150405Z"
+	partExtension  = ".part"
+)
+
+// parallelJob queries a sub range of a parallel query and writes the results to a part file.
+type parallelJob struct {
+	q    *Query
+	path string
+}
+
+// tmpPath is where the part is written until it is completed, so that incomplete parts are
+// never mistaken for completed ones.
+func (j *parallelJob) tmpPath() string {
+	return j.path + ".tmp"
+}
+
+func (j *parallelJob) completed() bool {
+	_, err := os.Stat(j.path)
+	return err == nil
+}
+
+func (j *parallelJob) run(c client.Client, out output.LogOutput, statistics bool) error {
+	f, err := os.Create(j.tmpPath())
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	j.q.DoQuery(c, out.WithWriter(w), statistics)
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(j.tmpPath(), j.path)
+}
+
+// DoQueryParallel splits the query range in sub ranges of ParallelDuration, runs them concurrently and
+// writes the results of each of them to its own part file. Completed parts are skipped unless
+// OverwriteCompletedParts is set, so that an interrupted export can be resumed by running the same
+// query again. When MergeParts is set, the parts are written to w in the order of the query once
+// they are all completed.
+func (q *Query) DoQueryParallel(c client.Client, out output.LogOutput, w io.Writer, statistics bool) {
+	if err := q.validateParallel(); err != nil {
+		log.Fatalf("Invalid parallel query: %s", err)
+	}
+
+	jobs := q.parallelJobs()
+	if len(jobs) > 0 {
+		if err := os.MkdirAll(filepath.Dir(jobs[0].path), 0755); err != nil {
+			log.Fatalf("Unable to create the parts directory: %s", err)
+		}
+	}
+
+	pending := make([]*parallelJob, 0, len(jobs))
+	for _, j := range jobs {
+		if q.OverwriteCompletedParts || !j.completed() {
+			pending = append(pending, j)
+		}
+	}
+	if !q.Quiet {
+		log.Printf("Querying %d parts of %s with %d workers, %d parts already completed", len(pending), q.ParallelDuration, q.ParallelMaxWorkers, len(jobs)-len(pending))
+	}
+
+	var (
+		wg    sync.WaitGroup
+		queue = make(chan *parallelJob)
+	)
+	workers := q.ParallelMaxWorkers
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				if err := j.run(c, out, statistics); err != nil {
+					log.Fatalf("Unable to write part %s: %s", j.path, err)
+				}
+				if !q.Quiet {
+					log.Printf("Completed part %s", j.path)
+				}
+			}
+		}()
+	}
+	for _, j := range pending {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	if q.MergeParts {
+		if err := q.mergeParts(jobs, w); err != nil {
+			log.Fatalf("Unable to merge parts: %s", err)
+		}
+	}
+}
+
+func (q *Query) validateParallel() error {
+	if q.ParallelDuration < time.Second {
+		return fmt.Errorf("the parallel duration must be at least 1s, got %s", q.ParallelDuration)
+	}
+	if q.ParallelMaxWorkers < 1 {
+		return fmt.Errorf("the number of parallel workers must be at least 1, got %d", q.ParallelMaxWorkers)
+	}
+	if q.isInstant() {
+		return fmt.Errorf("instant queries cannot be run in parallel")
+	}
+	if q.PartPathPrefix == "" && !q.MergeParts {
+		return fmt.Errorf("a part path prefix is required when the parts are not merged")
+	}
+	expr, err := logql.ParseExpr(q.QueryString)
+	if err != nil {
+		return err
+	}
+	if _, ok := expr.(logql.LogSelectorExpr); !ok {
+		return fmt.Errorf("only log queries can be run in parallel")
+	}
+	return nil
+}
+
+// parallelJobs returns the jobs of the sub ranges in the order of the query.
+func (q *Query) parallelJobs() []*parallelJob {
+	prefix := q.PartPathPrefix
+	if prefix == "" {
+		// The parts are merged, they are kept in a temporary directory unique to the query
+		// so that the query can still be resumed.
+		h := fnv.New32a()
+		_, _ = fmt.Fprintf(h, "%s %d %d", q.QueryString, q.Start.UnixNano(), q.End.UnixNano())
+		prefix = filepath.Join(os.TempDir(), fmt.Sprintf("logcli-%08x", h.Sum32())) + string(filepath.Separator)
+	}
+
+	var jobs []*parallelJob
+	for start := q.Start; start.Before(q.End); start = start.Add(q.ParallelDuration) {
+		end := start.Add(q.ParallelDuration)
+		if end.After(q.End) {
+			end = q.End
+		}
+		jq := *q
+		jq.Start, jq.End = start, end
+		jq.Quiet = true
+		jq.keepCommonLabels = true
+		// The limit applies to each part, 0 means no limit.
+		if jq.Limit == 0 {
+			jq.Limit = math.MaxInt32
+		}
+		jobs = append(jobs, &parallelJob{
+			q:    &jq,
+			path: fmt.Sprintf("%s%s_%s%s", prefix, start.UTC().Format(partTimeFormat), end.UTC().Format(partTimeFormat), partExtension),
+		})
+	}
+
+	if !q.Forward {
+		for i, j := 0, len(jobs)-1; i < j; i, j = i+1, j-1 {
+			jobs[i], jobs[j] = jobs[j], jobs[i]
+		}
+	}
+	return jobs
+}
+
+// mergeParts writes the parts to w in order, the parts are removed unless KeepParts is set.
+func (q *Query) mergeParts(jobs []*parallelJob, w io.Writer) error {
+	for _, j := range jobs {
+		f, err := os.Open(j.path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if q.KeepParts {
+		return nil
+	}
+	for _, j := range jobs {
+		if err := os.Remove(j.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
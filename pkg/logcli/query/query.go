@@ -1,9 +1,6 @@
 package query
 
 import (
-	"context"
-	"errors"
-	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -12,25 +9,14 @@ import (
 	"text/tabwriter"
 	"time"
 
-	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/fatih/color"
-	json "github.com/json-iterator/go"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
-	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
-	"github.com/grafana/loki/pkg/loki"
-	"github.com/grafana/loki/pkg/storage"
-	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
-	"github.com/grafana/loki/pkg/util/cfg"
-	"github.com/grafana/loki/pkg/util/marshal"
-	"github.com/grafana/loki/pkg/validation"
 )
 
 type streamEntryPair struct {
@@ -54,18 +40,25 @@ type Query struct {
 	ShowLabelsKey   []string
 	FixedLabelsLen  int
 	ColoredOutput   bool
-	LocalConfig     string
+	// MetricOutput prints the results of metric queries, they are printed as JSON when it's nil.
+	MetricOutput output.MetricOutput
+
+	// Parallel queries split the query range in sub ranges of ParallelDuration and write
+	// the results of each of them to a part file.
+	ParallelDuration        time.Duration
+	ParallelMaxWorkers      int
+	PartPathPrefix          string
+	OverwriteCompletedParts bool
+	MergeParts              bool
+	KeepParts               bool
+
+	// keepCommonLabels prints the labels common to all the streams of the results with each line, which
+	// is used for the parts of a parallel query as each part would have different common labels.
+	keepCommonLabels bool
 }
 
 // DoQuery executes the query and prints out the results
 func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool) {
-	if q.LocalConfig != "" {
-		if err := q.DoLocalQuery(out, statistics, c.GetOrgID()); err != nil {
-			log.Fatalf("Query failed: %+v", err)
-		}
-		return
-	}
-
 	d := q.resultsDirection()
 
 	var resp *loghttp.QueryResponse
@@ -169,83 +162,6 @@ func (q *Query) printResult(value loghttp.ResultValue, out output.LogOutput, las
 	return length, entry
 }
 
-// DoLocalQuery executes the query against the local store using a Loki configuration file.
-func (q *Query) DoLocalQuery(out output.LogOutput, statistics bool, orgID string) error {
-	var conf loki.Config
-	conf.RegisterFlags(flag.CommandLine)
-	if q.LocalConfig == "" {
-		return errors.New("no supplied config file")
-	}
-	if err := cfg.YAML(q.LocalConfig, false)(&conf); err != nil {
-		return err
-	}
-
-	if err := conf.Validate(); err != nil {
-		return err
-	}
-
-	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
-	if err != nil {
-		return err
-	}
-
-	chunkStore, err := chunk_storage.NewStore(conf.StorageConfig.Config, conf.ChunkStoreConfig.StoreConfig, conf.SchemaConfig.SchemaConfig, limits, prometheus.DefaultRegisterer, nil, util_log.Logger)
-	if err != nil {
-		return err
-	}
-
-	querier, err := storage.NewStore(conf.StorageConfig, conf.SchemaConfig, chunkStore, prometheus.DefaultRegisterer)
-	if err != nil {
-		return err
-	}
-
-	eng := logql.NewEngine(conf.Querier.Engine, querier, limits)
-	var query logql.Query
-
-	if q.isInstant() {
-		query = eng.Query(logql.NewLiteralParams(
-			q.QueryString,
-			q.Start,
-			q.Start,
-			0,
-			0,
-			q.resultsDirection(),
-			uint32(q.Limit),
-			nil,
-		))
-	} else {
-		query = eng.Query(logql.NewLiteralParams(
-			q.QueryString,
-			q.Start,
-			q.End,
-			q.Step,
-			q.Interval,
-			q.resultsDirection(),
-			uint32(q.Limit),
-			nil,
-		))
-	}
-
-	// execute the query
-	ctx := user.InjectOrgID(context.Background(), orgID)
-	result, err := query.Exec(ctx)
-	if err != nil {
-		return err
-	}
-
-	if statistics {
-		q.printStats(result.Statistics)
-	}
-
-	value, err := marshal.NewResultValue(result.Data)
-	if err != nil {
-		return err
-	}
-
-	q.printResult(value, out, nil)
-	return nil
-}
-
 // SetInstant makes the Query an instant type
 func (q *Query) SetInstant(time time.Time) {
 	q.Start = time
@@ -257,7 +173,10 @@ func (q *Query) isInstant() bool {
 }
 
 func (q *Query) printStream(streams loghttp.Streams, out output.LogOutput, lastEntry []*loghttp.Entry) (int, []*loghttp.Entry) {
-	common := commonLabels(streams)
+	var common loghttp.LabelSet
+	if !q.keepCommonLabels {
+		common = commonLabels(streams)
+	}
 
 	// Remove the labels we want to show from common
 	if len(q.ShowLabelsKey) > 0 {
@@ -363,33 +282,23 @@ func (q *Query) printStream(streams loghttp.Streams, out output.LogOutput, lastE
 }
 
 func (q *Query) printMatrix(matrix loghttp.Matrix) {
-	// yes we are effectively unmarshalling and then immediately marshalling this object back to json.  we are doing this b/c
-	// it gives us more flexibility with regard to output types in the future.  initially we are supporting just formatted json but eventually
-	// we might add output options such as render to an image file on disk
-	bytes, err := json.MarshalIndent(matrix, "", "  ")
-	if err != nil {
-		log.Fatalf("Error marshalling matrix: %v", err)
-	}
-
-	fmt.Print(string(bytes))
+	q.metricOutput().PrintMatrix(matrix)
 }
 
 func (q *Query) printVector(vector loghttp.Vector) {
-	bytes, err := json.MarshalIndent(vector, "", "  ")
-	if err != nil {
-		log.Fatalf("Error marshalling vector: %v", err)
-	}
-
-	fmt.Print(string(bytes))
+	q.metricOutput().PrintVector(vector)
 }
 
 func (q *Query) printScalar(scalar loghttp.Scalar) {
-	bytes, err := json.MarshalIndent(scalar, "", "  ")
-	if err != nil {
-		log.Fatalf("Error marshalling scalar: %v", err)
-	}
+	q.metricOutput().PrintScalar(scalar)
+}
 
-	fmt.Print(string(bytes))
+func (q *Query) metricOutput() output.MetricOutput {
+	if q.MetricOutput != nil {
+		return q.MetricOutput
+	}
+	out, _ := output.NewMetricOutput(os.Stdout, "json", &output.LogOutputOptions{})
+	return out
 }
 
 type kvLogger struct {
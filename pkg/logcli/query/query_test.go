@@ -3,14 +3,21 @@ package query
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/loghttp"
@@ -470,7 +477,6 @@ func Test_batch(t *testing.T) {
 				IgnoreLabelsKey: nil,
 				ShowLabelsKey:   nil,
 				FixedLabelsLen:  0,
-				LocalConfig:     "",
 			}
 			q.DoQuery(tc, out, false)
 			split := strings.Split(writer.String(), "\n")
@@ -485,6 +491,166 @@ func Test_batch(t *testing.T) {
 	}
 }
 
+func TestQuery_DoQueryParallel(t *testing.T) {
+	streams := []logproto.Stream{
+		{
+			Labels: `{test="parallel"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "line1"},
+				{Timestamp: time.Unix(2, 0), Line: "line2"},
+				{Timestamp: time.Unix(3, 0), Line: "line3"},
+				{Timestamp: time.Unix(4, 0), Line: "line4"},
+				{Timestamp: time.Unix(5, 0), Line: "line5"},
+				{Timestamp: time.Unix(6, 0), Line: "line6"},
+			},
+		},
+	}
+	newQuery := func(dir string, forward bool) *Query {
+		return &Query{
+			QueryString:        `{test="parallel"}`,
+			Start:              time.Unix(1, 0),
+			End:                time.Unix(6, 0),
+			BatchSize:          1000,
+			Forward:            forward,
+			Quiet:              true,
+			ParallelDuration:   2 * time.Second,
+			ParallelMaxWorkers: 2,
+			PartPathPrefix:     filepath.Join(dir, "parts") + "/",
+			MergeParts:         true,
+		}
+	}
+
+	for _, tc := range []struct {
+		forward  bool
+		expected string
+	}{
+		{true, "line1\nline2\nline3\nline4\nline5\n"},
+		{false, "line5\nline4\nline3\nline2\nline1\n"},
+	} {
+		t.Run(fmt.Sprintf("forward=%v", tc.forward), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "logcli-parallel")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			tc1 := newTestQueryClient(streams...)
+			var buf bytes.Buffer
+			q := newQuery(dir, tc.forward)
+			q.DoQueryParallel(tc1, output.NewRaw(nil, nil), &buf, false)
+			require.Equal(t, tc.expected, buf.String())
+			// Each of the 3 parts needs a second call to know there are no more results.
+			require.Equal(t, 6, tc1.queryRangeCalls)
+
+			// The parts are removed once merged.
+			files, err := ioutil.ReadDir(filepath.Join(dir, "parts"))
+			require.NoError(t, err)
+			require.Len(t, files, 0)
+		})
+	}
+
+	t.Run("resume", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "logcli-parallel")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		q := newQuery(dir, true)
+		q.KeepParts = true
+		jobs := q.parallelJobs()
+		require.Len(t, jobs, 3)
+		require.Equal(t, filepath.Join(dir, "parts", "19700101T000003Z_19700101T000005Z.part"), jobs[1].path)
+
+		// The completed part is not queried again, an incomplete part is.
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "parts"), 0755))
+		require.NoError(t, ioutil.WriteFile(jobs[1].path, []byte("completed\n"), 0644))
+		require.NoError(t, ioutil.WriteFile(jobs[2].tmpPath(), []byte("incomplete\n"), 0644))
+
+		tc := newTestQueryClient(streams...)
+		var buf bytes.Buffer
+		q.DoQueryParallel(tc, output.NewRaw(nil, nil), &buf, false)
+		require.Equal(t, "line1\nline2\ncompleted\nline5\n", buf.String())
+		require.Equal(t, 4, tc.queryRangeCalls)
+
+		files, err := ioutil.ReadDir(filepath.Join(dir, "parts"))
+		require.NoError(t, err)
+		require.Len(t, files, 3)
+
+		// Overwriting the completed parts queries all of them again.
+		q.OverwriteCompletedParts = true
+		buf.Reset()
+		q.DoQueryParallel(tc, output.NewRaw(nil, nil), &buf, false)
+		require.Equal(t, "line1\nline2\nline3\nline4\nline5\n", buf.String())
+	})
+}
+
+func TestQuery_DoQueryParallel_commonLabels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logcli-parallel")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Each part has a single stream, the labels common to its results are all the labels of the stream.
+	tc := newTestQueryClient(
+		logproto.Stream{
+			Labels:  `{app="a", test="parallel"}`,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "line1"}},
+		},
+		logproto.Stream{
+			Labels:  `{app="b", test="parallel"}`,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "line3"}},
+		},
+	)
+	q := &Query{
+		QueryString:        `{test="parallel"}`,
+		Start:              time.Unix(1, 0),
+		End:                time.Unix(5, 0),
+		BatchSize:          1000,
+		Forward:            true,
+		Quiet:              true,
+		ParallelDuration:   2 * time.Second,
+		ParallelMaxWorkers: 2,
+		PartPathPrefix:     filepath.Join(dir, "parts") + "/",
+		MergeParts:         true,
+	}
+	out, err := output.NewLogOutput(nil, "jsonl", &output.LogOutputOptions{Timezone: time.UTC})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	q.DoQueryParallel(tc, out, &buf, false)
+
+	var labels []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Labels map[string]string `json:"labels"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		labels = append(labels, entry.Labels)
+	}
+	require.Equal(t, []map[string]string{
+		{"app": "a", "test": "parallel"},
+		{"app": "b", "test": "parallel"},
+	}, labels)
+}
+
+func TestQuery_validateParallel(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		q       Query
+		wantErr string
+	}{
+		{"valid", Query{QueryString: `{foo="bar"}`, Start: time.Unix(0, 0), End: time.Unix(10, 0), ParallelDuration: time.Second, ParallelMaxWorkers: 1, PartPathPrefix: "out/"}, ""},
+		{"duration", Query{QueryString: `{foo="bar"}`, ParallelDuration: time.Millisecond, ParallelMaxWorkers: 1}, "the parallel duration must be at least 1s, got 1ms"},
+		{"workers", Query{QueryString: `{foo="bar"}`, ParallelDuration: time.Second}, "the number of parallel workers must be at least 1, got 0"},
+		{"prefix", Query{QueryString: `{foo="bar"}`, Start: time.Unix(0, 0), End: time.Unix(10, 0), ParallelDuration: time.Second, ParallelMaxWorkers: 1}, "a part path prefix is required when the parts are not merged"},
+		{"metric query", Query{QueryString: `rate({foo="bar"}[1m])`, Start: time.Unix(0, 0), End: time.Unix(10, 0), ParallelDuration: time.Second, ParallelMaxWorkers: 1, MergeParts: true}, "only log queries can be run in parallel"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.q.validateParallel()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
 func mustParseLabels(s string) loghttp.LabelSet {
 	l, err := marshal.NewLabelSet(s)
 
@@ -498,6 +664,7 @@ func mustParseLabels(s string) loghttp.LabelSet {
 type testQueryClient struct {
 	engine          *logql.Engine
 	queryRangeCalls int
+	mtx             sync.Mutex
 }
 
 func newTestQueryClient(testStreams ...logproto.Stream) *testQueryClient {
@@ -535,7 +702,9 @@ func (t *testQueryClient) QueryRange(queryStr string, limit int, from, through t
 			Statistics: v.Statistics,
 		},
 	}
+	t.mtx.Lock()
 	t.queryRangeCalls++
+	t.mtx.Unlock()
 	return q, nil
 }
 
@@ -51,27 +51,8 @@ func (q *Query) TailQuery(delayFor time.Duration, c client.Client, out output.Lo
 			return
 		}
 
-		labels := loghttp.LabelSet{}
 		for _, stream := range tailResponse.Streams {
-			if !q.NoLabels {
-				if len(q.IgnoreLabelsKey) > 0 || len(q.ShowLabelsKey) > 0 {
-
-					ls := stream.Labels
-
-					if len(q.ShowLabelsKey) > 0 {
-						ls = matchLabels(true, ls, q.ShowLabelsKey)
-					}
-
-					if len(q.IgnoreLabelsKey) > 0 {
-						ls = matchLabels(false, ls, q.ShowLabelsKey)
-					}
-
-					labels = ls
-
-				} else {
-					labels = stream.Labels
-				}
-			}
+			labels := q.tailLabels(stream.Labels)
 
 			for _, entry := range stream.Entries {
 				out.FormatAndPrintln(entry.Timestamp, labels, 0, entry.Line)
@@ -86,3 +67,20 @@ func (q *Query) TailQuery(delayFor time.Duration, c client.Client, out output.Lo
 		}
 	}
 }
+
+// tailLabels returns the labels of a tailed stream to print.
+func (q *Query) tailLabels(ls loghttp.LabelSet) loghttp.LabelSet {
+	if q.NoLabels {
+		return loghttp.LabelSet{}
+	}
+
+	if len(q.ShowLabelsKey) > 0 {
+		ls = matchLabels(true, ls, q.ShowLabelsKey)
+	}
+
+	if len(q.IgnoreLabelsKey) > 0 {
+		ls = matchLabels(false, ls, q.IgnoreLabelsKey)
+	}
+
+	return ls
+}
@@ -0,0 +1,463 @@
+package rules
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/ruler"
+	"github.com/grafana/loki/pkg/ruler/unittest"
+)
+
+// Rules contains all the fields necessary to manage the rule groups of a Loki ruler and print out the results
+type Rules struct {
+	// Files are the rule files, or directories of rule files, to lint or to load, or the test files to run. The
+	// namespace of the rule groups of a file is its base name, like with the local rules storage of the ruler, unless
+	// Namespace is set.
+	Files     []string
+	Namespace string
+	GroupName string
+	// Prune deletes the namespaces of the ruler which are not in the files when syncing.
+	Prune bool
+	Quiet bool
+}
+
+// DoLint validates the rule files and prints out the errors.
+func (r *Rules) DoLint() {
+	local, err := LoadFiles(r.Files, r.Namespace)
+	if err != nil {
+		log.Fatalf("Invalid rule files:\n%s", err)
+	}
+	if !r.Quiet {
+		groups, rules := count(local)
+		log.Printf("%d namespaces, %d rule groups and %d rules are valid", len(local), groups, rules)
+	}
+}
+
+// DoTest runs the unit tests of the test files and prints out their results, it exits with an error status when a
+// test fails.
+func (r *Rules) DoTest() {
+	if !unittest.RunFiles(os.Stdout, r.Files...) {
+		os.Exit(1)
+	}
+}
+
+// DoList prints out the namespaces and rule groups of the ruler.
+func (r *Rules) DoList(c client.RulesClient) {
+	remote, err := c.ListRules(r.Namespace, r.Quiet)
+	if err != nil {
+		log.Fatalf("Error listing rules: %+v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tGROUP\tRULES")
+	for _, namespace := range namespaces(remote) {
+		for _, g := range remote[namespace] {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", namespace, g.Name, len(g.Rules))
+		}
+	}
+	_ = w.Flush()
+}
+
+// DoGet prints out a rule group of the ruler.
+func (r *Rules) DoGet(c client.RulesClient) {
+	g, err := c.GetRuleGroup(r.Namespace, r.GroupName, r.Quiet)
+	if err != nil {
+		log.Fatalf("Error getting rule group: %+v", err)
+	}
+	s, err := formatGroup(*g)
+	if err != nil {
+		log.Fatalf("Error formatting rule group: %+v", err)
+	}
+	fmt.Print(s)
+}
+
+// DoLoad creates or updates the rule groups of the files on the ruler, other rule groups are left untouched.
+func (r *Rules) DoLoad(c client.RulesClient) {
+	changes := r.changes(c)
+	var updates []Change
+	for _, ch := range changes {
+		if ch.Type != Delete {
+			updates = append(updates, ch)
+		}
+	}
+	r.apply(c, updates)
+}
+
+// DoDiff prints out the changes a sync would apply to the ruler.
+func (r *Rules) DoDiff(c client.RulesClient) {
+	if err := PrintChanges(os.Stdout, r.changes(c)); err != nil {
+		log.Fatalf("Error printing changes: %+v", err)
+	}
+}
+
+// DoSync makes the rule groups of the ruler match the files: the rule groups of the files are created or
+// updated and the other rule groups of their namespaces are deleted, as well as the namespaces which are
+// not in the files if Prune is set.
+func (r *Rules) DoSync(c client.RulesClient) {
+	r.apply(c, r.changes(c))
+}
+
+// DoDelete deletes a rule group of the ruler, or a whole namespace when no rule group is given.
+func (r *Rules) DoDelete(c client.RulesClient) {
+	var err error
+	if r.GroupName != "" {
+		err = c.DeleteRuleGroup(r.Namespace, r.GroupName, r.Quiet)
+	} else {
+		err = c.DeleteNamespace(r.Namespace, r.Quiet)
+	}
+	if err != nil {
+		log.Fatalf("Error deleting rules: %+v", err)
+	}
+}
+
+func (r *Rules) changes(c client.RulesClient) []Change {
+	local, err := LoadFiles(r.Files, r.Namespace)
+	if err != nil {
+		log.Fatalf("Invalid rule files:\n%s", err)
+	}
+	remote, err := c.ListRules("", r.Quiet)
+	if err != nil {
+		log.Fatalf("Error listing rules: %+v", err)
+	}
+	changes, err := Diff(local, remote, r.Prune)
+	if err != nil {
+		log.Fatalf("Error comparing rules: %+v", err)
+	}
+	return changes
+}
+
+func (r *Rules) apply(c client.RulesClient, changes []Change) {
+	if err := Apply(c, changes, r.Quiet); err != nil {
+		log.Fatalf("Error applying changes: %+v", err)
+	}
+	if !r.Quiet {
+		log.Printf("%d changes applied", len(changes))
+	}
+}
+
+// LoadFiles loads and validates the rule groups of the files, directories are expanded to the files they
+// contain. The rule groups are returned by namespace, which is the base name of their file unless a
+// namespace is given.
+func LoadFiles(paths []string, namespace string) (map[string][]rulefmt.RuleGroup, error) {
+	files, err := expandFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		errs   []string
+		groups = map[string][]rulefmt.RuleGroup{}
+		seen   = map[string]string{}
+	)
+	for _, file := range files {
+		rgs, fileErrs := ruler.GroupLoader{}.Load(file)
+		for _, err := range fileErrs {
+			errs = append(errs, err.Error())
+		}
+		if rgs == nil {
+			continue
+		}
+
+		ns := namespace
+		if ns == "" {
+			ns = filepath.Base(file)
+		}
+		for _, g := range rgs.Groups {
+			key := ns + "/" + g.Name
+			if other, ok := seen[key]; ok {
+				errs = append(errs, fmt.Sprintf("%s: rule group %q of namespace %q is already defined in %s", file, g.Name, ns, other))
+				continue
+			}
+			seen[key] = file
+			groups[ns] = append(groups[ns], g)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return groups, nil
+}
+
+func expandFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		infos, err := ioutil.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.Mode().IsRegular() {
+				files = append(files, filepath.Join(p, info.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// ChangeType is the type of change to apply to a rule group of the ruler.
+type ChangeType string
+
+// Valid ChangeType values.
+const (
+	Create ChangeType = "create"
+	Update ChangeType = "update"
+	Delete ChangeType = "delete"
+)
+
+// Change is a change to apply to a rule group of the ruler, Old is nil for creations and New for deletions.
+type Change struct {
+	Type      ChangeType
+	Namespace string
+	Group     string
+	Old       *rulefmt.RuleGroup
+	New       *rulefmt.RuleGroup
+}
+
+// Diff returns the changes to apply to the remote rule groups so that they match the local ones, sorted by
+// namespace and rule group. Only the namespaces of the local rule groups are compared unless prune is set.
+func Diff(local, remote map[string][]rulefmt.RuleGroup, prune bool) ([]Change, error) {
+	var changes []Change
+	for ns, groups := range local {
+		remoteGroups := byName(remote[ns])
+		for i := range groups {
+			g := &groups[i]
+			old, ok := remoteGroups[g.Name]
+			if !ok {
+				changes = append(changes, Change{Type: Create, Namespace: ns, Group: g.Name, New: g})
+				continue
+			}
+			equal, err := groupsEqual(*old, *g)
+			if err != nil {
+				return nil, err
+			}
+			if !equal {
+				changes = append(changes, Change{Type: Update, Namespace: ns, Group: g.Name, Old: old, New: g})
+			}
+		}
+	}
+
+	for ns, groups := range remote {
+		if _, ok := local[ns]; !ok && !prune {
+			continue
+		}
+		localGroups := byName(local[ns])
+		for i := range groups {
+			g := &groups[i]
+			if _, ok := localGroups[g.Name]; !ok {
+				changes = append(changes, Change{Type: Delete, Namespace: ns, Group: g.Name, Old: g})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Group < changes[j].Group
+	})
+	return changes, nil
+}
+
+// Apply applies the changes to the ruler. Applying the same changes twice is harmless.
+func Apply(c client.RulesClient, changes []Change, quiet bool) error {
+	for _, ch := range changes {
+		var err error
+		switch ch.Type {
+		case Create, Update:
+			err = c.SetRuleGroup(ch.Namespace, *ch.New, quiet)
+		case Delete:
+			err = c.DeleteRuleGroup(ch.Namespace, ch.Group, quiet)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to %s rule group %q of namespace %q: %w", ch.Type, ch.Group, ch.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// PrintChanges prints out the changes, updates are printed as a diff of their rule groups.
+func PrintChanges(w io.Writer, changes []Change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes")
+		return err
+	}
+	for _, ch := range changes {
+		var before, after string
+		var err error
+		if ch.Old != nil {
+			if before, err = formatGroup(*ch.Old); err != nil {
+				return err
+			}
+		}
+		if ch.New != nil {
+			if after, err = formatGroup(*ch.New); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s %s/%s\n", ch.Type, ch.Namespace, ch.Group); err != nil {
+			return err
+		}
+		for _, l := range diffLines(splitLines(before), splitLines(after)) {
+			if _, err := fmt.Fprintf(w, "  %s\n", l); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rule is a rulefmt.RuleNode without the YAML nodes, so that rule groups can be compared regardless of
+// the formatting of their files.
+type rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         model.Duration    `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type ruleGroup struct {
+	Name     string         `yaml:"name"`
+	Interval model.Duration `yaml:"interval,omitempty"`
+	Rules    []rule         `yaml:"rules"`
+}
+
+func formatGroup(g rulefmt.RuleGroup) (string, error) {
+	rg := ruleGroup{
+		Name:     g.Name,
+		Interval: g.Interval,
+		Rules:    make([]rule, 0, len(g.Rules)),
+	}
+	for _, r := range g.Rules {
+		rg.Rules = append(rg.Rules, rule{
+			Record:      r.Record.Value,
+			Alert:       r.Alert.Value,
+			Expr:        r.Expr.Value,
+			For:         r.For,
+			Labels:      r.Labels,
+			Annotations: r.Annotations,
+		})
+	}
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(rg); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func groupsEqual(a, b rulefmt.RuleGroup) (bool, error) {
+	fa, err := formatGroup(a)
+	if err != nil {
+		return false, err
+	}
+	fb, err := formatGroup(b)
+	if err != nil {
+		return false, err
+	}
+	return fa == fb, nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the lines of a and b prefixed with "-" when they are only in a, "+" when they are only
+// in b and " " when they are in both, using their longest common subsequence.
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+ "+b[j])
+	}
+	return out
+}
+
+func byName(groups []rulefmt.RuleGroup) map[string]*rulefmt.RuleGroup {
+	m := make(map[string]*rulefmt.RuleGroup, len(groups))
+	for i := range groups {
+		m[groups[i].Name] = &groups[i]
+	}
+	return m
+}
+
+func namespaces(groups map[string][]rulefmt.RuleGroup) []string {
+	names := make([]string, 0, len(groups))
+	for ns := range groups {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func count(groups map[string][]rulefmt.RuleGroup) (int, int) {
+	var g, r int
+	for _, rgs := range groups {
+		g += len(rgs)
+		for _, rg := range rgs {
+			r += len(rg.Rules)
+		}
+	}
+	return g, r
+}
@@ -0,0 +1,243 @@
+package rules
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+)
+
+const appRules = `
+groups:
+  - name: errors
+    rules:
+      - record: app:errors:rate1m
+        expr: sum(rate({app="foo"} |= "error" [1m]))
+  - name: alerts
+    rules:
+      - alert: HighErrors
+        expr: sum(rate({app="foo"} |= "error" [1m])) > 10
+        for: 5m
+`
+
+const dbRules = `
+groups:
+  - name: slow
+    rules:
+      - alert: SlowQueries
+        expr: count_over_time({app="db"} |= "slow" [5m]) > 0
+        labels:
+          severity: warning
+`
+
+func writeRules(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "logcli-rules")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func groupNames(groups map[string][]rulefmt.RuleGroup) map[string][]string {
+	names := map[string][]string{}
+	for ns, rgs := range groups {
+		for _, g := range rgs {
+			names[ns] = append(names[ns], g.Name)
+		}
+	}
+	return names
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := tempDir(t)
+	app := writeRules(t, dir, "app.yaml", appRules)
+	writeRules(t, dir, "db.yaml", dbRules)
+
+	groups, err := LoadFiles([]string{dir}, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"app.yaml": {"errors", "alerts"},
+		"db.yaml":  {"slow"},
+	}, groupNames(groups))
+
+	groups, err = LoadFiles([]string{dir}, "all")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"all": {"errors", "alerts", "slow"},
+	}, groupNames(groups))
+
+	other := tempDir(t)
+	writeRules(t, other, "app.yaml", appRules)
+	_, err = LoadFiles([]string{app, other}, "")
+	require.EqualError(t, err, filepath.Join(other, "app.yaml")+`: rule group "errors" of namespace "app.yaml" is already defined in `+app+"\n"+
+		filepath.Join(other, "app.yaml")+`: rule group "alerts" of namespace "app.yaml" is already defined in `+app)
+
+	bad := writeRules(t, tempDir(t), "bad.yaml", `
+groups:
+  - name: bad
+    rules:
+      - record: bad
+        expr: sum(rate({app="foo" [1m]))
+`)
+	_, err = LoadFiles([]string{bad}, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), bad+": could not parse expression")
+
+	_, err = LoadFiles([]string{filepath.Join(dir, "missing.yaml")}, "")
+	require.Error(t, err)
+}
+
+func parseGroups(t *testing.T, content string) []rulefmt.RuleGroup {
+	t.Helper()
+	var groups rulefmt.RuleGroups
+	require.NoError(t, yaml.Unmarshal([]byte(content), &groups))
+	return groups.Groups
+}
+
+func TestDiff(t *testing.T) {
+	local := map[string][]rulefmt.RuleGroup{
+		"app.yaml": parseGroups(t, appRules),
+	}
+	changed := parseGroups(t, appRules)
+	changed[0].Rules[0].Expr.Value = `sum(rate({app="foo"} |= "err" [1m]))`
+	remote := map[string][]rulefmt.RuleGroup{
+		"app.yaml": append(changed[:1], parseGroups(t, dbRules)...),
+		"db.yaml":  parseGroups(t, dbRules),
+	}
+
+	changes, err := Diff(local, remote, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"create app.yaml/alerts", "update app.yaml/errors", "delete app.yaml/slow"}, describe(changes))
+
+	changes, err = Diff(local, remote, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"create app.yaml/alerts", "update app.yaml/errors", "delete app.yaml/slow", "delete db.yaml/slow"}, describe(changes))
+
+	changes, err = Diff(local, map[string][]rulefmt.RuleGroup{"app.yaml": parseGroups(t, appRules)}, true)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func describe(changes []Change) []string {
+	var res []string
+	for _, ch := range changes {
+		res = append(res, string(ch.Type)+" "+ch.Namespace+"/"+ch.Group)
+	}
+	return res
+}
+
+// fakeRulesClient is an in memory ruler.
+type fakeRulesClient struct {
+	groups map[string][]rulefmt.RuleGroup
+	calls  int
+}
+
+func (c *fakeRulesClient) ListRules(namespace string, _ bool) (map[string][]rulefmt.RuleGroup, error) {
+	res := map[string][]rulefmt.RuleGroup{}
+	for ns, groups := range c.groups {
+		if namespace == "" || namespace == ns {
+			res[ns] = append([]rulefmt.RuleGroup(nil), groups...)
+		}
+	}
+	return res, nil
+}
+
+func (c *fakeRulesClient) GetRuleGroup(namespace, groupName string, _ bool) (*rulefmt.RuleGroup, error) {
+	for _, g := range c.groups[namespace] {
+		if g.Name == groupName {
+			return &g, nil
+		}
+	}
+	return nil, client.ErrNotFound
+}
+
+func (c *fakeRulesClient) SetRuleGroup(namespace string, group rulefmt.RuleGroup, _ bool) error {
+	c.calls++
+	c.remove(namespace, group.Name)
+	c.groups[namespace] = append(c.groups[namespace], group)
+	return nil
+}
+
+func (c *fakeRulesClient) DeleteRuleGroup(namespace, groupName string, _ bool) error {
+	c.calls++
+	c.remove(namespace, groupName)
+	return nil
+}
+
+func (c *fakeRulesClient) remove(namespace, groupName string) {
+	var groups []rulefmt.RuleGroup
+	for _, g := range c.groups[namespace] {
+		if g.Name != groupName {
+			groups = append(groups, g)
+		}
+	}
+	c.groups[namespace] = groups
+	if len(groups) == 0 {
+		delete(c.groups, namespace)
+	}
+}
+
+func (c *fakeRulesClient) DeleteNamespace(namespace string, _ bool) error {
+	c.calls++
+	delete(c.groups, namespace)
+	return nil
+}
+
+func TestApply(t *testing.T) {
+	local := map[string][]rulefmt.RuleGroup{
+		"app.yaml": parseGroups(t, appRules),
+	}
+	c := &fakeRulesClient{groups: map[string][]rulefmt.RuleGroup{
+		"app.yaml": parseGroups(t, dbRules),
+		"db.yaml":  parseGroups(t, dbRules),
+	}}
+
+	changes, err := Diff(local, c.groups, true)
+	require.NoError(t, err)
+	require.NoError(t, Apply(c, changes, true))
+	require.Equal(t, 4, c.calls)
+	require.Equal(t, map[string][]string{"app.yaml": {"alerts", "errors"}}, groupNames(c.groups))
+
+	// the ruler is in sync, there is nothing left to apply.
+	changes, err = Diff(local, c.groups, true)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func TestPrintChanges(t *testing.T) {
+	old := parseGroups(t, dbRules)
+	updated := parseGroups(t, dbRules)
+	updated[0].Rules[0].Labels["severity"] = "critical"
+
+	var b bytes.Buffer
+	require.NoError(t, PrintChanges(&b, []Change{
+		{Type: Update, Namespace: "db.yaml", Group: "slow", Old: &old[0], New: &updated[0]},
+	}))
+	require.Equal(t, `update db.yaml/slow
+    name: slow
+    rules:
+      - alert: SlowQueries
+        expr: count_over_time({app="db"} |= "slow" [5m]) > 0
+        labels:
+  -       severity: warning
+  +       severity: critical
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, PrintChanges(&b, nil))
+	require.Equal(t, "No changes\n", b.String())
+}
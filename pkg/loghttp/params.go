@@ -62,7 +62,7 @@ func bounds(r *http.Request) (time.Time, time.Time, error) {
 func step(r *http.Request, start, end time.Time) (time.Duration, error) {
 	value := r.Form.Get("step")
 	if value == "" {
-		return time.Duration(defaultQueryRangeStep(start, end)) * time.Second, nil
+		return time.Duration(DefaultQueryRangeStep(start, end)) * time.Second, nil
 	}
 	return parseSecondsOrDuration(value)
 }
@@ -75,9 +75,9 @@ func interval(r *http.Request) (time.Duration, error) {
 	return parseSecondsOrDuration(value)
 }
 
-// defaultQueryRangeStep returns the default step used in the query range API,
+// DefaultQueryRangeStep returns the default step used in the query range API,
 // which is dynamically calculated based on the time range
-func defaultQueryRangeStep(start time.Time, end time.Time) int {
+func DefaultQueryRangeStep(start time.Time, end time.Time) int {
 	return int(math.Max(math.Floor(end.Sub(start).Seconds()/250), 1))
 }
 
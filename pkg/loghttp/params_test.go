@@ -12,7 +12,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 )
 
-func TestHttp_defaultQueryRangeStep(t *testing.T) {
+func TestHttp_DefaultQueryRangeStep(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
@@ -41,7 +41,7 @@ func TestHttp_defaultQueryRangeStep(t *testing.T) {
 		testData := testData
 
 		t.Run(testName, func(t *testing.T) {
-			assert.Equal(t, testData.expected, defaultQueryRangeStep(testData.start, testData.end))
+			assert.Equal(t, testData.expected, DefaultQueryRangeStep(testData.start, testData.end))
 		})
 	}
 }
@@ -282,6 +282,13 @@ func newNestedLineFilterExpr(left *LineFilterExpr, right *LineFilterExpr) *LineF
 	}
 }
 
+// MatchType returns the match type of the line filter, without the filters on its left.
+func (e *LineFilterExpr) MatchType() labels.MatchType { return e.ty }
+
+// Match returns the value matched by the line filter and the name of its filter operation if any,
+// without the filters on its left.
+func (e *LineFilterExpr) Match() (string, string) { return e.match, e.op }
+
 func (e *LineFilterExpr) Walk(f WalkFn) {
 	f(e)
 	if e.left == nil {
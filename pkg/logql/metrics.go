@@ -110,7 +110,7 @@ func RecordMetrics(ctx context.Context, p Params, status string, stats stats.Res
 		Observe(stats.Summary.ExecTime)
 	chunkDownloadLatency.WithLabelValues(status, queryType, rt).
 		Observe(stats.Store.ChunksDownloadTime)
-	duplicatesTotal.Add(float64(stats.Store.TotalDuplicates))
+	duplicatesTotal.Add(float64(stats.Store.TotalDuplicates + stats.Querier.TotalDuplicatesRemoved))
 	chunkDownloadedTotal.WithLabelValues(status, queryType, rt).
 		Add(float64(stats.Store.TotalChunksDownloaded))
 	ingesterLineTotal.Add(float64(stats.Ingester.TotalLinesSent))
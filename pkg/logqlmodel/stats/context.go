@@ -10,6 +10,8 @@ Then you can update statistics by mutating data by using:
 	stats.GetChunkData(ctx)
 	stats.GetIngesterData(ctx)
 	stats.GetStoreData
+	stats.GetQuerierData(ctx)
+	stats.GetCacheData(ctx, stats.ChunkCache)
 
 Finally to get a snapshot of the current query statistic use
 
@@ -25,6 +27,7 @@ import (
 	"errors"
 	fmt "fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -34,12 +37,15 @@ import (
 type ctxKeyType string
 
 const (
-	trailersKey ctxKeyType = "trailers"
-	chunksKey   ctxKeyType = "chunks"
-	ingesterKey ctxKeyType = "ingester"
-	storeKey    ctxKeyType = "store"
-	resultKey   ctxKeyType = "result" // key for pre-computed results to be merged in  `Snapshot`
-	lockKey     ctxKeyType = "lock"   // key for locking a context when stats is used concurrently
+	trailersKey   ctxKeyType = "trailers"
+	chunksKey     ctxKeyType = "chunks"
+	ingesterKey   ctxKeyType = "ingester"
+	storeKey      ctxKeyType = "store"
+	querierKey    ctxKeyType = "querier"
+	chunkCacheKey ctxKeyType = "chunk-cache"
+	indexCacheKey ctxKeyType = "index-cache"
+	resultKey     ctxKeyType = "result" // key for pre-computed results to be merged in  `Snapshot`
+	lockKey       ctxKeyType = "lock"   // key for locking a context when stats is used concurrently
 )
 
 // Log logs a query statistics result.
@@ -56,6 +62,7 @@ func (r Result) Log(log log.Logger) {
 		"Ingester.DecompressedLines", r.Ingester.DecompressedLines,
 		"Ingester.CompressedBytes", humanize.Bytes(uint64(r.Ingester.CompressedBytes)),
 		"Ingester.TotalDuplicates", r.Ingester.TotalDuplicates,
+		"Ingester.PostFilterLines", r.Ingester.PostFilterLines,
 
 		"Store.TotalChunksRef", r.Store.TotalChunksRef,
 		"Store.TotalChunksDownloaded", r.Store.TotalChunksDownloaded,
@@ -67,10 +74,28 @@ func (r Result) Log(log log.Logger) {
 		"Store.DecompressedLines", r.Store.DecompressedLines,
 		"Store.CompressedBytes", humanize.Bytes(uint64(r.Store.CompressedBytes)),
 		"Store.TotalDuplicates", r.Store.TotalDuplicates,
+		"Store.PostFilterLines", r.Store.PostFilterLines,
+
+		"Querier.TotalDuplicatesRemoved", r.Querier.TotalDuplicatesRemoved,
 	)
+	r.Caches.Log(log)
 	r.Summary.Log(log)
 }
 
+func (c Caches) Log(log log.Logger) {
+	_ = log.Log(
+		"Cache.Chunk.Requests", c.Chunk.Requests,
+		"Cache.Chunk.EntriesRequested", c.Chunk.EntriesRequested,
+		"Cache.Chunk.EntriesFound", c.Chunk.EntriesFound,
+		"Cache.Chunk.BytesFetched", humanize.Bytes(uint64(c.Chunk.BytesFetched)),
+
+		"Cache.Index.Requests", c.Index.Requests,
+		"Cache.Index.EntriesRequested", c.Index.EntriesRequested,
+		"Cache.Index.EntriesFound", c.Index.EntriesFound,
+		"Cache.Index.BytesFetched", humanize.Bytes(uint64(c.Index.BytesFetched)),
+	)
+}
+
 func (s Summary) Log(log log.Logger) {
 	_ = log.Log(
 		"Summary.BytesProcessedPerSecond", humanize.Bytes(uint64(s.BytesProcessedPerSecond)),
@@ -78,7 +103,17 @@ func (s Summary) Log(log log.Logger) {
 		"Summary.TotalBytesProcessed", humanize.Bytes(uint64(s.TotalBytesProcessed)),
 		"Summary.TotalLinesProcessed", s.TotalLinesProcessed,
 		"Summary.ExecTime", time.Duration(int64(s.ExecTime*float64(time.Second))),
+		"Summary.QueueTime", time.Duration(int64(s.QueueTime*float64(time.Second))),
+		"Summary.Splits", s.Splits,
+		"Summary.Shards", s.Shards,
 	)
+	for _, split := range s.SplitTimings {
+		_ = log.Log(
+			"Summary.Split.Start", time.Unix(0, split.Start*int64(time.Millisecond)).UTC(),
+			"Summary.Split.End", time.Unix(0, split.End*int64(time.Millisecond)).UTC(),
+			"Summary.Split.ExecTime", time.Duration(int64(split.ExecTime*float64(time.Second))),
+		)
+	}
 }
 
 // NewContext creates a new statistics context
@@ -87,6 +122,9 @@ func NewContext(ctx context.Context) context.Context {
 	ctx = context.WithValue(ctx, storeKey, &StoreData{})
 	ctx = context.WithValue(ctx, chunksKey, &ChunkData{})
 	ctx = context.WithValue(ctx, ingesterKey, &IngesterData{})
+	ctx = context.WithValue(ctx, querierKey, &QuerierData{})
+	ctx = context.WithValue(ctx, chunkCacheKey, &CacheData{})
+	ctx = context.WithValue(ctx, indexCacheKey, &CacheData{})
 	ctx = context.WithValue(ctx, resultKey, &Result{})
 	ctx = context.WithValue(ctx, lockKey, &sync.Mutex{})
 	return ctx
@@ -100,6 +138,7 @@ type ChunkData struct {
 	DecompressedLines int64 `json:"decompressedLines"` // Total lines decompressed and processed from chunks.
 	CompressedBytes   int64 `json:"compressedBytes"`   // Total bytes of compressed chunks (blocks) processed.
 	TotalDuplicates   int64 `json:"totalDuplicates"`   // Total duplicates found while processing.
+	PostFilterLines   int64 `json:"postFilterLines"`   // Total lines left after applying the query filters.
 }
 
 // GetChunkData returns the chunks statistics data from the current context.
@@ -143,6 +182,50 @@ func GetStoreData(ctx context.Context) *StoreData {
 	return res
 }
 
+// QuerierData contains querier specific statistics.
+type QuerierData struct {
+	TotalDuplicatesRemoved int64 `json:"totalDuplicatesRemoved"` // Total replicated lines and samples removed while merging ingesters and store results.
+}
+
+// GetQuerierData returns the querier statistics data from the current context.
+func GetQuerierData(ctx context.Context) *QuerierData {
+	res, ok := ctx.Value(querierKey).(*QuerierData)
+	if !ok {
+		return &QuerierData{}
+	}
+	return res
+}
+
+// CacheType is the type of a cache used by queries.
+type CacheType string
+
+const (
+	ChunkCache CacheType = "chunk" // The cache of chunks fetched from the store.
+	IndexCache CacheType = "index" // The cache of index queries.
+)
+
+// CacheData contains statistics of a cache type.
+// Caches can be used concurrently, fields must be updated atomically.
+type CacheData struct {
+	Requests         int64 // Total requests made to the cache.
+	EntriesRequested int64 // Total entries looked up in the cache.
+	EntriesFound     int64 // Total entries found in the cache.
+	BytesFetched     int64 // Total bytes fetched from the cache.
+}
+
+// GetCacheData returns the statistics data of the given cache type from the current context.
+func GetCacheData(ctx context.Context, t CacheType) *CacheData {
+	key := chunkCacheKey
+	if t == IndexCache {
+		key = indexCacheKey
+	}
+	res, ok := ctx.Value(key).(*CacheData)
+	if !ok {
+		return &CacheData{}
+	}
+	return res
+}
+
 // Snapshot compute query statistics from a context using the total exec time.
 func Snapshot(ctx context.Context, execTime time.Duration) Result {
 	// ingester data is decoded from grpc trailers.
@@ -163,7 +246,16 @@ func Snapshot(ctx context.Context, execTime time.Duration) Result {
 		res.Store.DecompressedLines = c.DecompressedLines
 		res.Store.CompressedBytes = c.CompressedBytes
 		res.Store.TotalDuplicates = c.TotalDuplicates
+		res.Store.PostFilterLines = c.PostFilterLines
+	}
+	// collect data from the querier merge of ingesters and store results.
+	q, ok := ctx.Value(querierKey).(*QuerierData)
+	if ok {
+		res.Querier.TotalDuplicatesRemoved = q.TotalDuplicatesRemoved
 	}
+	// collect data from caches.
+	res.Caches.Chunk = snapshotCache(ctx, chunkCacheKey)
+	res.Caches.Index = snapshotCache(ctx, indexCacheKey)
 
 	existing, err := GetResult(ctx)
 	if err != nil {
@@ -177,6 +269,19 @@ func Snapshot(ctx context.Context, execTime time.Duration) Result {
 
 }
 
+func snapshotCache(ctx context.Context, key ctxKeyType) Cache {
+	c, ok := ctx.Value(key).(*CacheData)
+	if !ok {
+		return Cache{}
+	}
+	return Cache{
+		Requests:         atomic.LoadInt64(&c.Requests),
+		EntriesRequested: atomic.LoadInt64(&c.EntriesRequested),
+		EntriesFound:     atomic.LoadInt64(&c.EntriesFound),
+		BytesFetched:     atomic.LoadInt64(&c.BytesFetched),
+	}
+}
+
 // ComputeSummary calculates the summary based on store and ingester data.
 func (r *Result) ComputeSummary(execTime time.Duration) {
 	// calculate the summary
@@ -206,6 +311,7 @@ func (r *Result) Merge(m Result) {
 	r.Store.DecompressedLines += m.Store.DecompressedLines
 	r.Store.CompressedBytes += m.Store.CompressedBytes
 	r.Store.TotalDuplicates += m.Store.TotalDuplicates
+	r.Store.PostFilterLines += m.Store.PostFilterLines
 
 	r.Ingester.TotalReached += m.Ingester.TotalReached
 	r.Ingester.TotalChunksMatched += m.Ingester.TotalChunksMatched
@@ -217,10 +323,28 @@ func (r *Result) Merge(m Result) {
 	r.Ingester.DecompressedLines += m.Ingester.DecompressedLines
 	r.Ingester.CompressedBytes += m.Ingester.CompressedBytes
 	r.Ingester.TotalDuplicates += m.Ingester.TotalDuplicates
+	r.Ingester.PostFilterLines += m.Ingester.PostFilterLines
+
+	r.Querier.TotalDuplicatesRemoved += m.Querier.TotalDuplicatesRemoved
+
+	r.Caches.Chunk.Merge(m.Caches.Chunk)
+	r.Caches.Index.Merge(m.Caches.Index)
+
+	r.Summary.QueueTime += m.Summary.QueueTime
+	r.Summary.Splits += m.Summary.Splits
+	r.Summary.Shards += m.Summary.Shards
+	r.Summary.SplitTimings = append(r.Summary.SplitTimings, m.Summary.SplitTimings...)
 
 	r.ComputeSummary(time.Duration(int64((r.Summary.ExecTime + m.Summary.ExecTime) * float64(time.Second))))
 }
 
+func (c *Cache) Merge(m Cache) {
+	c.Requests += m.Requests
+	c.EntriesRequested += m.EntriesRequested
+	c.EntriesFound += m.EntriesFound
+	c.BytesFetched += m.BytesFetched
+}
+
 // JoinResults merges a Result with the embedded Result in a context in a concurrency-safe manner.
 func JoinResults(ctx context.Context, res Result) error {
 	mtx, err := GetMutex(ctx)
@@ -19,11 +19,23 @@ func TestSnapshot(t *testing.T) {
 	GetChunkData(ctx).DecompressedLines += 20
 	GetChunkData(ctx).CompressedBytes += 30
 	GetChunkData(ctx).TotalDuplicates += 10
+	GetChunkData(ctx).PostFilterLines += 15
 
 	GetStoreData(ctx).TotalChunksRef += 50
 	GetStoreData(ctx).TotalChunksDownloaded += 60
 	GetStoreData(ctx).ChunksDownloadTime += time.Second
 
+	GetQuerierData(ctx).TotalDuplicatesRemoved += 5
+
+	GetCacheData(ctx, ChunkCache).Requests += 2
+	GetCacheData(ctx, ChunkCache).EntriesRequested += 60
+	GetCacheData(ctx, ChunkCache).EntriesFound += 40
+	GetCacheData(ctx, ChunkCache).BytesFetched += 1000
+	GetCacheData(ctx, IndexCache).Requests += 3
+	GetCacheData(ctx, IndexCache).EntriesRequested += 30
+	GetCacheData(ctx, IndexCache).EntriesFound += 25
+	GetCacheData(ctx, IndexCache).BytesFetched += 500
+
 	fakeIngesterQuery(ctx)
 	fakeIngesterQuery(ctx)
 
@@ -41,6 +53,7 @@ func TestSnapshot(t *testing.T) {
 			CompressedBytes:    60,
 			TotalDuplicates:    2,
 			TotalReached:       2,
+			PostFilterLines:    16,
 		},
 		Store: Store{
 			TotalChunksRef:        50,
@@ -52,6 +65,24 @@ func TestSnapshot(t *testing.T) {
 			DecompressedLines:     20,
 			CompressedBytes:       30,
 			TotalDuplicates:       10,
+			PostFilterLines:       15,
+		},
+		Querier: Querier{
+			TotalDuplicatesRemoved: 5,
+		},
+		Caches: Caches{
+			Chunk: Cache{
+				Requests:         2,
+				EntriesRequested: 60,
+				EntriesFound:     40,
+				BytesFetched:     1000,
+			},
+			Index: Cache{
+				Requests:         3,
+				EntriesRequested: 30,
+				EntriesFound:     25,
+				BytesFetched:     500,
+			},
 		},
 		Summary: Summary{
 			ExecTime:                2 * time.Second.Seconds(),
@@ -78,6 +109,7 @@ func TestSnapshot_MergesResults(t *testing.T) {
 			CompressedBytes:    60,
 			TotalDuplicates:    2,
 			TotalReached:       2,
+			PostFilterLines:    16,
 		},
 		Store: Store{
 			TotalChunksRef:        50,
@@ -89,6 +121,24 @@ func TestSnapshot_MergesResults(t *testing.T) {
 			DecompressedLines:     20,
 			CompressedBytes:       30,
 			TotalDuplicates:       10,
+			PostFilterLines:       15,
+		},
+		Querier: Querier{
+			TotalDuplicatesRemoved: 5,
+		},
+		Caches: Caches{
+			Chunk: Cache{
+				Requests:         2,
+				EntriesRequested: 60,
+				EntriesFound:     40,
+				BytesFetched:     1000,
+			},
+			Index: Cache{
+				Requests:         3,
+				EntriesRequested: 30,
+				EntriesFound:     25,
+				BytesFetched:     500,
+			},
 		},
 		Summary: Summary{
 			ExecTime:                2 * time.Second.Seconds(),
@@ -96,6 +146,9 @@ func TestSnapshot_MergesResults(t *testing.T) {
 			LinesProcessedPerSecond: int64(50),
 			TotalBytesProcessed:     int64(84),
 			TotalLinesProcessed:     int64(100),
+			QueueTime:               time.Second.Seconds(),
+			Splits:                  4,
+			Shards:                  16,
 		},
 	}
 
@@ -122,6 +175,7 @@ func fakeIngesterQuery(ctx context.Context) {
 		DecompressedLines: 20,
 		CompressedBytes:   30,
 		TotalDuplicates:   1,
+		PostFilterLines:   8,
 	})
 	meta.Set(chunkDataKey, c)
 	i, _ := jsoniter.MarshalToString(IngesterData{
@@ -150,6 +204,7 @@ func TestResult_Merge(t *testing.T) {
 			CompressedBytes:    60,
 			TotalDuplicates:    2,
 			TotalReached:       2,
+			PostFilterLines:    16,
 		},
 		Store: Store{
 			TotalChunksRef:        50,
@@ -161,6 +216,24 @@ func TestResult_Merge(t *testing.T) {
 			DecompressedLines:     20,
 			CompressedBytes:       30,
 			TotalDuplicates:       10,
+			PostFilterLines:       15,
+		},
+		Querier: Querier{
+			TotalDuplicatesRemoved: 5,
+		},
+		Caches: Caches{
+			Chunk: Cache{
+				Requests:         2,
+				EntriesRequested: 60,
+				EntriesFound:     40,
+				BytesFetched:     1000,
+			},
+			Index: Cache{
+				Requests:         3,
+				EntriesRequested: 30,
+				EntriesFound:     25,
+				BytesFetched:     500,
+			},
 		},
 		Summary: Summary{
 			ExecTime:                2 * time.Second.Seconds(),
@@ -168,6 +241,10 @@ func TestResult_Merge(t *testing.T) {
 			LinesProcessedPerSecond: int64(50),
 			TotalBytesProcessed:     int64(84),
 			TotalLinesProcessed:     int64(100),
+			QueueTime:               time.Second.Seconds(),
+			Splits:                  4,
+			Shards:                  16,
+			SplitTimings:            []Split{{Start: 0, End: 1000, ExecTime: 1}},
 		},
 	}
 
@@ -188,6 +265,7 @@ func TestResult_Merge(t *testing.T) {
 			CompressedBytes:    2 * 60,
 			TotalDuplicates:    2 * 2,
 			TotalReached:       2 * 2,
+			PostFilterLines:    2 * 16,
 		},
 		Store: Store{
 			TotalChunksRef:        2 * 50,
@@ -199,6 +277,24 @@ func TestResult_Merge(t *testing.T) {
 			DecompressedLines:     2 * 20,
 			CompressedBytes:       2 * 30,
 			TotalDuplicates:       2 * 10,
+			PostFilterLines:       2 * 15,
+		},
+		Querier: Querier{
+			TotalDuplicatesRemoved: 2 * 5,
+		},
+		Caches: Caches{
+			Chunk: Cache{
+				Requests:         2 * 2,
+				EntriesRequested: 2 * 60,
+				EntriesFound:     2 * 40,
+				BytesFetched:     2 * 1000,
+			},
+			Index: Cache{
+				Requests:         2 * 3,
+				EntriesRequested: 2 * 30,
+				EntriesFound:     2 * 25,
+				BytesFetched:     2 * 500,
+			},
 		},
 		Summary: Summary{
 			ExecTime:                2 * 2 * time.Second.Seconds(),
@@ -206,6 +302,10 @@ func TestResult_Merge(t *testing.T) {
 			LinesProcessedPerSecond: int64(50),
 			TotalBytesProcessed:     2 * int64(84),
 			TotalLinesProcessed:     2 * int64(100),
+			QueueTime:               2 * time.Second.Seconds(),
+			Splits:                  2 * 4,
+			Shards:                  2 * 16,
+			SplitTimings:            []Split{{Start: 0, End: 1000, ExecTime: 1}, {Start: 0, End: 1000, ExecTime: 1}},
 		},
 	}, res)
 
@@ -104,6 +104,7 @@ func decodeTrailers(ctx context.Context) Result {
 		res.Ingester.DecompressedLines += ing.Ingester.DecompressedLines
 		res.Ingester.CompressedBytes += ing.Ingester.CompressedBytes
 		res.Ingester.TotalDuplicates += ing.Ingester.TotalDuplicates
+		res.Ingester.PostFilterLines += ing.Ingester.PostFilterLines
 		res.Store.TotalChunksRef += ing.Store.TotalChunksRef
 		res.Store.TotalChunksDownloaded += ing.Store.TotalChunksDownloaded
 		res.Store.ChunksDownloadTime += ing.Store.ChunksDownloadTime
@@ -145,6 +146,7 @@ func decodeTrailer(ctx context.Context, meta *metadata.MD) Result {
 			DecompressedLines:  chunkData.DecompressedLines,
 			CompressedBytes:    chunkData.CompressedBytes,
 			TotalDuplicates:    chunkData.TotalDuplicates,
+			PostFilterLines:    chunkData.PostFilterLines,
 		},
 		Store: Store{
 			TotalChunksRef:        storeData.TotalChunksRef,
@@ -31,6 +31,8 @@ type Result struct {
 	Summary  Summary  `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary"`
 	Store    Store    `protobuf:"bytes,2,opt,name=store,proto3" json:"store"`
 	Ingester Ingester `protobuf:"bytes,3,opt,name=ingester,proto3" json:"ingester"`
+	Querier  Querier  `protobuf:"bytes,4,opt,name=querier,proto3" json:"querier"`
+	Caches   Caches   `protobuf:"bytes,5,opt,name=caches,proto3" json:"caches"`
 }
 
 func (m *Result) Reset()      { *m = Result{} }
@@ -86,6 +88,20 @@ func (m *Result) GetIngester() Ingester {
 	return Ingester{}
 }
 
+func (m *Result) GetQuerier() Querier {
+	if m != nil {
+		return m.Querier
+	}
+	return Querier{}
+}
+
+func (m *Result) GetCaches() Caches {
+	if m != nil {
+		return m.Caches
+	}
+	return Caches{}
+}
+
 // Summary is the summary of a query statistics.
 type Summary struct {
 	// Total bytes processed per second.
@@ -98,6 +114,14 @@ type Summary struct {
 	TotalLinesProcessed int64 `protobuf:"varint,4,opt,name=totalLinesProcessed,proto3" json:"totalLinesProcessed"`
 	// Execution time in seconds.
 	ExecTime float64 `protobuf:"fixed64,5,opt,name=execTime,proto3" json:"execTime"`
+	// Total time in seconds sub-queries spent queued before being executed by queriers.
+	QueueTime float64 `protobuf:"fixed64,6,opt,name=queueTime,proto3" json:"queueTime"`
+	// Total sub-queries the query was split into by time.
+	Splits int64 `protobuf:"varint,7,opt,name=splits,proto3" json:"splits"`
+	// Total shards the query and its splits were executed with.
+	Shards int64 `protobuf:"varint,8,opt,name=shards,proto3" json:"shards"`
+	// Execution of each sub-query the query was split into by time.
+	SplitTimings []Split `protobuf:"bytes,9,rep,name=splitTimings,proto3" json:"splitTimings,omitempty"`
 }
 
 func (m *Summary) Reset()      { *m = Summary{} }
@@ -167,6 +191,97 @@ func (m *Summary) GetExecTime() float64 {
 	return 0
 }
 
+func (m *Summary) GetQueueTime() float64 {
+	if m != nil {
+		return m.QueueTime
+	}
+	return 0
+}
+
+func (m *Summary) GetSplits() int64 {
+	if m != nil {
+		return m.Splits
+	}
+	return 0
+}
+
+func (m *Summary) GetShards() int64 {
+	if m != nil {
+		return m.Shards
+	}
+	return 0
+}
+
+func (m *Summary) GetSplitTimings() []Split {
+	if m != nil {
+		return m.SplitTimings
+	}
+	return nil
+}
+
+// Split is the execution of a sub-query a query was split into by time.
+type Split struct {
+	// Start of the sub-query in milliseconds since epoch.
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start"`
+	// End of the sub-query in milliseconds since epoch.
+	End int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end"`
+	// Execution time in seconds.
+	ExecTime float64 `protobuf:"fixed64,3,opt,name=execTime,proto3" json:"execTime"`
+}
+
+func (m *Split) Reset()      { *m = Split{} }
+func (*Split) ProtoMessage() {}
+func (*Split) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{2}
+}
+func (m *Split) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Split) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Split.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Split) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Split.Merge(m, src)
+}
+func (m *Split) XXX_Size() int {
+	return m.Size()
+}
+func (m *Split) XXX_DiscardUnknown() {
+	xxx_messageInfo_Split.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Split proto.InternalMessageInfo
+
+func (m *Split) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *Split) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *Split) GetExecTime() float64 {
+	if m != nil {
+		return m.ExecTime
+	}
+	return 0
+}
+
 type Store struct {
 	// The total of chunk reference fetched from index.
 	TotalChunksRef int64 `protobuf:"varint,1,opt,name=totalChunksRef,proto3" json:"totalChunksRef"`
@@ -186,12 +301,14 @@ type Store struct {
 	CompressedBytes int64 `protobuf:"varint,8,opt,name=compressedBytes,proto3" json:"compressedBytes"`
 	// Total duplicates found while processing.
 	TotalDuplicates int64 `protobuf:"varint,9,opt,name=totalDuplicates,proto3" json:"totalDuplicates"`
+	// Total lines left after applying the query filters.
+	PostFilterLines int64 `protobuf:"varint,10,opt,name=postFilterLines,proto3" json:"postFilterLines"`
 }
 
 func (m *Store) Reset()      { *m = Store{} }
 func (*Store) ProtoMessage() {}
 func (*Store) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{2}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{3}
 }
 func (m *Store) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -283,6 +400,13 @@ func (m *Store) GetTotalDuplicates() int64 {
 	return 0
 }
 
+func (m *Store) GetPostFilterLines() int64 {
+	if m != nil {
+		return m.PostFilterLines
+	}
+	return 0
+}
+
 type Ingester struct {
 	// Total ingester reached for this query.
 	TotalReached int32 `protobuf:"varint,1,opt,name=totalReached,proto3" json:"totalReached"`
@@ -304,12 +428,14 @@ type Ingester struct {
 	CompressedBytes int64 `protobuf:"varint,9,opt,name=compressedBytes,proto3" json:"compressedBytes"`
 	// Total duplicates found while processing.
 	TotalDuplicates int64 `protobuf:"varint,10,opt,name=totalDuplicates,proto3" json:"totalDuplicates"`
+	// Total lines left after applying the query filters.
+	PostFilterLines int64 `protobuf:"varint,11,opt,name=postFilterLines,proto3" json:"postFilterLines"`
 }
 
 func (m *Ingester) Reset()      { *m = Ingester{} }
 func (*Ingester) ProtoMessage() {}
 func (*Ingester) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{3}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{4}
 }
 func (m *Ingester) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -408,60 +534,257 @@ func (m *Ingester) GetTotalDuplicates() int64 {
 	return 0
 }
 
+func (m *Ingester) GetPostFilterLines() int64 {
+	if m != nil {
+		return m.PostFilterLines
+	}
+	return 0
+}
+
+type Querier struct {
+	// Total replicated lines removed while merging ingesters and store results.
+	TotalDuplicatesRemoved int64 `protobuf:"varint,1,opt,name=totalDuplicatesRemoved,proto3" json:"totalDuplicatesRemoved"`
+}
+
+func (m *Querier) Reset()      { *m = Querier{} }
+func (*Querier) ProtoMessage() {}
+func (*Querier) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{5}
+}
+func (m *Querier) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Querier) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Querier.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Querier) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Querier.Merge(m, src)
+}
+func (m *Querier) XXX_Size() int {
+	return m.Size()
+}
+func (m *Querier) XXX_DiscardUnknown() {
+	xxx_messageInfo_Querier.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Querier proto.InternalMessageInfo
+
+func (m *Querier) GetTotalDuplicatesRemoved() int64 {
+	if m != nil {
+		return m.TotalDuplicatesRemoved
+	}
+	return 0
+}
+
+type Caches struct {
+	Chunk Cache `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk"`
+	Index Cache `protobuf:"bytes,2,opt,name=index,proto3" json:"index"`
+}
+
+func (m *Caches) Reset()      { *m = Caches{} }
+func (*Caches) ProtoMessage() {}
+func (*Caches) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{6}
+}
+func (m *Caches) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Caches) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Caches.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Caches) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Caches.Merge(m, src)
+}
+func (m *Caches) XXX_Size() int {
+	return m.Size()
+}
+func (m *Caches) XXX_DiscardUnknown() {
+	xxx_messageInfo_Caches.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Caches proto.InternalMessageInfo
+
+func (m *Caches) GetChunk() Cache {
+	if m != nil {
+		return m.Chunk
+	}
+	return Cache{}
+}
+
+func (m *Caches) GetIndex() Cache {
+	if m != nil {
+		return m.Index
+	}
+	return Cache{}
+}
+
+type Cache struct {
+	// Total requests made to the cache.
+	Requests int64 `protobuf:"varint,1,opt,name=requests,proto3" json:"requests"`
+	// Total entries looked up in the cache.
+	EntriesRequested int64 `protobuf:"varint,2,opt,name=entriesRequested,proto3" json:"entriesRequested"`
+	// Total entries found in the cache.
+	EntriesFound int64 `protobuf:"varint,3,opt,name=entriesFound,proto3" json:"entriesFound"`
+	// Total bytes fetched from the cache.
+	BytesFetched int64 `protobuf:"varint,4,opt,name=bytesFetched,proto3" json:"bytesFetched"`
+}
+
+func (m *Cache) Reset()      { *m = Cache{} }
+func (*Cache) ProtoMessage() {}
+func (*Cache) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{7}
+}
+func (m *Cache) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Cache) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Cache.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Cache) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Cache.Merge(m, src)
+}
+func (m *Cache) XXX_Size() int {
+	return m.Size()
+}
+func (m *Cache) XXX_DiscardUnknown() {
+	xxx_messageInfo_Cache.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Cache proto.InternalMessageInfo
+
+func (m *Cache) GetRequests() int64 {
+	if m != nil {
+		return m.Requests
+	}
+	return 0
+}
+
+func (m *Cache) GetEntriesRequested() int64 {
+	if m != nil {
+		return m.EntriesRequested
+	}
+	return 0
+}
+
+func (m *Cache) GetEntriesFound() int64 {
+	if m != nil {
+		return m.EntriesFound
+	}
+	return 0
+}
+
+func (m *Cache) GetBytesFetched() int64 {
+	if m != nil {
+		return m.BytesFetched
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Result)(nil), "stats.Result")
 	proto.RegisterType((*Summary)(nil), "stats.Summary")
+	proto.RegisterType((*Split)(nil), "stats.Split")
 	proto.RegisterType((*Store)(nil), "stats.Store")
 	proto.RegisterType((*Ingester)(nil), "stats.Ingester")
+	proto.RegisterType((*Querier)(nil), "stats.Querier")
+	proto.RegisterType((*Caches)(nil), "stats.Caches")
+	proto.RegisterType((*Cache)(nil), "stats.Cache")
 }
 
 func init() { proto.RegisterFile("pkg/logqlmodel/stats/stats.proto", fileDescriptor_6cdfe5d2aea33ebb) }
 
 var fileDescriptor_6cdfe5d2aea33ebb = []byte{
-	// 677 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0xcd, 0x6e, 0x13, 0x3d,
-	0x14, 0x1d, 0x37, 0xcd, 0x4f, 0xfd, 0xf5, 0x6b, 0x8b, 0xab, 0xd2, 0x00, 0x92, 0xa7, 0xca, 0xaa,
-	0x0b, 0x68, 0xc4, 0xcf, 0x06, 0xa4, 0x6e, 0xa6, 0x15, 0x52, 0x25, 0x10, 0x95, 0x0b, 0x1b, 0x76,
-	0x93, 0x89, 0x9b, 0x44, 0x9d, 0x8c, 0xc3, 0x8c, 0x23, 0xe8, 0x8e, 0x47, 0xe0, 0x31, 0x78, 0x01,
-	0xde, 0xa1, 0xcb, 0x2e, 0xbb, 0x40, 0x23, 0x3a, 0xdd, 0xa0, 0x59, 0x75, 0x87, 0xc4, 0x0a, 0xcd,
-	0x9d, 0x9f, 0x64, 0x1c, 0x47, 0x42, 0x0a, 0x9b, 0xc4, 0xf7, 0x9c, 0x7b, 0xae, 0xed, 0xeb, 0xe3,
-	0x31, 0xde, 0x19, 0x9d, 0xf5, 0xda, 0xae, 0xe8, 0x7d, 0x70, 0x87, 0xa2, 0xcb, 0xdd, 0x76, 0x20,
-	0x6d, 0x19, 0xa4, 0xbf, 0x7b, 0x23, 0x5f, 0x48, 0x41, 0xaa, 0x10, 0xdc, 0x7f, 0xd4, 0x1b, 0xc8,
-	0xfe, 0xb8, 0xb3, 0xe7, 0x88, 0x61, 0xbb, 0x27, 0x7a, 0xa2, 0x0d, 0x6c, 0x67, 0x7c, 0x0a, 0x11,
-	0x04, 0x30, 0x4a, 0x55, 0xad, 0x6f, 0x08, 0xd7, 0x18, 0x0f, 0xc6, 0xae, 0x24, 0xcf, 0x71, 0x3d,
-	0x18, 0x0f, 0x87, 0xb6, 0x7f, 0xde, 0x44, 0x3b, 0x68, 0xf7, 0xbf, 0x27, 0x6b, 0x7b, 0x69, 0xfd,
-	0x93, 0x14, 0xb5, 0xd6, 0x2f, 0x42, 0xd3, 0x88, 0x43, 0x33, 0x4f, 0x63, 0xf9, 0x80, 0x3c, 0xc6,
-	0xd5, 0x40, 0x0a, 0x9f, 0x37, 0x97, 0x40, 0xb8, 0x9a, 0x0b, 0x13, 0xcc, 0xfa, 0x3f, 0x93, 0xa5,
-	0x29, 0x2c, 0xfd, 0x23, 0xfb, 0xb8, 0x31, 0xf0, 0x7a, 0x3c, 0x90, 0xdc, 0x6f, 0x56, 0x40, 0xb5,
-	0x9e, 0xa9, 0x8e, 0x32, 0xd8, 0xda, 0xc8, 0x84, 0x45, 0x22, 0x2b, 0x46, 0xad, 0x5f, 0x4b, 0xb8,
-	0x9e, 0xad, 0x8b, 0xbc, 0xc3, 0xdb, 0x9d, 0x73, 0xc9, 0x83, 0x63, 0x5f, 0x38, 0x3c, 0x08, 0x78,
-	0xf7, 0x98, 0xfb, 0x27, 0xdc, 0x11, 0x5e, 0x17, 0x36, 0x52, 0xb1, 0x1e, 0xc4, 0xa1, 0x39, 0x2f,
-	0x85, 0xcd, 0x23, 0x92, 0xb2, 0xee, 0xc0, 0xd3, 0x96, 0x5d, 0x9a, 0x94, 0x9d, 0x93, 0xc2, 0xe6,
-	0x11, 0xe4, 0x08, 0x6f, 0x4a, 0x21, 0x6d, 0xd7, 0x2a, 0x4d, 0x0b, 0x3d, 0xa8, 0x58, 0xdb, 0x71,
-	0x68, 0xea, 0x68, 0xa6, 0x03, 0x8b, 0x52, 0xaf, 0x4a, 0x53, 0x35, 0x97, 0x95, 0x52, 0x65, 0x9a,
-	0xe9, 0x40, 0xb2, 0x8b, 0x1b, 0xfc, 0x13, 0x77, 0xde, 0x0e, 0x86, 0xbc, 0x59, 0xdd, 0x41, 0xbb,
-	0xc8, 0x5a, 0x4d, 0x3a, 0x9f, 0x63, 0xac, 0x18, 0xb5, 0xbe, 0x2f, 0xe3, 0x2a, 0x1c, 0x2c, 0x79,
-	0x81, 0xd7, 0xa0, 0xd4, 0x41, 0x7f, 0xec, 0x9d, 0x05, 0x8c, 0x9f, 0x66, 0xed, 0x26, 0x71, 0x68,
-	0x2a, 0x0c, 0x53, 0x62, 0xf2, 0x06, 0x6f, 0x4d, 0x21, 0x87, 0xe2, 0xa3, 0xe7, 0x0a, 0xbb, 0xcb,
-	0xf3, 0xd6, 0xde, 0x8b, 0x43, 0x53, 0x9f, 0xc0, 0xf4, 0x30, 0x79, 0x89, 0x89, 0x53, 0xc2, 0x60,
-	0x2b, 0x15, 0xd8, 0xca, 0xdd, 0x38, 0x34, 0x35, 0x2c, 0xd3, 0x60, 0xc9, 0xa6, 0xfa, 0xdc, 0xee,
-	0x42, 0x7d, 0x68, 0x77, 0xd6, 0x4e, 0xd8, 0x54, 0x99, 0x61, 0x4a, 0x5c, 0xd2, 0x42, 0x7f, 0xa1,
-	0x95, 0xaa, 0x16, 0x18, 0xa6, 0xc4, 0xe4, 0x00, 0xdf, 0xe9, 0x72, 0x47, 0x0c, 0x47, 0x3e, 0x1c,
-	0x48, 0x3a, 0x75, 0x0d, 0xe4, 0x5b, 0x71, 0x68, 0xce, 0x92, 0x6c, 0x16, 0x52, 0x8b, 0xa4, 0x6b,
-	0xa8, 0xeb, 0x8b, 0xa4, 0xcb, 0x98, 0x85, 0xc8, 0x3e, 0x5e, 0x57, 0xd7, 0xd1, 0x80, 0x12, 0x9b,
-	0x71, 0x68, 0xaa, 0x14, 0x53, 0x81, 0x44, 0x0e, 0x27, 0x74, 0x38, 0x1e, 0xb9, 0x03, 0xc7, 0x4e,
-	0xe4, 0x2b, 0x13, 0xb9, 0x42, 0x31, 0x15, 0x68, 0xfd, 0x5e, 0xc6, 0x8d, 0xfc, 0x0b, 0x40, 0x9e,
-	0xe1, 0x55, 0xe0, 0x19, 0xb7, 0x9d, 0x3e, 0x4f, 0xaf, 0x73, 0xd5, 0xda, 0x88, 0x43, 0xb3, 0x84,
-	0xb3, 0x52, 0x94, 0x58, 0x61, 0xca, 0x23, 0xaf, 0x6d, 0x09, 0xda, 0xd4, 0x58, 0x60, 0x85, 0x59,
-	0x96, 0x69, 0xb0, 0x62, 0x76, 0x0b, 0xe2, 0x20, 0xbb, 0xa2, 0x93, 0xd9, 0x33, 0x9c, 0x95, 0xa2,
-	0xe2, 0x56, 0x40, 0x33, 0x4f, 0xb8, 0x27, 0xa7, 0x0d, 0x54, 0x66, 0x98, 0x12, 0x6b, 0xcc, 0x57,
-	0x5d, 0xc0, 0x7c, 0xb5, 0xc5, 0xcc, 0x57, 0xff, 0x17, 0xe6, 0x6b, 0x2c, 0x6e, 0xbe, 0x95, 0xc5,
-	0xcc, 0x87, 0xff, 0xde, 0x7c, 0x56, 0xe7, 0xf2, 0x9a, 0x1a, 0x57, 0xd7, 0xd4, 0xb8, 0xbd, 0xa6,
-	0xe8, 0x73, 0x44, 0xd1, 0xd7, 0x88, 0xa2, 0x8b, 0x88, 0xa2, 0xcb, 0x88, 0xa2, 0x1f, 0x11, 0x45,
-	0x3f, 0x23, 0x6a, 0xdc, 0x46, 0x14, 0x7d, 0xb9, 0xa1, 0xc6, 0xe5, 0x0d, 0x35, 0xae, 0x6e, 0xa8,
-	0xf1, 0xfe, 0xe1, 0xf4, 0x93, 0xeb, 0xdb, 0xa7, 0xb6, 0x67, 0xb7, 0x5d, 0x71, 0x36, 0x68, 0xeb,
-	0xde, 0xec, 0x4e, 0x0d, 0x1e, 0xde, 0xa7, 0x7f, 0x02, 0x00, 0x00, 0xff, 0xff, 0x4f, 0x1f, 0x09,
-	0x6a, 0xd2, 0x07, 0x00, 0x00,
+	// 995 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4d, 0x6f, 0xdb, 0x46,
+	0x13, 0x16, 0x2d, 0x53, 0xb2, 0x37, 0xfe, 0x7a, 0x37, 0x6f, 0x1c, 0x26, 0x05, 0x48, 0x43, 0xa7,
+	0x00, 0x4d, 0x2d, 0xf4, 0xeb, 0xd0, 0x02, 0x01, 0x0a, 0x3a, 0x30, 0x10, 0xa0, 0x45, 0xdd, 0x71,
+	0x7a, 0x29, 0xd0, 0x03, 0x45, 0xad, 0x25, 0xc2, 0xfc, 0x90, 0xc9, 0x65, 0x1b, 0xdf, 0xfa, 0x13,
+	0x7a, 0xef, 0x0f, 0x68, 0xff, 0x42, 0x2f, 0x3d, 0xe7, 0xe8, 0x63, 0x4e, 0x6c, 0x2d, 0x5f, 0x0a,
+	0x9e, 0xf2, 0x13, 0x8a, 0x9d, 0x5d, 0xf1, 0x4b, 0x54, 0x80, 0x42, 0xbd, 0x68, 0x77, 0x9e, 0x99,
+	0x67, 0x76, 0xb8, 0xfb, 0xec, 0x6a, 0xc8, 0xd1, 0xec, 0x72, 0x32, 0xf4, 0xa3, 0xc9, 0x95, 0x1f,
+	0x44, 0x63, 0xe6, 0x0f, 0x13, 0xee, 0xf0, 0x44, 0xfe, 0x1e, 0xcf, 0xe2, 0x88, 0x47, 0x54, 0x47,
+	0xe3, 0xf1, 0x07, 0x13, 0x8f, 0x4f, 0xd3, 0xd1, 0xb1, 0x1b, 0x05, 0xc3, 0x49, 0x34, 0x89, 0x86,
+	0xe8, 0x1d, 0xa5, 0x17, 0x68, 0xa1, 0x81, 0x33, 0xc9, 0x1a, 0xfc, 0xba, 0x41, 0x7a, 0xc0, 0x92,
+	0xd4, 0xe7, 0xf4, 0x33, 0xd2, 0x4f, 0xd2, 0x20, 0x70, 0xe2, 0x6b, 0x43, 0x3b, 0xd2, 0x9e, 0xdc,
+	0xfb, 0x68, 0xef, 0x58, 0xe6, 0x3f, 0x97, 0xa8, 0xbd, 0xff, 0x3a, 0xb3, 0x3a, 0x79, 0x66, 0x2d,
+	0xc2, 0x60, 0x31, 0xa1, 0x1f, 0x12, 0x3d, 0xe1, 0x51, 0xcc, 0x8c, 0x0d, 0x24, 0xee, 0x2c, 0x88,
+	0x02, 0xb3, 0x77, 0x15, 0x4d, 0x86, 0x80, 0x1c, 0xe8, 0x33, 0xb2, 0xe5, 0x85, 0x13, 0x96, 0x70,
+	0x16, 0x1b, 0x5d, 0x64, 0xed, 0x2b, 0xd6, 0x0b, 0x05, 0xdb, 0x07, 0x8a, 0x58, 0x04, 0x42, 0x31,
+	0x13, 0xc5, 0x5e, 0xa5, 0x2c, 0xf6, 0x58, 0x6c, 0x6c, 0xd6, 0x8a, 0xfd, 0x46, 0xa2, 0x65, 0xb1,
+	0x2a, 0x0c, 0x16, 0x13, 0xfa, 0x29, 0xe9, 0xb9, 0x8e, 0x3b, 0x65, 0x89, 0xa1, 0x23, 0x73, 0x57,
+	0x31, 0x4f, 0x10, 0xb4, 0xf7, 0x14, 0x51, 0x05, 0x81, 0x1a, 0x07, 0x7f, 0x6c, 0x92, 0xbe, 0xda,
+	0x09, 0xfa, 0x2d, 0x79, 0x38, 0xba, 0xe6, 0x2c, 0x39, 0x8b, 0x23, 0x97, 0x25, 0x09, 0x1b, 0x9f,
+	0xb1, 0xf8, 0x9c, 0xb9, 0x51, 0x38, 0xc6, 0xad, 0xeb, 0xda, 0xef, 0xe5, 0x99, 0xb5, 0x2a, 0x04,
+	0x56, 0x39, 0x44, 0x5a, 0xdf, 0x0b, 0x5b, 0xd3, 0x6e, 0x94, 0x69, 0x57, 0x84, 0xc0, 0x2a, 0x07,
+	0x7d, 0x41, 0xee, 0xf3, 0x88, 0x3b, 0xbe, 0x5d, 0x5b, 0x16, 0x77, 0xbd, 0x6b, 0x3f, 0xcc, 0x33,
+	0xab, 0xcd, 0x0d, 0x6d, 0x60, 0x91, 0xea, 0xcb, 0xda, 0x52, 0xc6, 0x66, 0x23, 0x55, 0xdd, 0x0d,
+	0x6d, 0x20, 0x7d, 0x42, 0xb6, 0xd8, 0x2b, 0xe6, 0xbe, 0xf4, 0x02, 0x86, 0x07, 0xa1, 0xd9, 0x3b,
+	0xe2, 0xac, 0x17, 0x18, 0x14, 0x33, 0xfa, 0x3e, 0xd9, 0xbe, 0x4a, 0x59, 0xca, 0x30, 0xb4, 0x87,
+	0xa1, 0xbb, 0x79, 0x66, 0x95, 0x20, 0x94, 0x53, 0x3a, 0x20, 0xbd, 0x64, 0xe6, 0x7b, 0x3c, 0x31,
+	0xfa, 0x58, 0x14, 0x11, 0x47, 0x29, 0x11, 0x50, 0x23, 0xc6, 0x4c, 0x9d, 0x78, 0x9c, 0x18, 0x5b,
+	0x95, 0x18, 0x44, 0x40, 0x8d, 0xf4, 0x8c, 0xec, 0x60, 0xf4, 0x4b, 0x2f, 0xf0, 0xc2, 0x49, 0x62,
+	0x6c, 0x1f, 0x75, 0xab, 0xca, 0x16, 0x2e, 0xdb, 0x54, 0x52, 0x39, 0xac, 0x46, 0x3e, 0x8d, 0x02,
+	0x8f, 0xb3, 0x60, 0xc6, 0xaf, 0xa1, 0x96, 0x61, 0x10, 0x10, 0x1d, 0x69, 0xd4, 0x12, 0xb7, 0xc5,
+	0x89, 0xb9, 0xd2, 0xca, 0xb6, 0xbc, 0x1b, 0x4e, 0xcc, 0x41, 0x0e, 0xf4, 0x11, 0xe9, 0xb2, 0xe2,
+	0xcc, 0xfb, 0x79, 0x66, 0x09, 0x13, 0xc4, 0x4f, 0x6d, 0xd7, 0xba, 0xef, 0xda, 0xb5, 0xc1, 0x2f,
+	0x3a, 0xd1, 0xf1, 0x02, 0xd2, 0xcf, 0xc9, 0x1e, 0x1e, 0xc0, 0xc9, 0x34, 0x0d, 0x2f, 0x13, 0x60,
+	0x17, 0x6a, 0x61, 0x9a, 0x67, 0x56, 0xc3, 0x03, 0x0d, 0x9b, 0x7e, 0x4d, 0x1e, 0x54, 0x90, 0xe7,
+	0xd1, 0x8f, 0xa1, 0x1f, 0x39, 0x63, 0xb6, 0x28, 0xee, 0x51, 0x9e, 0x59, 0xed, 0x01, 0xd0, 0x0e,
+	0xd3, 0x53, 0x42, 0xdd, 0x1a, 0x56, 0xf9, 0x94, 0xc3, 0x3c, 0xb3, 0x5a, 0xbc, 0xd0, 0x82, 0x89,
+	0x8f, 0x9a, 0x32, 0x67, 0x8c, 0xf9, 0x51, 0xa4, 0xc6, 0x66, 0xf9, 0x51, 0x75, 0x0f, 0x34, 0xec,
+	0x1a, 0x17, 0x55, 0x69, 0xe8, 0x2d, 0x5c, 0xf4, 0x40, 0xc3, 0xa6, 0x27, 0xe4, 0x7f, 0x63, 0xe6,
+	0x46, 0xc1, 0x2c, 0x46, 0x19, 0xcb, 0xa5, 0x7b, 0x48, 0x7f, 0x90, 0x67, 0xd6, 0xb2, 0x13, 0x96,
+	0xa1, 0x66, 0x12, 0x59, 0x43, 0xbf, 0x3d, 0x89, 0x2c, 0x63, 0x19, 0xa2, 0xcf, 0xc8, 0x7e, 0xb3,
+	0x0e, 0x29, 0xe7, 0xfb, 0x79, 0x66, 0x35, 0x5d, 0xd0, 0x04, 0x04, 0x1d, 0x4f, 0xe8, 0x79, 0x3a,
+	0xf3, 0x3d, 0xd7, 0x11, 0xf4, 0xed, 0x92, 0xde, 0x70, 0x41, 0x13, 0x10, 0xf4, 0x59, 0x94, 0xf0,
+	0x53, 0xcf, 0xe7, 0x2c, 0x96, 0x1f, 0x40, 0x4a, 0x7a, 0xc3, 0x05, 0x4d, 0x60, 0xf0, 0xbb, 0x4e,
+	0xb6, 0x16, 0x0f, 0x3d, 0xfd, 0x84, 0xec, 0x60, 0x7a, 0x60, 0xe2, 0xa9, 0x95, 0x6f, 0xa8, 0x6e,
+	0x1f, 0xe4, 0x99, 0x55, 0xc3, 0xa1, 0x66, 0x09, 0x25, 0x55, 0x24, 0xf6, 0x95, 0xc3, 0xdd, 0x69,
+	0xa1, 0x4b, 0x54, 0xd2, 0xb2, 0x17, 0x5a, 0xb0, 0x62, 0x75, 0x1b, 0xed, 0x44, 0xbd, 0x8b, 0xe5,
+	0xea, 0x0a, 0x87, 0x9a, 0x55, 0x5c, 0x2a, 0xfc, 0x9c, 0x73, 0x16, 0xf2, 0xaa, 0xfe, 0xea, 0x1e,
+	0x68, 0xd8, 0x2d, 0xda, 0xd5, 0xd7, 0xd0, 0x6e, 0x6f, 0x3d, 0xed, 0xf6, 0xff, 0x0b, 0xed, 0x6e,
+	0xad, 0xaf, 0xdd, 0xed, 0xf5, 0xb4, 0x4b, 0xd6, 0xd3, 0xee, 0xbd, 0x7f, 0xa1, 0xdd, 0xef, 0x49,
+	0x5f, 0x75, 0x19, 0x14, 0xc8, 0x61, 0x73, 0x35, 0x16, 0x44, 0x3f, 0xb0, 0x45, 0x1f, 0xf0, 0x58,
+	0xfc, 0x3b, 0xb4, 0x47, 0xc0, 0x0a, 0x7c, 0x10, 0x92, 0x9e, 0x6c, 0x45, 0x44, 0x5b, 0x85, 0x2f,
+	0x9f, 0xea, 0xc7, 0x76, 0xaa, 0x8d, 0x4a, 0xd9, 0x56, 0x61, 0x08, 0xc8, 0x41, 0x50, 0xbc, 0x70,
+	0xcc, 0x5e, 0x19, 0x1b, 0xef, 0xa2, 0x60, 0x08, 0xc8, 0x61, 0xf0, 0xa7, 0x46, 0x74, 0xf4, 0x8b,
+	0x3f, 0x97, 0x98, 0x5d, 0xa5, 0x2c, 0xe1, 0x89, 0xaa, 0x1f, 0xff, 0x5c, 0x16, 0x18, 0x14, 0x33,
+	0xfa, 0x05, 0x39, 0x60, 0x21, 0x8f, 0x3d, 0x51, 0x35, 0x42, 0xc5, 0xcd, 0xfb, 0x7f, 0x9e, 0x59,
+	0x4b, 0x3e, 0x58, 0x42, 0xc4, 0xad, 0x53, 0xd8, 0x69, 0x94, 0x86, 0xe3, 0xea, 0xad, 0xab, 0xe2,
+	0x50, 0xb3, 0x04, 0x0b, 0x9b, 0xa7, 0x53, 0x26, 0x6f, 0xfb, 0x66, 0xc9, 0xaa, 0xe2, 0x50, 0xb3,
+	0xec, 0xd1, 0xcd, 0xad, 0xd9, 0x79, 0x73, 0x6b, 0x76, 0xde, 0xde, 0x9a, 0xda, 0x4f, 0x73, 0x53,
+	0xfb, 0x6d, 0x6e, 0x6a, 0xaf, 0xe7, 0xa6, 0x76, 0x33, 0x37, 0xb5, 0xbf, 0xe6, 0xa6, 0xf6, 0xf7,
+	0xdc, 0xec, 0xbc, 0x9d, 0x9b, 0xda, 0xcf, 0x77, 0x66, 0xe7, 0xe6, 0xce, 0xec, 0xbc, 0xb9, 0x33,
+	0x3b, 0xdf, 0x3d, 0xad, 0x76, 0xd2, 0xb1, 0x73, 0xe1, 0x84, 0xce, 0xd0, 0x8f, 0x2e, 0xbd, 0x61,
+	0x5b, 0x2b, 0x3e, 0xea, 0x61, 0x3f, 0xfd, 0xf1, 0x3f, 0x03, 0x00, 0x5d, 0x7b, 0xf8, 0x4d, 0xa9,
+	0x0b, 0x00, 0x00,
 }
 
 func (this *Result) Equal(that interface{}) bool {
@@ -492,6 +815,12 @@ func (this *Result) Equal(that interface{}) bool {
 	if !this.Ingester.Equal(&that1.Ingester) {
 		return false
 	}
+	if !this.Querier.Equal(&that1.Querier) {
+		return false
+	}
+	if !this.Caches.Equal(&that1.Caches) {
+		return false
+	}
 	return true
 }
 func (this *Summary) Equal(that interface{}) bool {
@@ -528,6 +857,53 @@ func (this *Summary) Equal(that interface{}) bool {
 	if this.ExecTime != that1.ExecTime {
 		return false
 	}
+	if this.QueueTime != that1.QueueTime {
+		return false
+	}
+	if this.Splits != that1.Splits {
+		return false
+	}
+	if this.Shards != that1.Shards {
+		return false
+	}
+	if len(this.SplitTimings) != len(that1.SplitTimings) {
+		return false
+	}
+	for i := range this.SplitTimings {
+		if !this.SplitTimings[i].Equal(&that1.SplitTimings[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *Split) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Split)
+	if !ok {
+		that2, ok := that.(Split)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Start != that1.Start {
+		return false
+	}
+	if this.End != that1.End {
+		return false
+	}
+	if this.ExecTime != that1.ExecTime {
+		return false
+	}
 	return true
 }
 func (this *Store) Equal(that interface{}) bool {
@@ -576,6 +952,9 @@ func (this *Store) Equal(that interface{}) bool {
 	if this.TotalDuplicates != that1.TotalDuplicates {
 		return false
 	}
+	if this.PostFilterLines != that1.PostFilterLines {
+		return false
+	}
 	return true
 }
 func (this *Ingester) Equal(that interface{}) bool {
@@ -627,31 +1006,142 @@ func (this *Ingester) Equal(that interface{}) bool {
 	if this.TotalDuplicates != that1.TotalDuplicates {
 		return false
 	}
+	if this.PostFilterLines != that1.PostFilterLines {
+		return false
+	}
 	return true
 }
-func (this *Result) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *Querier) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&stats.Result{")
-	s = append(s, "Summary: "+strings.Replace(this.Summary.GoString(), `&`, ``, 1)+",\n")
-	s = append(s, "Store: "+strings.Replace(this.Store.GoString(), `&`, ``, 1)+",\n")
-	s = append(s, "Ingester: "+strings.Replace(this.Ingester.GoString(), `&`, ``, 1)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
+
+	that1, ok := that.(*Querier)
+	if !ok {
+		that2, ok := that.(Querier)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TotalDuplicatesRemoved != that1.TotalDuplicatesRemoved {
+		return false
+	}
+	return true
 }
-func (this *Summary) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *Caches) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 9)
-	s = append(s, "&stats.Summary{")
-	s = append(s, "BytesProcessedPerSecond: "+fmt.Sprintf("%#v", this.BytesProcessedPerSecond)+",\n")
+
+	that1, ok := that.(*Caches)
+	if !ok {
+		that2, ok := that.(Caches)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Chunk.Equal(&that1.Chunk) {
+		return false
+	}
+	if !this.Index.Equal(&that1.Index) {
+		return false
+	}
+	return true
+}
+func (this *Cache) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Cache)
+	if !ok {
+		that2, ok := that.(Cache)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Requests != that1.Requests {
+		return false
+	}
+	if this.EntriesRequested != that1.EntriesRequested {
+		return false
+	}
+	if this.EntriesFound != that1.EntriesFound {
+		return false
+	}
+	if this.BytesFetched != that1.BytesFetched {
+		return false
+	}
+	return true
+}
+func (this *Result) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&stats.Result{")
+	s = append(s, "Summary: "+strings.Replace(this.Summary.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Store: "+strings.Replace(this.Store.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Ingester: "+strings.Replace(this.Ingester.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Querier: "+strings.Replace(this.Querier.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Caches: "+strings.Replace(this.Caches.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Summary) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 13)
+	s = append(s, "&stats.Summary{")
+	s = append(s, "BytesProcessedPerSecond: "+fmt.Sprintf("%#v", this.BytesProcessedPerSecond)+",\n")
 	s = append(s, "LinesProcessedPerSecond: "+fmt.Sprintf("%#v", this.LinesProcessedPerSecond)+",\n")
 	s = append(s, "TotalBytesProcessed: "+fmt.Sprintf("%#v", this.TotalBytesProcessed)+",\n")
 	s = append(s, "TotalLinesProcessed: "+fmt.Sprintf("%#v", this.TotalLinesProcessed)+",\n")
 	s = append(s, "ExecTime: "+fmt.Sprintf("%#v", this.ExecTime)+",\n")
+	s = append(s, "QueueTime: "+fmt.Sprintf("%#v", this.QueueTime)+",\n")
+	s = append(s, "Splits: "+fmt.Sprintf("%#v", this.Splits)+",\n")
+	s = append(s, "Shards: "+fmt.Sprintf("%#v", this.Shards)+",\n")
+	if this.SplitTimings != nil {
+		vs := make([]Split, len(this.SplitTimings))
+		for i := range vs {
+			vs[i] = this.SplitTimings[i]
+		}
+		s = append(s, "SplitTimings: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Split) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&stats.Split{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	s = append(s, "ExecTime: "+fmt.Sprintf("%#v", this.ExecTime)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -659,7 +1149,7 @@ func (this *Store) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 14)
 	s = append(s, "&stats.Store{")
 	s = append(s, "TotalChunksRef: "+fmt.Sprintf("%#v", this.TotalChunksRef)+",\n")
 	s = append(s, "TotalChunksDownloaded: "+fmt.Sprintf("%#v", this.TotalChunksDownloaded)+",\n")
@@ -670,6 +1160,7 @@ func (this *Store) GoString() string {
 	s = append(s, "DecompressedLines: "+fmt.Sprintf("%#v", this.DecompressedLines)+",\n")
 	s = append(s, "CompressedBytes: "+fmt.Sprintf("%#v", this.CompressedBytes)+",\n")
 	s = append(s, "TotalDuplicates: "+fmt.Sprintf("%#v", this.TotalDuplicates)+",\n")
+	s = append(s, "PostFilterLines: "+fmt.Sprintf("%#v", this.PostFilterLines)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -677,7 +1168,7 @@ func (this *Ingester) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 14)
+	s := make([]string, 0, 15)
 	s = append(s, "&stats.Ingester{")
 	s = append(s, "TotalReached: "+fmt.Sprintf("%#v", this.TotalReached)+",\n")
 	s = append(s, "TotalChunksMatched: "+fmt.Sprintf("%#v", this.TotalChunksMatched)+",\n")
@@ -689,6 +1180,41 @@ func (this *Ingester) GoString() string {
 	s = append(s, "DecompressedLines: "+fmt.Sprintf("%#v", this.DecompressedLines)+",\n")
 	s = append(s, "CompressedBytes: "+fmt.Sprintf("%#v", this.CompressedBytes)+",\n")
 	s = append(s, "TotalDuplicates: "+fmt.Sprintf("%#v", this.TotalDuplicates)+",\n")
+	s = append(s, "PostFilterLines: "+fmt.Sprintf("%#v", this.PostFilterLines)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Querier) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&stats.Querier{")
+	s = append(s, "TotalDuplicatesRemoved: "+fmt.Sprintf("%#v", this.TotalDuplicatesRemoved)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Caches) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&stats.Caches{")
+	s = append(s, "Chunk: "+strings.Replace(this.Chunk.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Index: "+strings.Replace(this.Index.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Cache) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&stats.Cache{")
+	s = append(s, "Requests: "+fmt.Sprintf("%#v", this.Requests)+",\n")
+	s = append(s, "EntriesRequested: "+fmt.Sprintf("%#v", this.EntriesRequested)+",\n")
+	s = append(s, "EntriesFound: "+fmt.Sprintf("%#v", this.EntriesFound)+",\n")
+	s = append(s, "BytesFetched: "+fmt.Sprintf("%#v", this.BytesFetched)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -720,6 +1246,26 @@ func (m *Result) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	{
+		size, err := m.Caches.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x2a
+	{
+		size, err := m.Querier.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x22
 	{
 		size, err := m.Ingester.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -773,6 +1319,36 @@ func (m *Summary) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.SplitTimings) > 0 {
+		for iNdEx := len(m.SplitTimings) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SplitTimings[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x4a
+		}
+	}
+	if m.Shards != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Shards))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Splits != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Splits))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.QueueTime != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.QueueTime))))
+		i--
+		dAtA[i] = 0x31
+	}
 	if m.ExecTime != 0 {
 		i -= 8
 		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ExecTime))))
@@ -802,6 +1378,45 @@ func (m *Summary) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Split) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Split) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Split) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.ExecTime != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ExecTime))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if m.End != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Start != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Store) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -822,6 +1437,11 @@ func (m *Store) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.PostFilterLines != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.PostFilterLines))
+		i--
+		dAtA[i] = 0x50
+	}
 	if m.TotalDuplicates != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.TotalDuplicates))
 		i--
@@ -891,6 +1511,11 @@ func (m *Ingester) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.PostFilterLines != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.PostFilterLines))
+		i--
+		dAtA[i] = 0x58
+	}
 	if m.TotalDuplicates != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.TotalDuplicates))
 		i--
@@ -944,6 +1569,120 @@ func (m *Ingester) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Querier) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Querier) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Querier) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.TotalDuplicatesRemoved != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.TotalDuplicatesRemoved))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Caches) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Caches) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Caches) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Index.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	{
+		size, err := m.Chunk.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0xa
+	return len(dAtA) - i, nil
+}
+
+func (m *Cache) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Cache) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Cache) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.BytesFetched != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.BytesFetched))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.EntriesFound != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EntriesFound))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.EntriesRequested != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EntriesRequested))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Requests != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Requests))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintStats(dAtA []byte, offset int, v uint64) int {
 	offset -= sovStats(v)
 	base := offset
@@ -967,6 +1706,10 @@ func (m *Result) Size() (n int) {
 	n += 1 + l + sovStats(uint64(l))
 	l = m.Ingester.Size()
 	n += 1 + l + sovStats(uint64(l))
+	l = m.Querier.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Caches.Size()
+	n += 1 + l + sovStats(uint64(l))
 	return n
 }
 
@@ -991,6 +1734,39 @@ func (m *Summary) Size() (n int) {
 	if m.ExecTime != 0 {
 		n += 9
 	}
+	if m.QueueTime != 0 {
+		n += 9
+	}
+	if m.Splits != 0 {
+		n += 1 + sovStats(uint64(m.Splits))
+	}
+	if m.Shards != 0 {
+		n += 1 + sovStats(uint64(m.Shards))
+	}
+	if len(m.SplitTimings) > 0 {
+		for _, e := range m.SplitTimings {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Split) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovStats(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovStats(uint64(m.End))
+	}
+	if m.ExecTime != 0 {
+		n += 9
+	}
 	return n
 }
 
@@ -1027,6 +1803,9 @@ func (m *Store) Size() (n int) {
 	if m.TotalDuplicates != 0 {
 		n += 1 + sovStats(uint64(m.TotalDuplicates))
 	}
+	if m.PostFilterLines != 0 {
+		n += 1 + sovStats(uint64(m.PostFilterLines))
+	}
 	return n
 }
 
@@ -1066,6 +1845,55 @@ func (m *Ingester) Size() (n int) {
 	if m.TotalDuplicates != 0 {
 		n += 1 + sovStats(uint64(m.TotalDuplicates))
 	}
+	if m.PostFilterLines != 0 {
+		n += 1 + sovStats(uint64(m.PostFilterLines))
+	}
+	return n
+}
+
+func (m *Querier) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.TotalDuplicatesRemoved != 0 {
+		n += 1 + sovStats(uint64(m.TotalDuplicatesRemoved))
+	}
+	return n
+}
+
+func (m *Caches) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = m.Chunk.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Index.Size()
+	n += 1 + l + sovStats(uint64(l))
+	return n
+}
+
+func (m *Cache) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Requests != 0 {
+		n += 1 + sovStats(uint64(m.Requests))
+	}
+	if m.EntriesRequested != 0 {
+		n += 1 + sovStats(uint64(m.EntriesRequested))
+	}
+	if m.EntriesFound != 0 {
+		n += 1 + sovStats(uint64(m.EntriesFound))
+	}
+	if m.BytesFetched != 0 {
+		n += 1 + sovStats(uint64(m.BytesFetched))
+	}
 	return n
 }
 
@@ -1083,6 +1911,8 @@ func (this *Result) String() string {
 		`Summary:` + strings.Replace(strings.Replace(this.Summary.String(), "Summary", "Summary", 1), `&`, ``, 1) + `,`,
 		`Store:` + strings.Replace(strings.Replace(this.Store.String(), "Store", "Store", 1), `&`, ``, 1) + `,`,
 		`Ingester:` + strings.Replace(strings.Replace(this.Ingester.String(), "Ingester", "Ingester", 1), `&`, ``, 1) + `,`,
+		`Querier:` + strings.Replace(strings.Replace(this.Querier.String(), "Querier", "Querier", 1), `&`, ``, 1) + `,`,
+		`Caches:` + strings.Replace(strings.Replace(this.Caches.String(), "Caches", "Caches", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1091,12 +1921,33 @@ func (this *Summary) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForSplitTimings := "[]Split{"
+	for _, f := range this.SplitTimings {
+		repeatedStringForSplitTimings += strings.Replace(strings.Replace(f.String(), "Split", "Split", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSplitTimings += "}"
 	s := strings.Join([]string{`&Summary{`,
 		`BytesProcessedPerSecond:` + fmt.Sprintf("%v", this.BytesProcessedPerSecond) + `,`,
 		`LinesProcessedPerSecond:` + fmt.Sprintf("%v", this.LinesProcessedPerSecond) + `,`,
 		`TotalBytesProcessed:` + fmt.Sprintf("%v", this.TotalBytesProcessed) + `,`,
 		`TotalLinesProcessed:` + fmt.Sprintf("%v", this.TotalLinesProcessed) + `,`,
 		`ExecTime:` + fmt.Sprintf("%v", this.ExecTime) + `,`,
+		`QueueTime:` + fmt.Sprintf("%v", this.QueueTime) + `,`,
+		`Splits:` + fmt.Sprintf("%v", this.Splits) + `,`,
+		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
+		`SplitTimings:` + repeatedStringForSplitTimings + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Split) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Split{`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`ExecTime:` + fmt.Sprintf("%v", this.ExecTime) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1115,6 +1966,7 @@ func (this *Store) String() string {
 		`DecompressedLines:` + fmt.Sprintf("%v", this.DecompressedLines) + `,`,
 		`CompressedBytes:` + fmt.Sprintf("%v", this.CompressedBytes) + `,`,
 		`TotalDuplicates:` + fmt.Sprintf("%v", this.TotalDuplicates) + `,`,
+		`PostFilterLines:` + fmt.Sprintf("%v", this.PostFilterLines) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1134,6 +1986,41 @@ func (this *Ingester) String() string {
 		`DecompressedLines:` + fmt.Sprintf("%v", this.DecompressedLines) + `,`,
 		`CompressedBytes:` + fmt.Sprintf("%v", this.CompressedBytes) + `,`,
 		`TotalDuplicates:` + fmt.Sprintf("%v", this.TotalDuplicates) + `,`,
+		`PostFilterLines:` + fmt.Sprintf("%v", this.PostFilterLines) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Querier) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Querier{`,
+		`TotalDuplicatesRemoved:` + fmt.Sprintf("%v", this.TotalDuplicatesRemoved) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Caches) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Caches{`,
+		`Chunk:` + strings.Replace(strings.Replace(this.Chunk.String(), "Cache", "Cache", 1), `&`, ``, 1) + `,`,
+		`Index:` + strings.Replace(strings.Replace(this.Index.String(), "Cache", "Cache", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Cache) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Cache{`,
+		`Requests:` + fmt.Sprintf("%v", this.Requests) + `,`,
+		`EntriesRequested:` + fmt.Sprintf("%v", this.EntriesRequested) + `,`,
+		`EntriesFound:` + fmt.Sprintf("%v", this.EntriesFound) + `,`,
+		`BytesFetched:` + fmt.Sprintf("%v", this.BytesFetched) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1274,6 +2161,72 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Querier", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Querier.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Caches", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Caches.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1414,6 +2367,89 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
 			iNdEx += 8
 			m.ExecTime = float64(math.Float64frombits(v))
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueueTime", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.QueueTime = float64(math.Float64frombits(v))
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Splits", wireType)
+			}
+			m.Splits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Splits |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shards", wireType)
+			}
+			m.Shards = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Shards |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SplitTimings", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SplitTimings = append(m.SplitTimings, Split{})
+			if err := m.SplitTimings[len(m.SplitTimings)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1438,7 +2474,7 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Store) Unmarshal(dAtA []byte) error {
+func (m *Split) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1461,17 +2497,17 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Store: wiretype end group for non-group")
+			return fmt.Errorf("proto: Split: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Store: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Split: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksRef", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			m.TotalChunksRef = 0
+			m.Start = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1481,16 +2517,16 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalChunksRef |= int64(b&0x7F) << shift
+				m.Start |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksDownloaded", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
-			m.TotalChunksDownloaded = 0
+			m.End = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowStats
@@ -1500,14 +2536,14 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TotalChunksDownloaded |= int64(b&0x7F) << shift
+				m.End |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
 			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ChunksDownloadTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ExecTime", wireType)
 			}
 			var v uint64
 			if (iNdEx + 8) > l {
@@ -1515,27 +2551,129 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 			}
 			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
 			iNdEx += 8
-			m.ChunksDownloadTime = float64(math.Float64frombits(v))
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HeadChunkBytes", wireType)
+			m.ExecTime = float64(math.Float64frombits(v))
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			m.HeadChunkBytes = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowStats
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.HeadChunkBytes |= int64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
 			}
-		case 5:
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Store) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Store: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Store: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksRef", wireType)
+			}
+			m.TotalChunksRef = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalChunksRef |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalChunksDownloaded", wireType)
+			}
+			m.TotalChunksDownloaded = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalChunksDownloaded |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksDownloadTime", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ChunksDownloadTime = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeadChunkBytes", wireType)
+			}
+			m.HeadChunkBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HeadChunkBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field HeadChunkLines", wireType)
 			}
@@ -1630,6 +2768,25 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostFilterLines", wireType)
+			}
+			m.PostFilterLines = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PostFilterLines |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1873,6 +3030,345 @@ func (m *Ingester) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostFilterLines", wireType)
+			}
+			m.PostFilterLines = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PostFilterLines |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Querier) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Querier: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Querier: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalDuplicatesRemoved", wireType)
+			}
+			m.TotalDuplicatesRemoved = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalDuplicatesRemoved |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Caches) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Caches: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Caches: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunk", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Chunk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Index.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Cache) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Cache: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Cache: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Requests", wireType)
+			}
+			m.Requests = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Requests |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntriesRequested", wireType)
+			}
+			m.EntriesRequested = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntriesRequested |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntriesFound", wireType)
+			}
+			m.EntriesFound = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntriesFound |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BytesFetched", wireType)
+			}
+			m.BytesFetched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BytesFetched |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -132,7 +132,8 @@ func (q *Querier) SelectLogs(ctx context.Context, params logql.SelectLogParams)
 		iters = append(iters, storeIter)
 	}
 
-	return iter.NewHeapIterator(ctx, iters, params.Direction), nil
+	// Ingesters and the store return replicated entries, remove them while merging.
+	return iter.NewDedupeIterator(ctx, iters, params.Direction), nil
 }
 
 func (q *Querier) SelectSamples(ctx context.Context, params logql.SelectSampleParams) (iter.SampleIterator, error) {
@@ -174,7 +175,9 @@ func (q *Querier) SelectSamples(ctx context.Context, params logql.SelectSamplePa
 
 		iters = append(iters, storeIter)
 	}
-	return iter.NewHeapSampleIterator(ctx, iters), nil
+
+	// Ingesters and the store return replicated samples, remove them while merging.
+	return iter.NewDedupeSampleIterator(ctx, iters), nil
 }
 
 func (q *Querier) buildQueryIntervals(queryStart, queryEnd time.Time) (*interval, *interval) {
@@ -846,6 +846,7 @@ var (
 			"totalBatches": 6,
 			"totalChunksMatched": 7,
 			"totalDuplicates": 8,
+			"postFilterLines": 26,
 			"totalLinesSent": 9,
 			"totalReached": 10
 		},
@@ -858,14 +859,35 @@ var (
 			"chunksDownloadTime": 16,
 			"totalChunksRef": 17,
 			"totalChunksDownloaded": 18,
-			"totalDuplicates": 19
+			"totalDuplicates": 19,
+			"postFilterLines": 27
+		},
+		"querier": {
+			"totalDuplicatesRemoved": 25
+		},
+		"caches": {
+			"chunk": {
+				"requests": 28,
+				"entriesRequested": 29,
+				"entriesFound": 30,
+				"bytesFetched": 31
+			},
+			"index": {
+				"requests": 32,
+				"entriesRequested": 33,
+				"entriesFound": 34,
+				"bytesFetched": 35
+			}
 		},
 		"summary": {
 			"bytesProcessedPerSecond": 20,
 			"execTime": 21,
 			"linesProcessedPerSecond": 22,
 			"totalBytesProcessed": 23,
-			"totalLinesProcessed": 24
+			"totalLinesProcessed": 24,
+			"queueTime": 36,
+			"splits": 37,
+			"shards": 38
 		}
 	},`
 	matrixString = `{
@@ -998,6 +1020,9 @@ var (
 			LinesProcessedPerSecond: 22,
 			TotalBytesProcessed:     23,
 			TotalLinesProcessed:     24,
+			QueueTime:               36,
+			Splits:                  37,
+			Shards:                  38,
 		},
 		Store: stats.Store{
 			CompressedBytes:       11,
@@ -1009,6 +1034,7 @@ var (
 			TotalChunksRef:        17,
 			TotalChunksDownloaded: 18,
 			TotalDuplicates:       19,
+			PostFilterLines:       27,
 		},
 		Ingester: stats.Ingester{
 			CompressedBytes:    1,
@@ -1021,6 +1047,24 @@ var (
 			TotalDuplicates:    8,
 			TotalLinesSent:     9,
 			TotalReached:       10,
+			PostFilterLines:    26,
+		},
+		Querier: stats.Querier{
+			TotalDuplicatesRemoved: 25,
+		},
+		Caches: stats.Caches{
+			Chunk: stats.Cache{
+				Requests:         28,
+				EntriesRequested: 29,
+				EntriesFound:     30,
+				BytesFetched:     31,
+			},
+			Index: stats.Cache{
+				Requests:         32,
+				EntriesRequested: 33,
+				EntriesFound:     34,
+				BytesFetched:     35,
+			},
 		},
 	}
 )
@@ -259,11 +259,23 @@ func (rt limitedRoundTripper) do(ctx context.Context, r queryrange.Request) (que
 		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 	}
 
+	start := time.Now()
 	response, err := rt.next.RoundTrip(request)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = response.Body.Close() }()
 
-	return rt.codec.DecodeResponse(ctx, response, r)
+	resp, err := rt.codec.DecodeResponse(ctx, response, r)
+	if err != nil {
+		return nil, err
+	}
+	// Queriers only report their own execution time, the rest of the round trip
+	// is mostly spent waiting in the queue for a querier to pick the request.
+	if statistics := responseStats(resp); statistics != nil {
+		if queued := time.Since(start).Seconds() - statistics.Summary.ExecTime; queued > 0 {
+			statistics.Summary.QueueTime += queued
+		}
+	}
+	return resp, nil
 }
@@ -18,6 +18,9 @@ var emptyStats = `"stats": {
 		"linesProcessedPerSecond": 0,
 		"totalBytesProcessed": 0,
 		"totalLinesProcessed": 0,
+		"queueTime": 0,
+		"splits": 0,
+		"shards": 0,
 		"execTime": 0.0
 	},
 	"store": {
@@ -29,7 +32,8 @@ var emptyStats = `"stats": {
 		"decompressedBytes": 0,
 		"decompressedLines": 0,
 		"compressedBytes": 0,
-		"totalDuplicates": 0
+		"totalDuplicates": 0,
+		"postFilterLines": 0
 	},
 	"ingester": {
 		"totalReached": 0,
@@ -41,7 +45,25 @@ var emptyStats = `"stats": {
 		"decompressedBytes": 0,
 		"decompressedLines": 0,
 		"compressedBytes": 0,
-		"totalDuplicates": 0
+		"totalDuplicates": 0,
+		"postFilterLines": 0
+	},
+	"querier": {
+		"totalDuplicatesRemoved": 0
+	},
+	"caches": {
+		"chunk": {
+			"requests": 0,
+			"entriesRequested": 0,
+			"entriesFound": 0,
+			"bytesFetched": 0
+		},
+		"index": {
+			"requests": 0,
+			"entriesRequested": 0,
+			"entriesFound": 0,
+			"bytesFetched": 0
+		}
 	}
 }`
 
@@ -137,6 +137,7 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrange.Request) (queryra
 	if err != nil {
 		return nil, err
 	}
+	res.Statistics.Summary.Shards = int64(conf.RowShards)
 
 	value, err := marshal.NewResultValue(res.Data)
 	if err != nil {
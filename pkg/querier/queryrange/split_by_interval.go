@@ -7,6 +7,9 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
 	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/opentracing/opentracing-go"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,11 +17,14 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 )
 
 type lokiResult struct {
 	req queryrange.Request
 	ch  chan *packedResp
+	// split is the execution of req, it is set before its response is sent.
+	split stats.Split
 }
 
 type packedResp struct {
@@ -146,7 +152,16 @@ func (h *splitByInterval) loop(ctx context.Context, ch <-chan *lokiResult, next
 		sp, ctx := opentracing.StartSpanFromContext(ctx, "interval")
 		data.req.LogToSpan(sp)
 
+		start := time.Now()
 		resp, err := next.Do(ctx, data.req)
+		elapsed := time.Since(start)
+		level.Debug(util_log.WithContext(ctx, util_log.Logger)).Log(
+			"msg", "split executed",
+			"start", util.TimeFromMillis(data.req.GetStart()),
+			"end", util.TimeFromMillis(data.req.GetEnd()),
+			"duration", elapsed,
+		)
+		data.split = stats.Split{Start: data.req.GetStart(), End: data.req.GetEnd(), ExecTime: elapsed.Seconds()}
 
 		select {
 		case <-ctx.Done():
@@ -210,7 +225,19 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrange.Request) (queryra
 	if err != nil {
 		return nil, err
 	}
-	return h.merger.MergeResponse(resps...)
+	resp, err := h.merger.MergeResponse(resps...)
+	if err != nil {
+		return nil, err
+	}
+	if statistics := responseStats(resp); statistics != nil {
+		statistics.Summary.Splits = int64(len(resps))
+		// The responses are the ones of the first inputs, the remaining ones are not executed once the limit is reached.
+		statistics.Summary.SplitTimings = make([]stats.Split, 0, len(resps))
+		for _, x := range input[:len(resps)] {
+			statistics.Summary.SplitTimings = append(statistics.Summary.SplitTimings, x.split)
+		}
+	}
+	return resp, nil
 }
 
 func splitByTime(req queryrange.Request, interval time.Duration) []queryrange.Request {
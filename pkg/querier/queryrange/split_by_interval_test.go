@@ -15,6 +15,7 @@ import (
 
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 )
 
 var nilMetrics = NewSplitByMetrics(nil)
@@ -317,10 +318,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.BACKWARD,
-				Limit:     1000,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.BACKWARD,
+				Limit:      1000,
+				Version:    1,
+				Statistics: stats.Result{Summary: stats.Summary{Splits: 4, SplitTimings: []stats.Split{hourSplit(3), hourSplit(2), hourSplit(1), hourSplit(0)}}},
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -349,10 +351,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.FORWARD,
-				Limit:     1000,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.FORWARD,
+				Limit:      1000,
+				Version:    1,
+				Statistics: stats.Result{Summary: stats.Summary{Splits: 4, SplitTimings: []stats.Split{hourSplit(0), hourSplit(1), hourSplit(2), hourSplit(3)}}},
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -381,10 +384,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.FORWARD,
-				Limit:     2,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.FORWARD,
+				Limit:      2,
+				Version:    1,
+				Statistics: stats.Result{Summary: stats.Summary{Splits: 2, SplitTimings: []stats.Split{hourSplit(0), hourSplit(1)}}},
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -411,10 +415,11 @@ func Test_splitByInterval_Do(t *testing.T) {
 				Path:      "/api/prom/query_range",
 			},
 			&LokiResponse{
-				Status:    loghttp.QueryStatusSuccess,
-				Direction: logproto.BACKWARD,
-				Limit:     2,
-				Version:   1,
+				Status:     loghttp.QueryStatusSuccess,
+				Direction:  logproto.BACKWARD,
+				Limit:      2,
+				Version:    1,
+				Statistics: stats.Result{Summary: stats.Summary{Splits: 2, SplitTimings: []stats.Split{hourSplit(3), hourSplit(2)}}},
 				Data: LokiData{
 					ResultType: loghttp.ResultTypeStream,
 					Result: []logproto.Stream{
@@ -435,11 +440,27 @@ func Test_splitByInterval_Do(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			res, err := split.Do(ctx, tt.req)
 			require.NoError(t, err)
+			clearSplitExecTimes(t, res)
 			require.Equal(t, tt.want, res)
 		})
 	}
 }
 
+// hourSplit is the split timing of the sub-query of the hour h, without its execution time.
+func hourSplit(h int64) stats.Split {
+	return stats.Split{Start: h * time.Hour.Milliseconds(), End: (h + 1) * time.Hour.Milliseconds()}
+}
+
+// clearSplitExecTimes checks that the execution time of every split was recorded and clears it, so that responses
+// can be compared.
+func clearSplitExecTimes(t *testing.T, resp queryrange.Response) {
+	splits := responseStats(resp).Summary.SplitTimings
+	for i := range splits {
+		require.Greater(t, splits[i].ExecTime, 0.0)
+		splits[i].ExecTime = 0
+	}
+}
+
 func Test_series_splitByInterval_Do(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "1")
 	next := queryrange.HandlerFunc(func(_ context.Context, r queryrange.Request) (queryrange.Response, error) {
@@ -560,10 +581,11 @@ func Test_ExitEarly(t *testing.T) {
 	}
 
 	expected := &LokiResponse{
-		Status:    loghttp.QueryStatusSuccess,
-		Direction: logproto.FORWARD,
-		Limit:     2,
-		Version:   1,
+		Status:     loghttp.QueryStatusSuccess,
+		Direction:  logproto.FORWARD,
+		Limit:      2,
+		Version:    1,
+		Statistics: stats.Result{Summary: stats.Summary{Splits: 2, SplitTimings: []stats.Split{hourSplit(0), hourSplit(1)}}},
 		Data: LokiData{
 			ResultType: loghttp.ResultTypeStream,
 			Result: []logproto.Stream{
@@ -588,6 +610,7 @@ func Test_ExitEarly(t *testing.T) {
 
 	require.Equal(t, int(req.Limit), callCt)
 	require.NoError(t, err)
+	clearSplitExecTimes(t, res)
 	require.Equal(t, expected, res)
 }
 
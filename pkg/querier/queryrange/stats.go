@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 	"github.com/go-kit/kit/log/level"
 	promql_parser "github.com/prometheus/prometheus/promql/parser"
@@ -27,6 +28,7 @@ const ctxKey ctxKeyType = "stats"
 var (
 	defaultMetricRecorder = metricRecorderFn(func(data *queryData) {
 		logql.RecordMetrics(data.ctx, data.params, data.status, *data.statistics, data.result)
+		logQueryStats(data)
 	})
 	// StatsHTTPMiddleware is an http middleware to record stats for query_range filter.
 	StatsHTTPMiddleware middleware.Interface = statsHTTPMiddleware(defaultMetricRecorder)
@@ -76,6 +78,46 @@ func statsHTTPMiddleware(recorder metricRecorder) middleware.Interface {
 	})
 }
 
+// logQueryStats logs the detailed statistics of a query, the tenant is added by the logger
+// so that the cost of queries can be attributed.
+func logQueryStats(data *queryData) {
+	var (
+		logger = util_log.WithContext(data.ctx, util_log.Logger)
+		s      = data.statistics
+	)
+	level.Info(logger).Log(
+		"msg", "query stats",
+		"query", data.params.Query(),
+		"status", data.status,
+		"length", data.params.End().Sub(data.params.Start()),
+		"duration", secondsToDuration(s.Summary.ExecTime),
+		"queue_time", secondsToDuration(s.Summary.QueueTime),
+		"splits", s.Summary.Splits,
+		"shards", s.Summary.Shards,
+		"total_bytes", s.Summary.TotalBytesProcessed,
+		"total_lines", s.Summary.TotalLinesProcessed,
+		"post_filter_lines", s.Store.PostFilterLines+s.Ingester.PostFilterLines,
+		"compressed_bytes", s.Store.CompressedBytes+s.Ingester.CompressedBytes,
+		"decompressed_bytes", s.Store.DecompressedBytes+s.Ingester.DecompressedBytes,
+		"chunks_ref", s.Store.TotalChunksRef,
+		"chunks_downloaded", s.Store.TotalChunksDownloaded,
+		"chunks_download_time", secondsToDuration(s.Store.ChunksDownloadTime),
+		"ingesters_reached", s.Ingester.TotalReached,
+		"ingester_lines_sent", s.Ingester.TotalLinesSent,
+		"duplicates_removed", s.Querier.TotalDuplicatesRemoved,
+		"chunk_cache_requested", s.Caches.Chunk.EntriesRequested,
+		"chunk_cache_found", s.Caches.Chunk.EntriesFound,
+		"chunk_cache_bytes", s.Caches.Chunk.BytesFetched,
+		"index_cache_requested", s.Caches.Index.EntriesRequested,
+		"index_cache_found", s.Caches.Index.EntriesFound,
+		"index_cache_bytes", s.Caches.Index.BytesFetched,
+	)
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(int64(s * float64(time.Second)))
+}
+
 // StatsCollectorMiddleware compute the stats summary based on the actual duration of the request and inject it in the request context.
 func StatsCollectorMiddleware() queryrange.Middleware {
 	return queryrange.MiddlewareFunc(func(next queryrange.Handler) queryrange.Handler {
@@ -90,13 +132,11 @@ func StatsCollectorMiddleware() queryrange.Middleware {
 			var statistics *stats.Result
 			var res promql_parser.Value
 			if resp != nil {
-				switch r := resp.(type) {
-				case *LokiResponse:
-					statistics = &r.Statistics
+				statistics = responseStats(resp)
+				if r, ok := resp.(*LokiResponse); ok {
 					res = logqlmodel.Streams(r.Data.Result)
-				case *LokiPromResponse:
-					statistics = &r.Statistics
-				default:
+				}
+				if statistics == nil {
 					level.Warn(logger).Log("msg", fmt.Sprintf("cannot compute stats, unexpected type: %T", resp))
 				}
 			}
@@ -121,6 +161,18 @@ func StatsCollectorMiddleware() queryrange.Middleware {
 	})
 }
 
+// responseStats returns the statistics of a response, or nil if the response doesn't have any.
+func responseStats(resp queryrange.Response) *stats.Result {
+	switch r := resp.(type) {
+	case *LokiResponse:
+		return &r.Statistics
+	case *LokiPromResponse:
+		return &r.Statistics
+	default:
+		return nil
+	}
+}
+
 // interceptor implements WriteHeader to intercept status codes. WriteHeader
 // may not be called on success, so initialize statusCode with the status you
 // want to report on success, i.e. http.StatusOK.
@@ -0,0 +1,474 @@
+// Package unittest runs unit tests of Loki rules, the equivalent of `promtool test rules` for LogQL: the rules
+// are evaluated over time with the LogQL engine against input log streams, and the alerts and the recorded
+// samples are compared with the expected ones.
+package unittest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/exemplar"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/weaveworks/common/user"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/ruler"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+const (
+	// The rules are evaluated as this tenant.
+	orgID = "fake"
+
+	defaultInterval = model.Duration(time.Minute)
+)
+
+// The epsilon of the comparison of the recorded samples.
+const epsilon = 1e-6
+
+// RunFiles runs the unit tests of the test files and prints out their results, it returns whether all of them
+// passed.
+func RunFiles(w io.Writer, files ...string) bool {
+	passed := true
+	for _, f := range files {
+		fmt.Fprintln(w, "Unit Testing:", f)
+		if errs := RunFile(f); len(errs) > 0 {
+			passed = false
+			fmt.Fprintln(w, "  FAILED:")
+			for _, err := range errs {
+				fmt.Fprintln(w, indent(err.Error(), "    "))
+			}
+		} else {
+			fmt.Fprintln(w, "  SUCCESS")
+		}
+		fmt.Fprintln(w)
+	}
+	return passed
+}
+
+// RunFile runs the unit tests of a test file, and returns the failures.
+func RunFile(filename string) []error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return []error{err}
+	}
+
+	var f testFile
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil {
+		return []error{errors.Wrap(err, filename)}
+	}
+	if f.EvaluationInterval == 0 {
+		f.EvaluationInterval = defaultInterval
+	}
+
+	ruleFiles, err := resolveRuleFiles(filepath.Dir(filename), f.RuleFiles)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, tg := range f.Tests {
+		errs = append(errs, tg.test(time.Duration(f.EvaluationInterval), ruleFiles)...)
+	}
+	return errs
+}
+
+// resolveRuleFiles expands the globs of the rule files, relative to the directory of the test file.
+func resolveRuleFiles(dir string, patterns []string) ([]string, error) {
+	var files []string
+	for _, p := range patterns {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no rule file matches %s", p)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// testFile is the content of a test file.
+type testFile struct {
+	RuleFiles          []string       `yaml:"rule_files"`
+	EvaluationInterval model.Duration `yaml:"evaluation_interval,omitempty"`
+	Tests              []testGroup    `yaml:"tests"`
+}
+
+// testGroup is a test of the rules against input log streams.
+type testGroup struct {
+	Name               string              `yaml:"name,omitempty"`
+	Interval           model.Duration      `yaml:"interval,omitempty"`
+	InputStreams       []inputStream       `yaml:"input_streams"`
+	AlertRuleTests     []alertTestCase     `yaml:"alert_rule_test,omitempty"`
+	RecordingRuleTests []recordingTestCase `yaml:"recording_rule_test,omitempty"`
+}
+
+// inputStream is a log stream whose number of lines at each interval is given in the series notation of promtool,
+// e.g. `0+2x5` logs 0, 2, 4 up to 10 lines in 6 consecutive intervals. The lines of an interval are evenly spread
+// across it.
+type inputStream struct {
+	Labels string `yaml:"labels"`
+	Line   string `yaml:"line"`
+	Values string `yaml:"values"`
+}
+
+type alertTestCase struct {
+	EvalTime  model.Duration `yaml:"eval_time"`
+	Alertname string         `yaml:"alertname"`
+	ExpAlerts []alert        `yaml:"exp_alerts"`
+}
+
+type alert struct {
+	ExpLabels      map[string]string `yaml:"exp_labels"`
+	ExpAnnotations map[string]string `yaml:"exp_annotations"`
+}
+
+type recordingTestCase struct {
+	EvalTime   model.Duration `yaml:"eval_time"`
+	Record     string         `yaml:"record"`
+	ExpSamples []sample       `yaml:"exp_samples"`
+}
+
+type sample struct {
+	Labels string  `yaml:"labels"`
+	Value  float64 `yaml:"value"`
+}
+
+// test evaluates the rules every evalInterval until the last evaluation time of the test cases, and checks the
+// test cases after the last evaluation before their evaluation time.
+func (tg *testGroup) test(evalInterval time.Duration, ruleFiles []string) []error {
+	if tg.Interval == 0 {
+		tg.Interval = defaultInterval
+	}
+
+	streams, err := tg.streams()
+	if err != nil {
+		return []error{tg.wrap(err)}
+	}
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	overrides, err := validation.NewOverrides(limits, nil)
+	if err != nil {
+		return []error{err}
+	}
+	engine := logql.NewEngine(logql.EngineOpts{}, logql.NewMockQuerier(0, streams), overrides)
+	recorder := newSampleRecorder()
+
+	ctx := user.InjectOrgID(context.Background(), orgID)
+	mgr := rules.NewManager(&rules.ManagerOptions{
+		QueryFunc:   queryFunc(engine),
+		NotifyFunc:  func(context.Context, string, ...*rules.Alert) {},
+		Context:     ctx,
+		Appendable:  recorder,
+		Logger:      log.NewNopLogger(),
+		GroupLoader: ruler.GroupLoader{},
+	})
+	groupsByKey, errs := mgr.LoadGroups(evalInterval, nil, "", ruleFiles...)
+	if len(errs) > 0 {
+		return errs
+	}
+	groups := make([]*rules.Group, 0, len(groupsByKey))
+	for _, g := range groupsByKey {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return rules.GroupKey(groups[i].File(), groups[i].Name()) < rules.GroupKey(groups[j].File(), groups[j].Name())
+	})
+
+	var maxEvalTime time.Duration
+	for _, tc := range tg.AlertRuleTests {
+		if d := time.Duration(tc.EvalTime); d > maxEvalTime {
+			maxEvalTime = d
+		}
+	}
+	for _, tc := range tg.RecordingRuleTests {
+		if d := time.Duration(tc.EvalTime); d > maxEvalTime {
+			maxEvalTime = d
+		}
+	}
+
+	var failures []error
+	failedRules := map[rules.Rule]struct{}{}
+	for ts := time.Duration(0); ts <= maxEvalTime; ts += evalInterval {
+		t := time.Unix(0, 0).UTC().Add(ts)
+		for _, g := range groups {
+			if ts%g.Interval() != 0 {
+				continue
+			}
+			g.Eval(ctx, t)
+			for _, r := range g.Rules() {
+				if _, ok := failedRules[r]; !ok && r.Health() == rules.HealthBad {
+					failedRules[r] = struct{}{}
+					failures = append(failures, tg.wrap(fmt.Errorf("rule %s failed at %s: %v", r.Name(), ts, r.LastError())))
+				}
+			}
+		}
+
+		// The test cases are checked against the last evaluation before their evaluation time.
+		inRange := func(d model.Duration) bool { return time.Duration(d) >= ts && time.Duration(d) < ts+evalInterval }
+		for _, tc := range tg.AlertRuleTests {
+			if inRange(tc.EvalTime) {
+				if err := tc.check(groups); err != nil {
+					failures = append(failures, tg.wrap(err))
+				}
+			}
+		}
+		for _, tc := range tg.RecordingRuleTests {
+			if inRange(tc.EvalTime) {
+				if err := tc.check(recorder); err != nil {
+					failures = append(failures, tg.wrap(err))
+				}
+			}
+		}
+	}
+
+	return failures
+}
+
+func (tg *testGroup) wrap(err error) error {
+	return fmt.Errorf("name: %s,\n%s", tg.Name, indent(err.Error(), "  "))
+}
+
+// streams returns the log streams of the inputs, the inputs with the same labels are merged in the same stream.
+func (tg *testGroup) streams() ([]logproto.Stream, error) {
+	byLabels := map[string]*logproto.Stream{}
+	for _, in := range tg.InputStreams {
+		lbs, err := logql.ParseLabels(in.Labels)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid labels of input stream %s", in.Labels)
+		}
+		_, values, err := parser.ParseSeriesDesc("{} " + in.Values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid values of input stream %s", in.Labels)
+		}
+
+		s, ok := byLabels[lbs.String()]
+		if !ok {
+			s = &logproto.Stream{Labels: lbs.String()}
+			byLabels[lbs.String()] = s
+		}
+		interval := time.Duration(tg.Interval)
+		for i, v := range values {
+			if v.Omitted {
+				continue
+			}
+			n := int(v.Value)
+			if v.Value < 0 || float64(n) != v.Value {
+				return nil, fmt.Errorf("the number of lines of input stream %s must be a non-negative integer, got %v", in.Labels, v.Value)
+			}
+			for j := 0; j < n; j++ {
+				s.Entries = append(s.Entries, logproto.Entry{
+					Timestamp: time.Unix(0, 0).UTC().Add(time.Duration(i)*interval + time.Duration(j)*interval/time.Duration(n)),
+					Line:      in.Line,
+				})
+			}
+		}
+	}
+
+	streams := make([]logproto.Stream, 0, len(byLabels))
+	for _, s := range byLabels {
+		sort.SliceStable(s.Entries, func(i, j int) bool { return s.Entries[i].Timestamp.Before(s.Entries[j].Timestamp) })
+		streams = append(streams, *s)
+	}
+	return streams, nil
+}
+
+// queryFunc evaluates the queries of the rules with the LogQL engine at the evaluation time.
+func queryFunc(engine *logql.Engine) rules.QueryFunc {
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		params := logql.NewLiteralParams(qs, t, t, 0, 0, logproto.FORWARD, 0, nil)
+		res, err := engine.Query(params).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch v := res.Data.(type) {
+		case promql.Vector:
+			return v, nil
+		case promql.Scalar:
+			return promql.Vector{promql.Sample{Point: promql.Point(v), Metric: labels.Labels{}}}, nil
+		default:
+			return nil, errors.New("rule result is not a vector or scalar")
+		}
+	}
+}
+
+// check compares the firing alerts of the alerting rules named after the alert with the expected alerts. The
+// annotations of an alert are only compared when expected annotations are given.
+func (tc *alertTestCase) check(groups []*rules.Group) error {
+	var firing []*rules.Alert
+	for _, g := range groups {
+		for _, r := range g.Rules() {
+			ar, ok := r.(*rules.AlertingRule)
+			if !ok || ar.Name() != tc.Alertname {
+				continue
+			}
+			for _, a := range ar.ActiveAlerts() {
+				if a.State == rules.StateFiring {
+					firing = append(firing, a)
+				}
+			}
+		}
+	}
+
+	got := make([]string, 0, len(firing))
+	for _, a := range firing {
+		got = append(got, formatAlert(a.Labels, a.Annotations))
+	}
+	exp := make([]string, 0, len(tc.ExpAlerts))
+	matched := make([]bool, len(firing))
+	missing := false
+	for _, a := range tc.ExpAlerts {
+		lbs := labels.NewBuilder(labels.FromMap(a.ExpLabels)).Set(labels.AlertName, tc.Alertname).Labels()
+		var annotations labels.Labels
+		if a.ExpAnnotations != nil {
+			annotations = labels.FromMap(a.ExpAnnotations)
+			exp = append(exp, formatAlert(lbs, annotations))
+		} else {
+			exp = append(exp, fmt.Sprintf("Labels:%s", lbs))
+		}
+
+		found := false
+		for i, f := range firing {
+			if matched[i] || !labels.Equal(f.Labels, lbs) || (a.ExpAnnotations != nil && !labels.Equal(f.Annotations, annotations)) {
+				continue
+			}
+			matched[i], found = true, true
+			break
+		}
+		missing = missing || !found
+	}
+
+	if !missing && len(exp) == len(got) {
+		return nil
+	}
+	sort.Strings(got)
+	sort.Strings(exp)
+	return fmt.Errorf("alertname: %s, time: %s,\n  exp: %s,\n  got: %s", tc.Alertname, tc.EvalTime, formatList(exp), formatList(got))
+}
+
+func formatAlert(lbs, annotations labels.Labels) string {
+	return fmt.Sprintf("Labels:%s Annotations:%s", lbs, annotations)
+}
+
+// check compares the last samples recorded by the recording rule with the expected samples.
+func (tc *recordingTestCase) check(recorder *sampleRecorder) error {
+	exp := make(map[string]float64, len(tc.ExpSamples))
+	for _, s := range tc.ExpSamples {
+		lbs := labels.Labels{}
+		if s.Labels != "" {
+			var err error
+			if lbs, err = logql.ParseLabels(s.Labels); err != nil {
+				return errors.Wrapf(err, "record: %s, time: %s, invalid labels of expected sample", tc.Record, tc.EvalTime)
+			}
+		}
+		lbs = labels.NewBuilder(lbs).Set(labels.MetricName, tc.Record).Labels()
+		exp[lbs.String()] = s.Value
+	}
+
+	got := recorder.latest(tc.Record)
+	equal := len(got) == len(exp)
+	for lbs, v := range exp {
+		if actual, ok := got[lbs]; !ok || !almostEqual(actual, v) {
+			equal = false
+		}
+	}
+	if equal {
+		return nil
+	}
+	return fmt.Errorf("record: %s, time: %s,\n  exp: %s,\n  got: %s", tc.Record, tc.EvalTime, formatSamples(exp), formatSamples(got))
+}
+
+func almostEqual(a, b float64) bool {
+	if a == b || (math.IsNaN(a) && math.IsNaN(b)) {
+		return true
+	}
+	return math.Abs(a-b) <= epsilon*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func formatSamples(samples map[string]float64) string {
+	res := make([]string, 0, len(samples))
+	for lbs, v := range samples {
+		res = append(res, fmt.Sprintf("%s %v", lbs, v))
+	}
+	sort.Strings(res)
+	return formatList(res)
+}
+
+func formatList(l []string) string {
+	if len(l) == 0 {
+		return "[]"
+	}
+	return "[\n" + indent(strings.Join(l, "\n"), "    ") + "\n  ]"
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// sampleRecorder is the storage of the recording rules, which keeps the last sample of each series.
+type sampleRecorder struct {
+	samples map[string]recordedSample
+}
+
+type recordedSample struct {
+	lbs labels.Labels
+	v   float64
+}
+
+func newSampleRecorder() *sampleRecorder {
+	return &sampleRecorder{samples: map[string]recordedSample{}}
+}
+
+// latest returns the values of the series of a metric which are not stale.
+func (r *sampleRecorder) latest(name string) map[string]float64 {
+	res := map[string]float64{}
+	for key, s := range r.samples {
+		if s.lbs.Get(labels.MetricName) == name && !value.IsStaleNaN(s.v) {
+			res[key] = s.v
+		}
+	}
+	return res
+}
+
+func (r *sampleRecorder) Appender(context.Context) storage.Appender {
+	return r
+}
+
+func (r *sampleRecorder) Append(_ uint64, lbs labels.Labels, _ int64, v float64) (uint64, error) {
+	r.samples[lbs.String()] = recordedSample{lbs: lbs, v: v}
+	return 0, nil
+}
+
+func (r *sampleRecorder) AppendExemplar(_ uint64, _ labels.Labels, _ exemplar.Exemplar) (uint64, error) {
+	return 0, nil
+}
+
+func (r *sampleRecorder) Commit() error   { return nil }
+func (r *sampleRecorder) Rollback() error { return nil }
@@ -0,0 +1,70 @@
+package unittest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func TestRunFile(t *testing.T) {
+	require.Empty(t, RunFile("testdata/tests.yaml"))
+
+	errs := RunFile("testdata/failing_tests.yaml")
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0].Error(), "alertname: HighErrorRate, time: 5m")
+	require.Contains(t, errs[1].Error(), "record: job:errors:count1m, time: 5m")
+	require.Contains(t, errs[2].Error(), "alertname: HighErrorRate, time: 5m")
+	require.Contains(t, errs[2].Error(), "summary=\"app is logging 10 errors per minute\"")
+}
+
+func TestRunFiles(t *testing.T) {
+	out := &bytes.Buffer{}
+	require.True(t, RunFiles(out, "testdata/tests.yaml"))
+	require.Contains(t, out.String(), "SUCCESS")
+
+	out.Reset()
+	require.False(t, RunFiles(out, "testdata/tests.yaml", "testdata/failing_tests.yaml", "testdata/missing.yaml"))
+	require.Contains(t, out.String(), "FAILED")
+	require.Contains(t, out.String(), "no such file or directory")
+}
+
+func TestTestGroup_Streams(t *testing.T) {
+	tg := testGroup{
+		Interval: model.Duration(time.Minute),
+		InputStreams: []inputStream{
+			{Labels: `{job="app"}`, Line: "a", Values: "2 _ 1"},
+			{Labels: `{job="app"}`, Line: "b", Values: "0 1"},
+			{Labels: `{job="other"}`, Line: "c", Values: "0+1x1"},
+		},
+	}
+	streams, err := tg.streams()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []logproto.Stream{
+		{
+			Labels: `{job="app"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(0, 0).UTC(), Line: "a"},
+				{Timestamp: time.Unix(30, 0).UTC(), Line: "a"},
+				{Timestamp: time.Unix(60, 0).UTC(), Line: "b"},
+				{Timestamp: time.Unix(120, 0).UTC(), Line: "a"},
+			},
+		},
+		{
+			Labels: `{job="other"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(60, 0).UTC(), Line: "c"},
+			},
+		},
+	}, streams)
+
+	for _, values := range []string{"1.5", "-1", "1 foo"} {
+		tg.InputStreams = []inputStream{{Labels: `{job="app"}`, Values: values}}
+		_, err := tg.streams()
+		require.Error(t, err, values)
+	}
+}
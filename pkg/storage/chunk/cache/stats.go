@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+type statsCollector struct {
+	Cache
+	cacheType stats.CacheType
+}
+
+// CollectStats wraps a Cache and records fetches in the statistics of the query they are made for.
+func CollectStats(cache Cache, cacheType stats.CacheType) Cache {
+	return &statsCollector{
+		Cache:     cache,
+		cacheType: cacheType,
+	}
+}
+
+func (s *statsCollector) Fetch(ctx context.Context, keys []string) (found []string, bufs [][]byte, missing []string) {
+	found, bufs, missing = s.Cache.Fetch(ctx, keys)
+
+	var bytes int
+	for _, buf := range bufs {
+		bytes += len(buf)
+	}
+	st := stats.GetCacheData(ctx, s.cacheType)
+	atomic.AddInt64(&st.Requests, 1)
+	atomic.AddInt64(&st.EntriesRequested, int64(len(keys)))
+	atomic.AddInt64(&st.EntriesFound, int64(len(found)))
+	atomic.AddInt64(&st.BytesFetched, int64(bytes))
+	return
+}
@@ -0,0 +1,41 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+func TestCollectStats(t *testing.T) {
+	ctx := stats.NewContext(context.Background())
+	c := cache.CollectStats(cache.NewMockCache(), stats.ChunkCache)
+
+	c.Store(ctx, []string{"key1", "key2"}, [][]byte{[]byte("hello"), []byte("world!")})
+
+	found, bufs, missing := c.Fetch(ctx, []string{"key1", "key2", "key3"})
+	require.Equal(t, []string{"key1", "key2"}, found)
+	require.Equal(t, [][]byte{[]byte("hello"), []byte("world!")}, bufs)
+	require.Equal(t, []string{"key3"}, missing)
+
+	_, _, _ = c.Fetch(ctx, []string{"key4"})
+
+	require.Equal(t, &stats.CacheData{
+		Requests:         2,
+		EntriesRequested: 4,
+		EntriesFound:     2,
+		BytesFetched:     11,
+	}, stats.GetCacheData(ctx, stats.ChunkCache))
+	require.Equal(t, &stats.CacheData{}, stats.GetCacheData(ctx, stats.IndexCache))
+
+	res := stats.Snapshot(ctx, 0)
+	require.Equal(t, stats.Cache{
+		Requests:         2,
+		EntriesRequested: 4,
+		EntriesFound:     2,
+		BytesFetched:     11,
+	}, res.Caches.Chunk)
+}
@@ -5,10 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/runutil"
@@ -19,9 +22,19 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk/util"
 )
 
+const (
+	// maxShardLevels is the maximum number of nested shard directories, each level has up to 256 directories.
+	maxShardLevels = 4
+	// shardDirPrefix is the prefix of shard directories, it is not a valid first character of base64 encoded chunk keys.
+	shardDirPrefix = ".shard-"
+	// tmpFilePrefix is the prefix of files being written, they are renamed to their final name once complete.
+	tmpFilePrefix = ".tmp-"
+)
+
 // FSConfig is the config for a FSObjectClient.
 type FSConfig struct {
-	Directory string `yaml:"directory"`
+	Directory   string `yaml:"directory"`
+	ShardLevels int    `yaml:"shard_levels"`
 }
 
 // RegisterFlags registers flags.
@@ -32,6 +45,15 @@ func (cfg *FSConfig) RegisterFlags(f *flag.FlagSet) {
 // RegisterFlags registers flags with prefix.
 func (cfg *FSConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.StringVar(&cfg.Directory, prefix+"local.chunk-directory", "", "Directory to store chunks in.")
+	f.IntVar(&cfg.ShardLevels, prefix+"local.shard-levels", 0, "Number of nested directory levels objects are sharded into, based on a hash of their name, each level has up to 256 directories. 0 disables sharding. Objects stored with a different number of levels are still readable, use the fs-reshard tool to move them.")
+}
+
+// Validate validates the config.
+func (cfg *FSConfig) Validate() error {
+	if cfg.ShardLevels < 0 || cfg.ShardLevels > maxShardLevels {
+		return fmt.Errorf("invalid filesystem shard levels %d, must be between 0 and %d", cfg.ShardLevels, maxShardLevels)
+	}
+	return nil
 }
 
 // FSObjectClient holds config for filesystem as object store
@@ -46,6 +68,9 @@ func NewFSObjectClient(cfg FSConfig) (*FSObjectClient, error) {
 	// This is needed because DeleteObject works on paths which are already cleaned up and it
 	// checks whether it is about to delete the configured directory when it becomes empty
 	cfg.Directory = filepath.Clean(cfg.Directory)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	if err := util.EnsureDirectory(cfg.Directory); err != nil {
 		return nil, err
 	}
@@ -59,9 +84,77 @@ func NewFSObjectClient(cfg FSConfig) (*FSObjectClient, error) {
 // Stop implements ObjectClient
 func (FSObjectClient) Stop() {}
 
+// objectPath returns the path of the object in the filesystem.
+// With sharding enabled, objects are stored in nested shard directories between the directory
+// of the key and its name, e.g. `index/table/file` is stored in `index/table/.shard-1a/.shard-f0/file`.
+func (f *FSObjectClient) objectPath(objectKey string) string {
+	return shardedPath(f.cfg.Directory, objectKey, f.cfg.ShardLevels)
+}
+
+func shardedPath(directory, objectKey string, levels int) string {
+	dir, name := filepath.Split(filepath.FromSlash(objectKey))
+	if levels <= 0 {
+		return filepath.Join(directory, dir, name)
+	}
+	parts := make([]string, 0, levels+3)
+	parts = append(parts, directory, dir)
+	h := xxhash.Sum64String(name)
+	for i := 0; i < levels; i++ {
+		parts = append(parts, fmt.Sprintf("%s%02x", shardDirPrefix, byte(h>>(8*i))))
+	}
+	parts = append(parts, name)
+	return filepath.Join(parts...)
+}
+
+// objectKey returns the key of the object stored at the given path relative to the directory,
+// regardless of the number of shard levels it was written with.
+func objectKey(relPath string) string {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	key := parts[:0]
+	for _, p := range parts {
+		if !isShardDir(p) {
+			key = append(key, p)
+		}
+	}
+	return strings.Join(key, "/")
+}
+
+func isShardDir(name string) bool {
+	return strings.HasPrefix(name, shardDirPrefix)
+}
+
+// resolve returns the path of an existing object. Objects which are not found at their path are looked up at the
+// paths they have with the other numbers of shard levels, so that they stay readable until they are resharded.
+func (f *FSObjectClient) resolve(objectKey string) (string, error) {
+	path := f.objectPath(objectKey)
+	_, err := os.Stat(path)
+	if err == nil || !os.IsNotExist(err) {
+		return path, err
+	}
+	for levels := 0; levels <= maxShardLevels; levels++ {
+		if levels == f.cfg.ShardLevels {
+			continue
+		}
+		if other := shardedPath(f.cfg.Directory, objectKey, levels); fileExists(other) {
+			return other, nil
+		}
+	}
+	// report the path of the object in the error.
+	return path, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 // GetObject from the store
 func (f *FSObjectClient) GetObject(_ context.Context, objectKey string) (io.ReadCloser, error) {
-	fl, err := os.Open(filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey)))
+	path, err := f.resolve(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	fl, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -69,36 +162,71 @@ func (f *FSObjectClient) GetObject(_ context.Context, objectKey string) (io.Read
 	return fl, nil
 }
 
-// PutObject into the store
+// PutObject into the store.
+// The object is written to a temporary file which is renamed once complete, so readers
+// never see partially written objects.
 func (f *FSObjectClient) PutObject(_ context.Context, objectKey string, object io.ReadSeeker) error {
-	fullPath := filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey))
-	err := util.EnsureDirectory(filepath.Dir(fullPath))
+	fullPath := f.objectPath(objectKey)
+	dir := filepath.Dir(fullPath)
+	err := util.EnsureDirectory(dir)
 	if err != nil {
 		return err
 	}
 
-	fl, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	fl, err := ioutil.TempFile(dir, tmpFilePrefix+"*")
 	if err != nil {
 		return err
 	}
+	tmpPath := fl.Name()
 
-	defer runutil.CloseWithLogOnErr(util_log.Logger, fl, "fullPath: %s", fullPath)
+	if err := writeAndSync(fl, object); err != nil {
+		if rmErr := os.Remove(tmpPath); rmErr != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to remove temporary file", "path", tmpPath, "err", rmErr)
+		}
+		return err
+	}
 
-	_, err = io.Copy(fl, object)
-	if err != nil {
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		if rmErr := os.Remove(tmpPath); rmErr != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to remove temporary file", "path", tmpPath, "err", rmErr)
+		}
 		return err
 	}
 
-	err = fl.Sync()
-	if err != nil {
+	return syncDir(dir)
+}
+
+func writeAndSync(fl *os.File, object io.Reader) error {
+	defer runutil.CloseWithLogOnErr(util_log.Logger, fl, "fullPath: %s", fl.Name())
+
+	if _, err := io.Copy(fl, object); err != nil {
+		return err
+	}
+
+	if err := fl.Sync(); err != nil {
 		return err
 	}
 
 	return fl.Close()
 }
 
+// syncDir makes the rename of a file in the directory durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithLogOnErr(util_log.Logger, d, "dir: %s", dir)
+	// Syncing directories is not supported on all platforms, e.g. Windows denies it.
+	if err := d.Sync(); err != nil && !os.IsPermission(err) {
+		return errors.Wrapf(err, "syncing directory %s", dir)
+	}
+	return nil
+}
+
 // List implements chunk.ObjectClient.
 // FSObjectClient assumes that prefix is a directory, and only supports "" and "/" delimiters.
+// Shard directories are transparent, objects are listed with their key regardless of how they are sharded.
 func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
 	if delimiter != "" && delimiter != "/" {
 		return nil, nil, fmt.Errorf("unsupported delimiter: %q", delimiter)
@@ -118,61 +246,103 @@ func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]
 		return []chunk.StorageObject{{Key: info.Name(), ModifiedAt: info.ModTime()}}, nil, nil
 	}
 
-	var storageObjects []chunk.StorageObject
-	var commonPrefixes []chunk.StorageCommonPrefix
+	l := lister{
+		root:      f.cfg.Directory,
+		recursive: delimiter == "",
+		delimiter: delimiter,
+	}
+	if err := l.list(ctx, folderPath); err != nil {
+		return nil, nil, err
+	}
+	return l.objects, l.commonPrefixes, nil
+}
 
-	err = filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// lister lists a directory without sorting entries, only descending into shard directories
+// and, when listing recursively, into all directories.
+type lister struct {
+	root      string
+	recursive bool
+	delimiter string
 
-		// Ignore starting folder itself.
-		if path == folderPath {
-			return nil
-		}
+	objects        []chunk.StorageObject
+	commonPrefixes []chunk.StorageCommonPrefix
+}
 
-		relPath, err := filepath.Rel(f.cfg.Directory, path)
-		if err != nil {
-			return err
-		}
+func (l *lister) list(ctx context.Context, dir string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	entries, err := d.Readdir(-1)
+	runutil.CloseWithLogOnErr(util_log.Logger, d, "dir: %s", dir)
+	if err != nil {
+		return err
+	}
 
-		relPath = filepath.ToSlash(relPath)
+	for _, info := range entries {
+		name := info.Name()
+		path := filepath.Join(dir, name)
 
 		if info.IsDir() {
-			if delimiter == "" {
-				// Go into directory
-				return nil
+			if l.recursive || isShardDir(name) {
+				if err := l.list(ctx, path); err != nil {
+					return err
+				}
+				continue
 			}
 
 			empty, err := isDirEmpty(path)
 			if err != nil {
 				return err
 			}
-
 			if !empty {
-				commonPrefixes = append(commonPrefixes, chunk.StorageCommonPrefix(relPath+delimiter))
+				relPath, err := filepath.Rel(l.root, path)
+				if err != nil {
+					return err
+				}
+				l.commonPrefixes = append(l.commonPrefixes, chunk.StorageCommonPrefix(objectKey(relPath)+l.delimiter))
 			}
-			return filepath.SkipDir
+			continue
 		}
 
-		storageObjects = append(storageObjects, chunk.StorageObject{Key: relPath, ModifiedAt: info.ModTime()})
-		return nil
-	})
+		if strings.HasPrefix(name, tmpFilePrefix) {
+			continue
+		}
 
-	return storageObjects, commonPrefixes, err
+		relPath, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		l.objects = append(l.objects, chunk.StorageObject{Key: objectKey(relPath), ModifiedAt: info.ModTime()})
+	}
+	return nil
 }
 
 func (f *FSObjectClient) DeleteObject(ctx context.Context, objectKey string) error {
 	// inspired from https://github.com/thanos-io/thanos/blob/55cb8ca38b3539381dc6a781e637df15c694e50a/pkg/objstore/filesystem/filesystem.go#L195
-	file := filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey))
+	file, err := f.resolve(objectKey)
+	if err != nil {
+		return err
+	}
 
-	for file != f.cfg.Directory {
-		if err := os.Remove(file); err != nil {
-			return err
-		}
+	return f.removeWithEmptyParents(file)
+}
 
-		file = filepath.Dir(file)
-		empty, err := isDirEmpty(file)
+// removeWithEmptyParents removes the file and its parent directories which are left empty.
+func (f *FSObjectClient) removeWithEmptyParents(file string) error {
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	return f.removeEmptyDirs(filepath.Dir(file))
+}
+
+// removeEmptyDirs removes the directory and its parents as long as they are empty.
+func (f *FSObjectClient) removeEmptyDirs(dir string) error {
+	for dir != f.cfg.Directory {
+		empty, err := isDirEmpty(dir)
 		if err != nil {
 			return err
 		}
@@ -180,6 +350,11 @@ func (f *FSObjectClient) DeleteObject(ctx context.Context, objectKey string) err
 		if !empty {
 			break
 		}
+
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+		dir = filepath.Dir(dir)
 	}
 
 	return nil
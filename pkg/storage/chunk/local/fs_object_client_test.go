@@ -3,6 +3,7 @@ package local
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
@@ -11,8 +12,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/log"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/util"
 )
 
@@ -215,3 +218,166 @@ func TestFSObjectClient_DeleteObject(t *testing.T) {
 	require.Len(t, commonPrefixes, 0)
 	require.Len(t, files, len(foldersWithFiles["folder2/"]))*/
 }
+
+func TestFSObjectClient_ReadsOtherShardLevels(t *testing.T) {
+	fsObjectsDir, err := ioutil.TempDir(os.TempDir(), "fs-shard-levels")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(fsObjectsDir))
+	}()
+
+	writer, err := NewFSObjectClient(FSConfig{Directory: fsObjectsDir, ShardLevels: 3})
+	require.NoError(t, err)
+	require.NoError(t, writer.PutObject(context.Background(), "folder/file", bytes.NewReader([]byte("content"))))
+
+	for levels := 0; levels <= maxShardLevels; levels++ {
+		reader, err := NewFSObjectClient(FSConfig{Directory: fsObjectsDir, ShardLevels: levels})
+		require.NoError(t, err)
+		r, err := reader.GetObject(context.Background(), "folder/file")
+		require.NoError(t, err, "shard levels %d", levels)
+		b, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "content", string(b))
+	}
+
+	reader, err := NewFSObjectClient(FSConfig{Directory: fsObjectsDir, ShardLevels: 1})
+	require.NoError(t, err)
+	require.NoError(t, reader.DeleteObject(context.Background(), "folder/file"))
+	_, err = reader.GetObject(context.Background(), "folder/file")
+	require.True(t, reader.IsObjectNotFoundErr(err))
+	empty, err := isDirEmpty(fsObjectsDir)
+	require.NoError(t, err)
+	require.True(t, empty)
+}
+
+func TestFSObjectClient_Sharding(t *testing.T) {
+	fsObjectsDir, err := ioutil.TempDir(os.TempDir(), "fs-sharding")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(fsObjectsDir))
+	}()
+
+	_, err = NewFSObjectClient(FSConfig{Directory: fsObjectsDir, ShardLevels: maxShardLevels + 1})
+	require.Error(t, err)
+
+	// Objects written before sharding was enabled.
+	legacyClient, err := NewFSObjectClient(FSConfig{Directory: fsObjectsDir})
+	require.NoError(t, err)
+	legacyFiles := []string{"legacy1", "folder/legacy2"}
+	for _, f := range legacyFiles {
+		require.NoError(t, legacyClient.PutObject(context.Background(), f, bytes.NewReader([]byte(f))))
+	}
+
+	bucketClient, err := NewFSObjectClient(FSConfig{Directory: fsObjectsDir, ShardLevels: 2})
+	require.NoError(t, err)
+	shardedFiles := []string{"file1", "file2", "folder/file3", "folder/nested/file4"}
+	for _, f := range shardedFiles {
+		require.NoError(t, bucketClient.PutObject(context.Background(), f, bytes.NewReader([]byte(f))))
+
+		path := filepath.Join(fsObjectsDir, filepath.FromSlash(f))
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err), "object %s should not be stored unsharded", f)
+		_, err = os.Stat(bucketClient.objectPath(f))
+		require.NoError(t, err)
+		require.Equal(t, 2, strings.Count(bucketClient.objectPath(f), shardDirPrefix))
+	}
+
+	// Both sharded and legacy objects are readable.
+	for _, f := range append(shardedFiles, legacyFiles...) {
+		r, err := bucketClient.GetObject(context.Background(), f)
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, f, string(b))
+	}
+	_, err = bucketClient.GetObject(context.Background(), "doesnt_exist")
+	require.True(t, bucketClient.IsObjectNotFoundErr(err))
+
+	// Shard directories are not visible when listing.
+	objects, prefixes, err := bucketClient.List(context.Background(), "", "/")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"file1", "file2", "legacy1"}, objectKeys(objects))
+	require.Equal(t, []chunk.StorageCommonPrefix{"folder/"}, prefixes)
+
+	objects, prefixes, err = bucketClient.List(context.Background(), "folder", "/")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"folder/file3", "folder/legacy2"}, objectKeys(objects))
+	require.Equal(t, []chunk.StorageCommonPrefix{"folder/nested/"}, prefixes)
+
+	objects, prefixes, err = bucketClient.List(context.Background(), "", "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, append(shardedFiles, legacyFiles...), objectKeys(objects))
+	require.Empty(t, prefixes)
+
+	// Reshard moves legacy objects, the layout is then the same as if all objects were written sharded.
+	moved, err := bucketClient.Reshard(context.Background(), log.NewNopLogger(), true)
+	require.NoError(t, err)
+	require.Equal(t, len(legacyFiles), moved)
+	moved, err = bucketClient.Reshard(context.Background(), log.NewNopLogger(), false)
+	require.NoError(t, err)
+	require.Equal(t, len(legacyFiles), moved)
+	for _, f := range legacyFiles {
+		_, err := os.Stat(bucketClient.objectPath(f))
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(fsObjectsDir, filepath.FromSlash(f)))
+		require.True(t, os.IsNotExist(err))
+	}
+	moved, err = bucketClient.Reshard(context.Background(), log.NewNopLogger(), false)
+	require.NoError(t, err)
+	require.Equal(t, 0, moved)
+
+	// Deleting objects removes the shard directories left empty.
+	for _, f := range append(shardedFiles, legacyFiles...) {
+		require.NoError(t, bucketClient.DeleteObject(context.Background(), f))
+	}
+	files, err := ioutil.ReadDir(fsObjectsDir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestFSObjectClient_PutObjectAtomic(t *testing.T) {
+	fsObjectsDir, err := ioutil.TempDir(os.TempDir(), "fs-atomic")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(fsObjectsDir))
+	}()
+
+	bucketClient, err := NewFSObjectClient(FSConfig{Directory: fsObjectsDir})
+	require.NoError(t, err)
+
+	require.NoError(t, bucketClient.PutObject(context.Background(), "file", bytes.NewReader([]byte("first"))))
+
+	// A failing write leaves the previous content in place and no temporary file behind.
+	require.Error(t, bucketClient.PutObject(context.Background(), "file", failingReader{}))
+	r, err := bucketClient.GetObject(context.Background(), "file")
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "first", string(b))
+
+	files, err := ioutil.ReadDir(fsObjectsDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// Temporary files of in-flight writes are not listed.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(fsObjectsDir, tmpFilePrefix+"123"), nil, 0644))
+	objects, _, err := bucketClient.List(context.Background(), "", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"file"}, objectKeys(objects))
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error)       { return 0, errors.New("read failed") }
+func (failingReader) Seek(int64, int) (int64, error) { return 0, nil }
+
+func objectKeys(objects []chunk.StorageObject) []string {
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	return keys
+}
@@ -0,0 +1,78 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/grafana/loki/pkg/storage/chunk/util"
+)
+
+// Reshard moves every object of the directory to the path matching the configured number of shard levels,
+// e.g. to shard a directory written before sharding was enabled. It returns the number of objects moved.
+// It is meant to be run once while nothing else writes to the directory.
+func (f *FSObjectClient) Reshard(ctx context.Context, logger log.Logger, dryRun bool) (int, error) {
+	type move struct {
+		from, to string
+	}
+	var moves []move
+
+	err := filepath.Walk(f.cfg.Directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), tmpFilePrefix) {
+			return nil
+		}
+		relPath, err := filepath.Rel(f.cfg.Directory, path)
+		if err != nil {
+			return err
+		}
+		if target := f.objectPath(objectKey(relPath)); target != path {
+			moves = append(moves, move{from: path, to: target})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	level.Info(logger).Log("msg", "objects to move", "count", len(moves), "shard_levels", f.cfg.ShardLevels, "dry_run", dryRun)
+	if dryRun {
+		return len(moves), nil
+	}
+
+	for i, m := range moves {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if err := util.EnsureDirectory(filepath.Dir(m.to)); err != nil {
+			return i, err
+		}
+		if _, err := os.Stat(m.to); err == nil {
+			// The object has been written again since sharding changed, the copy at the new path is the latest one.
+			level.Warn(logger).Log("msg", "object already exists at its new path, removing the old copy", "path", m.from)
+			if err := f.removeWithEmptyParents(m.from); err != nil {
+				return i, err
+			}
+			continue
+		}
+		if err := os.Rename(m.from, m.to); err != nil {
+			return i, err
+		}
+		if err := f.removeEmptyDirs(filepath.Dir(m.from)); err != nil {
+			return i, err
+		}
+		if (i+1)%10000 == 0 {
+			level.Info(logger).Log("msg", "moving objects", "moved", i+1, "total", len(moves))
+		}
+	}
+	return len(moves), nil
+}
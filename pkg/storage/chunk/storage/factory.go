@@ -14,6 +14,7 @@ import (
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/aws"
 	"github.com/grafana/loki/pkg/storage/chunk/azure"
@@ -71,6 +72,17 @@ func RegisterIndexStore(name string, indexClientFactory IndexClientFactoryFunc,
 	customIndexStores[name] = indexStoreFactories{indexClientFactory, tableClientFactory}
 }
 
+// ChunkClientFactoryFunc defines signature of function which creates chunk.Client for managing chunks in object store
+type ChunkClientFactoryFunc func() (chunk.Client, error)
+
+var customChunkClients = map[string]ChunkClientFactoryFunc{}
+
+// RegisterChunkClient is used for registering a custom object store type for chunks.
+// When an object store type is registered here with same name as existing types, the registered one takes the precedence.
+func RegisterChunkClient(name string, chunkClientFactory ChunkClientFactoryFunc) {
+	customChunkClients[name] = chunkClientFactory
+}
+
 // StoreLimits helps get Limits specific to Queries for Stores
 type StoreLimits interface {
 	CardinalityLimit(userID string) int
@@ -142,6 +154,9 @@ func (cfg *Config) Validate() error {
 	if err := cfg.AWSStorageConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid AWS Storage config")
 	}
+	if err := cfg.FSConfig.Validate(); err != nil {
+		return errors.Wrap(err, "invalid Filesystem Storage config")
+	}
 	return nil
 }
 
@@ -180,6 +195,10 @@ func NewStore(
 	chunksCache = cache.StopOnce(chunksCache)
 	writeDedupeCache = cache.StopOnce(writeDedupeCache)
 
+	// Record cache usage in the statistics of queries.
+	indexReadCache = cache.CollectStats(indexReadCache, stats.IndexCache)
+	chunksCache = cache.CollectStats(chunksCache, stats.ChunkCache)
+
 	// Lets wrap all caches except chunksCache with CacheGenMiddleware to facilitate cache invalidation using cache generation numbers.
 	// chunksCache is not wrapped because chunks content can't be anyways modified without changing its ID so there is no use of
 	// invalidating chunks cache. Also chunks can be fetched only by their ID found in index and we are anyways removing the index and invalidating index cache here.
@@ -267,6 +286,10 @@ func NewIndexClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, regis
 
 // NewChunkClient makes a new chunk.Client of the desired types.
 func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, registerer prometheus.Registerer) (chunk.Client, error) {
+	if chunkClientFactory, ok := customChunkClients[name]; ok {
+		return chunkClientFactory()
+	}
+
 	switch name {
 	case StorageTypeInMemory:
 		return chunk.NewMockStorage(), nil
@@ -53,6 +53,7 @@ var queryTests = []struct {
 					"totalBatches": 0,
 					"totalChunksMatched": 0,
 					"totalDuplicates": 0,
+					"postFilterLines": 0,
 					"totalLinesSent": 0,
 					"totalReached": 0
 				},
@@ -65,14 +66,35 @@ var queryTests = []struct {
 					"chunksDownloadTime": 0,
 					"totalChunksRef": 0,
 					"totalChunksDownloaded": 0,
-					"totalDuplicates": 0
+					"totalDuplicates": 0,
+					"postFilterLines": 0
+				},
+				"querier": {
+					"totalDuplicatesRemoved": 0
+				},
+				"caches": {
+					"chunk": {
+						"requests": 0,
+						"entriesRequested": 0,
+						"entriesFound": 0,
+						"bytesFetched": 0
+					},
+					"index": {
+						"requests": 0,
+						"entriesRequested": 0,
+						"entriesFound": 0,
+						"bytesFetched": 0
+					}
 				},
 				"summary": {
 					"bytesProcessedPerSecond": 0,
 					"execTime": 0,
 					"linesProcessedPerSecond": 0,
 					"totalBytesProcessed":0,
-					"totalLinesProcessed":0
+					"totalLinesProcessed":0,
+					"queueTime":0,
+					"splits":0,
+					"shards":0
 				}
 			}
 		}`,
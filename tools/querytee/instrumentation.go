@@ -1,3 +1,6 @@
+// Forked from github.com/cortexproject/cortex/tools/querytee at commit a4bf10354786, licensed under
+// the Apache License 2.0, see the LICENSE file in this directory.
+
 package querytee
 
 import (
@@ -0,0 +1,109 @@
+package querytee
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// MismatchReport holds a request whose responses didn't match, along with both responses, so that the
+// mismatch can be investigated after the fact.
+type MismatchReport struct {
+	Time      time.Time      `json:"time"`
+	RouteName string         `json:"route_name"`
+	Method    string         `json:"method"`
+	Path      string         `json:"path"`
+	Query     string         `json:"query"`
+	OrgID     string         `json:"org_id,omitempty"`
+	Error     string         `json:"error"`
+	Expected  ResponseReport `json:"expected"`
+	Actual    ResponseReport `json:"actual"`
+}
+
+// ResponseReport is the response of a backend in a MismatchReport.
+type ResponseReport struct {
+	Backend string          `json:"backend"`
+	Status  int             `json:"status"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// MismatchReporter writes a MismatchReport to a file of its directory for every failed comparison, up to
+// a maximum number of files.
+type MismatchReporter struct {
+	dir      string
+	maxFiles int
+	logger   log.Logger
+
+	mtx     sync.Mutex
+	written int
+}
+
+func NewMismatchReporter(dir string, maxFiles int, logger log.Logger) (*MismatchReporter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "creating mismatch report directory")
+	}
+
+	return &MismatchReporter{
+		dir:      dir,
+		maxFiles: maxFiles,
+		logger:   logger,
+	}, nil
+}
+
+// Report writes the report of a request whose responses didn't match.
+func (m *MismatchReporter) Report(r *http.Request, routeName string, expected, actual *backendResponse, comparisonErr error) {
+	m.mtx.Lock()
+	if m.maxFiles > 0 && m.written >= m.maxFiles {
+		m.mtx.Unlock()
+		level.Debug(m.logger).Log("msg", "Maximum number of mismatch reports reached, skipping report", "route-name", routeName)
+		return
+	}
+	m.written++
+	m.mtx.Unlock()
+
+	now := time.Now()
+	report := MismatchReport{
+		Time:      now,
+		RouteName: routeName,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Query:     r.URL.RawQuery,
+		OrgID:     r.Header.Get(orgIDHeader),
+		Error:     comparisonErr.Error(),
+		Expected:  newResponseReport(expected),
+		Actual:    newResponseReport(actual),
+	}
+
+	buf, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		path := filepath.Join(m.dir, fmt.Sprintf("%d-%s.json", now.UnixNano(), routeName))
+		err = ioutil.WriteFile(path, buf, 0644)
+	}
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "Unable to write mismatch report", "route-name", routeName, "err", err)
+	}
+}
+
+func newResponseReport(res *backendResponse) ResponseReport {
+	report := ResponseReport{
+		Backend: res.backend.name,
+		Status:  res.status,
+		Body:    res.body,
+	}
+
+	// Keep the JSON bodies as is to make them easy to diff, and quote the others.
+	if !json.Valid(res.body) {
+		report.Body, _ = json.Marshal(string(res.body))
+	}
+
+	return report
+}
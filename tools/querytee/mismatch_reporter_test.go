@@ -0,0 +1,72 @@
+package querytee
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyEndpoint_MismatchReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "querytee-reports")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	backend := func(body string) *ProxyBackend {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		return NewProxyBackend(u.Hostname(), u, time.Second, body == `{"status":"success"}`)
+	}
+	backends := []*ProxyBackend{backend(`{"status":"success"}`), backend(`not json`)}
+
+	reporter, err := NewMismatchReporter(dir, 1, log.NewNopLogger())
+	require.NoError(t, err)
+	endpoint := NewProxyEndpoint(backends, "api_v1_query", NewProxyMetrics(prometheus.NewRegistry()), log.NewNopLogger(), failingComparator{}, reporter)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", `/loki/api/v1/query?query={app="foo"}`, nil)
+		req.Header.Set(orgIDHeader, "tenant")
+		endpoint.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// The comparison runs after the response is sent, and only one report is written.
+	var files []string
+	require.Eventually(t, func() bool {
+		files, err = filepath.Glob(filepath.Join(dir, "*-api_v1_query.json"))
+		require.NoError(t, err)
+		return len(files) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	buf, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	var report MismatchReport
+	require.NoError(t, json.Unmarshal(buf, &report))
+	require.Equal(t, "api_v1_query", report.RouteName)
+	require.Equal(t, "/loki/api/v1/query", report.Path)
+	require.Equal(t, `query={app="foo"}`, report.Query)
+	require.Equal(t, "tenant", report.OrgID)
+	require.Equal(t, "responses differ", report.Error)
+	require.JSONEq(t, `{"status":"success"}`, string(report.Expected.Body))
+	require.Equal(t, `"not json"`, string(report.Actual.Body))
+	require.Equal(t, http.StatusOK, report.Actual.Status)
+}
+
+type failingComparator struct{}
+
+func (failingComparator) Compare(_, _ []byte) error {
+	return errors.New("responses differ")
+}
@@ -1,3 +1,6 @@
+// Forked from github.com/cortexproject/cortex/tools/querytee at commit a4bf10354786, licensed under
+// the Apache License 2.0, see the LICENSE file in this directory.
+
 package querytee
 
 import (
@@ -29,9 +32,15 @@ type ProxyConfig struct {
 	BackendEndpoints               string
 	PreferredBackend               string
 	BackendReadTimeout             time.Duration
+	BackendWriteTimeout            time.Duration
 	CompareResponses               bool
 	ValueComparisonTolerance       float64
 	PassThroughNonRegisteredRoutes bool
+	MismatchReportDir              string
+	MismatchReportMaxFiles         int
+	ShadowWrites                   bool
+	ShadowWriteQueueSize           int
+	ShadowWriteConcurrency         int
 }
 
 func (cfg *ProxyConfig) RegisterFlags(f *flag.FlagSet) {
@@ -39,9 +48,15 @@ func (cfg *ProxyConfig) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.BackendEndpoints, "backend.endpoints", "", "Comma separated list of backend endpoints to query.")
 	f.StringVar(&cfg.PreferredBackend, "backend.preferred", "", "The hostname of the preferred backend when selecting the response to send back to the client. If no preferred backend is configured then the query-tee will send back to the client the first successful response received without waiting for other backends.")
 	f.DurationVar(&cfg.BackendReadTimeout, "backend.read-timeout", 90*time.Second, "The timeout when reading the response from a backend.")
+	f.DurationVar(&cfg.BackendWriteTimeout, "backend.write-timeout", 30*time.Second, "The timeout when forwarding a write request to a backend.")
 	f.BoolVar(&cfg.CompareResponses, "proxy.compare-responses", false, "Compare responses between preferred and secondary endpoints for supported routes.")
 	f.Float64Var(&cfg.ValueComparisonTolerance, "proxy.value-comparison-tolerance", 0.000001, "The tolerance to apply when comparing floating point values in the responses. 0 to disable tolerance and require exact match (not recommended).")
 	f.BoolVar(&cfg.PassThroughNonRegisteredRoutes, "proxy.passthrough-non-registered-routes", false, "Passthrough requests for non-registered routes to preferred backend.")
+	f.StringVar(&cfg.MismatchReportDir, "proxy.mismatch-report-dir", "", "Directory where a report with the request and both responses is written for every comparison which fails. Empty to disable the reports.")
+	f.IntVar(&cfg.MismatchReportMaxFiles, "proxy.mismatch-report-max-files", 1000, "Maximum number of mismatch reports written to the report directory, further mismatches are only logged. 0 for no limit.")
+	f.BoolVar(&cfg.ShadowWrites, "proxy.shadow-writes", false, "Proxy the write routes, the write requests are forwarded to the preferred backend synchronously and to the secondary backends asynchronously.")
+	f.IntVar(&cfg.ShadowWriteQueueSize, "proxy.shadow-write-queue-size", 1000, "Maximum number of write requests queued for each secondary backend and route, further write requests are not forwarded to the backend until the queue drains.")
+	f.IntVar(&cfg.ShadowWriteConcurrency, "proxy.shadow-write-concurrency", 10, "Number of write requests forwarded concurrently to each secondary backend and route.")
 }
 
 type Route struct {
@@ -52,25 +67,39 @@ type Route struct {
 }
 
 type Proxy struct {
-	cfg      ProxyConfig
-	backends []*ProxyBackend
-	logger   log.Logger
-	metrics  *ProxyMetrics
-	routes   []Route
+	cfg         ProxyConfig
+	backends    []*ProxyBackend
+	logger      log.Logger
+	metrics     *ProxyMetrics
+	routes      []Route
+	writeRoutes []Route
+	reporter    *MismatchReporter
+
+	// The endpoints of the write routes, stopped with the proxy.
+	writeEndpoints []*ProxyWriteEndpoint
 
 	// The HTTP server used to run the proxy service.
 	srv         *http.Server
 	srvListener net.Listener
 
-	// Wait group used to wait until the server has done.
+	// Wait group used to wait until the server and the write endpoints have done.
 	done sync.WaitGroup
 }
 
-func NewProxy(cfg ProxyConfig, logger log.Logger, routes []Route, registerer prometheus.Registerer) (*Proxy, error) {
+// NewProxy returns a proxy for the read routes, and for the write routes when shadow writes are enabled.
+func NewProxy(cfg ProxyConfig, logger log.Logger, routes, writeRoutes []Route, registerer prometheus.Registerer) (*Proxy, error) {
 	if cfg.CompareResponses && cfg.PreferredBackend == "" {
 		return nil, fmt.Errorf("when enabling comparison of results -backend.preferred flag must be set to hostname of preferred backend")
 	}
 
+	if cfg.ShadowWrites && cfg.PreferredBackend == "" {
+		return nil, fmt.Errorf("when enabling shadow writes -backend.preferred flag must be set to hostname of backend where write requests are forwarded synchronously")
+	}
+
+	if cfg.ShadowWrites && (cfg.ShadowWriteQueueSize < 1 || cfg.ShadowWriteConcurrency < 1) {
+		return nil, fmt.Errorf("when enabling shadow writes the queue size and the concurrency must be at least 1")
+	}
+
 	if cfg.PassThroughNonRegisteredRoutes && cfg.PreferredBackend == "" {
 		return nil, fmt.Errorf("when enabling passthrough for non-registered routes -backend.preferred flag must be set to hostname of backend where those requests needs to be passed")
 	}
@@ -81,6 +110,9 @@ func NewProxy(cfg ProxyConfig, logger log.Logger, routes []Route, registerer pro
 		metrics: NewProxyMetrics(registerer),
 		routes:  routes,
 	}
+	if cfg.ShadowWrites {
+		p.writeRoutes = writeRoutes
+	}
 
 	// Parse the backend endpoints (comma separated).
 	parts := strings.Split(cfg.BackendEndpoints, ",")
@@ -135,6 +167,14 @@ func NewProxy(cfg ProxyConfig, logger log.Logger, routes []Route, registerer pro
 		return nil, fmt.Errorf("when enabling comparison of results number of backends should be 2 exactly")
 	}
 
+	if cfg.CompareResponses && cfg.MismatchReportDir != "" {
+		reporter, err := NewMismatchReporter(cfg.MismatchReportDir, cfg.MismatchReportMaxFiles, logger)
+		if err != nil {
+			return nil, err
+		}
+		p.reporter = reporter
+	}
+
 	// At least 2 backends are suggested
 	if len(p.backends) < 2 {
 		level.Warn(p.logger).Log("msg", "The proxy is running with only 1 backend. At least 2 backends are required to fulfil the purpose of the proxy and compare results.")
@@ -163,7 +203,13 @@ func (p *Proxy) Start() error {
 		if p.cfg.CompareResponses {
 			comparator = route.ResponseComparator
 		}
-		router.Path(route.Path).Methods(route.Methods...).Handler(NewProxyEndpoint(p.backends, route.RouteName, p.metrics, p.logger, comparator))
+		router.Path(route.Path).Methods(route.Methods...).Handler(NewProxyEndpoint(p.backends, route.RouteName, p.metrics, p.logger, comparator, p.reporter))
+	}
+
+	for _, route := range p.writeRoutes {
+		endpoint := NewProxyWriteEndpoint(p.backends, route.RouteName, p.metrics, p.logger, p.cfg.BackendWriteTimeout, p.cfg.ShadowWriteQueueSize, p.cfg.ShadowWriteConcurrency)
+		p.writeEndpoints = append(p.writeEndpoints, endpoint)
+		router.Path(route.Path).Methods(route.Methods...).Handler(endpoint)
 	}
 
 	if p.cfg.PassThroughNonRegisteredRoutes {
@@ -187,7 +233,7 @@ func (p *Proxy) Start() error {
 	go func() {
 		defer p.done.Done()
 
-		if err := p.srv.Serve(p.srvListener); err != nil {
+		if err := p.srv.Serve(p.srvListener); err != nil && err != http.ErrServerClosed {
 			level.Error(p.logger).Log("msg", "Proxy server failed", "err", err)
 		}
 	}()
@@ -201,7 +247,18 @@ func (p *Proxy) Stop() error {
 		return nil
 	}
 
-	return p.srv.Shutdown(context.Background())
+	// Await returns once the write endpoints are stopped, not as soon as the server is.
+	p.done.Add(1)
+	defer p.done.Done()
+
+	err := p.srv.Shutdown(context.Background())
+
+	// The write requests still queued are forwarded before stopping.
+	for _, endpoint := range p.writeEndpoints {
+		endpoint.Stop()
+	}
+
+	return err
 }
 
 func (p *Proxy) Await() {
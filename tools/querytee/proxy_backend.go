@@ -1,7 +1,12 @@
+// Forked from github.com/cortexproject/cortex/tools/querytee at commit a4bf10354786, licensed under
+// the Apache License 2.0, see the LICENSE file in this directory.
+
 package querytee
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -52,16 +57,34 @@ func NewProxyBackend(name string, endpoint *url.URL, timeout time.Duration, pref
 }
 
 func (b *ProxyBackend) ForwardRequest(orig *http.Request) (int, []byte, error) {
-	req, err := b.createBackendRequest(orig)
+	req, err := b.createBackendRequest(orig, nil)
 	if err != nil {
 		return 0, nil, err
 	}
 
-	return b.doBackendRequest(req)
+	return b.doBackendRequest(req, b.timeout)
+}
+
+// ForwardWriteRequest forwards a request along with its body, which has already been read from the original
+// request so that it can be forwarded to several backends.
+func (b *ProxyBackend) ForwardWriteRequest(orig *http.Request, body []byte, timeout time.Duration) (int, []byte, error) {
+	req, err := b.createBackendRequest(orig, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Forward the headers describing the body.
+	for _, h := range []string{"Content-Type", "Content-Encoding"} {
+		if v := orig.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+
+	return b.doBackendRequest(req, timeout)
 }
 
-func (b *ProxyBackend) createBackendRequest(orig *http.Request) (*http.Request, error) {
-	req, err := http.NewRequest(orig.Method, orig.URL.String(), nil)
+func (b *ProxyBackend) createBackendRequest(orig *http.Request, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(orig.Method, orig.URL.String(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -97,9 +120,9 @@ func (b *ProxyBackend) createBackendRequest(orig *http.Request) (*http.Request,
 	return req, nil
 }
 
-func (b *ProxyBackend) doBackendRequest(req *http.Request) (int, []byte, error) {
-	// Honor the read timeout.
-	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+func (b *ProxyBackend) doBackendRequest(req *http.Request, timeout time.Duration) (int, []byte, error) {
+	// Honor the timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Execute the request.
@@ -1,3 +1,6 @@
+// Forked from github.com/cortexproject/cortex/tools/querytee at commit a4bf10354786, licensed under
+// the Apache License 2.0, see the LICENSE file in this directory.
+
 package querytee
 
 import (
@@ -22,6 +25,7 @@ type ProxyEndpoint struct {
 	metrics    *ProxyMetrics
 	logger     log.Logger
 	comparator ResponsesComparator
+	reporter   *MismatchReporter
 
 	// Whether for this endpoint there's a preferred backend configured.
 	hasPreferredBackend bool
@@ -30,7 +34,7 @@ type ProxyEndpoint struct {
 	routeName string
 }
 
-func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *ProxyMetrics, logger log.Logger, comparator ResponsesComparator) *ProxyEndpoint {
+func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *ProxyMetrics, logger log.Logger, comparator ResponsesComparator, reporter *MismatchReporter) *ProxyEndpoint {
 	hasPreferredBackend := false
 	for _, backend := range backends {
 		if backend.preferred {
@@ -45,6 +49,7 @@ func NewProxyEndpoint(backends []*ProxyBackend, routeName string, metrics *Proxy
 		metrics:             metrics,
 		logger:              logger,
 		comparator:          comparator,
+		reporter:            reporter,
 		hasPreferredBackend: hasPreferredBackend,
 	}
 }
@@ -72,7 +77,10 @@ func (p *ProxyEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *backendResponse) {
-	responses := make([]*backendResponse, 0, len(p.backends))
+	var (
+		responses    = make([]*backendResponse, 0, len(p.backends))
+		responsesMtx sync.Mutex
+	)
 
 	wg := sync.WaitGroup{}
 	wg.Add(len(p.backends))
@@ -105,7 +113,9 @@ func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *back
 
 			// Keep track of the response if required.
 			if p.comparator != nil {
+				responsesMtx.Lock()
 				responses = append(responses, res)
+				responsesMtx.Unlock()
 			}
 
 			resCh <- res
@@ -130,6 +140,10 @@ func (p *ProxyEndpoint) executeBackendRequests(r *http.Request, resCh chan *back
 			level.Error(util_log.Logger).Log("msg", "response comparison failed", "route-name", p.routeName,
 				"query", r.URL.RawQuery, "err", err)
 			result = comparisonFailed
+
+			if p.reporter != nil {
+				p.reporter.Report(r, p.routeName, expectedResponse, actualResponse, err)
+			}
 		}
 
 		p.metrics.responsesComparedTotal.WithLabelValues(p.routeName, result).Inc()
@@ -1,3 +1,6 @@
+// Forked from github.com/cortexproject/cortex/tools/querytee at commit a4bf10354786, licensed under
+// the Apache License 2.0, see the LICENSE file in this directory.
+
 package querytee
 
 import (
@@ -14,6 +17,9 @@ type ProxyMetrics struct {
 	requestDuration        *prometheus.HistogramVec
 	responsesTotal         *prometheus.CounterVec
 	responsesComparedTotal *prometheus.CounterVec
+
+	shadowWritesDroppedTotal   *prometheus.CounterVec
+	writeStatusMismatchesTotal *prometheus.CounterVec
 }
 
 func NewProxyMetrics(registerer prometheus.Registerer) *ProxyMetrics {
@@ -34,6 +40,16 @@ func NewProxyMetrics(registerer prometheus.Registerer) *ProxyMetrics {
 			Name:      "responses_compared_total",
 			Help:      "Total number of responses compared per route name by result.",
 		}, []string{"route", "result"}),
+		shadowWritesDroppedTotal: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex_querytee",
+			Name:      "shadow_writes_dropped_total",
+			Help:      "Total number of write requests not forwarded to a secondary backend because its queue was full.",
+		}, []string{"backend", "route"}),
+		writeStatusMismatchesTotal: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex_querytee",
+			Name:      "write_status_mismatches_total",
+			Help:      "Total number of write requests for which a secondary backend returned a different status code than the preferred backend.",
+		}, []string{"backend", "route"}),
 	}
 
 	return m
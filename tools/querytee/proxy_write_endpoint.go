@@ -0,0 +1,152 @@
+package querytee
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// ProxyWriteEndpoint forwards the write requests to the preferred backend synchronously, and sends back its
+// response to the client. The requests are shadowed to the secondary backends asynchronously, through a
+// bounded queue per backend, so that a slow or failing secondary backend doesn't affect the clients.
+type ProxyWriteEndpoint struct {
+	preferred   *ProxyBackend
+	secondaries []*shadowWriter
+	timeout     time.Duration
+	metrics     *ProxyMetrics
+	logger      log.Logger
+
+	// The route name used to track metrics.
+	routeName string
+
+	stopOnce sync.Once
+}
+
+type shadowWriter struct {
+	backend *ProxyBackend
+	queue   chan *writeRequest
+	done    sync.WaitGroup
+}
+
+type writeRequest struct {
+	req             *http.Request
+	body            []byte
+	preferredStatus int
+}
+
+func NewProxyWriteEndpoint(backends []*ProxyBackend, routeName string, metrics *ProxyMetrics, logger log.Logger, timeout time.Duration, queueSize, concurrency int) *ProxyWriteEndpoint {
+	p := &ProxyWriteEndpoint{
+		timeout:   timeout,
+		metrics:   metrics,
+		logger:    logger,
+		routeName: routeName,
+	}
+
+	for _, b := range backends {
+		if b.preferred {
+			p.preferred = b
+			continue
+		}
+
+		s := &shadowWriter{
+			backend: b,
+			queue:   make(chan *writeRequest, queueSize),
+		}
+		s.done.Add(concurrency)
+		for i := 0; i < concurrency; i++ {
+			go p.runShadowWriter(s)
+		}
+		p.secondaries = append(p.secondaries, s)
+	}
+
+	return p
+}
+
+func (p *ProxyWriteEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	level.Debug(p.logger).Log("msg", "Received write request", "path", r.URL.Path)
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, resBody, err := p.forward(p.preferred, r, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(status)
+		if _, err := w.Write(resBody); err != nil {
+			level.Warn(p.logger).Log("msg", "Unable to write response", "err", err)
+		}
+	}
+	p.metrics.responsesTotal.WithLabelValues(p.preferred.name, r.Method, p.routeName).Inc()
+
+	// The original request can't be used once it has been answered.
+	shadow := &writeRequest{
+		req:             r.Clone(context.Background()),
+		body:            body,
+		preferredStatus: statusCode(status, err),
+	}
+	for _, s := range p.secondaries {
+		select {
+		case s.queue <- shadow:
+		default:
+			p.metrics.shadowWritesDroppedTotal.WithLabelValues(s.backend.name, p.routeName).Inc()
+			level.Warn(p.logger).Log("msg", "Shadow write queue is full, dropping write request", "backend", s.backend.name, "path", r.URL.Path)
+		}
+	}
+}
+
+// Stop waits for the queued write requests to be forwarded to the secondary backends. It must be called once
+// the endpoint doesn't receive requests anymore, further calls are no-ops.
+func (p *ProxyWriteEndpoint) Stop() {
+	p.stopOnce.Do(func() {
+		for _, s := range p.secondaries {
+			close(s.queue)
+		}
+		for _, s := range p.secondaries {
+			s.done.Wait()
+		}
+	})
+}
+
+func (p *ProxyWriteEndpoint) runShadowWriter(s *shadowWriter) {
+	defer s.done.Done()
+
+	for w := range s.queue {
+		status, body, err := p.forward(s.backend, w.req, w.body)
+		if secondaryStatus := statusCode(status, err); secondaryStatus != w.preferredStatus {
+			p.metrics.writeStatusMismatchesTotal.WithLabelValues(s.backend.name, p.routeName).Inc()
+			level.Warn(p.logger).Log("msg", "Shadow write status differs from the preferred backend", "route-name", p.routeName,
+				"backend", s.backend.name, "expected-status", w.preferredStatus, "status", secondaryStatus, "response", string(body), "err", err)
+		}
+	}
+}
+
+// forward forwards a write request to a backend and tracks its latency.
+func (p *ProxyWriteEndpoint) forward(b *ProxyBackend, r *http.Request, body []byte) (int, []byte, error) {
+	start := time.Now()
+	status, resBody, err := b.ForwardWriteRequest(r, body, p.timeout)
+	elapsed := time.Since(start)
+
+	lvl := level.Debug
+	if err != nil || status/100 != 2 {
+		lvl = level.Warn
+	}
+	lvl(p.logger).Log("msg", "Backend write response", "path", r.URL.Path, "backend", b.name, "status", status, "elapsed", elapsed, "err", err)
+	p.metrics.requestDuration.WithLabelValues(b.name, r.Method, p.routeName, strconv.Itoa(statusCode(status, err))).Observe(elapsed.Seconds())
+
+	return status, resBody, err
+}
+
+// statusCode returns the status code of a backend response, failed requests are accounted as internal errors.
+func statusCode(status int, err error) int {
+	return (&backendResponse{status: status, err: err}).statusCode()
+}
@@ -0,0 +1,189 @@
+package querytee
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWriteBackend records the bodies of the write requests, and answers them with status once unblocked.
+type fakeWriteBackend struct {
+	mtx     sync.Mutex
+	bodies  []string
+	headers []http.Header
+	status  int
+	block   chan struct{}
+}
+
+func (f *fakeWriteBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.block != nil {
+		<-f.block
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	f.mtx.Lock()
+	f.bodies = append(f.bodies, string(body))
+	f.headers = append(f.headers, r.Header)
+	f.mtx.Unlock()
+	w.WriteHeader(f.status)
+}
+
+func newFakeWriteBackend(t *testing.T, name string, f *fakeWriteBackend, preferred bool) *ProxyBackend {
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return NewProxyBackend(name, u, time.Second, preferred)
+}
+
+func TestProxyWriteEndpoint(t *testing.T) {
+	preferred := &fakeWriteBackend{status: http.StatusNoContent}
+	secondary := &fakeWriteBackend{status: http.StatusInternalServerError}
+	backends := []*ProxyBackend{
+		newFakeWriteBackend(t, "preferred", preferred, true),
+		newFakeWriteBackend(t, "secondary", secondary, false),
+	}
+
+	metrics := NewProxyMetrics(prometheus.NewRegistry())
+	endpoint := NewProxyWriteEndpoint(backends, "api_v1_push", metrics, log.NewNopLogger(), time.Second, 10, 1)
+
+	req := httptest.NewRequest("POST", "/loki/api/v1/push", bytes.NewReader([]byte("payload")))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set(orgIDHeader, "tenant")
+	rec := httptest.NewRecorder()
+	endpoint.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, []string{"payload"}, preferred.bodies)
+
+	// The secondary backend gets the request once the queue is drained.
+	endpoint.Stop()
+	require.Equal(t, []string{"payload"}, secondary.bodies)
+	require.Equal(t, "application/x-protobuf", secondary.headers[0].Get("Content-Type"))
+	require.Equal(t, "tenant", secondary.headers[0].Get(orgIDHeader))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.writeStatusMismatchesTotal.WithLabelValues("secondary", "api_v1_push")))
+}
+
+func TestProxyWriteEndpoint_DropsWhenQueueIsFull(t *testing.T) {
+	preferred := &fakeWriteBackend{status: http.StatusNoContent}
+	secondary := &fakeWriteBackend{status: http.StatusNoContent, block: make(chan struct{})}
+	backends := []*ProxyBackend{
+		newFakeWriteBackend(t, "preferred", preferred, true),
+		newFakeWriteBackend(t, "secondary", secondary, false),
+	}
+
+	metrics := NewProxyMetrics(prometheus.NewRegistry())
+	endpoint := NewProxyWriteEndpoint(backends, "api_v1_push", metrics, log.NewNopLogger(), time.Second, 1, 1)
+
+	push := func() {
+		rec := httptest.NewRecorder()
+		endpoint.ServeHTTP(rec, httptest.NewRequest("POST", "/loki/api/v1/push", bytes.NewReader([]byte("payload"))))
+		require.Equal(t, http.StatusNoContent, rec.Code)
+	}
+
+	// The first request is picked by the only shadow writer, which blocks.
+	push()
+	require.Eventually(t, func() bool {
+		return len(endpoint.secondaries[0].queue) == 0
+	}, time.Second, time.Millisecond)
+	// The second one fills the queue, and the third one is dropped.
+	push()
+	push()
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.shadowWritesDroppedTotal.WithLabelValues("secondary", "api_v1_push")))
+	require.Len(t, preferred.bodies, 3)
+
+	close(secondary.block)
+	endpoint.Stop()
+	require.Len(t, secondary.bodies, 2)
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.writeStatusMismatchesTotal.WithLabelValues("secondary", "api_v1_push")))
+}
+
+func TestProxy_ShadowWrites(t *testing.T) {
+	preferred := &fakeWriteBackend{status: http.StatusNoContent}
+	secondary := &fakeWriteBackend{status: http.StatusNoContent, block: make(chan struct{})}
+	var backendURLs []string
+	for _, f := range []*fakeWriteBackend{preferred, secondary} {
+		s := httptest.NewServer(f)
+		defer s.Close()
+		backendURLs = append(backendURLs, s.URL)
+	}
+
+	p, err := NewProxy(ProxyConfig{
+		BackendEndpoints:       strings.Join(backendURLs, ","),
+		PreferredBackend:       "0",
+		BackendReadTimeout:     time.Second,
+		BackendWriteTimeout:    time.Second,
+		ShadowWrites:           true,
+		ShadowWriteQueueSize:   10,
+		ShadowWriteConcurrency: 1,
+	}, log.NewNopLogger(), nil, []Route{
+		{Path: "/loki/api/v1/push", RouteName: "api_v1_push", Methods: []string{"POST"}},
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+
+	for i := 0; i < 3; i++ {
+		res, err := http.Post(fmt.Sprintf("http://%s/loki/api/v1/push", p.Endpoint()), "application/x-protobuf", bytes.NewReader([]byte("payload")))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+	}
+
+	// Stopping the proxy forwards the queued shadow writes, and it can be stopped several times.
+	close(secondary.block)
+	require.NoError(t, p.Stop())
+	require.Len(t, secondary.bodies, 3)
+	require.NoError(t, p.Stop())
+}
+
+func TestProxy_AwaitShadowWrites(t *testing.T) {
+	preferred := &fakeWriteBackend{status: http.StatusNoContent}
+	secondary := &fakeWriteBackend{status: http.StatusNoContent, block: make(chan struct{})}
+	var backendURLs []string
+	for _, f := range []*fakeWriteBackend{preferred, secondary} {
+		s := httptest.NewServer(f)
+		defer s.Close()
+		backendURLs = append(backendURLs, s.URL)
+	}
+
+	p, err := NewProxy(ProxyConfig{
+		BackendEndpoints:       strings.Join(backendURLs, ","),
+		PreferredBackend:       "0",
+		BackendReadTimeout:     time.Second,
+		BackendWriteTimeout:    time.Second,
+		ShadowWrites:           true,
+		ShadowWriteQueueSize:   10,
+		ShadowWriteConcurrency: 1,
+	}, log.NewNopLogger(), nil, []Route{
+		{Path: "/loki/api/v1/push", RouteName: "api_v1_push", Methods: []string{"POST"}},
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+
+	for i := 0; i < 3; i++ {
+		res, err := http.Post(fmt.Sprintf("http://%s/loki/api/v1/push", p.Endpoint()), "application/x-protobuf", bytes.NewReader([]byte("payload")))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+	}
+
+	// The proxy is stopped like on termination, the secondary is still busy with the queued writes.
+	go func() {
+		_ = p.Stop()
+	}()
+	time.AfterFunc(100*time.Millisecond, func() { close(secondary.block) })
+
+	p.Await()
+	secondary.mtx.Lock()
+	defer secondary.mtx.Unlock()
+	require.Len(t, secondary.bodies, 3)
+}
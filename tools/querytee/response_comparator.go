@@ -1,3 +1,6 @@
+// Forked from github.com/cortexproject/cortex/tools/querytee at commit a4bf10354786, licensed under
+// the Apache License 2.0, see the LICENSE file in this directory.
+
 package querytee
 
 import (
@@ -25,7 +28,8 @@ type SamplesResponse struct {
 
 func NewSamplesComparator(tolerance float64) *SamplesComparator {
 	return &SamplesComparator{
-		tolerance: tolerance,
+		tolerance:  tolerance,
+		tolerances: map[string]float64{},
 		sampleTypesComparator: map[string]SamplesComparatorFunc{
 			"matrix": compareMatrix,
 			"vector": compareVector,
@@ -36,6 +40,7 @@ func NewSamplesComparator(tolerance float64) *SamplesComparator {
 
 type SamplesComparator struct {
 	tolerance             float64
+	tolerances            map[string]float64
 	sampleTypesComparator map[string]SamplesComparatorFunc
 }
 
@@ -44,6 +49,11 @@ func (s *SamplesComparator) RegisterSamplesType(samplesType string, comparator S
 	s.sampleTypesComparator[samplesType] = comparator
 }
 
+// SetTolerance overrides the tolerance applied to the values of a sample type.
+func (s *SamplesComparator) SetTolerance(samplesType string, tolerance float64) {
+	s.tolerances[samplesType] = tolerance
+}
+
 func (s *SamplesComparator) Compare(expectedResponse, actualResponse []byte) error {
 	var expected, actual SamplesResponse
 
@@ -70,7 +80,12 @@ func (s *SamplesComparator) Compare(expectedResponse, actualResponse []byte) err
 		return fmt.Errorf("resultType %s not registered for comparison", expected.Data.ResultType)
 	}
 
-	return comparator(expected.Data.Result, actual.Data.Result, s.tolerance)
+	tolerance, ok := s.tolerances[expected.Data.ResultType]
+	if !ok {
+		tolerance = s.tolerance
+	}
+
+	return comparator(expected.Data.Result, actual.Data.Result, tolerance)
 }
 
 func compareMatrix(expectedRaw, actualRaw json.RawMessage, tolerance float64) error {